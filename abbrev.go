@@ -0,0 +1,118 @@
+package cliche
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ExpandAbbreviations rewrites long flags in args which are unique prefixes of
+// one of names to the full name, as GNU getopt_long does; for example,
+// --verb to --verbose. Only flags of the --name form are considered, with or
+// without an =value suffix, and no arguments following -- are rewritten. An
+// exact match is never ambiguous. Otherwise, a prefix of several names is an
+// error listing the candidates.
+//
+// Abbreviation is not enabled by default. Command trees which want it call
+// ExpandAbbreviations on their arguments before parsing, as the commands
+// generated with -abbrev do by AbbreviateFlags and AbbreviateCommand.
+func ExpandAbbreviations(args []string, names []string) ([]string, error) {
+	sorted := append([]string(nil), names...)
+	sort.Strings(sorted)
+
+	ret := make([]string, 0, len(args))
+	for i, arg := range args {
+		if arg == "--" {
+			return append(ret, args[i:]...), nil
+		}
+		prefix, ok := strings.CutPrefix(arg, "--")
+		if !ok || prefix == "" {
+			ret = append(ret, arg)
+			continue
+		}
+		prefix, value, hasValue := strings.Cut(prefix, "=")
+
+		var candidates []string
+		exact := false
+		for _, name := range sorted {
+			if name == prefix {
+				exact = true
+				break
+			}
+			if strings.HasPrefix(name, prefix) {
+				candidates = append(candidates, "--"+name)
+			}
+		}
+		switch {
+		case exact || len(candidates) == 0:
+			// Unknown flags are left for the parser to complain about.
+			ret = append(ret, arg)
+			continue
+		case len(candidates) > 1:
+			return nil, fmt.Errorf("flag --%v is ambiguous; candidates are: %v", prefix, strings.Join(candidates, " "))
+		}
+		if hasValue {
+			ret = append(ret, candidates[0]+"="+value)
+		} else {
+			ret = append(ret, candidates[0])
+		}
+	}
+	return ret, nil
+}
+
+// AbbreviateFlags rewrites the long flags in args which are unique prefixes of
+// the names of flags of fs to the full names, as ExpandAbbreviations does. As
+// the flag package does, it stops at the first argument which is neither a
+// flag nor the value of one, or at --, leaving those of subcommands as they
+// are.
+func AbbreviateFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	var names []string
+	fs.VisitAll(func(f *flag.Flag) {
+		if len(f.Name) > 1 {
+			names = append(names, f.Name)
+		}
+	})
+	ret := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		if !isFlag(args[i]) {
+			return append(ret, args[i:]...), nil
+		}
+		expanded, err := ExpandAbbreviations(args[i:i+1], names)
+		if err != nil {
+			return nil, err
+		}
+		arg := expanded[0]
+		ret = append(ret, arg)
+		if name, _, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "="); !hasValue && takesValue(fs, name) && i+1 < len(args) {
+			i++
+			ret = append(ret, args[i])
+		}
+	}
+	return ret, nil
+}
+
+// AbbreviateCommand returns the one of names, which are those of the verbs and
+// subcommands of a command, of which name is a unique prefix, as st for
+// status. Names which are one of names, or a prefix of none, are returned as
+// they are. Otherwise, a prefix of several names is an error listing the
+// candidates.
+func AbbreviateCommand(name string, names []string) (string, error) {
+	var candidates []string
+	for _, n := range names {
+		if n == name {
+			return name, nil
+		}
+		if strings.HasPrefix(n, name) {
+			candidates = append(candidates, n)
+		}
+	}
+	switch len(candidates) {
+	case 0:
+		return name, nil
+	case 1:
+		return candidates[0], nil
+	}
+	sort.Strings(candidates)
+	return "", fmt.Errorf("command %q is ambiguous; candidates are: %v", name, strings.Join(candidates, " "))
+}
@@ -0,0 +1,98 @@
+package cliche
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestExpandAbbreviations(t *testing.T) {
+	names := []string{"verbose", "version", "name", "names"}
+
+	type test struct {
+		args    []string
+		want    []string
+		wantErr string
+	}
+	for tn, tc := range map[string]test{
+		"empty":            {},
+		"unique prefix":    {[]string{"--verb", "-v", "arg"}, []string{"--verbose", "-v", "arg"}, ""},
+		"unique with =":    {[]string{"--vers=2"}, []string{"--version=2"}, ""},
+		"exact match wins": {[]string{"--name"}, []string{"--name"}, ""},
+		"unknown left":     {[]string{"--nope"}, []string{"--nope"}, ""},
+		"single dash left": {[]string{"-verb"}, []string{"-verb"}, ""},
+		"after terminator": {[]string{"--", "--verb"}, []string{"--", "--verb"}, ""},
+		"ambiguous":        {[]string{"--ver"}, nil, "flag --ver is ambiguous; candidates are: --verbose --version"},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			got, err := ExpandAbbreviations(tc.args, names)
+			var gotErr string
+			if err != nil {
+				gotErr = err.Error()
+			}
+			if gotErr != tc.wantErr {
+				t.Errorf("ExpandAbbreviations(): error mismatch: got: %q want: %q", gotErr, tc.wantErr)
+			}
+			if diff := cmp.Diff(got, tc.want, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("ExpandAbbreviations(): mismatch(-got,+want):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestAbbreviateFlags(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Bool("verbose", false, "")
+	fs.Bool("version", false, "")
+	fs.String("output", "", "")
+	fs.String("o", "", "")
+
+	type test struct {
+		args    []string
+		want    []string
+		wantErr bool
+	}
+	for tn, tc := range map[string]test{
+		"empty":         {},
+		"flags":         {[]string{"--verb", "--out", "--verb"}, []string{"--verbose", "--output", "--verb"}, false},
+		"with =":        {[]string{"--out=x", "sub", "--out=y"}, []string{"--output=x", "sub", "--out=y"}, false},
+		"at positional": {[]string{"sub", "--verb"}, []string{"sub", "--verb"}, false},
+		"unknown left":  {[]string{"--x", "--verb"}, []string{"--x", "--verbose"}, false},
+		"terminator":    {[]string{"--", "--verb"}, []string{"--", "--verb"}, false},
+		"ambiguous":     {[]string{"--ver"}, nil, true},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			got, err := AbbreviateFlags(fs, tc.args)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("AbbreviateFlags(): error mismatch: got: %v wantErr: %v", err, tc.wantErr)
+			}
+			if diff := cmp.Diff(got, tc.want, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("AbbreviateFlags(): mismatch(-got,+want):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestAbbreviateCommand(t *testing.T) {
+	names := []string{"status", "stash", "push", "pu"}
+	for name, want := range map[string]string{
+		"stat":  "status",
+		"sta":   "",
+		"pu":    "pu",
+		"pus":   "push",
+		"fetch": "fetch",
+	} {
+		got, err := AbbreviateCommand(name, names)
+		if want == "" {
+			if err == nil || err.Error() != `command "sta" is ambiguous; candidates are: stash status` {
+				t.Errorf("AbbreviateCommand(%q): got %q, %v, want an error listing stash and status", name, got, err)
+			}
+			continue
+		}
+		if err != nil || got != want {
+			t.Errorf("AbbreviateCommand(%q): got %q, %v, want %q", name, got, err, want)
+		}
+	}
+}
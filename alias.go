@@ -0,0 +1,173 @@
+package cliche
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// ExpandAliases rewrites the first of args, which names a command, when it is
+// one of aliases, in the style of git aliases; for example, expanding
+// ["st", "."] with the alias st = "status --short" to
+// ["status", "--short", "."]. Expansions are split on whitespace, and may
+// themselves begin with an alias, which is expanded in turn. An alias which
+// refers back to itself, directly or not, is an error.
+//
+// Command trees which support aliases call ExpandAliases on their arguments,
+// excluding the program name, before dispatching.
+func ExpandAliases(args []string, aliases map[string]string) ([]string, error) {
+	var chain []string
+	for len(args) > 0 {
+		expansion, ok := aliases[args[0]]
+		if !ok {
+			break
+		}
+		for _, name := range chain {
+			if name == args[0] {
+				return nil, fmt.Errorf("alias loop: %v -> %v", strings.Join(chain, " -> "), args[0])
+			}
+		}
+		chain = append(chain, args[0])
+		args = append(strings.Fields(expansion), args[1:]...)
+	}
+	return args, nil
+}
+
+// WriteAliases lists aliases to w, one per line and sorted by name, for an
+// alias command showing the user's configured aliases.
+func WriteAliases(w io.Writer, aliases map[string]string) error {
+	names := make([]string, 0, len(aliases))
+	for name := range aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, err := fmt.Fprintf(w, "%v = %v\n", name, aliases[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validShellName is true for names which every supported shell accepts as the
+// name of an alias or function.
+func validShellName(name string) bool {
+	return name != "" && name[0] != '-' && strings.IndexFunc(name, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("_-.", r))
+	}) < 0
+}
+
+// quoteFish quotes s for fish, in which backslashes and single quotes are
+// escaped within single quotes.
+func quoteFish(s string) string {
+	if s != "" && strings.IndexFunc(s, func(r rune) bool { return !posixSafe(r) }) < 0 {
+		return s
+	}
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
+
+// WriteShellAliases writes to w a snippet for the given shell, which is one of
+// bash, zsh or fish, to be sourced from its startup file. It declares each of
+// aliases as a shell alias running program with its expansion, so that with
+// the alias k = "kube", k get runs program kube get. Expansions are split on
+// whitespace, as by ExpandAliases, and each word quoted. Aliases whose names
+// the shell can't take are an error.
+func WriteShellAliases(w io.Writer, shell, program string, aliases map[string]string) error {
+	quote := quotePOSIX
+	switch shell {
+	case "bash", "zsh":
+	case "fish":
+		quote = quoteFish
+	default:
+		return fmt.Errorf("unsupported shell %q: want bash, zsh or fish", shell)
+	}
+	names := make([]string, 0, len(aliases))
+	for name := range aliases {
+		if !validShellName(name) {
+			return fmt.Errorf("alias %q is not a valid %v alias name", name, shell)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if _, err := fmt.Fprintf(w, "# Aliases of %v. Generated by cliche.\n", program); err != nil {
+		return err
+	}
+	for _, name := range names {
+		words := []string{quote(program)}
+		for _, word := range strings.Fields(aliases[name]) {
+			words = append(words, quote(word))
+		}
+		line := fmt.Sprintf("alias %v=%v\n", name, quotePOSIX(strings.Join(words, " ")))
+		if shell == "fish" {
+			// An alias is a function in fish, which is declared as one
+			// to keep the expansion from being quoted twice.
+			line = fmt.Sprintf("function %v --wraps %v\n\t%v $argv\nend\n", name, quote(program), strings.Join(words, " "))
+		}
+		if _, err := io.WriteString(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// posixWrapper is the wrapper written by WriteShellWrapper for bash and zsh,
+// with %[1]v standing for the program and %[2]v for the pattern matching the
+// commands which change directory.
+const posixWrapper = `%[1]v() {
+	case "$1" in
+	%[2]v)
+		local dir
+		dir="$(command %[1]v "$@")" && builtin cd -- "$dir"
+		;;
+	*) command %[1]v "$@" ;;
+	esac
+}
+`
+
+// wrapperSnippets are the shell functions written by WriteShellWrapper, as
+// for posixWrapper.
+var wrapperSnippets = map[string]string{
+	"bash": posixWrapper,
+	"zsh":  posixWrapper,
+	"fish": `function %[1]v --wraps %[1]v
+	switch "$argv[1]"
+	case %[2]v
+		set -l dir (command %[1]v $argv); and builtin cd $dir
+	case '*'
+		command %[1]v $argv
+	end
+end
+`,
+}
+
+// WriteShellWrapper writes to w a snippet for the given shell, which is one of
+// bash, zsh or fish, to be sourced from its startup file. It wraps program in
+// a shell function of the same name, so that its commands named by cd, which
+// print a directory to stdout, change the shell to that directory, as
+// program cd does for a program with a cd command. Only the shell can change
+// its own directory. Other commands run as they would without the wrapper.
+func WriteShellWrapper(w io.Writer, shell, program string, cd ...string) error {
+	snippet, ok := wrapperSnippets[shell]
+	if !ok {
+		return fmt.Errorf("unsupported shell %q: want bash, zsh or fish", shell)
+	}
+	if !validShellName(program) {
+		return fmt.Errorf("program %q is not a valid %v function name", program, shell)
+	}
+	if len(cd) == 0 {
+		return errors.New("no commands changing directory to wrap")
+	}
+	for _, name := range cd {
+		if !validShellName(name) {
+			return fmt.Errorf("command %q can't be matched by %v", name, shell)
+		}
+	}
+	sep := "|"
+	if shell == "fish" {
+		sep = " "
+	}
+	_, err := fmt.Fprintf(w, "# Wrapper of %v, changing directory. Generated by cliche.\n"+snippet, program, strings.Join(cd, sep))
+	return err
+}
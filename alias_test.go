@@ -0,0 +1,192 @@
+package cliche
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestExpandAliases(t *testing.T) {
+	aliases := map[string]string{
+		"st":    "status --short",
+		"s":     "st",
+		"co":    "checkout",
+		"loop":  "again -v",
+		"again": "loop",
+		"self":  "self --really",
+		"bare":  "",
+	}
+
+	type test struct {
+		args    []string
+		want    []string
+		wantErr string
+	}
+	for tn, tc := range map[string]test{
+		"empty":        {},
+		"not an alias": {[]string{"status", "st"}, []string{"status", "st"}, ""},
+		"alias":        {[]string{"st", "."}, []string{"status", "--short", "."}, ""},
+		"nested":       {[]string{"s"}, []string{"status", "--short"}, ""},
+		"empty alias":  {[]string{"bare", "x"}, []string{"x"}, ""},
+		"loop":         {[]string{"loop"}, nil, "alias loop: loop -> again -> loop"},
+		"self":         {[]string{"self"}, nil, "alias loop: self -> self"},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			got, err := ExpandAliases(tc.args, aliases)
+			var gotErr string
+			if err != nil {
+				gotErr = err.Error()
+			}
+			if gotErr != tc.wantErr {
+				t.Errorf("ExpandAliases(): error mismatch: got: %q want: %q", gotErr, tc.wantErr)
+			}
+			if diff := cmp.Diff(got, tc.want, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("ExpandAliases(): mismatch (-got,+want):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestWriteAliases(t *testing.T) {
+	var b strings.Builder
+	if err := WriteAliases(&b, map[string]string{"st": "status --short", "co": "checkout"}); err != nil {
+		t.Fatalf("WriteAliases(): unexpected error: %v", err)
+	}
+	want := "co = checkout\nst = status --short\n"
+	if diff := cmp.Diff(b.String(), want); diff != "" {
+		t.Errorf("WriteAliases(): mismatch (-got,+want):\n%v", diff)
+	}
+}
+
+func TestWriteShellAliases(t *testing.T) {
+	aliases := map[string]string{"k": "kube", "kg": "kube get --all", "q": "say it's"}
+	for tn, tc := range map[string]struct {
+		shell   string
+		aliases map[string]string
+		want    string
+		wantErr string
+	}{
+		"bash": {"bash", aliases, `# Aliases of app. Generated by cliche.
+alias k='app kube'
+alias kg='app kube get --all'
+alias q='app say '"'"'it'"'"'"'"'"'"'"'"'s'"'"''
+`, ""},
+		"fish": {"fish", aliases, `# Aliases of app. Generated by cliche.
+function k --wraps app
+	app kube $argv
+end
+function kg --wraps app
+	app kube get --all $argv
+end
+function q --wraps app
+	app say 'it\'s' $argv
+end
+`, ""},
+		"bad name":  {"zsh", map[string]string{"k;rm": "kube"}, "", `alias "k;rm" is not a valid zsh alias name`},
+		"bad shell": {"tcsh", aliases, "", `unsupported shell "tcsh": want bash, zsh or fish`},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			var b strings.Builder
+			err := WriteShellAliases(&b, tc.shell, "app", tc.aliases)
+			var gotErr string
+			if err != nil {
+				gotErr = err.Error()
+			}
+			if gotErr != tc.wantErr {
+				t.Errorf("WriteShellAliases(): error mismatch: got: %q want: %q", gotErr, tc.wantErr)
+			}
+			if diff := cmp.Diff(b.String(), tc.want); tc.wantErr == "" && diff != "" {
+				t.Errorf("WriteShellAliases(): mismatch (-got,+want):\n%v", diff)
+			}
+		})
+	}
+}
+
+// fakeProgram writes to a new directory an executable app, which prints its
+// arguments, or for cd, the directory named by the second, and returns a PATH
+// finding it first.
+func fakeProgram(t *testing.T) string {
+	t.Helper()
+	bin := t.TempDir()
+	script := "#!/bin/sh\nif [ \"$1\" = cd ]; then echo \"$2\"; else echo \"app $*\"; fi\n"
+	if err := os.WriteFile(filepath.Join(bin, "app"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return bin + string(os.PathListSeparator) + os.Getenv("PATH")
+}
+
+func TestShellAliasesRun(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash is not installed")
+	}
+	var b strings.Builder
+	if err := WriteShellAliases(&b, "bash", "app", map[string]string{"kg": "kube get", "q": "say it's"}); err != nil {
+		t.Fatalf("WriteShellAliases(): unexpected error: %v", err)
+	}
+	// Aliases are only expanded in lines read after they are declared.
+	script := "shopt -s expand_aliases\n" + b.String() + "kg pods\nq so\n"
+	cmd := exec.Command(bash, "--norc", "-c", script)
+	cmd.Env = append(os.Environ(), "PATH="+fakeProgram(t))
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("bash: %v: %s", err, out)
+	}
+	if got, want := string(out), "app kube get pods\napp say it's so\n"; got != want {
+		t.Errorf("WriteShellAliases(): got %q from bash, want %q", got, want)
+	}
+}
+
+func TestWriteShellWrapper(t *testing.T) {
+	var b strings.Builder
+	for _, tc := range []struct {
+		shell, program string
+		cd             []string
+		wantErr        string
+	}{
+		{"tcsh", "app", []string{"cd"}, `unsupported shell "tcsh": want bash, zsh or fish`},
+		{"bash", "my app", []string{"cd"}, `program "my app" is not a valid bash function name`},
+		{"bash", "app", nil, "no commands changing directory to wrap"},
+		{"fish", "app", []string{"cd)"}, `command "cd)" can't be matched by fish`},
+	} {
+		if err := WriteShellWrapper(&b, tc.shell, tc.program, tc.cd...); err == nil || err.Error() != tc.wantErr {
+			t.Errorf("WriteShellWrapper(%v, %v, %q): got error %v, want %v", tc.shell, tc.program, tc.cd, err, tc.wantErr)
+		}
+	}
+
+	b.Reset()
+	if err := WriteShellWrapper(&b, "fish", "app", "cd", "jump"); err != nil {
+		t.Fatalf("WriteShellWrapper(): unexpected error: %v", err)
+	}
+	if want := "\tcase cd jump\n"; !strings.Contains(b.String(), want) {
+		t.Errorf("WriteShellWrapper(): fish wrapper does not contain %q:\n%v", want, b.String())
+	}
+
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash is not installed")
+	}
+	b.Reset()
+	if err := WriteShellWrapper(&b, "bash", "app", "cd", "jump"); err != nil {
+		t.Fatalf("WriteShellWrapper(): unexpected error: %v", err)
+	}
+	dir := t.TempDir()
+	script := b.String() + "app cd " + quotePOSIX(dir) + " && pwd\napp jump /nonexistent || echo failed\napp kube get\n"
+	cmd := exec.Command(bash, "--norc", "-c", script)
+	cmd.Env = append(os.Environ(), "PATH="+fakeProgram(t))
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("bash: %v: %s", err, out)
+	}
+	// The wrapper changes to the directory printed, and no further when cd
+	// fails.
+	got := string(out)
+	if !strings.HasPrefix(got, dir+"\n") || !strings.HasSuffix(got, "failed\napp kube get\n") {
+		t.Errorf("WriteShellWrapper(): got %q from bash, want %v, then failed and app kube get", got, dir)
+	}
+}
@@ -0,0 +1,75 @@
+package cliche
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ExpandResponseFiles replaces each argument of the form @file with the
+// arguments contained in that file, so that very long invocations, as are
+// common from build systems, need not fit on the command line.
+//
+// Response files contain one argument per line. Surrounding whitespace is
+// trimmed from each line, and blank lines and lines beginning with # are
+// ignored. Response files may themselves reference other response files,
+// relative to the working directory, but not cyclically. An argument of @@file
+// is passed along as the literal @file, and no arguments following --, whether
+// given directly or in a response file, are expanded.
+func ExpandResponseFiles(args []string) ([]string, error) {
+	ret, _, err := expandResponseFiles(args, nil)
+	return ret, err
+}
+
+// expandResponseFiles expands args, given within the response files open,
+// and reports whether it met a --, after which nothing more is expanded.
+func expandResponseFiles(args []string, open []string) (ret []string, done bool, err error) {
+	ret = make([]string, 0, len(args))
+	for i, arg := range args {
+		if arg == "--" {
+			return append(ret, args[i:]...), true, nil
+		}
+		if strings.HasPrefix(arg, "@@") {
+			ret = append(ret, arg[1:])
+			continue
+		}
+		path, ok := strings.CutPrefix(arg, "@")
+		if !ok || path == "" {
+			ret = append(ret, arg)
+			continue
+		}
+
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return nil, false, fmt.Errorf("response file %v: %w", path, err)
+		}
+		for _, o := range open {
+			if o == abs {
+				return nil, false, fmt.Errorf("response file %v includes itself", path)
+			}
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, false, fmt.Errorf("response file: %w", err)
+		}
+
+		var contents []string
+		for _, line := range strings.Split(string(b), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			contents = append(contents, line)
+		}
+		expanded, done, err := expandResponseFiles(contents, append(open, abs))
+		if err != nil {
+			return nil, false, err
+		}
+		ret = append(ret, expanded...)
+		if done {
+			return append(ret, args[i+1:]...), true, nil
+		}
+	}
+	return ret, false, nil
+}
@@ -0,0 +1,55 @@
+package cliche
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestExpandResponseFiles(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, contents string) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	simple := write("simple.txt", "# Flags for the thing.\n--name\nWorld\n\n  --greeting=Hello there  \r\n")
+	outer := write("outer.txt", "--outer\n@"+simple+"\n")
+	terminated := write("terminated.txt", "--name\n--\n@"+simple+"\n")
+	cyclic := filepath.Join(dir, "cyclic.txt")
+	write("cyclic.txt", "@"+cyclic+"\n")
+
+	type test struct {
+		args    []string
+		want    []string
+		wantErr bool
+	}
+	for tn, tc := range map[string]test{
+		"empty":              {},
+		"no response files":  {[]string{"-a", "b"}, []string{"-a", "b"}, false},
+		"simple":             {[]string{"-a", "@" + simple, "b"}, []string{"-a", "--name", "World", "--greeting=Hello there", "b"}, false},
+		"nested":             {[]string{"@" + outer}, []string{"--outer", "--name", "World", "--greeting=Hello there"}, false},
+		"escaped":            {[]string{"@@" + simple}, []string{"@" + simple}, false},
+		"bare at":            {[]string{"@"}, []string{"@"}, false},
+		"after terminator":   {[]string{"--", "@" + simple}, []string{"--", "@" + simple}, false},
+		"terminator in file": {[]string{"@" + terminated, "@" + simple}, []string{"--name", "--", "@" + simple, "@" + simple}, false},
+		"missing file":       {[]string{"@" + filepath.Join(dir, "missing.txt")}, nil, true},
+		"cyclic not allowed": {[]string{"@" + cyclic}, nil, true},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			got, err := ExpandResponseFiles(tc.args)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("ExpandResponseFiles(): error mismatch: got: %v wantErr: %v", err, tc.wantErr)
+			}
+			if diff := cmp.Diff(got, tc.want, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("ExpandResponseFiles(): mismatch(-got,+want):\n%v", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package cliche
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/user"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AuditRecord describes one invocation of a command, as recorded by an
+// Auditor.
+type AuditRecord struct {
+	// Path of the command which was run.
+	Path Path `json:"path"`
+
+	// Args with which the command was invoked, with secrets redacted.
+	Args []string `json:"args"`
+
+	// User who ran the command.
+	User string `json:"user"`
+
+	// Time at which the command was started.
+	Time time.Time `json:"time"`
+
+	// ExitStatus with which the program exits.
+	ExitStatus int `json:"exit_status"`
+}
+
+// Auditor records each invocation of a command, for organizations which must
+// audit the use of their operator tooling.
+type Auditor interface {
+	Audit(ctx context.Context, rec AuditRecord) error
+}
+
+// AuditorFunc adapts a function to the Auditor interface.
+type AuditorFunc func(ctx context.Context, rec AuditRecord) error
+
+// Audit calls f.
+func (f AuditorFunc) Audit(ctx context.Context, rec AuditRecord) error {
+	return f(ctx, rec)
+}
+
+// Audit rec to a, unless a is nil. Unlike usage reporting, users cannot opt
+// out of auditing.
+func Audit(ctx context.Context, a Auditor, rec AuditRecord) error {
+	if a == nil {
+		return nil
+	}
+	return a.Audit(ctx, rec)
+}
+
+// Redacted replaces the values of secret flags in audited arguments.
+const Redacted = "REDACTED"
+
+// RedactArgs returns a copy of args in which the values of the flags named by
+// secret are replaced with Redacted, whether given as --name=value or as
+// --name value. The argument following a secret flag is only redacted when
+// the flag takes a value: boolean flags of fs, such as --insecure, do not.
+// Secret flags which are not defined by fs, as when fs is nil or the flag
+// belongs to a subcommand, are taken to have values. Arguments after "--"
+// are not flags, and are kept.
+func RedactArgs(fs *flag.FlagSet, args []string, secret ...string) []string {
+	isSecret := make(map[string]bool, len(secret))
+	for _, name := range secret {
+		isSecret[name] = true
+	}
+
+	ret := make([]string, len(args))
+	copy(ret, args)
+	for i := 0; i < len(ret); i++ {
+		arg := ret[i]
+		if arg == "--" {
+			break
+		}
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		name, _, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !isSecret[name] {
+			continue
+		}
+		switch {
+		case hasValue:
+			ret[i] = arg[:strings.Index(arg, "=")+1] + Redacted
+		case fs != nil && fs.Lookup(name) != nil && !takesValue(fs, name):
+		case i+1 < len(ret):
+			i++
+			ret[i] = Redacted
+		}
+	}
+	return ret
+}
+
+// CurrentUser returns the name of the user running the program, for use in
+// an AuditRecord.
+func CurrentUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+// jsonAuditor writes each record as a line of JSON.
+type jsonAuditor struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (a *jsonAuditor) Audit(ctx context.Context, rec AuditRecord) error {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	_, err = a.w.Write(append(line, '\n'))
+	return err
+}
+
+// NewJSONAuditor returns an Auditor which writes each record to w as a line of
+// JSON, such as to an append-only file or a syslog writer.
+func NewJSONAuditor(w io.Writer) Auditor {
+	return &jsonAuditor{w: w}
+}
+
+// NewHTTPAuditor returns an Auditor which POSTs each record as JSON to url
+// using client, or http.DefaultClient if client is nil. Any response status
+// other than 2xx is an error.
+func NewHTTPAuditor(url string, client *http.Client) Auditor {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return AuditorFunc(func(ctx context.Context, rec AuditRecord) error {
+		body, err := json.Marshal(rec)
+		if err != nil {
+			return err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		res, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer res.Body.Close()
+		if res.StatusCode/100 != 2 {
+			return fmt.Errorf("audit: %v: %v", url, res.Status)
+		}
+		return nil
+	})
+}
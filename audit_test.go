@@ -0,0 +1,100 @@
+package cliche
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRedactArgs(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		args   []string
+		secret []string
+		want   []string
+	}{
+		"empty":        {want: []string{}},
+		"no secrets":   {[]string{"--token", "abc"}, nil, []string{"--token", "abc"}},
+		"equals":       {[]string{"--token=abc", "-v"}, []string{"token"}, []string{"--token=REDACTED", "-v"}},
+		"separate":     {[]string{"-v", "--token", "abc", "x"}, []string{"token"}, []string{"-v", "--token", "REDACTED", "x"}},
+		"short":        {[]string{"-t", "abc"}, []string{"token", "t"}, []string{"-t", "REDACTED"}},
+		"trailing":     {[]string{"--token"}, []string{"token"}, []string{"--token"}},
+		"after dashes": {[]string{"--", "--token", "abc"}, []string{"token"}, []string{"--", "--token", "abc"}},
+		"bool":         {[]string{"--insecure", "abc"}, []string{"insecure"}, []string{"--insecure", "abc"}},
+		"undefined":    {[]string{"--password", "abc"}, []string{"password"}, []string{"--password", "REDACTED"}},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			var token string
+			var insecure bool
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			BindFlag(fs, &token, "token", "token", "t")
+			BindFlag(fs, &insecure, "insecure", "insecure")
+			args := append([]string(nil), tc.args...)
+			got := RedactArgs(fs, tc.args, tc.secret...)
+			if diff := cmp.Diff(got, tc.want); diff != "" {
+				t.Errorf("RedactArgs(): mismatch (-got,+want):\n%v", diff)
+			}
+			if diff := cmp.Diff(tc.args, args); diff != "" {
+				t.Errorf("RedactArgs(): modified args (-got,+want):\n%v", diff)
+			}
+		})
+	}
+}
+
+var testRecord = AuditRecord{
+	Path:       []string{"app", "remote", "add"},
+	Args:       []string{"--token=REDACTED", "origin"},
+	User:       "operator",
+	Time:       time.Date(2023, 4, 5, 6, 7, 8, 0, time.UTC),
+	ExitStatus: 1,
+}
+
+func TestJSONAuditor(t *testing.T) {
+	var buf bytes.Buffer
+	a := NewJSONAuditor(&buf)
+	for i := 0; i < 2; i++ {
+		if err := Audit(context.Background(), a, testRecord); err != nil {
+			t.Fatalf("Audit(): unexpected error: %v", err)
+		}
+	}
+	want := `{"path":["app","remote","add"],"args":["--token=REDACTED","origin"],"user":"operator","time":"2023-04-05T06:07:08Z","exit_status":1}` + "\n"
+	if diff := cmp.Diff(buf.String(), want+want); diff != "" {
+		t.Errorf("Audit(): mismatch (-got,+want):\n%v", diff)
+	}
+
+	// A nil Auditor is fine.
+	if err := Audit(context.Background(), nil, testRecord); err != nil {
+		t.Errorf("Audit(nil): unexpected error: %v", err)
+	}
+}
+
+func TestHTTPAuditor(t *testing.T) {
+	var got AuditRecord
+	status := http.StatusNoContent
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("Decode(): unexpected error: %v", err)
+		}
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	a := NewHTTPAuditor(srv.URL, srv.Client())
+	if err := a.Audit(context.Background(), testRecord); err != nil {
+		t.Fatalf("Audit(): unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(got, testRecord); diff != "" {
+		t.Errorf("Audit(): mismatch (-got,+want):\n%v", diff)
+	}
+
+	status = http.StatusInternalServerError
+	if err := a.Audit(context.Background(), testRecord); err == nil {
+		t.Error("Audit(): wanted error for failed request, got nil")
+	}
+}
@@ -0,0 +1,74 @@
+package cliche
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"sync"
+)
+
+// lockedBuffer is a bytes.Buffer which is safe for concurrent use, since
+// commands may write output from several goroutines.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// Capture holds the output written to an IO by a command, so that it can be
+// inspected, typically by tests.
+type Capture struct {
+	out, err lockedBuffer
+}
+
+// Out returns everything written to the captured IO's Out so far.
+func (c *Capture) Out() string {
+	return c.out.String()
+}
+
+// Err returns everything written to the captured IO's Err so far.
+func (c *Capture) Err() string {
+	return c.err.String()
+}
+
+// NewCaptureIO returns an IO with empty input, whose output is captured rather
+// than written anywhere. Callers wanting to provide input may set In on the
+// returned IO.
+func NewCaptureIO() (IO, *Capture) {
+	c := &Capture{}
+	return IO{
+		In:  strings.NewReader(""),
+		Out: &c.out,
+		Err: &c.err,
+	}, c
+}
+
+// TeeIO returns an IO which mirrors everything written to it to target, while
+// also capturing it. Input is read from, and paths resolved against, target.
+func TeeIO(target IO) (IO, *Capture) {
+	c := &Capture{}
+	tee := IO{
+		In:  target.In,
+		Out: &c.out,
+		Err: &c.err,
+		Dir: target.Dir,
+	}
+	if target.Out != nil {
+		tee.Out = io.MultiWriter(target.Out, &c.out)
+	}
+	if target.Err != nil {
+		tee.Err = io.MultiWriter(target.Err, &c.err)
+	}
+	return tee, c
+}
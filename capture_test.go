@@ -0,0 +1,59 @@
+package cliche
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestNewCaptureIO(t *testing.T) {
+	cio, c := NewCaptureIO()
+	if b, err := io.ReadAll(cio.In); err != nil || len(b) != 0 {
+		t.Errorf("NewCaptureIO(): got input %q, %v, want none", b, err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fmt.Fprint(cio.Out, "out")
+			fmt.Fprint(cio.Err, "err")
+		}()
+	}
+	wg.Wait()
+
+	if got, want := c.Out(), strings.Repeat("out", 10); got != want {
+		t.Errorf("Out(): got: %q want: %q", got, want)
+	}
+	if got, want := c.Err(), strings.Repeat("err", 10); got != want {
+		t.Errorf("Err(): got: %q want: %q", got, want)
+	}
+}
+
+func TestTeeIO(t *testing.T) {
+	var out, errs strings.Builder
+	target := IO{In: strings.NewReader("input"), Out: &out, Err: &errs}
+	tee, c := TeeIO(target)
+
+	fmt.Fprint(tee.Out, "Hello, World!")
+	fmt.Fprint(tee.Err, "Oh no!")
+	if b, err := io.ReadAll(tee.In); err != nil || string(b) != "input" {
+		t.Errorf("TeeIO(): got input %q, %v, want %q", b, err, "input")
+	}
+
+	for _, tc := range []struct {
+		name, got, want string
+	}{
+		{"target out", out.String(), "Hello, World!"},
+		{"target err", errs.String(), "Oh no!"},
+		{"captured out", c.Out(), "Hello, World!"},
+		{"captured err", c.Err(), "Oh no!"},
+	} {
+		if tc.got != tc.want {
+			t.Errorf("TeeIO(): %v: got: %q want: %q", tc.name, tc.got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package cliche
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// Shutdowner is implemented by commands which must release resources once
+// they have been Run, and which need a context to do so.
+type Shutdowner interface {
+	Shutdown(ctx context.Context) error
+}
+
+// Cleanup releases the resources held by cmd after it has been Run. If cmd
+// implements Shutdowner, its Shutdown method is called with a context which is
+// not cancelled along with ctx, so that cleanup still happens when a signal
+// has cancelled the command. If cmd implements io.Closer, its Close method is
+// called. Errors from either are returned together.
+func Cleanup(ctx context.Context, cmd any) error {
+	var errs []error
+	if s, ok := cmd.(Shutdowner); ok {
+		errs = append(errs, s.Shutdown(context.WithoutCancel(ctx)))
+	}
+	if c, ok := cmd.(io.Closer); ok {
+		errs = append(errs, c.Close())
+	}
+	return errors.Join(errs...)
+}
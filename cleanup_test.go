@@ -0,0 +1,65 @@
+package cliche
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type cleanupRecorder struct {
+	calls []string
+	err   error
+}
+
+func (r *cleanupRecorder) Shutdown(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		r.calls = append(r.calls, "Shutdown with "+err.Error())
+		return r.err
+	}
+	r.calls = append(r.calls, "Shutdown")
+	return r.err
+}
+
+func (r *cleanupRecorder) Close() error {
+	r.calls = append(r.calls, "Close")
+	return r.err
+}
+
+func TestCleanup(t *testing.T) {
+	errCleanup := errors.New("oh no")
+
+	for tn, tc := range map[string]struct {
+		err       error
+		cancelled bool
+		wantErr   bool
+	}{
+		"clean":                 {},
+		"cancelled":             {cancelled: true},
+		"errors are propagated": {err: errCleanup, wantErr: true},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tc.cancelled {
+				cancel()
+			}
+			r := &cleanupRecorder{err: tc.err}
+			err := Cleanup(ctx, r)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("Cleanup(): error mismatch: got: %v wantErr: %v", err, tc.wantErr)
+			}
+			if tc.wantErr && !errors.Is(err, errCleanup) {
+				t.Errorf("Cleanup(): got error %v, want %v", err, errCleanup)
+			}
+			if diff := cmp.Diff(r.calls, []string{"Shutdown", "Close"}); diff != "" {
+				t.Errorf("Cleanup(): calls mismatch(-got,+want):\n%v", diff)
+			}
+		})
+	}
+
+	if err := Cleanup(context.Background(), struct{}{}); err != nil {
+		t.Errorf("Cleanup(): got error %v for type without cleanup methods", err)
+	}
+}
@@ -22,6 +22,15 @@ type IO struct {
 	In  io.Reader
 	Out io.Writer
 	Err io.Writer
+
+	// Dir is the working directory against which the command resolves
+	// relative paths, as with Path. The empty string is the process's working
+	// directory.
+	Dir string
+
+	// Plain is true when help is to be shown plainly, for screen readers, as
+	// set by PlainIO.
+	Plain bool
 }
 
 type Tag string
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"idontfixcomputers.com/cliche"
+	"idontfixcomputers.com/cliche/meta"
+)
+
+// completionWriters write the completion script for each supported shell.
+var completionWriters = map[string]func(w io.Writer, prog string, cmds ...*meta.Command) error{
+	"bash": meta.WriteBashCompletion,
+	"zsh":  meta.WriteZshCompletion,
+	"fish": meta.WriteFishCompletion,
+}
+
+func completionUsage(fs *flag.FlagSet) func() {
+	return func() {
+		fmt.Fprintf(os.Stderr, "Usage: cliche completion -type=T [flags] bash|zsh|fish [file or directory]\n"+
+			"       cliche completion -types=T,U,... [flags] bash|zsh|fish [file or directory]\n\n"+
+			"Writes to stdout a completion script for the command which the same\n"+
+			"flags would generate.\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+}
+
+// runCompletion implements the completion subcommand, which is given the
+// arguments which follow it. It returns the program's exit status.
+func runCompletion(args []string) int {
+	fs := flag.NewFlagSet("cliche completion", flag.ExitOnError)
+	typeName := fs.String("type", "", "name of the type to complete; required unless -types is set")
+	types := fs.String("types", "", "comma-separated names of types completed as subcommands of one command")
+	name := fs.String("name", "", "name of the command; default is the package name, or the directory name for package main")
+	var verbosity cliche.Verbosity
+	verbosity.RegisterFlags(fs)
+	fs.Usage = completionUsage(fs)
+	fs.Parse(args)
+	setLogging(verbosity)
+
+	if (*typeName == "") == (*types == "") || fs.NArg() < 1 || fs.NArg() > 2 {
+		fs.Usage()
+		return 2
+	}
+	write, ok := completionWriters[fs.Arg(0)]
+	if !ok {
+		log.Printf("unsupported shell %q: want bash, zsh or fish", fs.Arg(0))
+		return 2
+	}
+	target := "."
+	if fs.NArg() == 2 {
+		target = fs.Arg(1)
+	}
+	cmd := compile(meta.Options{Type: *typeName, Types: *types, Name: *name, Strict: true}, target)
+
+	// A command with subcommands is completed by them, and one without as
+	// the program itself.
+	cmds := cmd.Children
+	if len(cmds) == 0 {
+		cmds = []*meta.Command{cmd}
+	}
+	if err := write(os.Stdout, cmd.Name, cmds...); err != nil {
+		log.Print(err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"idontfixcomputers.com/cliche/meta"
+)
+
+func fmtUsage(fs *flag.FlagSet) func() {
+	return func() {
+		fmt.Fprintf(os.Stderr, "Usage: cliche fmt [-l] [-w] [file or directory ...]\n\n"+
+			"Rewrites cliche struct tags into canonical form. Directories are\n"+
+			"formatted recursively.\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+}
+
+// goFiles returns the Go files named by paths, walking directories
+// recursively. An empty list of paths is the current directory.
+func goFiles(paths []string) ([]string, error) {
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	var files []string
+	for _, path := range paths {
+		err := filepath.WalkDir(path, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && strings.HasSuffix(path, ".go") {
+				files = append(files, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// runFmt implements the fmt subcommand, which is given the arguments which
+// follow it. It returns the program's exit status.
+func runFmt(args []string) int {
+	fs := flag.NewFlagSet("cliche fmt", flag.ExitOnError)
+	list := fs.Bool("l", false, "list files whose tags are not in canonical form")
+	write := fs.Bool("w", false, "write result to the source file instead of standard output")
+	fs.Usage = fmtUsage(fs)
+	fs.Parse(args)
+
+	files, err := goFiles(fs.Args())
+	if err != nil {
+		log.Print(err)
+		return 2
+	}
+	status := 0
+	for _, file := range files {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			log.Print(err)
+			status = 2
+			continue
+		}
+		out, err := meta.FormatTags(file, src)
+		if err != nil {
+			log.Print(err)
+			status = 2
+			if out == nil {
+				continue
+			}
+		}
+		changed := !bytes.Equal(src, out)
+		if *list && changed {
+			fmt.Println(file)
+		}
+		switch {
+		case *write && changed:
+			if err := os.WriteFile(file, out, 0o644); err != nil {
+				log.Print(err)
+				status = 2
+			}
+		case !*list && !*write:
+			os.Stdout.Write(out)
+		}
+	}
+	return status
+}
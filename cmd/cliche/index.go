@@ -0,0 +1,105 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/build"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/iancoleman/strcase"
+	"idontfixcomputers.com/cliche"
+	"idontfixcomputers.com/cliche/meta"
+)
+
+func indexUsage(fs *flag.FlagSet) func() {
+	return func() {
+		fmt.Fprintf(os.Stderr, "Usage: cliche index [flags] [module directory]\n\n"+
+			"Writes a command to the current directory which runs, as its\n"+
+			"subcommands, every command generated by cliche in the packages of\n"+
+			"the module, which is that of the current directory by default.\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+}
+
+// runIndex implements the index subcommand, which is given the arguments
+// which follow it. It returns the program's exit status.
+func runIndex(args []string) int {
+	fs := flag.NewFlagSet("cliche index", flag.ExitOnError)
+	name := fs.String("name", "", "name of the command; default is the name of the current directory")
+	output := fs.String("output", "", "output file; default <name>_cliche.go")
+	dflt := fs.String("default", "", "subcommand run when none is named; default is to show help")
+	var verbosity cliche.Verbosity
+	verbosity.RegisterFlags(fs)
+	fs.Usage = indexUsage(fs)
+	fs.Parse(args)
+	setLogging(verbosity)
+
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return 2
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		log.Print(err)
+		return 1
+	}
+	root := fs.Arg(0)
+	if root == "" {
+		if root, err = moduleRoot(wd); err != nil {
+			log.Print(err)
+			return 1
+		}
+	}
+	cmds, err := meta.ScanModule(root)
+	if err != nil {
+		log.Print(err)
+		return 1
+	}
+
+	// The index joins the package of the current directory, if there is one.
+	pkg := "main"
+	if p, err := build.ImportDir(wd, 0); err == nil {
+		pkg = p.Name
+	}
+	if *name == "" {
+		*name = strcase.ToKebab(filepath.Base(wd))
+	}
+	idx := meta.NewIndex(*name, pkg, cmds...)
+	idx.Default = *dflt
+	out := *output
+	if out == "" {
+		out = strcase.ToSnake(idx.Name) + "_cliche.go"
+	}
+
+	f, err := os.Create(out)
+	if err != nil {
+		log.Print(err)
+		return 1
+	}
+	if err := idx.Generate(f); err != nil {
+		f.Close()
+		os.Remove(out)
+		log.Print(err)
+		return 1
+	}
+	if err := f.Close(); err != nil {
+		log.Print(err)
+		return 1
+	}
+	return 0
+}
+
+// moduleRoot returns the directory of the module containing dir: the nearest
+// of it and its parents with a go.mod file.
+func moduleRoot(dir string) (string, error) {
+	for d := dir; ; d = filepath.Dir(d) {
+		if _, err := os.Stat(filepath.Join(d, "go.mod")); err == nil {
+			return d, nil
+		}
+		if filepath.Dir(d) == d {
+			return "", fmt.Errorf("%v is not in a module", dir)
+		}
+	}
+}
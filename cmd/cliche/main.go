@@ -0,0 +1,231 @@
+// Command cliche generates Go source wrapping a type as a command line
+// program. It is intended to be run by go generate, from a directive next to
+// the type:
+//
+//	//go:generate cliche -type=Tester
+//
+// Usage:
+//
+//	cliche -type=T [-output=file] [-name=name] [-internal] [-pflag] [-argfiles] [-abbrev] [-env] [-selfupdate] [-pipes] [-timeout] [-schedule] [-interactive] [-manifest] [-showconfig] [-slices=policy] [-strict=false] [file or directory]
+//	cliche -types=T,U,... [-output=file] [-name=name] [-internal] [-pflag] [-argfiles] [-abbrev] [-env] [-selfupdate] [-pipes] [-timeout] [-schedule] [-interactive] [-manifest] [-showconfig] [-slices=policy] [-strict=false] [file or directory]
+//	cliche fmt [-l] [-w] [file or directory ...]
+//	cliche completion -type=T|-types=T,U,... [-name=name] bash|zsh|fish [file or directory]
+//	cliche preview -type=T|-types=T,U,... [-name=name] [-format=help|man] [file or directory]
+//	cliche index [-name=name] [-output=file] [-default=name] [module directory]
+//	cliche vet [-format=text|sarif] [package directory ...]
+//	cliche status-env bash|zsh|fish
+//
+// The type is found in the Go files of the package in the given directory,
+// which is the current one by default, or in the single file given. Its command is written
+// to the output file, which defaults to t_cliche.go in the same directory,
+// where t is the lower-cased type name.
+//
+// A type of another package is named by its import path, as in
+// -type=example.com/lib/tool.Options. Its package is loaded as the go command
+// would load it from the current directory, and its command is generated into
+// the package of the given directory, which imports it. Only the exported
+// fields of the type, and of its subcommands, can then be inputs. With -types,
+// every type is named so, and all are of one package.
+//
+// With -types, each of several types becomes a subcommand, named after the
+// type in kebab-case, of a parent command named after the package. The parent
+// dispatches by its first argument, and is written along with its
+// subcommands to the output file, which defaults to name_cliche.go, where
+// name is that of the parent in snake_case.
+//
+// With -internal, the command is generated into a package of its own instead,
+// in the directory internal/clichegen/pkg beneath the given one, where pkg is
+// the name of the command in lower case, less anything but letters and digits.
+// That package imports the one declaring the types, keeping generated code out
+// of it, and declares no main function: the program runs the command by
+// calling pkg.RunT. Only the exported fields of the types can then be inputs.
+//
+// Run without a verb or subcommand, a command which can't run itself shows
+// its help, unless -default names one to run instead. Commands with verbs or
+// subcommands take help, followed by the names of one, to show its help, and
+// every command shows its help when asked with -h or -help anywhere among
+// its arguments.
+//
+// With -pflag, a NewTFlagSet function is also written for each command type
+// T, which binds the command's flags to a pflag.FlagSet, for programs built on
+// github.com/spf13/pflag to parse along with their own. The module of the
+// command then requires that package.
+//
+// With -argfiles, the command replaces each argument of the form @file with the
+// arguments held by the file, one per line, before parsing any of them, as
+// cliche.ExpandResponseFiles does. With -abbrev, every command of the tree
+// accepts unique prefixes of the long names of its flags, as --verb for
+// --verbose, and of the names of its verbs and subcommands, unless it takes
+// positional arguments of its own.
+//
+// With -env, the command, which must have verbs or subcommands, also takes
+// env, which lists the environment variables the program reads: those bound
+// to flags by env tag components, with the flag each sets, where its value
+// comes from and its default, and those read by cliche itself.
+//
+// With -selfupdate, the command, which must have verbs or subcommands, also
+// takes selfupdate, which updates the program to its latest release with the
+// cliche.Updater registered with cliche.Provide, as cliche.SelfUpdate does.
+//
+// With -pipes, arguments separated by '|' arguments, before any --, are run as
+// a pipeline of commands in one process, as cliche.RunPipeline runs them, the
+// output of each read as the standard input of the next.
+//
+// With -timeout, the command takes a --timeout flag, bounding how long
+// whichever command of the tree runs, as cliche.RunTimed does, unless it
+// implements cliche.Untimed. With -schedule, it takes --every, --jitter,
+// --until and --keep-going flags, running whichever command runs repeatedly on
+// that schedule, as cliche.RunScheduled does.
+//
+// With -interactive, each command with flags takes an --interactive flag,
+// asking for those not given on the command line or by the environment with a
+// form, as cliche.Form does. Positional arguments are still given on the
+// command line.
+//
+// With -manifest, each command with flags takes a --from-file flag, naming a
+// JSON manifest decoded into the command, as cliche.DecodeManifest does, before
+// the flags given on the command line and by the environment apply on top.
+//
+// With -showconfig, each command with flags takes a --show-config flag, which
+// shows the final value of each flag and where it comes from, rather than
+// running the command, as cliche.ShowConfig does.
+//
+// With -slices=split or -slices=both, the values given to each flag bound to a
+// slice are split by commas, or the separator of its sep tag component, as
+// for cliche.SliceSplit and cliche.SliceBoth. By default, each use of the flag
+// adds one value, or those separated by its sep.
+//
+// Struct tags are parsed strictly: a component which is not part of the
+// cliche tag grammar, such as a misspelled falg:, is an error, which suggests
+// the component likely meant. With -strict=false, it is only a warning, and
+// the component is ignored.
+//
+// The fmt subcommand rewrites the cliche struct tags of Go files into
+// canonical form, much as gofmt does for the rest of the source. Legacy tags
+// without a key are given the cliche key.
+//
+// The completion subcommand writes to stdout a completion script for the
+// given shell, which completes the subcommands, verbs and flags of the
+// command which -type or -types would generate, along with the values hinted
+// by complete tag components.
+//
+// The preview subcommand writes to stdout the help which the command that
+// -type or -types would generate shows, for it and each of its subcommands,
+// without generating any code. With -format=man, the help is written as man
+// pages instead.
+//
+// The index subcommand writes to the current directory a command which runs,
+// as its subcommands, every command which the cliche go:generate directives
+// of the packages of the module generate, importing each from its package.
+// Commands of package main, which can't be imported, are skipped. Run from a
+// go:generate directive of its own, it keeps the index up to date as command
+// packages are added.
+//
+// The vet subcommand compiles every command which the cliche go:generate
+// directives of the packages in the given directories generate, the current
+// one by default, and reports the problems which generating them would find,
+// with their positions, without writing any code. A directory ending in /...,
+// as in ./..., includes the packages beneath it, as for the go command. It
+// exits with status 1 when any problem is more than a warning. With
+// -format=sarif, the problems are written to stdout as a SARIF log instead,
+// for code review systems and editors to annotate the source with.
+//
+// The status-env subcommand writes to stdout a snippet for the startup file of
+// the given shell, which exports the exit status and duration of the last
+// generated command run in the shell for prompts to show. Generated commands
+// write their status to the file named by CLICHE_STATUS_ENV, which the snippet
+// sets.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"idontfixcomputers.com/cliche"
+	"idontfixcomputers.com/cliche/meta"
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: cliche -type=T [flags] [file or directory]\n       cliche -types=T,U,... [flags] [file or directory]\n       cliche fmt [-l] [-w] [file or directory ...]\n       cliche completion [flags] bash|zsh|fish [file or directory]\n       cliche preview [flags] [file or directory]\n       cliche index [flags] [module directory]\n       cliche vet [flags] [package directory ...]\n       cliche status-env bash|zsh|fish\n\nFlags:\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("cliche: ")
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "fmt":
+			os.Exit(runFmt(os.Args[2:]))
+		case "completion":
+			os.Exit(runCompletion(os.Args[2:]))
+		case "index":
+			os.Exit(runIndex(os.Args[2:]))
+		case "preview":
+			os.Exit(runPreview(os.Args[2:]))
+		case "vet":
+			os.Exit(runVet(os.Args[2:]))
+		case "status-env":
+			os.Exit(runStatus(os.Args[2:]))
+		}
+	}
+	var opts meta.Options
+	opts.RegisterFlags(flag.CommandLine)
+	var verbosity cliche.Verbosity
+	verbosity.RegisterFlags(flag.CommandLine)
+	flag.Usage = usage
+	flag.Parse()
+	setLogging(verbosity)
+
+	if (opts.Type == "") == (opts.Types == "") || flag.NArg() > 1 {
+		usage()
+		os.Exit(2)
+	}
+	target := "."
+	if flag.NArg() == 1 {
+		target = flag.Arg(0)
+	}
+	cmd := compile(opts, target)
+	out := opts.OutputFile(cmd, target)
+	if err := os.MkdirAll(filepath.Dir(out), 0o755); err != nil {
+		log.Fatal(err)
+	}
+
+	f, err := os.Create(out)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := cmd.Generate(f); err != nil {
+		f.Close()
+		os.Remove(out)
+		log.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// setLogging sends logs to stderr at the given verbosity.
+func setLogging(verbosity cliche.Verbosity) {
+	// Compilation is chatty at the information level, which is only of
+	// interest when asked for.
+	verbosity--
+	slog.SetDefault(slog.New(verbosity.Handler(os.Stderr)))
+	// Setting the default slog logger sends the log package's output through
+	// it too, where fatal errors would be logged at the information level.
+	log.SetOutput(os.Stderr)
+}
+
+// compile the command which opts generate from target, which is a file or
+// directory, exiting when it can't be.
+func compile(opts meta.Options, target string) *meta.Command {
+	cmd, err := opts.Compile(target)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return cmd
+}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// run calls a subcommand with args, returning what it wrote to stdout and
+// stderr, and its exit status.
+func run(t *testing.T, sub func(args []string) int, args ...string) (stdout, stderr string, code int) {
+	t.Helper()
+	dir := t.TempDir()
+	outFile, err := os.Create(filepath.Join(dir, "stdout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer outFile.Close()
+	errFile, err := os.Create(filepath.Join(dir, "stderr"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer errFile.Close()
+
+	oldStdout, oldStderr, oldLogger := os.Stdout, os.Stderr, slog.Default()
+	os.Stdout, os.Stderr = outFile, errFile
+	log.SetOutput(errFile)
+	defer func() {
+		os.Stdout, os.Stderr = oldStdout, oldStderr
+		slog.SetDefault(oldLogger)
+		log.SetOutput(oldStderr)
+	}()
+	code = sub(args)
+
+	out, err := os.ReadFile(outFile.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	errOut, err := os.ReadFile(errFile.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out), string(errOut), code
+}
+
+// testCase is a run of a subcommand, and what it should write and exit with.
+// Output is checked for containing each of stdout and stderr.
+type testCase struct {
+	args           []string
+	stdout, stderr []string
+	code           int
+}
+
+func (tc testCase) check(t *testing.T, name string, sub func(args []string) int) {
+	t.Helper()
+	stdout, stderr, code := run(t, sub, tc.args...)
+	if code != tc.code {
+		t.Errorf("%v %q: got exit status %d, want %d; stderr:\n%v", name, tc.args, code, tc.code, stderr)
+	}
+	for _, want := range tc.stdout {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("%v %q: stdout does not contain %q:\n%v", name, tc.args, want, stdout)
+		}
+	}
+	for _, want := range tc.stderr {
+		if !strings.Contains(stderr, want) {
+			t.Errorf("%v %q: stderr does not contain %q:\n%v", name, tc.args, want, stderr)
+		}
+	}
+	if len(tc.stdout) == 0 && stdout != "" {
+		t.Errorf("%v %q: got stdout %q, want none", name, tc.args, stdout)
+	}
+}
+
+const testdata = "../../meta/testdata"
+
+func TestRunFmt(t *testing.T) {
+	src := "package tags\n\ntype Tags struct {\n\tPort int `cliche:\" default:80 ; flag:port\"`\n}\n"
+	want := "package tags\n\ntype Tags struct {\n\tPort int `cliche:\"flag:port;default:80\"`\n}\n"
+	canonical := filepath.Join(testdata, "simple")
+
+	// Each case is given a directory holding a file whose tags are not in
+	// canonical form, and says what the file should hold afterwards.
+	for tn, tc := range map[string]func(dir, file string) (testCase, string){
+		"stdout": func(dir, file string) (testCase, string) {
+			return testCase{args: []string{file}, stdout: []string{want}}, src
+		},
+		"list": func(dir, file string) (testCase, string) {
+			return testCase{args: []string{"-l", dir, canonical}, stdout: []string{file + "\n"}}, src
+		},
+		"canonical": func(dir, file string) (testCase, string) {
+			return testCase{args: []string{"-l", canonical}}, src
+		},
+		"write": func(dir, file string) (testCase, string) {
+			return testCase{args: []string{"-w", dir}}, want
+		},
+		"missing": func(dir, file string) (testCase, string) {
+			return testCase{args: []string{filepath.Join(dir, "missing")}, stderr: []string{"no such file or directory"}, code: 2}, src
+		},
+		"malformed": func(dir, file string) (testCase, string) {
+			bad := filepath.Join(dir, "bad.go")
+			if err := os.WriteFile(bad, []byte("package tags\n\ntype Bad struct {\n\tX string `cliche:\"arg:[x]\"`\n}\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			return testCase{args: []string{"-l", bad}, stderr: []string{"bad.go:4:11"}, code: 2}, src
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			dir := t.TempDir()
+			file := filepath.Join(dir, "tags.go")
+			if err := os.WriteFile(file, []byte(src), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			c, after := tc(dir, file)
+			c.check(t, "cliche fmt", runFmt)
+
+			got, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != after {
+				t.Errorf("cliche fmt: got file %q, want %q", got, after)
+			}
+		})
+	}
+}
+
+func TestRunCompletion(t *testing.T) {
+	simple := filepath.Join(testdata, "simple")
+	for tn, tc := range map[string]testCase{
+		"bash": {
+			args:   []string{"-type=Tester", "bash", simple},
+			stdout: []string{"# bash completion for simple, generated by cliche.", "simple:--string)"},
+		},
+		"zsh":  {args: []string{"-type=Tester", "-name=tester", "zsh", simple}, stdout: []string{"tester"}},
+		"fish": {args: []string{"-type=Tester", "fish", simple}, stdout: []string{"complete -c simple"}},
+		"unsupported shell": {
+			args:   []string{"-type=Tester", "tcsh", simple},
+			stderr: []string{`unsupported shell "tcsh": want bash, zsh or fish`},
+			code:   2,
+		},
+		"no type":  {args: []string{"bash", simple}, stderr: []string{"Usage: cliche completion"}, code: 2},
+		"no shell": {args: []string{"-type=Tester"}, stderr: []string{"Usage: cliche completion"}, code: 2},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			tc.check(t, "cliche completion", runCompletion)
+		})
+	}
+}
+
+func TestRunIndex(t *testing.T) {
+	module := filepath.Join(testdata, "index")
+	out := filepath.Join(t.TempDir(), "tool_cliche.go")
+	for tn, tc := range map[string]testCase{
+		"index":     {args: []string{"-name=tool", "-output=" + out, "-default=greet", module}},
+		"no module": {args: []string{"-output=" + out, testdata}, stderr: []string{"go.mod"}, code: 1},
+		"too many":  {args: []string{module, module}, stderr: []string{"Usage: cliche index"}, code: 2},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			tc.check(t, "cliche index", runIndex)
+		})
+	}
+
+	got, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"// Code generated by cliche index; DO NOT EDIT.\n",
+		"func RunTool(ctx context.Context, stdio cliche.IO, args []string) error {",
+		"return greet.RunGreet(ctx, stdio, args[1:])",
+		"return suite.RunUpdown(ctx, stdio, args[1:])",
+	} {
+		if !strings.Contains(string(got), want) {
+			t.Errorf("cliche index: code does not contain %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestRunPreview(t *testing.T) {
+	simple := filepath.Join(testdata, "simple")
+	for tn, tc := range map[string]testCase{
+		"help": {
+			args:   []string{"-type=Tester", simple},
+			stdout: []string{"Usage: simple [flags]\n", "Tester is a cliche command which exercises default inputs.\n", "-string string\tString command input.\n"},
+		},
+		"man": {args: []string{"-type=Tester", "-format=man", simple}, stdout: []string{".TH SIMPLE 1"}},
+		"invalid": {
+			args:   []string{"-type=Dialer", filepath.Join(testdata, "validators")},
+			stdout: []string{"Usage: validators"},
+			stderr: []string{"has no validator method CheckMissing(string) error"},
+		},
+		"unsupported format": {
+			args:   []string{"-type=Tester", "-format=html", simple},
+			stderr: []string{`unsupported format "html": want help or man`},
+			code:   2,
+		},
+		"no type": {args: []string{simple}, stderr: []string{"Usage: cliche preview"}, code: 2},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			tc.check(t, "cliche preview", runPreview)
+		})
+	}
+}
+
+func TestRunVet(t *testing.T) {
+	simple := filepath.Join(testdata, "simple")
+	validators := filepath.Join(testdata, "validators")
+	for tn, tc := range map[string]testCase{
+		"valid": {args: []string{simple}},
+		"invalid": {
+			args:   []string{simple, validators},
+			stderr: []string{"validators.go:27:17: field Missing: has no validator method CheckMissing(string) error\n"},
+			code:   1,
+		},
+		"sarif valid": {args: []string{"-format=sarif", simple}, stdout: []string{`"version": "2.1.0"`}},
+		"sarif invalid": {
+			args:   []string{"-format=sarif", validators},
+			stdout: []string{`"ruleId": "invalid-command"`, `"uri": "../../meta/testdata/validators/validators.go"`},
+			code:   1,
+		},
+		"missing": {args: []string{filepath.Join(testdata, "missing")}, stderr: []string{"missing"}, code: 1},
+		"unsupported format": {
+			args:   []string{"-format=json", simple},
+			stderr: []string{`unsupported format "json": want text or sarif`},
+			code:   2,
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			tc.check(t, "cliche vet", runVet)
+		})
+	}
+
+	// SARIF is written alone to stdout, for tools which read it, with a
+	// result for each problem.
+	stdout, stderr, _ := run(t, runVet, "-format=sarif", simple, validators, filepath.Join(testdata, "norun"))
+	if strings.Contains(stderr, "CheckMissing(string)") {
+		t.Errorf("cliche vet -format=sarif: problems written to stderr:\n%v", stderr)
+	}
+	var sarif struct {
+		Runs []struct {
+			Results []struct {
+				RuleID string `json:"ruleId"`
+				Level  string `json:"level"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &sarif); err != nil {
+		t.Fatalf("cliche vet -format=sarif: output does not decode: %v\n%v", err, stdout)
+	}
+	if len(sarif.Runs) != 1 || len(sarif.Runs[0].Results) != 2 {
+		t.Fatalf("cliche vet -format=sarif: got %+v, want one run with 2 results", sarif.Runs)
+	}
+	for _, result := range sarif.Runs[0].Results {
+		if result.RuleID != "invalid-command" || result.Level != "error" {
+			t.Errorf("cliche vet -format=sarif: got result %+v, want an invalid-command error", result)
+		}
+	}
+}
+
+func TestRunStatus(t *testing.T) {
+	for tn, tc := range map[string]testCase{
+		"bash": {args: []string{"bash"}, stdout: []string{`PROMPT_COMMAND="__cliche_status${PROMPT_COMMAND:+;$PROMPT_COMMAND}"`}},
+		"zsh":  {args: []string{"zsh"}, stdout: []string{"add-zsh-hook precmd __cliche_status\n"}},
+		"fish": {args: []string{"fish"}, stdout: []string{"# Status of the last cliche command, for prompts. Generated by cliche.\n", "set -gx CLICHE_STATUS_ENV"}},
+		"unsupported shell": {
+			args:   []string{"tcsh"},
+			stderr: []string{`unsupported shell "tcsh": want bash, zsh or fish`},
+			code:   2,
+		},
+		"no shell":   {stderr: []string{"Usage: cliche status-env bash|zsh|fish\n"}, code: 2},
+		"two shells": {args: []string{"bash", "zsh"}, stderr: []string{"Usage: cliche status-env"}, code: 2},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			tc.check(t, "cliche status-env", runStatus)
+		})
+	}
+}
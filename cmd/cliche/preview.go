@@ -0,0 +1,73 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+
+	"idontfixcomputers.com/cliche"
+	"idontfixcomputers.com/cliche/meta"
+)
+
+// previewWriters write the help of a command in each supported format.
+var previewWriters = map[string]func(cmd *meta.Command, w io.Writer) error{
+	"help": (*meta.Command).WriteHelp,
+	"man":  (*meta.Command).WriteManPages,
+}
+
+func previewUsage(fs *flag.FlagSet) func() {
+	return func() {
+		fmt.Fprintf(os.Stderr, "Usage: cliche preview -type=T [flags] [file or directory]\n"+
+			"       cliche preview -types=T,U,... [flags] [file or directory]\n\n"+
+			"Writes to stdout the help of the command which the same flags would\n"+
+			"generate, without generating it.\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+}
+
+// runPreview implements the preview subcommand, which is given the arguments
+// which follow it. It returns the program's exit status.
+func runPreview(args []string) int {
+	fs := flag.NewFlagSet("cliche preview", flag.ExitOnError)
+	typeName := fs.String("type", "", "name of the type to preview; required unless -types is set")
+	types := fs.String("types", "", "comma-separated names of types previewed as subcommands of one command")
+	name := fs.String("name", "", "name of the command; default is the package name, or the directory name for package main")
+	format := fs.String("format", "help", "format of the preview: help, as shown by -help, or man")
+	var verbosity cliche.Verbosity
+	verbosity.RegisterFlags(fs)
+	fs.Usage = previewUsage(fs)
+	fs.Parse(args)
+	setLogging(verbosity)
+
+	if (*typeName == "") == (*types == "") || fs.NArg() > 1 {
+		fs.Usage()
+		return 2
+	}
+	write, ok := previewWriters[*format]
+	if !ok {
+		log.Printf("unsupported format %q: want help or man", *format)
+		return 2
+	}
+	target := "."
+	if fs.NArg() == 1 {
+		target = fs.Arg(0)
+	}
+	cmd := compile(meta.Options{Type: *typeName, Types: *types, Name: *name, Strict: true}, target)
+
+	// Help is previewed even for a command which would not generate, so that
+	// its docs can be worked on alongside its tags.
+	if err := cmd.Validate(); err != nil {
+		log.Print(err)
+	}
+	for _, warning := range cmd.Warnings() {
+		slog.Warn(warning.Error())
+	}
+	if err := write(cmd, os.Stdout); err != nil {
+		log.Print(err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"idontfixcomputers.com/cliche"
+)
+
+func statusUsage() {
+	fmt.Fprintf(os.Stderr, "Usage: cliche status-env bash|zsh|fish\n\n"+
+		"Writes to stdout a snippet for the shell's startup file, which exports\n"+
+		"the status of the last cliche command run in the shell to\n"+
+		"CLICHE_STATUS_EXIT, CLICHE_STATUS_DURATION_MS and CLICHE_STATUS_COMMAND\n"+
+		"before each prompt.\n")
+}
+
+// runStatus implements the status-env subcommand, which is given the
+// arguments which follow it. It returns the program's exit status.
+func runStatus(args []string) int {
+	fs := flag.NewFlagSet("cliche status-env", flag.ExitOnError)
+	fs.Usage = statusUsage
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+	if err := cliche.WriteStatusSnippet(os.Stdout, fs.Arg(0)); err != nil {
+		log.Print(err)
+		return 2
+	}
+	return 0
+}
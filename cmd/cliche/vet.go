@@ -0,0 +1,115 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"idontfixcomputers.com/cliche"
+	"idontfixcomputers.com/cliche/meta"
+)
+
+func vetUsage(fs *flag.FlagSet) func() {
+	return func() {
+		fmt.Fprintf(os.Stderr, "Usage: cliche vet [flags] [package directory ...]\n\n"+
+			"Reports the problems with the commands which the cliche go:generate\n"+
+			"directives of the packages generate, without generating them. A\n"+
+			"directory ending in /... includes the packages beneath it.\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+}
+
+// packageDirs returns the directories of the packages matched by patterns, as
+// given to the vet subcommand: each directory, or with a /... suffix, it and
+// those beneath it which the go command would match, skipping testdata, vendor,
+// those beginning with . or _, and nested modules. No patterns is the current
+// directory.
+func packageDirs(patterns []string) ([]string, error) {
+	if len(patterns) == 0 {
+		patterns = []string{"."}
+	}
+	var dirs []string
+	for _, pattern := range patterns {
+		root, ok := strings.CutSuffix(pattern, "/...")
+		if !ok {
+			dirs = append(dirs, pattern)
+			continue
+		}
+		if root == "" {
+			root = "/"
+		}
+		err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+			if err != nil || !d.IsDir() {
+				return err
+			}
+			if p != root {
+				if name := d.Name(); name == "testdata" || name == "vendor" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") {
+					return filepath.SkipDir
+				}
+				if _, err := os.Stat(filepath.Join(p, "go.mod")); err == nil {
+					return filepath.SkipDir
+				}
+			}
+			dirs = append(dirs, p)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return dirs, nil
+}
+
+// runVet implements the vet subcommand, which is given the arguments which
+// follow it. It returns the program's exit status: 1 when any command has
+// problems which would stop it from being generated, and 0 when there are at
+// most warnings.
+func runVet(args []string) int {
+	fs := flag.NewFlagSet("cliche vet", flag.ExitOnError)
+	format := fs.String("format", "text", "format of the problems: text, written to stderr, or sarif, written to stdout")
+	var verbosity cliche.Verbosity
+	verbosity.RegisterFlags(fs)
+	fs.Usage = vetUsage(fs)
+	fs.Parse(args)
+	setLogging(verbosity)
+
+	if *format != "text" && *format != "sarif" {
+		log.Printf("unsupported format %q: want text or sarif", *format)
+		return 2
+	}
+	dirs, err := packageDirs(fs.Args())
+	if err != nil {
+		log.Print(err)
+		return 1
+	}
+	status := 0
+	var all []*meta.ValidationError
+	for _, dir := range dirs {
+		problems, err := meta.Vet(dir)
+		if err != nil {
+			log.Print(err)
+			status = 1
+			continue
+		}
+		for _, problem := range problems {
+			if *format == "text" {
+				fmt.Fprintln(os.Stderr, problem)
+			}
+			if !problem.Warning {
+				status = 1
+			}
+		}
+		all = append(all, problems...)
+	}
+	if *format == "sarif" {
+		if err := meta.WriteSARIF(os.Stdout, all); err != nil {
+			log.Print(err)
+			return 1
+		}
+	}
+	return status
+}
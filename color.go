@@ -0,0 +1,154 @@
+package cliche
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// ColorMode selects whether output may contain ANSI escape sequences, as set
+// by a --color flag.
+type ColorMode string
+
+const (
+	// ColorAuto allows escape sequences only when writing to a terminal.
+	ColorAuto ColorMode = "auto"
+
+	// ColorAlways allows escape sequences, even when output is piped.
+	ColorAlways ColorMode = "always"
+
+	// ColorNever strips escape sequences from all output.
+	ColorNever ColorMode = "never"
+)
+
+// ParseColorMode parses the value of a --color flag. The empty string is
+// ColorAuto.
+func ParseColorMode(s string) (ColorMode, error) {
+	switch mode := ColorMode(s); mode {
+	case "":
+		return ColorAuto, nil
+	case ColorAuto, ColorAlways, ColorNever:
+		return mode, nil
+	}
+	return "", fmt.Errorf("invalid color mode %q: must be one of auto, always, never", s)
+}
+
+// isTerminal is true when w is a character device, such as a terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// UseColor is true when output to w may contain escape sequences under mode.
+// In ColorAuto mode, that is when w is a terminal and the NO_COLOR environment
+// variable is unset or empty. On Windows, virtual terminal processing is
+// enabled on consoles which are to receive escape sequences, and in ColorAuto
+// mode, consoles which can't enable it get plain output.
+func UseColor(w io.Writer, mode ColorMode) bool {
+	switch mode {
+	case ColorAlways:
+		enableVirtualTerminal(w)
+		return true
+	case ColorNever:
+		return false
+	}
+	return isTerminal(w) && os.Getenv("NO_COLOR") == "" && enableVirtualTerminal(w)
+}
+
+// ColorIO returns stdio with ANSI escape sequences stripped from Out and Err,
+// where they may not contain color under mode. Output which StripANSI already
+// strips is unwrapped first, so that the mode chosen by a --color flag replaces
+// that with which a generated main function first wrapped its output.
+func ColorIO(stdio IO, mode ColorMode) IO {
+	stdio.Out = colorWriter(stdio.Out, mode)
+	stdio.Err = colorWriter(stdio.Err, mode)
+	return stdio
+}
+
+// colorWriter returns w, or the writer it strips, when it may contain color
+// under mode, and otherwise a writer stripping escape sequences from it.
+func colorWriter(w io.Writer, mode ColorMode) io.Writer {
+	if s, ok := w.(*ansiStripper); ok {
+		w = s.w
+	}
+	if UseColor(w, mode) {
+		return w
+	}
+	return StripANSI(w)
+}
+
+// ansiState is the state of an ansiStripper between bytes.
+type ansiState int
+
+const (
+	ansiText ansiState = iota
+	ansiEscape
+	ansiCSI
+	ansiString
+	ansiStringEscape
+)
+
+// ansiStripper removes escape sequences from what is written through it. It
+// tracks its place in a sequence, so sequences may be split across writes.
+type ansiStripper struct {
+	w     io.Writer
+	state ansiState
+}
+
+// StripANSI returns a writer which removes ANSI escape sequences, such as
+// those setting colors, before writing to w.
+func StripANSI(w io.Writer) io.Writer {
+	return &ansiStripper{w: w}
+}
+
+func (s *ansiStripper) Write(p []byte) (int, error) {
+	out := make([]byte, 0, len(p))
+	for _, b := range p {
+		switch s.state {
+		case ansiText:
+			if b == 0x1b {
+				s.state = ansiEscape
+				continue
+			}
+			out = append(out, b)
+		case ansiEscape:
+			switch b {
+			case '[':
+				s.state = ansiCSI
+			case ']', 'P', '_', '^':
+				// Operating system commands and other strings, such as
+				// hyperlinks, run until a terminator.
+				s.state = ansiString
+			default:
+				s.state = ansiText
+			}
+		case ansiCSI:
+			// Parameter and intermediate bytes continue the sequence until
+			// the final byte.
+			if b >= 0x40 && b <= 0x7e {
+				s.state = ansiText
+			}
+		case ansiString:
+			switch b {
+			case 0x07:
+				s.state = ansiText
+			case 0x1b:
+				s.state = ansiStringEscape
+			}
+		case ansiStringEscape:
+			if b == '\\' {
+				s.state = ansiText
+			} else {
+				s.state = ansiString
+			}
+		}
+	}
+	if _, err := s.w.Write(out); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,94 @@
+package cliche
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseColorMode(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		s       string
+		want    ColorMode
+		wantErr bool
+	}{
+		"empty":   {"", ColorAuto, false},
+		"auto":    {"auto", ColorAuto, false},
+		"always":  {"always", ColorAlways, false},
+		"never":   {"never", ColorNever, false},
+		"invalid": {"sometimes", "", true},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			got, err := ParseColorMode(tc.s)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("ParseColorMode(): error mismatch: got: %v wantErr: %v", err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Errorf("ParseColorMode(): got: %q want: %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestStripANSI(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		writes []string
+		want   string
+	}{
+		"plain":      {[]string{"hello\n"}, "hello\n"},
+		"color":      {[]string{"\x1b[1;31merror\x1b[0m: oh no"}, "error: oh no"},
+		"split":      {[]string{"\x1b", "[3", "2mok\x1b[", "0m"}, "ok"},
+		"hyperlink":  {[]string{"\x1b]8;;https://example.com\x07link\x1b]8;;\x1b\\"}, "link"},
+		"two byte":   {[]string{"a\x1bcb"}, "ab"},
+		"unicode":    {[]string{"\x1b[4m☃\x1b[24m"}, "☃"},
+		"only codes": {[]string{"\x1b[2J", "\x1b[H"}, ""},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			var buf bytes.Buffer
+			w := StripANSI(&buf)
+			for _, s := range tc.writes {
+				if n, err := w.Write([]byte(s)); err != nil || n != len(s) {
+					t.Fatalf("Write(): got: %v, %v want: %v, nil", n, err, len(s))
+				}
+			}
+			if diff := cmp.Diff(buf.String(), tc.want); diff != "" {
+				t.Errorf("StripANSI(): mismatch (-got,+want):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestColorIO(t *testing.T) {
+	const colored = "\x1b[32mok\x1b[0m"
+	for tn, tc := range map[string]struct {
+		mode ColorMode
+		want string
+	}{
+		// Captured output is never a terminal.
+		"auto":   {ColorAuto, "ok"},
+		"always": {ColorAlways, colored},
+		"never":  {ColorNever, "ok"},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			stdio, c := NewCaptureIO()
+			stdio = ColorIO(stdio, tc.mode)
+			stdio.Out.Write([]byte(colored))
+			stdio.Err.Write([]byte(colored))
+			if diff := cmp.Diff(c.Out(), tc.want); diff != "" {
+				t.Errorf("ColorIO(): Out mismatch (-got,+want):\n%v", diff)
+			}
+			if diff := cmp.Diff(c.Err(), tc.want); diff != "" {
+				t.Errorf("ColorIO(): Err mismatch (-got,+want):\n%v", diff)
+			}
+
+			// The mode replaces that of output already wrapped.
+			stdio, c = NewCaptureIO()
+			stdio = ColorIO(ColorIO(stdio, ColorNever), tc.mode)
+			stdio.Out.Write([]byte(colored))
+			if diff := cmp.Diff(c.Out(), tc.want); diff != "" {
+				t.Errorf("ColorIO() rewrapped: Out mismatch (-got,+want):\n%v", diff)
+			}
+		})
+	}
+}
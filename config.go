@@ -0,0 +1,72 @@
+package cliche
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+)
+
+// ConfigInput is a flag of a command listed by ShowConfig.
+type ConfigInput struct {
+	// Flags are the names by which the flag may be given, of which the
+	// first is listed.
+	Flags []string
+
+	// Env names the environment variable bound to the flag, if any.
+	Env string
+
+	// Secret flags have their values redacted.
+	Secret bool
+}
+
+// GivenFlags returns the names of the flags of fs which were given, as
+// fs.Visit visits them.
+func GivenFlags(fs *flag.FlagSet) map[string]bool {
+	given := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		given[f.Name] = true
+	})
+	return given
+}
+
+// ShowConfig writes a table of inputs to the Out of stdio, as the
+// --show-config flag of commands generated with -showconfig does: the first
+// name of each flag, its final value in fs, and where that comes from. The
+// source is flag when it was among those parsed from the command line, env
+// when it was set from its environment variable, config when set by the
+// manifest, as DecodeManifest returns the names of those it set, form when
+// answered on an interactive form, and otherwise default. The values of
+// secret inputs are redacted, unless empty.
+func ShowConfig(stdio IO, fs *flag.FlagSet, parsed map[string]bool, manifest []string, inputs []ConfigInput) error {
+	given := GivenFlags(fs)
+	fromManifest := make(map[string]bool)
+	for _, name := range manifest {
+		fromManifest[name] = true
+	}
+	w := tabwriter.NewWriter(stdio.Out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "FLAG\tVALUE\tSOURCE")
+	for _, input := range inputs {
+		f := fs.Lookup(input.Flags[0])
+		if f == nil {
+			continue
+		}
+		source := "default"
+		switch {
+		case anyGiven(parsed, input.Flags):
+			source = "flag"
+		case input.Env != "" && os.Getenv(input.Env) != "":
+			source = "env " + input.Env
+		case fromManifest[f.Name]:
+			source = "config"
+		case anyGiven(given, input.Flags):
+			source = "form"
+		}
+		value := f.Value.String()
+		if input.Secret && value != "" {
+			value = "(redacted)"
+		}
+		fmt.Fprintf(w, "-%v\t%v\t%v\n", f.Name, orDash(value), source)
+	}
+	return w.Flush()
+}
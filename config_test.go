@@ -0,0 +1,54 @@
+package cliche
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestShowConfig(t *testing.T) {
+	t.Setenv("APP_PORT", "9090")
+	t.Setenv("APP_HOST", "")
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	var name string
+	BindFlag(fs, &name, "", "name", "n")
+	fs.Int("port", 80, "")
+	fs.String("host", "localhost", "")
+	fs.String("token", "", "")
+	fs.String("password", "", "")
+	fs.String("region", "us-east", "")
+	fs.Bool("loud", false, "")
+	if err := fs.Parse([]string{"-n=app", "-token=s3cret"}); err != nil {
+		t.Fatal(err)
+	}
+	parsed := GivenFlags(fs)
+	fs.Set("port", "9090")
+	fs.Set("region", "eu-west")
+	fs.Set("loud", "true")
+
+	stdio, capture := NewCaptureIO()
+	if err := ShowConfig(stdio, fs, parsed, []string{"region", "host"}, []ConfigInput{
+		{Flags: []string{"name", "n"}},
+		{Flags: []string{"port"}, Env: "APP_PORT"},
+		{Flags: []string{"host"}, Env: "APP_HOST"},
+		{Flags: []string{"token"}, Secret: true},
+		{Flags: []string{"password"}, Secret: true},
+		{Flags: []string{"region"}},
+		{Flags: []string{"loud"}},
+	}); err != nil {
+		t.Fatalf("ShowConfig(): unexpected error: %v", err)
+	}
+	want := `FLAG       VALUE       SOURCE
+-name      app         flag
+-port      9090        env APP_PORT
+-host      localhost   config
+-token     (redacted)  flag
+-password  -           default
+-region    eu-west     config
+-loud      true        form
+`
+	if diff := cmp.Diff(capture.Out(), want); diff != "" {
+		t.Errorf("ShowConfig(): mismatch (-got,+want):\n%v", diff)
+	}
+}
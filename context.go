@@ -0,0 +1,109 @@
+package cliche
+
+import (
+	"context"
+	"os"
+)
+
+// Keys under which the framework's shared state is carried by contexts.
+type (
+	ioKey          struct{}
+	dryRunKey      struct{}
+	commandPathKey struct{}
+	parentsKey     struct{}
+	slicePolicyKey struct{}
+	crashesKey     struct{}
+	ranKey         struct{}
+)
+
+// WithIO returns a copy of ctx carrying stdio, the IO of the running command.
+func WithIO(ctx context.Context, stdio IO) context.Context {
+	return context.WithValue(ctx, ioKey{}, stdio)
+}
+
+// IOFrom returns the IO carried by ctx, or one of the process's standard
+// streams when ctx carries none.
+func IOFrom(ctx context.Context) IO {
+	if stdio, ok := ctx.Value(ioKey{}).(IO); ok {
+		return stdio
+	}
+	return IO{In: os.Stdin, Out: os.Stdout, Err: os.Stderr}
+}
+
+// WithDryRun returns a copy of ctx carrying whether the command should only
+// report what it would do, rather than doing it.
+func WithDryRun(ctx context.Context, dryRun bool) context.Context {
+	return context.WithValue(ctx, dryRunKey{}, dryRun)
+}
+
+// DryRunFrom returns whether ctx carries a request for a dry run.
+func DryRunFrom(ctx context.Context) bool {
+	dryRun, _ := ctx.Value(dryRunKey{}).(bool)
+	return dryRun
+}
+
+// WithSlicePolicy returns a copy of ctx carrying the policy by which commands
+// run with it by Run take the values of their flags bound to slices. Generated
+// commands have theirs chosen by the -slices flag of their go:generate
+// directive instead.
+func WithSlicePolicy(ctx context.Context, policy SlicePolicy) context.Context {
+	return context.WithValue(ctx, slicePolicyKey{}, policy)
+}
+
+// SlicePolicyFrom returns the SlicePolicy carried by ctx, or SliceRepeat when
+// it carries none.
+func SlicePolicyFrom(ctx context.Context) SlicePolicy {
+	policy, _ := ctx.Value(slicePolicyKey{}).(SlicePolicy)
+	return policy
+}
+
+// WithCommand returns a copy of ctx whose command path has name appended, as
+// when a parent command runs its subcommand.
+func WithCommand(ctx context.Context, name string) context.Context {
+	path := append(CommandPath(ctx), name)
+	if ran, ok := ctx.Value(ranKey{}).(*Path); ok {
+		*ran = path
+	}
+	return context.WithValue(ctx, commandPathKey{}, path)
+}
+
+// withRan returns a copy of ctx recording in ran the path of the innermost
+// command run with it, so that Main may report the verb or subcommand which
+// actually ran.
+func withRan(ctx context.Context, ran *Path) context.Context {
+	return context.WithValue(ctx, ranKey{}, ran)
+}
+
+// Path of a command which was run, starting with the name of the program, such
+// as ["app", "remote", "add"], as CommandPath returns it.
+type Path []string
+
+// CommandPath returns the names of the commands being run, outermost first,
+// such as ["app", "remote", "add"], as carried by ctx. The returned slice is a
+// copy, which the caller may modify.
+func CommandPath(ctx context.Context) []string {
+	path, _ := ctx.Value(commandPathKey{}).([]string)
+	return append([]string(nil), path...)
+}
+
+// WithParent returns a copy of ctx carrying cmd as a parent of the commands
+// run with it, as when a command runs the subcommand held by its field, so
+// that the subcommand may reach the parent's inputs with Parent.
+func WithParent(ctx context.Context, cmd any) context.Context {
+	parents, _ := ctx.Value(parentsKey{}).([]any)
+	return context.WithValue(ctx, parentsKey{}, append(parents[:len(parents):len(parents)], cmd))
+}
+
+// Parent returns the innermost parent of the running command of type T, as
+// carried by ctx, such as a *Remote for the add subcommand of a Remote command
+// with flags of its own. It is not ok when there is none.
+func Parent[T any](ctx context.Context) (T, bool) {
+	parents, _ := ctx.Value(parentsKey{}).([]any)
+	for i := len(parents) - 1; i >= 0; i-- {
+		if parent, ok := parents[i].(T); ok {
+			return parent, true
+		}
+	}
+	var zero T
+	return zero, false
+}
@@ -0,0 +1,75 @@
+package cliche
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestIOFrom(t *testing.T) {
+	if got := IOFrom(context.Background()); got.In != os.Stdin || got.Out != os.Stdout || got.Err != os.Stderr {
+		t.Errorf("IOFrom(): got %+v without IO, want standard streams", got)
+	}
+	stdio, _ := NewCaptureIO()
+	stdio.Dir = "/tmp"
+	if got := IOFrom(WithIO(context.Background(), stdio)); got != stdio {
+		t.Errorf("IOFrom(): got: %+v want: %+v", got, stdio)
+	}
+}
+
+func TestDryRunFrom(t *testing.T) {
+	ctx := context.Background()
+	if DryRunFrom(ctx) {
+		t.Error("DryRunFrom(): got true by default")
+	}
+	if !DryRunFrom(WithDryRun(ctx, true)) {
+		t.Error("DryRunFrom(): got false after WithDryRun(true)")
+	}
+}
+
+func TestCommandPath(t *testing.T) {
+	root := WithCommand(context.Background(), "app")
+	remote := WithCommand(root, "remote")
+	add := WithCommand(remote, "add")
+	// Siblings must not share the path's storage.
+	rm := WithCommand(remote, "rm")
+
+	for _, tc := range []struct {
+		ctx  context.Context
+		want []string
+	}{
+		{context.Background(), nil},
+		{root, []string{"app"}},
+		{add, []string{"app", "remote", "add"}},
+		{rm, []string{"app", "remote", "rm"}},
+	} {
+		if diff := cmp.Diff(CommandPath(tc.ctx), tc.want); diff != "" {
+			t.Errorf("CommandPath(): mismatch (-got,+want):\n%v", diff)
+		}
+	}
+
+	path := CommandPath(add)
+	path[0] = "changed"
+	if got := CommandPath(add)[0]; got != "app" {
+		t.Errorf("CommandPath(): modifying the result changed the context's path to %q", got)
+	}
+}
+
+func TestParent(t *testing.T) {
+	type tool struct{ Verbose bool }
+	type remote struct{ Name string }
+	outer, inner := &tool{Verbose: true}, &remote{Name: "origin"}
+	ctx := WithParent(WithParent(context.Background(), outer), inner)
+
+	if got, ok := Parent[*tool](ctx); !ok || got != outer {
+		t.Errorf("Parent[*tool](): got %v, %v, want %v", got, ok, outer)
+	}
+	if got, ok := Parent[*remote](ctx); !ok || got != inner {
+		t.Errorf("Parent[*remote](): got %v, %v, want %v", got, ok, inner)
+	}
+	if got, ok := Parent[*tool](context.Background()); ok || got != nil {
+		t.Errorf("Parent[*tool](): got %v, %v without parents, want nil, false", got, ok)
+	}
+}
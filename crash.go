@@ -0,0 +1,81 @@
+package cliche
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+)
+
+// Crash describes a panic recovered while running a command, as passed to a
+// CrashReporter.
+type Crash struct {
+	// Path of the command which panicked.
+	Path Path
+
+	// Err is the panic's value, as an error.
+	Err error
+
+	// Stack of the panicking goroutine.
+	Stack []byte
+}
+
+// CrashReporter receives panics recovered while running a command, so that
+// they can be forwarded to a crash reporting service.
+type CrashReporter interface {
+	ReportCrash(ctx context.Context, crash Crash)
+}
+
+// CrashReporterFunc adapts a function to the CrashReporter interface.
+type CrashReporterFunc func(ctx context.Context, crash Crash)
+
+// ReportCrash calls f.
+func (f CrashReporterFunc) ReportCrash(ctx context.Context, crash Crash) {
+	f(ctx, crash)
+}
+
+// PanicError is returned by Recover in place of a panic.
+type PanicError struct {
+	// Value with which the command panicked.
+	Value any
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Unwrap returns the panic's value when it is an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// WithCrashReporter returns a copy of ctx carrying r, to which Recover reports
+// the panics of the commands run with it.
+func WithCrashReporter(ctx context.Context, r CrashReporter) context.Context {
+	return context.WithValue(ctx, crashesKey{}, r)
+}
+
+// CrashReporterFrom returns the CrashReporter carried by ctx, or nil when it
+// carries none.
+func CrashReporterFrom(ctx context.Context) CrashReporter {
+	r, _ := ctx.Value(crashesKey{}).(CrashReporter)
+	return r
+}
+
+// Recover calls run, and recovers from any panic in it, which is returned as a
+// *PanicError. The panic is reported to the CrashReporter carried by ctx, if
+// any, as that of the command at the CommandPath of ctx. Generated commands,
+// and those run by Run, are run through Recover.
+func Recover(ctx context.Context, run func(context.Context) error) (err error) {
+	defer func() {
+		v := recover()
+		if v == nil {
+			return
+		}
+		err = &PanicError{Value: v}
+		if r := CrashReporterFrom(ctx); r != nil {
+			r.ReportCrash(ctx, Crash{Path: CommandPath(ctx), Err: err, Stack: debug.Stack()})
+		}
+	}()
+	return run(ctx)
+}
@@ -0,0 +1,69 @@
+package cliche
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRecover(t *testing.T) {
+	errRun := errors.New("oh no")
+	path := Path{"app", "crash"}
+
+	for tn, tc := range map[string]struct {
+		run        func(context.Context) error
+		wantErr    string
+		wantIs     error
+		wantReport bool
+	}{
+		"no panic":    {func(context.Context) error { return nil }, "", nil, false},
+		"error":       {func(context.Context) error { return errRun }, "oh no", errRun, false},
+		"panic":       {func(context.Context) error { panic("boom") }, "panic: boom", nil, true},
+		"panic error": {func(context.Context) error { panic(errRun) }, "panic: oh no", errRun, true},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			var got []Crash
+			ctx := WithCrashReporter(WithCommand(WithCommand(context.Background(), "app"), "crash"), CrashReporterFunc(func(ctx context.Context, crash Crash) {
+				got = append(got, crash)
+			}))
+			err := Recover(ctx, tc.run)
+
+			var gotErr string
+			if err != nil {
+				gotErr = err.Error()
+			}
+			if gotErr != tc.wantErr {
+				t.Errorf("Recover(): error mismatch: got: %q want: %q", gotErr, tc.wantErr)
+			}
+			if tc.wantIs != nil && !errors.Is(err, tc.wantIs) {
+				t.Errorf("Recover(): error %v is not %v", err, tc.wantIs)
+			}
+			if !tc.wantReport {
+				if len(got) != 0 {
+					t.Errorf("Recover(): unexpected crash reports: %v", got)
+				}
+				return
+			}
+			if len(got) != 1 {
+				t.Fatalf("Recover(): got %d crash reports, want 1", len(got))
+			}
+			if diff := cmp.Diff(got[0].Path, path); diff != "" {
+				t.Errorf("Recover(): path mismatch (-got,+want):\n%v", diff)
+			}
+			if got[0].Err != err {
+				t.Errorf("Recover(): reported error %v, returned %v", got[0].Err, err)
+			}
+			if !strings.Contains(string(got[0].Stack), "TestRecover") {
+				t.Errorf("Recover(): stack does not include the panicking function:\n%s", got[0].Stack)
+			}
+		})
+	}
+
+	// A context carrying no CrashReporter is fine.
+	if err := Recover(context.Background(), func(context.Context) error { panic("boom") }); err == nil {
+		t.Error("Recover(): wanted error, got nil")
+	}
+}
@@ -0,0 +1,177 @@
+package cliche
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// DebugFlag is the hidden flag which traces how a command parses and binds its
+// arguments, for finding out why a flag did not take effect. It is accepted
+// anywhere before a "--", by every command, and never listed in help.
+const DebugFlag = "cliche-debug"
+
+type debugKey struct{}
+
+// Debug removes every -cliche-debug or --cliche-debug from args before any
+// "--". When there was one, it returns a copy of ctx carrying a trace, which
+// is written to the Err of the IO carried by ctx, and which the commands run
+// with the context, including subcommands, report to through TraceFrom. A
+// context which already carries a trace is returned as is.
+func Debug(ctx context.Context, args []string) (context.Context, []string) {
+	var rest []string
+	debug := false
+	for i, arg := range args {
+		if arg == "--" {
+			rest = append(rest, args[i:]...)
+			break
+		}
+		if arg == "-"+DebugFlag || arg == "--"+DebugFlag {
+			debug = true
+			continue
+		}
+		rest = append(rest, arg)
+	}
+	if !debug {
+		return ctx, args
+	}
+	if _, ok := ctx.Value(debugKey{}).(io.Writer); ok {
+		return ctx, rest
+	}
+	return context.WithValue(ctx, debugKey{}, IOFrom(ctx).Err), rest
+}
+
+// TraceFrom returns the trace carried by ctx for the command being run, or
+// nil when the command is not being debugged.
+func TraceFrom(ctx context.Context) *Trace {
+	w, ok := ctx.Value(debugKey{}).(io.Writer)
+	if !ok {
+		return nil
+	}
+	return &Trace{w: w, command: strings.Join(CommandPath(ctx), " ")}
+}
+
+// Trace reports how a command parses and binds its arguments, a line at a
+// time, each starting with the command's path. The methods of a nil Trace
+// report nothing, so that commands need not check whether they are debugged.
+type Trace struct {
+	w       io.Writer
+	command string
+}
+
+// printf writes a line of the trace.
+func (t *Trace) printf(format string, a ...any) {
+	fmt.Fprintf(t.w, "%v: %v: %v\n", DebugFlag, t.command, fmt.Sprintf(format, a...))
+}
+
+// Parsing reports the arguments as the flag set is about to parse them, once
+// response files, abbreviations and former flag names have been expanded.
+func (t *Trace) Parsing(args []string) {
+	if t == nil {
+		return
+	}
+	t.printf("parsing %q", args)
+}
+
+// Flags reports the value of the field bound to each flag of fs once parsed,
+// by the names of the flags bound to each field, and which of them set it, or
+// that it was left at its default. Other flags are reported when given.
+func (t *Trace) Flags(fs *flag.FlagSet, fields map[string][]string) {
+	if t == nil {
+		return
+	}
+	given := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		given[f.Name] = true
+	})
+	bound := make(map[string]bool)
+	var names []string
+	for field, flags := range fields {
+		names = append(names, field)
+		for _, name := range flags {
+			bound[name] = true
+		}
+	}
+	sort.Strings(names)
+	for _, field := range names {
+		flags := fields[field]
+		source := "by default"
+		for _, name := range flags {
+			if given[name] {
+				source = "from flag -" + name
+			}
+		}
+		if f := fs.Lookup(flags[0]); f != nil {
+			t.printf("field %v = %q %v", field, f.Value.String(), source)
+		}
+	}
+	fs.Visit(func(f *flag.Flag) {
+		if !bound[f.Name] {
+			t.printf("flag -%v = %q from the command line", f.Name, f.Value.String())
+		}
+	})
+	if fs.NArg() > 0 {
+		t.printf("arguments %q", fs.Args())
+	}
+}
+
+// Env reports that the flag called name was set to value from the environment
+// variable env, since it was not given on the command line.
+func (t *Trace) Env(env, name, value string) {
+	if t == nil {
+		return
+	}
+	t.printf("flag -%v = %q from environment variable %v", name, value, env)
+}
+
+// Arg reports the arguments bound to the positional argument name: every
+// step-th of args from start up to end, or all the rest when end is negative.
+func (t *Trace) Arg(name string, start, end, step int, args []string) {
+	if t == nil {
+		return
+	}
+	if end < 0 || end > len(args) {
+		end = len(args)
+	}
+	if step < 1 {
+		step = 1
+	}
+	var values []string
+	for i := start; i < end; i += step {
+		values = append(values, args[i])
+	}
+	switch len(values) {
+	case 0:
+		t.printf("argument %v not given, left as is", name)
+	case 1:
+		t.printf("argument %v = %q from argument %d", name, values[0], start+1)
+	default:
+		t.printf("argument %v = %q from arguments %d to %d", name, values, start+1, end)
+	}
+}
+
+// Source reports where a field not bound to a flag or argument got its value
+// from, such as standard input.
+func (t *Trace) Source(field, source string) {
+	if t == nil {
+		return
+	}
+	t.printf("field %v %v", field, source)
+}
+
+// Validated reports the result of validating value for input, a flag or
+// argument, and returns err.
+func (t *Trace) Validated(input, value string, err error) error {
+	if t == nil {
+		return err
+	}
+	if err != nil {
+		t.printf("validating %v = %q: %v", input, value, err)
+	} else {
+		t.printf("validating %v = %q: ok", input, value)
+	}
+	return err
+}
@@ -0,0 +1,87 @@
+package cliche
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDebug(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		args  []string
+		want  []string
+		debug bool
+	}{
+		"none":         {[]string{"-v", "x"}, []string{"-v", "x"}, false},
+		"long":         {[]string{"--cliche-debug", "x"}, []string{"x"}, true},
+		"short":        {[]string{"x", "-cliche-debug"}, []string{"x"}, true},
+		"after dashes": {[]string{"x", "--", "--cliche-debug"}, []string{"x", "--", "--cliche-debug"}, false},
+		"valued":       {[]string{"--cliche-debug=false"}, []string{"--cliche-debug=false"}, false},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			ctx, got := Debug(context.Background(), tc.args)
+			if diff := cmp.Diff(got, tc.want); diff != "" {
+				t.Errorf("Debug(): mismatch (-got,+want):\n%v", diff)
+			}
+			if debug := TraceFrom(ctx) != nil; debug != tc.debug {
+				t.Errorf("TraceFrom(): got: %v want: %v", debug, tc.debug)
+			}
+		})
+	}
+}
+
+func TestTraceNil(t *testing.T) {
+	var trace *Trace
+	trace.Parsing([]string{"x"})
+	trace.Arg("name", 0, 1, 1, []string{"x"})
+	trace.Source("Field", "injected")
+	want := errors.New("invalid")
+	if err := trace.Validated("name", "x", want); err != want {
+		t.Errorf("Validated(): got: %v want: %v", err, want)
+	}
+}
+
+func TestRunDebug(t *testing.T) {
+	var stderr bytes.Buffer
+	stdio := IO{Out: new(bytes.Buffer), Err: &stderr}
+	err := run(context.Background(), stdio, new(validatedArg), "greet", []string{"--cliche-debug", "nobody"})
+	if err == nil {
+		t.Fatalf("run(): expected an error")
+	}
+	want := `cliche-debug: greet: parsing ["nobody"]
+cliche-debug: greet: arguments ["nobody"]
+cliche-debug: greet: argument name = "nobody" from argument 1
+cliche-debug: greet: validating name = "nobody": unknown name
+`
+	if diff := cmp.Diff(stderr.String(), want); diff != "" {
+		t.Errorf("run(): trace mismatch (-got,+want):\n%v", diff)
+	}
+
+	ProvideNamed("run-test", func(context.Context) (*runTarget, error) {
+		return &runTarget{"example.com"}, nil
+	})
+	stderr.Reset()
+	stdio.In = strings.NewReader("body")
+	if err := run(context.Background(), stdio, new(runCommand), "greet", []string{"-n", "2", "Gopher", "-cliche-debug"}); err != nil {
+		t.Fatalf("run(): unexpected error: %v", err)
+	}
+	for _, line := range []string{
+		`greet: parsing ["-n" "2" "Gopher"]`,
+		`greet: field Count = "2" from flag -n`,
+		`greet: field Tags = "a,b" by default`,
+		`greet: field RunOptions.Host = "localhost" by default`,
+		`greet: validating -count = "2": ok`,
+		`greet: argument name = "Gopher" from argument 1`,
+		`greet: argument rest not given, left as is`,
+		`greet: field Target injected`,
+		`greet: field Body read from standard input`,
+	} {
+		if !strings.Contains(stderr.String(), line) {
+			t.Errorf("run(): trace %q lacks %q", stderr.String(), line)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package cliche
+
+import (
+	"flag"
+	"path/filepath"
+)
+
+// Path resolves name against the working directory of stdio. Absolute names,
+// and all names when Dir is empty, are returned unchanged.
+func (stdio IO) Path(name string) string {
+	if stdio.Dir == "" || filepath.IsAbs(name) {
+		return name
+	}
+	return filepath.Join(stdio.Dir, name)
+}
+
+// RegisterDirFlag registers a -C flag on fs, which sets the working directory
+// of stdio, as with git -C and make -C. Like those, each -C is relative to the
+// directory set by the one before it. The process's working directory is not
+// changed, so commands resolve paths with Path.
+func (stdio *IO) RegisterDirFlag(fs *flag.FlagSet) {
+	fs.Func("C", "run as if started in `dir`", func(dir string) error {
+		stdio.Dir = stdio.Path(dir)
+		return nil
+	})
+}
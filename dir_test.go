@@ -0,0 +1,40 @@
+package cliche
+
+import (
+	"flag"
+	"path/filepath"
+	"testing"
+)
+
+func TestIOPath(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		dir, name, want string
+	}{
+		"no dir":   {"", "file.txt", "file.txt"},
+		"relative": {"work", "sub/file.txt", filepath.Join("work", "sub", "file.txt")},
+		"absolute": {"work", filepath.Join(string(filepath.Separator), "etc", "hosts"), filepath.Join(string(filepath.Separator), "etc", "hosts")},
+		"parent":   {"work/sub", "../file.txt", filepath.Join("work", "file.txt")},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			if got := (IO{Dir: tc.dir}).Path(tc.name); got != tc.want {
+				t.Errorf("Path(): got: %q want: %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestIORegisterDirFlag(t *testing.T) {
+	stdio, _ := NewCaptureIO()
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	stdio.RegisterDirFlag(fs)
+	if err := fs.Parse([]string{"-C", "repo", "-C", "sub", "status"}); err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	if want := filepath.Join("repo", "sub"); stdio.Dir != want {
+		t.Errorf("RegisterDirFlag(): Dir mismatch: got: %q want: %q", stdio.Dir, want)
+	}
+	tee, _ := TeeIO(stdio)
+	if tee.Dir != stdio.Dir {
+		t.Errorf("TeeIO(): Dir mismatch: got: %q want: %q", tee.Dir, stdio.Dir)
+	}
+}
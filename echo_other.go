@@ -0,0 +1,35 @@
+//go:build !windows
+
+package cliche
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// disableEcho turns off echoing of what is typed on the terminal from which r
+// reads, when it is one, with stty, and returns a function restoring it.
+// Otherwise, it returns nil.
+func disableEcho(r io.Reader) func() {
+	f, ok := r.(*os.File)
+	if !ok {
+		return nil
+	}
+	stty := func(args ...string) ([]byte, error) {
+		cmd := exec.Command("stty", args...)
+		cmd.Stdin = f
+		return cmd.Output()
+	}
+	state, err := stty("-g")
+	if err != nil {
+		return nil
+	}
+	if _, err := stty("-echo"); err != nil {
+		return nil
+	}
+	return func() {
+		stty(strings.TrimSpace(string(state)))
+	}
+}
@@ -0,0 +1,34 @@
+//go:build windows
+
+package cliche
+
+import (
+	"io"
+	"os"
+	"syscall"
+)
+
+// enableEchoInput is the console mode flag with which Windows consoles echo
+// what is typed.
+const enableEchoInput = 0x0004
+
+// disableEcho turns off echoing of what is typed on the console from which r
+// reads, when it is one, and returns a function restoring it. Otherwise, it
+// returns nil.
+func disableEcho(r io.Reader) func() {
+	f, ok := r.(*os.File)
+	if !ok {
+		return nil
+	}
+	h := syscall.Handle(f.Fd())
+	var mode uint32
+	if err := syscall.GetConsoleMode(h, &mode); err != nil {
+		return nil
+	}
+	if r, _, _ := procSetConsoleMode.Call(uintptr(h), uintptr(mode&^enableEchoInput)); r == 0 {
+		return nil
+	}
+	return func() {
+		procSetConsoleMode.Call(uintptr(h), uintptr(mode))
+	}
+}
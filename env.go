@@ -0,0 +1,98 @@
+package cliche
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+)
+
+// EnvVar is an environment variable read by a program, as listed by ShowEnv.
+type EnvVar struct {
+	// Name of the variable, such as APP_PORT.
+	Name string
+
+	// Flag is the flag set from the variable when it is not given on the
+	// command line, as the command path followed by the flag, such as
+	// "app serve -port". It is empty for variables read otherwise.
+	Flag string
+
+	// Default is the value of the flag when neither it nor the variable is
+	// given, if any.
+	Default string
+
+	// Usage is a short description of the variable.
+	Usage string
+}
+
+// RuntimeEnv lists the environment variables read by cliche itself, on behalf
+// of every program.
+var RuntimeEnv = []EnvVar{
+	{Name: "NO_COLOR", Usage: "strips color from output to terminals, unless empty"},
+	{Name: PlainEnv, Usage: "shows help plainly, for screen readers, unless empty"},
+	{Name: OptOutEnv, Usage: "opts out of usage reporting, unless false"},
+	{Name: StatusEnv, Usage: "names the file to which the status of each run is written, for shell prompts"},
+	{Name: "XDG_RUNTIME_DIR", Usage: "directory in which single-instance locks are kept"},
+}
+
+// BindEnv sets each flag of fs which was not given on the command line from
+// the environment variable bound to it, unless that is unset or empty. Env maps
+// the name of each variable to the names by which its flag may be given, of
+// which the first is set. Flags set from the environment count as given, so
+// are validated and satisfy requirements as if given on the command line.
+// Values which the flags can't take are usage errors.
+func BindEnv(ctx context.Context, fs *flag.FlagSet, env map[string][]string) error {
+	given := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		given[f.Name] = true
+	})
+	var names []string
+	for name := range env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	trace := TraceFrom(ctx)
+	for _, name := range names {
+		flags := env[name]
+		value := os.Getenv(name)
+		if value == "" || len(flags) == 0 || anyGiven(given, flags) {
+			continue
+		}
+		if err := fs.Set(flags[0], value); err != nil {
+			return Usagef("environment variable %v: invalid value %q for flag -%v: %w", name, value, flags[0], err)
+		}
+		trace.Env(name, flags[0], value)
+	}
+	return nil
+}
+
+// ShowEnv writes a table of vars, followed by RuntimeEnv, to the Out of stdio:
+// the name of each variable, the flag it sets, where the value of that flag
+// comes from, and its default. The value comes from the environment when the
+// variable is set, or else from the default, if any. Values are not shown,
+// since they may be secret.
+func ShowEnv(stdio IO, vars []EnvVar) error {
+	w := tabwriter.NewWriter(stdio.Out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tFLAG\tSOURCE\tDEFAULT\tUSAGE")
+	for _, v := range append(vars[:len(vars):len(vars)], RuntimeEnv...) {
+		source := "unset"
+		switch {
+		case os.Getenv(v.Name) != "":
+			source = "environment"
+		case v.Default != "":
+			source = "default"
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\n", v.Name, orDash(v.Flag), source, orDash(v.Default), orDash(v.Usage))
+	}
+	return w.Flush()
+}
+
+// orDash returns s, or a dash when it is empty, to mark an empty column.
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,129 @@
+package cliche
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type runEnv struct {
+	Port    int    `cliche:"flag:port,p;default:8080;env:TEST_PORT"`
+	Log     bool   `cliche:"flag:log;negatable;env:TEST_LOG"`
+	Region  string `cliche:"flag:region;required;env:TEST_REGION;validate:CheckRegion"`
+	Ignored string `cliche:"flag:ignored"`
+}
+
+func (runEnv) Run(context.Context) error { return nil }
+
+func (runEnv) CheckRegion(region string) error {
+	if region == "mars" {
+		return errors.New("no such region")
+	}
+	return nil
+}
+
+func TestRunEnv(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		env     map[string]string
+		args    []string
+		want    runEnv
+		wantErr string
+	}{
+		"flags":       {nil, []string{"-region", "eu"}, runEnv{Port: 8080, Region: "eu"}, ""},
+		"environment": {map[string]string{"TEST_PORT": "9090", "TEST_LOG": "true", "TEST_REGION": "us"}, nil, runEnv{Port: 9090, Log: true, Region: "us"}, ""},
+		"flags first": {map[string]string{"TEST_PORT": "9090", "TEST_REGION": "us"}, []string{"-p", "80", "-region", "eu"}, runEnv{Port: 80, Region: "eu"}, ""},
+		"negated":     {map[string]string{"TEST_LOG": "true", "TEST_REGION": "us"}, []string{"-no-log"}, runEnv{Port: 8080, Region: "us"}, ""},
+		"empty":       {map[string]string{"TEST_PORT": "", "TEST_REGION": "us"}, nil, runEnv{Port: 8080, Region: "us"}, ""},
+		"missing":     {nil, nil, runEnv{Port: 8080}, "missing required input -region"},
+		"invalid": {
+			map[string]string{"TEST_PORT": "eighty"}, nil, runEnv{Port: 8080},
+			`environment variable TEST_PORT: invalid value "eighty" for flag -port: invalid integer "eighty"`,
+		},
+		"validated": {
+			map[string]string{"TEST_REGION": "mars"}, nil, runEnv{Port: 8080, Region: "mars"},
+			"flag -region: no such region",
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			for name, value := range tc.env {
+				t.Setenv(name, value)
+			}
+			cmd := new(runEnv)
+			stdio, _ := NewCaptureIO()
+			err := Run(context.Background(), stdio, cmd, tc.args)
+			var gotErr string
+			if err != nil {
+				gotErr = err.Error()
+			}
+			if gotErr != tc.wantErr {
+				t.Errorf("Run(): error mismatch: got: %q want: %q", gotErr, tc.wantErr)
+			}
+			if diff := cmp.Diff(*cmd, tc.want); diff != "" {
+				t.Errorf("Run(): mismatch (-got,+want):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestRunEnvHelp(t *testing.T) {
+	stdio, capture := NewCaptureIO()
+	if err := Run(context.Background(), stdio, new(runEnv), []string{"-h"}); !errors.Is(err, flag.ErrHelp) {
+		t.Errorf("Run(): got error %v, want flag.ErrHelp", err)
+	}
+	if want := "\n  -port, -p int\t(env TEST_PORT) (default 8080)\n"; !strings.Contains(capture.Out(), want) {
+		t.Errorf("Run(): help does not contain %q:\n%v", want, capture.Out())
+	}
+}
+
+func TestBindEnvTrace(t *testing.T) {
+	t.Setenv("TEST_PORT", "9090")
+	var stderr bytes.Buffer
+	ctx, _ := Debug(WithCommand(WithIO(context.Background(), IO{Err: &stderr}), "serve"), []string{"--cliche-debug"})
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	port := fs.Int("port", 8080, "")
+	if err := BindEnv(ctx, fs, map[string][]string{"TEST_PORT": {"port"}}); err != nil {
+		t.Fatalf("BindEnv(): unexpected error: %v", err)
+	}
+	if *port != 9090 {
+		t.Errorf("BindEnv(): got port %d, want 9090", *port)
+	}
+	want := "cliche-debug: serve: flag -port = \"9090\" from environment variable TEST_PORT\n"
+	if diff := cmp.Diff(stderr.String(), want); diff != "" {
+		t.Errorf("BindEnv(): trace mismatch (-got,+want):\n%v", diff)
+	}
+}
+
+func TestShowEnv(t *testing.T) {
+	t.Setenv("TEST_PORT", "9090")
+	t.Setenv("TEST_ADDR", "")
+	for _, v := range RuntimeEnv {
+		t.Setenv(v.Name, "")
+	}
+	stdio, capture := NewCaptureIO()
+	err := ShowEnv(stdio, []EnvVar{
+		{Name: "TEST_PORT", Flag: "app serve -port", Default: "8080", Usage: "Port to listen on."},
+		{Name: "TEST_LOG", Flag: "app serve -log", Default: "true"},
+		{Name: "TEST_ADDR", Flag: "app status -addr", Usage: "Address of the server."},
+	})
+	if err != nil {
+		t.Fatalf("ShowEnv(): unexpected error: %v", err)
+	}
+	want := `NAME               FLAG              SOURCE       DEFAULT  USAGE
+TEST_PORT          app serve -port   environment  8080     Port to listen on.
+TEST_LOG           app serve -log    default      true     -
+TEST_ADDR          app status -addr  unset        -        Address of the server.
+NO_COLOR           -                 unset        -        strips color from output to terminals, unless empty
+CLICHE_PLAIN       -                 unset        -        shows help plainly, for screen readers, unless empty
+DO_NOT_TRACK       -                 unset        -        opts out of usage reporting, unless false
+CLICHE_STATUS_ENV  -                 unset        -        names the file to which the status of each run is written, for shell prompts
+XDG_RUNTIME_DIR    -                 unset        -        directory in which single-instance locks are kept
+`
+	if diff := cmp.Diff(capture.Out(), want); diff != "" {
+		t.Errorf("ShowEnv(): mismatch (-got,+want):\n%v", diff)
+	}
+}
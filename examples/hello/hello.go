@@ -0,0 +1,40 @@
+// Package hello is an example cliche command, which greets someone.
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"idontfixcomputers.com/cliche"
+)
+
+//go:generate go run idontfixcomputers.com/cliche/cmd/cliche -type=Hello
+
+func init() {
+	cliche.ProvideNamed("stdout", func(context.Context) (io.Writer, error) {
+		return os.Stdout, nil
+	})
+}
+
+// Hello greets someone by name.
+type Hello struct {
+	// Name of the person to greet.
+	Name string `cliche:"arg:0;default:World"`
+	// Shout the greeting.
+	Shout bool `cliche:"flag:shout,s"`
+	// Out is where the greeting is written.
+	Out io.Writer `cliche:"inject:stdout"`
+}
+
+// Run the Hello command.
+func (h *Hello) Run(ctx context.Context) error {
+	greeting := fmt.Sprintf("Hello, %v!", h.Name)
+	if h.Shout {
+		greeting = strings.ToUpper(greeting)
+	}
+	_, err := fmt.Fprintln(h.Out, greeting)
+	return err
+}
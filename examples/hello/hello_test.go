@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"idontfixcomputers.com/cliche"
+	"idontfixcomputers.com/cliche/meta"
+)
+
+func TestRunHello(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		args    []string
+		want    string
+		wantErr string
+	}{
+		"default":        {nil, "Hello, World!\n", ""},
+		"name":           {[]string{"Gopher"}, "Hello, Gopher!\n", ""},
+		"shout":          {[]string{"-s", "Gopher"}, "HELLO, GOPHER!\n", ""},
+		"too many":       {[]string{"Gopher", "Again"}, "", `unexpected arguments: ["Again"]`},
+		"unknown flag":   {[]string{"--whisper"}, "", "flag provided but not defined: -whisper"},
+		"flag after arg": {[]string{"Gopher", "-s"}, "", `unexpected arguments: ["-s"]`},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			var out bytes.Buffer
+			cliche.ProvideNamed("stdout", func(context.Context) (io.Writer, error) {
+				return &out, nil
+			})
+			stdio, _ := cliche.NewCaptureIO()
+			err := RunHello(context.Background(), stdio, tc.args)
+			var gotErr string
+			if err != nil {
+				gotErr = err.Error()
+			}
+			if gotErr != tc.wantErr {
+				t.Errorf("RunHello(): error mismatch: got: %q want: %q", gotErr, tc.wantErr)
+			}
+			if diff := cmp.Diff(out.String(), tc.want); diff != "" {
+				t.Errorf("RunHello(): mismatch (-got,+want):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestRunHelloHelp(t *testing.T) {
+	stdio, c := cliche.NewCaptureIO()
+	if err := RunHello(context.Background(), stdio, []string{"-h"}); !errors.Is(err, flag.ErrHelp) {
+		t.Errorf("RunHello(): got error %v, want %v", err, flag.ErrHelp)
+	}
+	if !strings.HasPrefix(c.Out(), "Usage: hello [flags] [name]\n") {
+		t.Errorf("RunHello(): unexpected help:\n%v", c.Out())
+	}
+
+	// Help is shown when asked for after an argument, or with a bad flag.
+	for _, args := range [][]string{{"Gopher", "--help"}, {"--whisper", "-h"}} {
+		stdio, c := cliche.NewCaptureIO()
+		if err := RunHello(context.Background(), stdio, args); !errors.Is(err, flag.ErrHelp) {
+			t.Errorf("RunHello(%q): got error %v, want %v", args, err, flag.ErrHelp)
+		}
+		if !strings.HasPrefix(c.Out(), "Usage: hello [flags] [name]\n") {
+			t.Errorf("RunHello(%q): unexpected help:\n%v", args, c.Out())
+		}
+	}
+}
+
+func TestRunHelloPlainHelp(t *testing.T) {
+	want := "Usage: hello [flags] [name]\n\nhello is an example cliche command, which greets someone.\n\nHello greets someone by name.\n\nArguments:\n[name]: Name of the person to greet.\n\nFlags:\n-shout, -s: Shout the greeting.\n\nRuntime flags:\n-color mode: Color output: auto, always or never; default auto.\n"
+	for tn, tc := range map[string]struct {
+		env  string
+		args []string
+	}{
+		"flag":        {"", []string{"Gopher", "--help=plain"}},
+		"environment": {"1", []string{"-h"}},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			t.Setenv(cliche.PlainEnv, tc.env)
+			stdio, c := cliche.NewCaptureIO()
+			if err := RunHello(context.Background(), stdio, tc.args); !errors.Is(err, flag.ErrHelp) {
+				t.Errorf("RunHello(): got error %v, want %v", err, flag.ErrHelp)
+			}
+			if diff := cmp.Diff(c.Out(), want); diff != "" {
+				t.Errorf("RunHello(): plain help mismatch (-got,+want):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestHelloHelp(t *testing.T) {
+	stdio, c := cliche.NewCaptureIO()
+	RunHello(context.Background(), stdio, []string{"-h"})
+	page := HelloHelp()
+	if page.Text != c.Out() {
+		t.Errorf("HelloHelp(): got text %q, want that shown by -h: %q", page.Text, c.Out())
+	}
+	want := []cliche.HelpGroup{
+		{Heading: "Flags", Flags: []cliche.HelpEntry{{Term: "-shout, -s", Doc: "Shout the greeting."}}},
+		{Heading: "Runtime flags", Flags: []cliche.HelpEntry{{Term: "-color", Value: "mode", Doc: "Color output: auto, always or never; default auto."}}},
+	}
+	if diff := cmp.Diff(page.Groups, want); diff != "" {
+		t.Errorf("HelloHelp(): flags mismatch (-got,+want):\n%v", diff)
+	}
+	if page.Synopsis != "[flags] [name]" || len(page.Arguments) != 1 {
+		t.Errorf("HelloHelp(): got synopsis %q and arguments %v, want [name]", page.Synopsis, page.Arguments)
+	}
+}
+
+// TestGenerated checks that the generated code is up to date with hello.go.
+func TestGenerated(t *testing.T) {
+	f, err := os.Open("hello.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	cmd := meta.FromFile(f, "Hello")
+	if cmd == nil {
+		t.Fatal("FromFile(): got nil Command")
+	}
+	cmd.Name = "hello"
+	var got bytes.Buffer
+	if err := cmd.Generate(&got); err != nil {
+		t.Fatalf("Generate(): unexpected error: %v", err)
+	}
+	want, err := os.ReadFile("hello_cliche.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(got.String(), string(want)); diff != "" {
+		t.Errorf("Generate(): hello_cliche.go is out of date; run go generate (-got,+want):\n%v", diff)
+	}
+}
+
+func TestRunHelloDebug(t *testing.T) {
+	cliche.ProvideNamed("stdout", func(context.Context) (io.Writer, error) {
+		return io.Discard, nil
+	})
+	stdio, c := cliche.NewCaptureIO()
+	if err := RunHello(context.Background(), stdio, []string{"-s", "--cliche-debug", "Gopher"}); err != nil {
+		t.Fatalf("RunHello(): unexpected error: %v", err)
+	}
+	want := `cliche-debug: hello: parsing ["-s" "Gopher"]
+cliche-debug: hello: field Shout = "true" from flag -s
+cliche-debug: hello: arguments ["Gopher"]
+cliche-debug: hello: argument name = "Gopher" from argument 1
+cliche-debug: hello: field Out injected
+`
+	if diff := cmp.Diff(c.Err(), want); diff != "" {
+		t.Errorf("RunHello(): trace mismatch (-got,+want):\n%v", diff)
+	}
+}
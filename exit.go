@@ -0,0 +1,235 @@
+package cliche
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Exiter terminates the program with an exit status. All exits made by the
+// runtime go through an Exiter, so that tests and programs embedding commands
+// can intercept them.
+type Exiter interface {
+	Exit(code int)
+}
+
+// ExiterFunc adapts a function to the Exiter interface.
+type ExiterFunc func(code int)
+
+// Exit calls f.
+func (f ExiterFunc) Exit(code int) {
+	f(code)
+}
+
+// DefaultExiter exits the process with os.Exit.
+var DefaultExiter Exiter = ExiterFunc(os.Exit)
+
+// Exit with code through e, or through DefaultExiter if e is nil.
+func Exit(e Exiter, code int) {
+	if e == nil {
+		e = DefaultExiter
+	}
+	e.Exit(code)
+}
+
+// ExitCoder is implemented by errors which carry the exit status with which
+// the program should exit.
+type ExitCoder interface {
+	ExitCode() int
+}
+
+// ExitCode returns the exit status for a command which returned err: zero for
+// a nil error, the status carried by the first ExitCoder in err's tree, and
+// DefaultExitCodes.Runtime otherwise.
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var ec ExitCoder
+	if errors.As(err, &ec) {
+		return ec.ExitCode()
+	}
+	return DefaultExitCodes.Runtime
+}
+
+// ExitCodes are the exit statuses of commands which fail, by whether they were
+// used wrongly or failed as they ran. Zero fields choose no status.
+type ExitCodes struct {
+	// Usage is the status of usage errors: a UsageError or MissingError.
+	Usage int
+	// Runtime is the status of other errors, unless they carry their own.
+	Runtime int
+}
+
+// DefaultExitCodes are the exit statuses of commands which don't choose their
+// own: 2 for usage errors, as the flag package uses, and 1 otherwise. Programs
+// in ecosystems which expect EX_USAGE may set Usage to 64 before running any
+// command.
+var DefaultExitCodes = ExitCodes{Usage: 2, Runtime: 1}
+
+// ExitCodeMapper is implemented by commands which choose the exit statuses of
+// their failures, in place of DefaultExitCodes. The choice extends to their
+// subcommands, unless those choose their own.
+type ExitCodeMapper interface {
+	ExitCodes() ExitCodes
+}
+
+// UsageError is returned by a command which was used wrongly: given flags or
+// arguments it can't accept, or no verb or subcommand where it needs one. The
+// program exits with DefaultExitCodes.Usage, unless the command chooses
+// another.
+type UsageError struct {
+	Err error
+}
+
+func (err *UsageError) Error() string {
+	return err.Err.Error()
+}
+
+func (err *UsageError) Unwrap() error {
+	return err.Err
+}
+
+// ExitCode of a usage error.
+func (err *UsageError) ExitCode() int {
+	return DefaultExitCodes.Usage
+}
+
+// NewUsageError returns err as a UsageError, such as for the errors of parsing
+// flags. Nil and flag.ErrHelp are returned as they are.
+func NewUsageError(err error) error {
+	if err == nil || errors.Is(err, flag.ErrHelp) {
+		return err
+	}
+	return &UsageError{Err: err}
+}
+
+// Usagef formats a UsageError as fmt.Errorf does.
+func Usagef(format string, a ...any) error {
+	return &UsageError{Err: fmt.Errorf(format, a...)}
+}
+
+// isUsage is true when err's tree holds a usage error.
+func isUsage(err error) bool {
+	var usage *UsageError
+	var missing *MissingError
+	return errors.As(err, &usage) || errors.As(err, &missing)
+}
+
+// mappedError carries the exit status which a command chose for err.
+type mappedError struct {
+	err  error
+	code int
+}
+
+func (err *mappedError) Error() string {
+	return err.err.Error()
+}
+
+func (err *mappedError) Unwrap() error {
+	return err.err
+}
+
+func (err *mappedError) ExitCode() int {
+	return err.code
+}
+
+// MapExitCodes returns err, which cmd returned, with the exit status chosen
+// by cmd when it implements ExitCodeMapper: its Usage status for usage
+// errors, and its Runtime status for errors which carry no status of their
+// own. Errors for which a subcommand of cmd already chose a status keep it, as
+// do nil and flag.ErrHelp.
+func MapExitCodes(cmd any, err error) error {
+	m, ok := cmd.(ExitCodeMapper)
+	var mapped *mappedError
+	if !ok || err == nil || errors.Is(err, flag.ErrHelp) || errors.As(err, &mapped) {
+		return err
+	}
+	codes := m.ExitCodes()
+	var ec ExitCoder
+	code := 0
+	switch {
+	case isUsage(err):
+		code = codes.Usage
+	case !errors.As(err, &ec):
+		code = codes.Runtime
+	}
+	if code == 0 {
+		return err
+	}
+	return &mappedError{err, code}
+}
+
+// MainOption configures Main.
+type MainOption func(*mainOptions)
+
+// mainOptions are those with which Main runs.
+type mainOptions struct {
+	crashes  CrashReporter
+	reporter Reporter
+}
+
+// ReportCrashesTo has Main report the panics of the commands it runs to r.
+// Without it, they are reported to the CrashReporter registered with Provide,
+// if any, as by an init function of a program whose main function is
+// generated.
+func ReportCrashesTo(r CrashReporter) MainOption {
+	return func(opts *mainOptions) {
+		opts.crashes = r
+	}
+}
+
+// ReportTo has Main report each run to r, with the path of the command which
+// actually ran. Without it, runs are reported to the Reporter registered with
+// Provide, if any. Either way, Report honours OptOutEnv.
+func ReportTo(r Reporter) MainOption {
+	return func(opts *mainOptions) {
+		opts.reporter = r
+	}
+}
+
+// Main calls run, which returns the program's exit status, and exits with it
+// through e. A generated main function is a thin wrapper around Main, leaving
+// run testable without terminating the test binary. Run is called with a
+// context carrying the CrashReporter chosen by the options, to which Recover
+// reports. Once run returns, the run is reported to the Reporter chosen by the
+// options, and its status is written for shell prompts when StatusEnv is set.
+func Main(e Exiter, run func(ctx context.Context) int, options ...MainOption) {
+	var opts mainOptions
+	for _, option := range options {
+		option(&opts)
+	}
+	ctx := context.Background()
+	if opts.crashes == nil {
+		opts.crashes, _ = Inject[CrashReporter](ctx, "")
+	}
+	if opts.crashes != nil {
+		ctx = WithCrashReporter(ctx, opts.crashes)
+	}
+	if opts.reporter == nil {
+		opts.reporter, _ = Inject[Reporter](ctx, "")
+	}
+	var ran Path
+	start := time.Now()
+	code := run(withRan(ctx, &ran))
+	if len(ran) == 0 {
+		// Nothing named the command run, so report the program itself.
+		ran = Path{strings.TrimSuffix(filepath.Base(os.Args[0]), ".exe")}
+	}
+	inv := Invocation{
+		Path:       ran,
+		Duration:   time.Since(start),
+		ExitStatus: code,
+	}
+	Report(ctx, opts.reporter, inv)
+	if err := WriteStatus(inv); err != nil {
+		slog.Warn("Failed writing status", slog.Any("error", err))
+	}
+	Exit(e, code)
+}
@@ -0,0 +1,130 @@
+package cliche
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type exitError int
+
+func (e exitError) Error() string { return fmt.Sprintf("exit %d", int(e)) }
+func (e exitError) ExitCode() int { return int(e) }
+
+func TestExitCode(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		err  error
+		want int
+	}{
+		"nil":     {nil, 0},
+		"plain":   {errors.New("oh no"), 1},
+		"coder":   {exitError(3), 3},
+		"wrapped": {fmt.Errorf("running: %w", exitError(4)), 4},
+		"joined":  {errors.Join(errors.New("oh no"), exitError(5)), 5},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			if got := ExitCode(tc.err); got != tc.want {
+				t.Errorf("ExitCode(): got: %v want: %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMainExits(t *testing.T) {
+	var got []int
+	e := ExiterFunc(func(code int) { got = append(got, code) })
+	Main(e, func(context.Context) int { return 2 })
+	if len(got) != 1 || got[0] != 2 {
+		t.Errorf("Main(): exits mismatch: got: %v want: [2]", got)
+	}
+}
+
+func TestMainReportsCrashes(t *testing.T) {
+	var got []int
+	e := ExiterFunc(func(code int) { got = append(got, code) })
+	var crashes []Crash
+	Main(e, func(ctx context.Context) int {
+		return ExitCode(Recover(WithCommand(ctx, "app"), func(context.Context) error { panic("boom") }))
+	}, ReportCrashesTo(CrashReporterFunc(func(ctx context.Context, crash Crash) {
+		crashes = append(crashes, crash)
+	})))
+	if len(got) != 1 || got[0] != 1 {
+		t.Errorf("Main(): exits mismatch: got: %v want: [1]", got)
+	}
+	if len(crashes) != 1 || len(crashes[0].Path) != 1 || crashes[0].Path[0] != "app" {
+		t.Errorf("Main(): got crash reports %v, want one for app", crashes)
+	}
+}
+
+func TestMainReports(t *testing.T) {
+	t.Setenv(OptOutEnv, "0")
+	e := ExiterFunc(func(int) {})
+	var got []Path
+	Main(e, func(ctx context.Context) int {
+		ctx = WithCommand(WithCommand(ctx, "app"), "remote")
+		WithCommand(ctx, "add")
+		return 3
+	}, ReportTo(ReporterFunc(func(ctx context.Context, inv Invocation) {
+		if inv.ExitStatus != 3 {
+			t.Errorf("Main(): reported exit status %v, want 3", inv.ExitStatus)
+		}
+		got = append(got, inv.Path)
+	})))
+	if diff := cmp.Diff(got, []Path{{"app", "remote", "add"}}); diff != "" {
+		t.Errorf("Main(): reported paths mismatch (-got,+want):\n%v", diff)
+	}
+}
+
+type exitCodesCmd ExitCodes
+
+func (cmd exitCodesCmd) ExitCodes() ExitCodes { return ExitCodes(cmd) }
+
+func TestMapExitCodes(t *testing.T) {
+	sysexits := exitCodesCmd{Usage: 64, Runtime: 70}
+	for tn, tc := range map[string]struct {
+		cmd  any
+		err  error
+		want int
+	}{
+		"no mapper":  {struct{}{}, Usagef("unexpected arguments"), 2},
+		"nil":        {sysexits, nil, 0},
+		"usage":      {sysexits, Usagef("unexpected arguments"), 64},
+		"parse":      {sysexits, NewUsageError(errors.New("flag provided but not defined: -x")), 64},
+		"missing":    {sysexits, fmt.Errorf("running: %w", &MissingError{Inputs: []string{"-name"}}), 64},
+		"runtime":    {sysexits, errors.New("oh no"), 70},
+		"coder":      {sysexits, exitError(3), 3},
+		"usage only": {exitCodesCmd{Usage: 64}, errors.New("oh no"), 1},
+		"subcommand": {sysexits, MapExitCodes(exitCodesCmd{Usage: 65}, Usagef("unexpected arguments")), 65},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			if got := ExitCode(MapExitCodes(tc.cmd, tc.err)); got != tc.want {
+				t.Errorf("ExitCode(MapExitCodes()): got: %v want: %v", got, tc.want)
+			}
+		})
+	}
+	if err := MapExitCodes(sysexits, NewUsageError(flag.ErrHelp)); err != flag.ErrHelp {
+		t.Errorf("MapExitCodes(): got: %v want: flag.ErrHelp", err)
+	}
+}
+
+func TestDefaultExitCodes(t *testing.T) {
+	defer func(codes ExitCodes) { DefaultExitCodes = codes }(DefaultExitCodes)
+	DefaultExitCodes = ExitCodes{Usage: 64, Runtime: 70}
+	for _, tc := range []struct {
+		err  error
+		want int
+	}{
+		{&MissingError{Inputs: []string{"-name"}}, 64},
+		{Usagef("unexpected arguments"), 64},
+		{errors.New("oh no"), 70},
+		{exitError(3), 3},
+	} {
+		if got := ExitCode(tc.err); got != tc.want {
+			t.Errorf("ExitCode(%v): got: %v want: %v", tc.err, got, tc.want)
+		}
+	}
+}
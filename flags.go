@@ -0,0 +1,434 @@
+package cliche
+
+import (
+	"flag"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// flagValue binds a flag to a value of type T, which is parsed with parse, or
+// Parse when it is nil.
+type flagValue[T any] struct {
+	p     *T
+	parse func(string) (T, error)
+}
+
+func (f flagValue[T]) String() string {
+	if f.p == nil {
+		// The flag package formats zero values to decide which defaults
+		// are worth showing.
+		var zero T
+		return fmt.Sprint(zero)
+	}
+	return fmt.Sprint(*f.p)
+}
+
+func (f flagValue[T]) Set(s string) error {
+	parse := f.parse
+	if parse == nil {
+		parse = Parse[T]
+	}
+	v, err := parse(s)
+	if err != nil {
+		return err
+	}
+	*f.p = v
+	return nil
+}
+
+// IsBoolFlag allows boolean flags to be given without a value, as -v.
+func (f flagValue[T]) IsBoolFlag() bool {
+	return typeOf[T]().Kind() == reflect.Bool
+}
+
+// Type names the type of values, as the flag values of the pflag package do,
+// so that flags bound by BindFlag may be added to a pflag.FlagSet as they are.
+func (f flagValue[T]) Type() string {
+	return typeOf[T]().String()
+}
+
+// BindFlag registers a flag on fs under each of names, which sets the value
+// pointed to by p. Values are parsed with Parse, so T may be any type it
+// supports. The current value of p is the flag's default. Boolean flags may
+// be given without a value.
+func BindFlag[T any](fs *flag.FlagSet, p *T, usage string, names ...string) {
+	BindFlagFunc(fs, p, nil, usage, names...)
+}
+
+// BindFlagFunc is BindFlag, with values parsed by parse rather than Parse.
+func BindFlagFunc[T any](fs *flag.FlagSet, p *T, parse func(string) (T, error), usage string, names ...string) {
+	for _, name := range names {
+		fs.Var(flagValue[T]{p, parse}, name, usage)
+	}
+}
+
+// negatedValue binds the negated form of a bool flag, which sets v, a bool, to
+// the opposite of the value given.
+type negatedValue struct {
+	v reflect.Value
+}
+
+func (f negatedValue) String() string {
+	if !f.v.IsValid() {
+		return "false"
+	}
+	return strconv.FormatBool(!f.v.Bool())
+}
+
+func (f negatedValue) Set(s string) error {
+	v, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	f.v.SetBool(!v)
+	return nil
+}
+
+// IsBoolFlag allows negated flags to be given without a value, as -no-color.
+func (f negatedValue) IsBoolFlag() bool {
+	return true
+}
+
+// Type is bool, as for the flag which is negated.
+func (f negatedValue) Type() string {
+	return "bool"
+}
+
+// BindNegatedFlag registers a flag on fs under each of names, which negates
+// the bool pointed to by p: given alone, it sets it to false. Names are those
+// of the negated form, such as no-color for the flag bound to p as color.
+// When both forms are given, the last wins.
+func BindNegatedFlag[T ~bool](fs *flag.FlagSet, p *T, usage string, names ...string) {
+	for _, name := range names {
+		fs.Var(negatedValue{reflect.ValueOf(p).Elem()}, name, usage)
+	}
+}
+
+// countValue binds a counting flag, which adds one to v, an integer, each
+// time it is given.
+type countValue struct {
+	v reflect.Value
+}
+
+func (f countValue) String() string {
+	if !f.v.IsValid() {
+		return "0"
+	}
+	return fmt.Sprint(f.v.Interface())
+}
+
+// Set adds one to the count when s is true, as it is when the flag is given
+// without a value, and resets it when s is false. Given a number, as
+// -v=3, it sets the count to it.
+func (f countValue) Set(s string) error {
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return f.set(n)
+	}
+	v, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	if !v {
+		return f.set(0)
+	}
+	if f.v.CanUint() {
+		return f.set(int64(f.v.Uint()) + 1)
+	}
+	return f.set(f.v.Int() + 1)
+}
+
+func (f countValue) set(n int64) error {
+	if n < 0 || (f.v.CanInt() && f.v.OverflowInt(n)) || (f.v.CanUint() && f.v.OverflowUint(uint64(n))) {
+		return fmt.Errorf("count %v out of range", n)
+	}
+	if f.v.CanUint() {
+		f.v.SetUint(uint64(n))
+	} else {
+		f.v.SetInt(n)
+	}
+	return nil
+}
+
+// IsBoolFlag allows counting flags to be given without a value, as -v.
+func (f countValue) IsBoolFlag() bool {
+	return true
+}
+
+// Type is count, as for the counting flags of the pflag package.
+func (f countValue) Type() string {
+	return "count"
+}
+
+// BindCountFlag registers a flag on fs under each of names, which counts the
+// times it is given in the integer pointed to by p, as for verbosity levels.
+// Given a number, as -v=3, it sets the count instead. The current value of p
+// is the flag's default. Together with ExpandCountFlags, a flag named by a
+// single letter may be repeated in one argument, as -vvv.
+func BindCountFlag[T ~int | ~int8 | ~int16 | ~int32 | ~int64 | ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64](fs *flag.FlagSet, p *T, usage string, names ...string) {
+	for _, name := range names {
+		fs.Var(countValue{reflect.ValueOf(p).Elem()}, name, usage)
+	}
+}
+
+// ExpandCountFlags rewrites each argument in args which repeats a counting
+// flag of fs named by a single letter, as -vvv, into one argument for each
+// repetition, as -v -v -v, which the flag package can parse. As the flag
+// package does, it stops at the first argument which is neither a flag nor
+// the value of one, or at --, leaving the rest as they are.
+func ExpandCountFlags(fs *flag.FlagSet, args []string) []string {
+	var shorts string
+	fs.VisitAll(func(f *flag.Flag) {
+		if v, ok := f.Value.(interface{ Type() string }); ok && v.Type() == "count" && len(f.Name) == 1 {
+			shorts += f.Name
+		}
+	})
+	if shorts == "" {
+		return args
+	}
+	ret := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !isFlag(arg) {
+			return append(ret, args[i:]...)
+		}
+		letters := arg[1:]
+		if len(letters) >= 2 && strings.ContainsRune(shorts, rune(letters[0])) &&
+			strings.Trim(letters, letters[:1]) == "" {
+			for range letters {
+				ret = append(ret, "-"+letters[:1])
+			}
+			continue
+		}
+		ret = append(ret, arg)
+		if name, _, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "="); !hasValue && takesValue(fs, name) && i+1 < len(args) {
+			i++
+			ret = append(ret, args[i])
+		}
+	}
+	return ret
+}
+
+// isFlag reports whether arg is parsed by the flag package as a flag, rather
+// than ending the flags as the first positional argument, - or -- do.
+func isFlag(arg string) bool {
+	return len(arg) > 1 && arg[0] == '-' && arg != "--"
+}
+
+// takesValue reports whether the flag of fs with name takes its value from the
+// following argument when it is given without one, as -o file. Flags which
+// are not defined do not.
+func takesValue(fs *flag.FlagSet, name string) bool {
+	f := fs.Lookup(name)
+	if f == nil {
+		return false
+	}
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return !ok || !b.IsBoolFlag()
+}
+
+// SlicePolicy is how the flags bound to slices take their values.
+type SlicePolicy int
+
+const (
+	// SliceRepeat appends one value for each use of the flag, unless its
+	// input has a separator, in which case it is as SliceBoth.
+	SliceRepeat SlicePolicy = iota
+	// SliceSplit replaces the values with those given by each use of the
+	// flag, separated by the input's separator, or commas by default.
+	SliceSplit
+	// SliceBoth appends the values given by each use of the flag, separated
+	// as for SliceSplit.
+	SliceBoth
+)
+
+// String returns the name of the policy, as given to the -slices flag of a
+// go:generate directive: repeat, split or both.
+func (policy SlicePolicy) String() string {
+	switch policy {
+	case SliceSplit:
+		return "split"
+	case SliceBoth:
+		return "both"
+	}
+	return "repeat"
+}
+
+// sliceValues splits s, given to a flag bound to a slice with the separator
+// sep, into values as policy says, and reports whether they replace those
+// given before.
+func sliceValues(s, sep string, policy SlicePolicy) ([]string, bool) {
+	if sep == "" && policy != SliceRepeat {
+		sep = ","
+	}
+	if sep == "" {
+		return []string{s}, false
+	}
+	return strings.Split(s, sep), policy == SliceSplit
+}
+
+// sliceValue binds a repeatable flag to a slice of values of type E, which
+// are parsed with parse, or Parse when it is nil, and separated by sep.
+type sliceValue[E any] struct {
+	p      *[]E
+	parse  func(string) (E, error)
+	sep    string
+	policy SlicePolicy
+	// set is shared between all names of the flag, and is true once the
+	// first value has replaced the default.
+	set *bool
+}
+
+func (f sliceValue[E]) String() string {
+	if f.p == nil {
+		return ""
+	}
+	var values []string
+	for _, v := range *f.p {
+		values = append(values, fmt.Sprint(v))
+	}
+	if f.sep != "" {
+		return strings.Join(values, f.sep)
+	}
+	return strings.Join(values, ",")
+}
+
+func (f sliceValue[E]) Set(s string) error {
+	parse := f.parse
+	if parse == nil {
+		parse = Parse[E]
+	}
+	values, replace := sliceValues(s, f.sep, f.policy)
+	if !*f.set || replace {
+		*f.p, *f.set = nil, true
+	}
+	for _, s := range values {
+		v, err := parse(s)
+		if err != nil {
+			return err
+		}
+		*f.p = append(*f.p, v)
+	}
+	return nil
+}
+
+// Type names the type of the slice, as for flagValue.
+func (f sliceValue[E]) Type() string {
+	return typeOf[[]E]().String()
+}
+
+// BindSliceFlag registers a repeatable flag on fs under each of names, each
+// use of which appends a value to the slice pointed to by p. The current
+// contents of the slice are the flag's default, which is replaced by the
+// first use.
+func BindSliceFlag[E any](fs *flag.FlagSet, p *[]E, usage string, names ...string) {
+	BindSliceFlagSep(fs, p, "", nil, usage, names...)
+}
+
+// BindSliceFlagFunc is BindSliceFlag, with values parsed by parse rather than
+// Parse.
+func BindSliceFlagFunc[E any](fs *flag.FlagSet, p *[]E, parse func(string) (E, error), usage string, names ...string) {
+	BindSliceFlagSep(fs, p, "", parse, usage, names...)
+}
+
+// BindSliceFlagSep is BindSliceFlagFunc, with several values given at once
+// separated by sep, and parsed by Parse when parse is nil.
+func BindSliceFlagSep[E any](fs *flag.FlagSet, p *[]E, sep string, parse func(string) (E, error), usage string, names ...string) {
+	BindSliceFlagPolicy(fs, p, SliceRepeat, sep, parse, usage, names...)
+}
+
+// BindSliceFlagPolicy is BindSliceFlagSep, with the values of each use taken
+// as policy says, separated by commas when sep is empty and policy splits
+// them. Generated commands bind their slice flags with the policy given by
+// the -slices flag of their go:generate directive.
+func BindSliceFlagPolicy[E any](fs *flag.FlagSet, p *[]E, policy SlicePolicy, sep string, parse func(string) (E, error), usage string, names ...string) {
+	set := new(bool)
+	for _, name := range names {
+		fs.Var(sliceValue[E]{p, parse, sep, policy, set}, name, usage)
+	}
+}
+
+// mapValue binds a repeatable flag to a map of values of type V by keys of
+// type K, each given as a key and value separated by sep.
+type mapValue[K comparable, V any] struct {
+	p   *map[K]V
+	sep string
+	// set is shared between all names of the flag, and is true once the
+	// first entry has replaced the default.
+	set *bool
+}
+
+func (f mapValue[K, V]) String() string {
+	if f.p == nil {
+		return ""
+	}
+	return formatMap(reflect.ValueOf(*f.p), f.sep)
+}
+
+func (f mapValue[K, V]) Set(s string) error {
+	if !*f.set || *f.p == nil {
+		*f.p, *f.set = make(map[K]V), true
+	}
+	return setMapEntry(reflect.ValueOf(*f.p), s, f.sep, parseValue)
+}
+
+// Type names the type of the map, as for flagValue.
+func (f mapValue[K, V]) Type() string {
+	return typeOf[map[K]V]().String()
+}
+
+// BindMapFlag registers a repeatable flag on fs under each of names, each use
+// of which sets an entry of the map pointed to by p, given as a key and value
+// separated by sep. Keys and values are parsed with Parse. The current
+// contents of the map are the flag's default, which is replaced by the first
+// use.
+func BindMapFlag[K comparable, V any](fs *flag.FlagSet, p *map[K]V, sep, usage string, names ...string) {
+	set := new(bool)
+	for _, name := range names {
+		fs.Var(mapValue[K, V]{p, sep, set}, name, usage)
+	}
+}
+
+// ParseMap parses the default of a map flag: entries separated by commas,
+// each a key and value separated by sep.
+func ParseMap[K comparable, V any](s, sep string) (map[K]V, error) {
+	m := make(map[K]V)
+	for _, entry := range strings.Split(s, ",") {
+		if err := setMapEntry(reflect.ValueOf(m), entry, sep, parseValue); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// setMapEntry sets the entry of the map m given by s, as a key and value
+// separated by sep, each parsed by parse.
+func setMapEntry(m reflect.Value, s, sep string, parse func(reflect.Type, string) (reflect.Value, error)) error {
+	key, value, ok := strings.Cut(s, sep)
+	if !ok {
+		return fmt.Errorf("%q is not a key and value separated by %q", s, sep)
+	}
+	k, err := parse(m.Type().Key(), key)
+	if err != nil {
+		return err
+	}
+	v, err := parse(m.Type().Elem(), value)
+	if err != nil {
+		return err
+	}
+	m.SetMapIndex(k, v)
+	return nil
+}
+
+// formatMap formats the entries of the map m as they are given to a map flag,
+// separated by commas and sorted.
+func formatMap(m reflect.Value, sep string) string {
+	var entries []string
+	for iter := m.MapRange(); iter.Next(); {
+		entries = append(entries, fmt.Sprint(iter.Key().Interface())+sep+fmt.Sprint(iter.Value().Interface()))
+	}
+	sort.Strings(entries)
+	return strings.Join(entries, ",")
+}
@@ -0,0 +1,296 @@
+package cliche
+
+import (
+	"flag"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestBindFlag(t *testing.T) {
+	var (
+		name    = "World"
+		verbose bool
+		timeout = time.Second
+		port    uint16
+	)
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	BindFlag(fs, &name, "name to greet", "name", "n")
+	BindFlag(fs, &verbose, "verbose output", "verbose", "v")
+	BindFlag(fs, &timeout, "how long to wait", "timeout")
+	BindFlag(fs, &port, "port to listen on", "port")
+
+	if got := fs.Lookup("timeout").DefValue; got != "1s" {
+		t.Errorf("BindFlag(): default mismatch: got: %q want: %q", got, "1s")
+	}
+	if err := fs.Parse([]string{"-v", "-n", "there", "--timeout=1m", "--port", "8080", "rest"}); err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	if name != "there" || !verbose || timeout != time.Minute || port != 8080 {
+		t.Errorf("BindFlag(): got name=%q verbose=%v timeout=%v port=%v", name, verbose, timeout, port)
+	}
+	if diff := cmp.Diff(fs.Args(), []string{"rest"}); diff != "" {
+		t.Errorf("Args(): mismatch (-got,+want):\n%v", diff)
+	}
+
+	if err := fs.Parse([]string{"--port=99999"}); err == nil {
+		t.Error("Parse(): wanted error for out of range port, got nil")
+	}
+}
+
+func TestFlagType(t *testing.T) {
+	var (
+		timeout time.Duration
+		verbose int
+		color   bool
+		tags    []string
+		limits  map[string]int
+	)
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	BindFlag(fs, &timeout, "how long to wait", "timeout")
+	BindCountFlag(fs, &verbose, "verbosity", "verbose")
+	BindNegatedFlag(fs, &color, "negates -color", "no-color")
+	BindSliceFlag(fs, &tags, "tags", "tag")
+	BindMapFlag(fs, &limits, "=", "limits", "limit")
+	for name, want := range map[string]string{
+		"timeout":  "time.Duration",
+		"verbose":  "count",
+		"no-color": "bool",
+		"tag":      "[]string",
+		"limit":    "map[string]int",
+	} {
+		typed, ok := fs.Lookup(name).Value.(interface{ Type() string })
+		if !ok {
+			t.Errorf("Lookup(%q): value has no Type method", name)
+			continue
+		}
+		if got := typed.Type(); got != want {
+			t.Errorf("Type(%q): got: %q want: %q", name, got, want)
+		}
+	}
+}
+
+func TestBindSliceFlag(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		args []string
+		want []int
+	}{
+		"default":  {nil, []int{1, 2}},
+		"replaced": {[]string{"-n", "3", "--num", "4"}, []int{3, 4}},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			nums := []int{1, 2}
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			BindSliceFlag(fs, &nums, "numbers", "num", "n")
+			if got := fs.Lookup("num").DefValue; got != "1,2" {
+				t.Errorf("BindSliceFlag(): default mismatch: got: %q want: %q", got, "1,2")
+			}
+			if err := fs.Parse(tc.args); err != nil {
+				t.Fatalf("Parse(): unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(nums, tc.want); diff != "" {
+				t.Errorf("BindSliceFlagPolicy(): words mismatch (-got,+want):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestBindNegatedFlag(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		args []string
+		want bool
+	}{
+		"default":   {nil, true},
+		"negated":   {[]string{"-no-color"}, false},
+		"explicit":  {[]string{"--no-color=false"}, true},
+		"last wins": {[]string{"-no-color", "-c"}, true},
+		"both":      {[]string{"-color", "-no-color"}, false},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			color := true
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			BindFlag(fs, &color, "color output", "color", "c")
+			BindNegatedFlag(fs, &color, "negates -color", "no-color")
+			if err := fs.Parse(tc.args); err != nil {
+				t.Fatalf("Parse(): unexpected error: %v", err)
+			}
+			if color != tc.want {
+				t.Errorf("BindNegatedFlag(): got: %v want: %v", color, tc.want)
+			}
+		})
+	}
+}
+
+func TestBindCountFlag(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		args    []string
+		want    int
+		wantErr bool
+	}{
+		"default":  {nil, 1, false},
+		"repeated": {[]string{"-v", "--verbose", "-v"}, 4, false},
+		"set":      {[]string{"-v", "-v=5"}, 5, false},
+		"reset":    {[]string{"-v", "-v=false", "-v"}, 1, false},
+		"negative": {[]string{"-v=-1"}, 0, true},
+		"bad":      {[]string{"-v=lots"}, 0, true},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			verbose := 1
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			fs.SetOutput(io.Discard)
+			BindCountFlag(fs, &verbose, "verbosity", "verbose", "v")
+			err := fs.Parse(tc.args)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Parse(): error mismatch: got: %v wantErr: %v", err, tc.wantErr)
+			}
+			if err == nil && verbose != tc.want {
+				t.Errorf("BindCountFlag(): got: %v want: %v", verbose, tc.want)
+			}
+		})
+	}
+
+	var quiet uint8
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	BindCountFlag(fs, &quiet, "quietness", "q")
+	if err := fs.Parse([]string{"-q=255", "-q"}); err == nil {
+		t.Error("Parse(): wanted error for count out of range, got nil")
+	}
+}
+
+func TestExpandCountFlags(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		args, want []string
+	}{
+		"none":       {[]string{"run"}, []string{"run"}},
+		"repeated":   {[]string{"-vvv", "run"}, []string{"-v", "-v", "-v", "run"}},
+		"several":    {[]string{"-vv", "-qq"}, []string{"-v", "-v", "-q", "-q"}},
+		"single":     {[]string{"-v"}, []string{"-v"}},
+		"mixed":      {[]string{"-vq", "-vvx"}, []string{"-vq", "-vvx"}},
+		"long":       {[]string{"--vv"}, []string{"--vv"}},
+		"not counts": {[]string{"-xx"}, []string{"-xx"}},
+		"terminated": {[]string{"-vv", "--", "-vv"}, []string{"-v", "-v", "--", "-vv"}},
+		"positional": {[]string{"-vv", "run", "-vv"}, []string{"-v", "-v", "run", "-vv"}},
+		"value":      {[]string{"-o", "-vv", "-vv"}, []string{"-o", "-vv", "-v", "-v"}},
+		"bool":       {[]string{"-x", "-vv"}, []string{"-x", "-v", "-v"}},
+		"stdin":      {[]string{"-", "-vv"}, []string{"-", "-vv"}},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			var verbose, quiet int
+			var out string
+			var x bool
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			BindCountFlag(fs, &verbose, "verbosity", "v")
+			BindCountFlag(fs, &quiet, "quietness", "q", "quiet")
+			BindFlag(fs, &out, "output", "o")
+			BindFlag(fs, &x, "x", "x")
+			if diff := cmp.Diff(ExpandCountFlags(fs, tc.args), tc.want); diff != "" {
+				t.Errorf("ExpandCountFlags(): mismatch (-got,+want):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestSlicePolicy(t *testing.T) {
+	args := []string{"-w", "a,b", "-w", "c", "-p", "a+b", "-p", "c"}
+	for tn, tc := range map[string]struct {
+		policy       SlicePolicy
+		words, plays []string
+	}{
+		"repeat": {SliceRepeat, []string{"a,b", "c"}, []string{"a", "b", "c"}},
+		"split":  {SliceSplit, []string{"c"}, []string{"c"}},
+		"both":   {SliceBoth, []string{"a", "b", "c"}, []string{"a", "b", "c"}},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			words, plays := []string{"default"}, []string{"default"}
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			BindSliceFlagPolicy(fs, &words, tc.policy, "", nil, "words", "w")
+			BindSliceFlagPolicy(fs, &plays, tc.policy, "+", nil, "plays", "p")
+			if err := fs.Parse(args); err != nil {
+				t.Fatalf("Parse(): unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(words, tc.words); diff != "" {
+				t.Errorf("BindSliceFlag(): mismatch (-got,+want):\n%v", diff)
+			}
+			if diff := cmp.Diff(plays, tc.plays); diff != "" {
+				t.Errorf("BindSliceFlagPolicy(): plays mismatch (-got,+want):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestBindMapFlag(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		args    []string
+		want    map[string]int
+		wantErr bool
+	}{
+		"default":     {nil, map[string]int{"cpu": 1, "mem": 512}, false},
+		"replaced":    {[]string{"-l", "cpu:2", "--limit", "disk:10"}, map[string]int{"cpu": 2, "disk": 10}, false},
+		"overridden":  {[]string{"-l", "cpu:2", "-l", "cpu:4"}, map[string]int{"cpu": 4}, false},
+		"unseparated": {[]string{"-l", "cpu=2"}, nil, true},
+		"bad value":   {[]string{"-l", "disk:10:20"}, nil, true},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			limits := map[string]int{"cpu": 1, "mem": 512}
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			fs.SetOutput(io.Discard)
+			BindMapFlag(fs, &limits, ":", "limits", "limit", "l")
+			if got := fs.Lookup("limit").DefValue; got != "cpu:1,mem:512" {
+				t.Errorf("BindMapFlag(): default mismatch: got: %q want: %q", got, "cpu:1,mem:512")
+			}
+			err := fs.Parse(tc.args)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Parse(): error mismatch: got: %v wantErr: %v", err, tc.wantErr)
+			}
+			if err == nil {
+				if diff := cmp.Diff(limits, tc.want); diff != "" {
+					t.Errorf("BindMapFlag(): mismatch (-got,+want):\n%v", diff)
+				}
+			}
+		})
+	}
+}
+
+func TestParseMap(t *testing.T) {
+	got, err := ParseMap[string, string]("team=infra,tier=web=1", "=")
+	if err != nil {
+		t.Fatalf("ParseMap(): unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(got, map[string]string{"team": "infra", "tier": "web=1"}); diff != "" {
+		t.Errorf("ParseMap(): mismatch (-got,+want):\n%v", diff)
+	}
+	if _, err := ParseMap[string, int]("a=1,b", "="); err == nil {
+		t.Error("ParseMap(): wanted error for entry without separator, got nil")
+	}
+}
+
+func TestBindFlagFunc(t *testing.T) {
+	var (
+		since time.Time
+		skip  []time.Time
+	)
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	BindFlagFunc(fs, &since, TimeParser(time.DateOnly, ""), "first day", "since")
+	BindSliceFlagFunc(fs, &skip, TimeParser(time.DateOnly, ""), "days to skip", "skip")
+	if err := fs.Parse([]string{"-since", "2026-10-01", "-skip", "2026-10-05", "-skip", "2026-10-06"}); err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	want := []time.Time{
+		time.Date(2026, 10, 5, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, 10, 6, 0, 0, 0, 0, time.UTC),
+	}
+	if !since.Equal(time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("BindFlagFunc(): got: %v want: 2026-10-01", since)
+	}
+	if diff := cmp.Diff(skip, want); diff != "" {
+		t.Errorf("BindSliceFlagFunc(): mismatch (-got,+want):\n%v", diff)
+	}
+	if err := fs.Parse([]string{"-since", "2026-10-01T00:00:00Z"}); err == nil {
+		t.Error("Parse(): wanted error for time not in layout, got nil")
+	}
+}
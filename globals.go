@@ -0,0 +1,87 @@
+package cliche
+
+import (
+	"flag"
+	"strings"
+)
+
+// Globals holds the flags given to a command which its subcommands declare
+// global, so that they may be given before the name of the subcommand, as
+// they are, to be passed on to the subcommands declaring them. Values are
+// not parsed until then.
+type Globals struct {
+	// given holds the values given for each flag, by its first name.
+	given map[string][]string
+}
+
+// globalValue is a global flag registered on a flag set, which records the
+// values given for it in g.
+type globalValue struct {
+	g          *Globals
+	name, kind string
+	isBool     bool
+}
+
+func (f *globalValue) String() string {
+	if f == nil || f.g == nil {
+		return ""
+	}
+	return strings.Join(f.g.given[f.name], ",")
+}
+
+func (f *globalValue) Set(s string) error {
+	f.g.given[f.name] = append(f.g.given[f.name], s)
+	return nil
+}
+
+// IsBoolFlag allows boolean and counting global flags to be given without a
+// value, as -v.
+func (f *globalValue) IsBoolFlag() bool {
+	return f.isBool
+}
+
+// Type is count for counting global flags, which ExpandCountFlags expands
+// as it does those bound by BindCountFlag.
+func (f *globalValue) Type() string {
+	return f.kind
+}
+
+func (g *Globals) register(fs *flag.FlagSet, f *globalValue, usage string, names []string) {
+	if g.given == nil {
+		g.given = make(map[string][]string)
+	}
+	f.g, f.name = g, names[0]
+	for _, name := range names {
+		fs.Var(f, name, usage)
+	}
+}
+
+// Var registers a global flag on fs under each of names, which takes a value.
+func (g *Globals) Var(fs *flag.FlagSet, usage string, names ...string) {
+	g.register(fs, &globalValue{kind: "string"}, usage, names)
+}
+
+// BoolVar registers a global boolean flag on fs under each of names, which
+// may be given without a value.
+func (g *Globals) BoolVar(fs *flag.FlagSet, usage string, names ...string) {
+	g.register(fs, &globalValue{kind: "bool", isBool: true}, usage, names)
+}
+
+// CountVar registers a global counting flag on fs under each of names, which
+// may be given without a value, and repeated, as -vvv.
+func (g *Globals) CountVar(fs *flag.FlagSet, usage string, names ...string) {
+	g.register(fs, &globalValue{kind: "count", isBool: true}, usage, names)
+}
+
+// Args returns arguments giving the global flags with names, each the first
+// name of a registered flag, the values which they were given, in order. They
+// go before the arguments of a subcommand declaring the flags.
+func (g *Globals) Args(names ...string) []string {
+	var args []string
+	for _, name := range names {
+		for _, s := range g.given[name] {
+			args = append(args, "--"+name+"="+s)
+		}
+	}
+	return args
+}
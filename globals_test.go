@@ -0,0 +1,33 @@
+package cliche
+
+import (
+	"flag"
+	"io"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGlobals(t *testing.T) {
+	var g Globals
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	g.CountVar(fs, "log more", "verbose", "v")
+	g.BoolVar(fs, "change nothing", "dry-run")
+	g.Var(fs, "config file", "config", "c")
+
+	args := ExpandCountFlags(fs, []string{"-vv", "--dry-run", "-c", "a.yaml", "--config=b.yaml", "build", "-v"})
+	if err := fs.Parse(args); err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(fs.Args(), []string{"build", "-v"}); diff != "" {
+		t.Errorf("Args(): mismatch (-got,+want):\n%v", diff)
+	}
+	want := []string{"--verbose=true", "--verbose=true", "--config=a.yaml", "--config=b.yaml"}
+	if diff := cmp.Diff(g.Args("verbose", "config"), want); diff != "" {
+		t.Errorf("Globals.Args(): mismatch (-got,+want):\n%v", diff)
+	}
+	if got := g.Args("dry-run", "missing"); len(got) != 1 || got[0] != "--dry-run=true" {
+		t.Errorf("Globals.Args(): got %q, want [--dry-run=true]", got)
+	}
+}
@@ -0,0 +1,168 @@
+package cliche
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Helper is implemented by commands which render their own help, in place of
+// the help generated by cliche.
+type Helper interface {
+	Help(stdio IO)
+}
+
+// Usager is implemented by commands which provide their own usage string, in
+// place of the one generated by cliche.
+type Usager interface {
+	Usage() string
+}
+
+// HelpPage is the help of a generated command, both as shown by -help and
+// structured for display by other means, such as a TUI, a web frontend or a
+// docs subcommand. Generated code returns it from a function named after the
+// command's type, as HelloHelp for type Hello.
+type HelpPage struct {
+	// Text of the help, as shown by -help.
+	Text string
+	// Path of the command, and Synopsis of its flags, subcommand and
+	// positional arguments.
+	Path, Synopsis string
+	// Docs are the doc comments of the command, rendered as text.
+	Docs []string
+	// Commands are the verbs or subcommands of the command, and Arguments its
+	// positional arguments.
+	Commands, Arguments []HelpEntry
+	// Groups of flags, in the order they are listed.
+	Groups []HelpGroup
+}
+
+// HelpEntry is a verb or subcommand, positional argument or flag listed in a
+// HelpPage.
+type HelpEntry struct {
+	// Term is the name of the entry. Value names the type of the value of a
+	// flag which takes one.
+	Term, Value string
+	// Doc is the first line of the entry's doc comment, noting whether a flag
+	// is required or has a default.
+	Doc string
+}
+
+// HelpGroup lists the flags of a group of a HelpPage under its Heading.
+type HelpGroup struct {
+	Heading string
+	Flags   []HelpEntry
+}
+
+// PlainEnv is the environment variable with which users ask for plain help,
+// as PlainIO describes, when it is set to anything but the empty string.
+const PlainEnv = "CLICHE_PLAIN"
+
+// PlainIO returns stdio set to show help plainly, for screen readers, when
+// PlainEnv is set, or when any of args, up to a -- ending the flags, is
+// -help=plain or --help=plain, which asks for help too. Help is then shown as
+// PlainText renders it, and escape sequences are stripped from Out and Err,
+// so that no color is shown.
+func PlainIO(stdio IO, args []string) IO {
+	plain := os.Getenv(PlainEnv) != ""
+	for _, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if arg == "-help=plain" || arg == "--help=plain" {
+			plain = true
+		}
+	}
+	if plain && !stdio.Plain {
+		stdio.Plain = true
+		stdio.Out, stdio.Err = StripANSI(stdio.Out), StripANSI(stdio.Err)
+	}
+	return stdio
+}
+
+// PlainText renders generated help plainly, for screen readers: each verb or
+// subcommand, positional argument and flag listed under a heading is on a
+// line of its own, as "term: doc", rather than in indented columns.
+func PlainText(help string) string {
+	lines := strings.Split(help, "\n")
+	for i, line := range lines {
+		if !strings.HasPrefix(line, "  ") {
+			continue
+		}
+		line = strings.TrimLeft(line, " ")
+		if term, doc, ok := strings.Cut(line, "\t"); ok {
+			line = term + ": " + doc
+		}
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ShowHelp for cmd on the IO. If cmd implements Helper, it renders its own
+// help. Otherwise, the generated help is written to the IO's Out, rendered by
+// PlainText when the IO is Plain.
+func ShowHelp(stdio IO, cmd any, generated string) {
+	if h, ok := cmd.(Helper); ok {
+		h.Help(stdio)
+		return
+	}
+	if stdio.Plain {
+		generated = PlainText(generated)
+	}
+	io.WriteString(stdio.Out, generated)
+}
+
+// UsageOf cmd, which is its own usage if it implements Usager, and the
+// generated usage otherwise.
+func UsageOf(cmd any, generated string) string {
+	if u, ok := cmd.(Usager); ok {
+		return u.Usage()
+	}
+	return generated
+}
+
+// HelpRequested is true when any of args, up to a -- ending the flags, asks
+// for help with -h or -help, or -help=plain. Commands which take positional
+// arguments check them, so that help asked for after the first is still
+// shown.
+func HelpRequested(args []string) bool {
+	for _, arg := range args {
+		switch arg {
+		case "--":
+			return false
+		case "-h", "--h", "-help", "--help", "-help=plain", "--help=plain":
+			return true
+		}
+	}
+	return false
+}
+
+// ParseFlags parses args with fs, for cmd, whose generated help is help. Help
+// requested with -h or -help is shown on stdio, as ShowHelp does, even when
+// other flags are wrong. Otherwise, when the flags are wrong, the usage of
+// cmd, as given by UsageOf, is written to the IO's Err; the generated usage is
+// the first line of help. Errors are returned as NewUsageError returns them,
+// and not written, which is left to the caller, as generated main functions
+// do.
+func ParseFlags(stdio IO, fs *flag.FlagSet, cmd any, help string, args []string) error {
+	fs.Usage = func() {}
+	// The flag package's complaint is returned rather than written.
+	out := fs.Output()
+	fs.SetOutput(io.Discard)
+	err := fs.Parse(args)
+	fs.SetOutput(out)
+	if err != nil && HelpRequested(args) {
+		err = flag.ErrHelp
+	}
+	switch {
+	case errors.Is(err, flag.ErrHelp):
+		ShowHelp(stdio, cmd, help)
+	case err != nil:
+		usage, _, _ := strings.Cut(help, "\n")
+		fmt.Fprintln(stdio.Err, UsageOf(cmd, usage))
+	}
+	return NewUsageError(err)
+}
@@ -0,0 +1,157 @@
+package cliche
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type selfDocumenting struct{}
+
+func (selfDocumenting) Help(stdio IO) {
+	fmt.Fprint(stdio.Out, "custom help")
+}
+
+func (selfDocumenting) Usage() string {
+	return "custom usage"
+}
+
+func TestShowHelp(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		cmd  any
+		want string
+	}{
+		"generated": {struct{}{}, "generated help"},
+		"custom":    {selfDocumenting{}, "custom help"},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			cio, c := NewCaptureIO()
+			ShowHelp(cio, tc.cmd, "generated help")
+			if got := c.Out(); got != tc.want {
+				t.Errorf("ShowHelp(): got: %q want: %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPlainIO(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		env   string
+		args  []string
+		plain bool
+	}{
+		"default":      {"", []string{"-h"}, false},
+		"flag":         {"", []string{"serve", "--help=plain"}, true},
+		"short flag":   {"", []string{"-help=plain"}, true},
+		"after dashes": {"", []string{"--", "--help=plain"}, false},
+		"environment":  {"1", nil, true},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			t.Setenv(PlainEnv, tc.env)
+			var out bytes.Buffer
+			stdio := PlainIO(IO{Out: &out, Err: io.Discard}, tc.args)
+			if stdio.Plain != tc.plain {
+				t.Fatalf("PlainIO(): got Plain %v, want %v", stdio.Plain, tc.plain)
+			}
+			io.WriteString(stdio.Out, "\x1b[1mbold\x1b[0m")
+			want := "\x1b[1mbold\x1b[0m"
+			if tc.plain {
+				want = "bold"
+			}
+			if got := out.String(); got != want {
+				t.Errorf("PlainIO(): got output %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestPlainText(t *testing.T) {
+	help := "Usage: tool [flags] command [name]\n\nTool does things.\n\nCommands:\n  add\tAdd a thing.\n\nArguments:\n  [name]\tName of the thing.\n\nFlags:\n  -port, -p int\tPort to listen on. (default 80)\n  -quiet\n"
+	want := "Usage: tool [flags] command [name]\n\nTool does things.\n\nCommands:\nadd: Add a thing.\n\nArguments:\n[name]: Name of the thing.\n\nFlags:\n-port, -p int: Port to listen on. (default 80)\n-quiet\n"
+	if diff := cmp.Diff(PlainText(help), want); diff != "" {
+		t.Errorf("PlainText(): mismatch (-got,+want):\n%v", diff)
+	}
+
+	stdio, c := NewCaptureIO()
+	stdio.Plain = true
+	ShowHelp(stdio, struct{}{}, help)
+	if got := c.Out(); got != want {
+		t.Errorf("ShowHelp(): got: %q want: %q", got, want)
+	}
+}
+
+func TestUsageOf(t *testing.T) {
+	if got, want := UsageOf(struct{}{}, "generated usage"), "generated usage"; got != want {
+		t.Errorf("UsageOf(): got: %q want: %q", got, want)
+	}
+	if got, want := UsageOf(selfDocumenting{}, "generated usage"), "custom usage"; got != want {
+		t.Errorf("UsageOf(): got: %q want: %q", got, want)
+	}
+}
+
+func TestHelpRequested(t *testing.T) {
+	for _, tc := range []struct {
+		args []string
+		want bool
+	}{
+		{nil, false},
+		{[]string{"arg", "-h"}, true},
+		{[]string{"--help"}, true},
+		{[]string{"-hx", "arg"}, false},
+		{[]string{"arg", "--", "-help"}, false},
+		{[]string{"--help=plain"}, true},
+		{[]string{"-help=fancy"}, false},
+	} {
+		if got := HelpRequested(tc.args); got != tc.want {
+			t.Errorf("HelpRequested(%q): got %v, want %v", tc.args, got, tc.want)
+		}
+	}
+}
+
+func TestParseFlags(t *testing.T) {
+	const help = "Usage: tool [flags]\n\nFlags:\n  -v\tVerbose.\n"
+	for tn, tc := range map[string]struct {
+		cmd      any
+		args     []string
+		wantErr  error
+		out, err string
+	}{
+		"help":         {struct{}{}, []string{"-h"}, flag.ErrHelp, help, ""},
+		"custom help":  {selfDocumenting{}, []string{"-help"}, flag.ErrHelp, "custom help", ""},
+		"usage":        {struct{}{}, []string{"-x"}, nil, "", "Usage: tool [flags]\n"},
+		"custom usage": {selfDocumenting{}, []string{"-x"}, nil, "", "custom usage\n"},
+		"valid":        {struct{}{}, []string{"-v", "arg"}, nil, "", ""},
+		"help and bad": {struct{}{}, []string{"-x", "-help"}, flag.ErrHelp, help, ""},
+		"plain help":   {struct{}{}, []string{"--help=plain"}, flag.ErrHelp, help, ""},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			stdio, capture := NewCaptureIO()
+			fs := flag.NewFlagSet("tool", flag.ContinueOnError)
+			fs.SetOutput(stdio.Err)
+			fs.Bool("v", false, "Verbose.")
+			err := ParseFlags(stdio, fs, tc.cmd, help, tc.args)
+			var usage *UsageError
+			switch {
+			case tc.wantErr != nil && !errors.Is(err, tc.wantErr):
+				t.Errorf("ParseFlags(): got error %v, want %v", err, tc.wantErr)
+			case tc.wantErr == nil && tc.err != "" && !errors.As(err, &usage):
+				t.Errorf("ParseFlags(): got error %v, want a UsageError", err)
+			case usage != nil && usage.Error() != "flag provided but not defined: -x":
+				t.Errorf("ParseFlags(): got error %q, want the flag package's", usage)
+			case tc.wantErr == nil && tc.err == "" && err != nil:
+				t.Errorf("ParseFlags(): unexpected error: %v", err)
+			}
+			if got := capture.Out(); got != tc.out {
+				t.Errorf("ParseFlags(): got output %q, want %q", got, tc.out)
+			}
+			if got := capture.Err(); got != tc.err {
+				t.Errorf("ParseFlags(): got error output %q, want %q", got, tc.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package cliche
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// ErrNoProvider is returned when a value is to be injected, but no provider
+// has been registered for it.
+var ErrNoProvider = errors.New("no provider registered")
+
+// providerKey identifies a registered provider by the type it provides, and
+// the name under which it was registered.
+type providerKey struct {
+	typ  reflect.Type
+	name string
+}
+
+var (
+	providersMu sync.RWMutex
+	providers   = make(map[providerKey]func(context.Context) (any, error))
+)
+
+// typeOf T, which works even when T is an interface type.
+func typeOf[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+// Provide registers fn as the constructor for command inputs of type T which
+// are tagged with a bare inject component. Registering another provider for
+// the same type replaces the first.
+func Provide[T any](fn func(ctx context.Context) (T, error)) {
+	ProvideNamed("", fn)
+}
+
+// ProvideNamed registers fn as the constructor for command inputs of type T
+// which are tagged with inject:name. Registering another provider for the same
+// type and name replaces the first.
+func ProvideNamed[T any](name string, fn func(ctx context.Context) (T, error)) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	providers[providerKey{typeOf[T](), name}] = func(ctx context.Context) (any, error) {
+		return fn(ctx)
+	}
+}
+
+// Inject a value of type T, constructed by the provider registered under name.
+// An empty name selects the provider registered with Provide. If there is no
+// such provider, the returned error wraps ErrNoProvider.
+func Inject[T any](ctx context.Context, name string) (T, error) {
+	var zero T
+	v, err := injectValue(ctx, typeOf[T](), name)
+	if err != nil || v == nil {
+		return zero, err
+	}
+	return v.(T), nil
+}
+
+// injectValue constructs a value of typ, as Inject does.
+func injectValue(ctx context.Context, typ reflect.Type, name string) (any, error) {
+	providersMu.RLock()
+	fn, ok := providers[providerKey{typ, name}]
+	providersMu.RUnlock()
+	if !ok {
+		if name == "" {
+			return nil, fmt.Errorf("injecting %v: %w", typ, ErrNoProvider)
+		}
+		return nil, fmt.Errorf("injecting %v named %q: %w", typ, name, ErrNoProvider)
+	}
+
+	v, err := fn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("injecting %v: %w", typ, err)
+	}
+	return v, nil
+}
@@ -0,0 +1,60 @@
+package cliche
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+type injectable struct {
+	Name string
+}
+
+func TestInject(t *testing.T) {
+	errBroken := errors.New("broken")
+	Provide(func(ctx context.Context) (*injectable, error) {
+		return &injectable{"unnamed"}, nil
+	})
+	ProvideNamed("primary", func(ctx context.Context) (*injectable, error) {
+		return &injectable{"primary"}, nil
+	})
+	ProvideNamed("broken", func(ctx context.Context) (*injectable, error) {
+		return nil, errBroken
+	})
+	Provide(func(ctx context.Context) (io.Reader, error) {
+		return strings.NewReader("interfaces work too"), nil
+	})
+
+	for tn, tc := range map[string]struct {
+		name    string
+		want    string
+		wantErr error
+	}{
+		"unnamed":      {"", "unnamed", nil},
+		"named":        {"primary", "primary", nil},
+		"provider err": {"broken", "", errBroken},
+		"not provided": {"secondary", "", ErrNoProvider},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			got, err := Inject[*injectable](context.Background(), tc.name)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("Inject(): error mismatch: got: %v want: %v", err, tc.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got.Name != tc.want {
+				t.Errorf("Inject(): got: %q want: %q", got.Name, tc.want)
+			}
+		})
+	}
+
+	if _, err := Inject[io.Reader](context.Background(), ""); err != nil {
+		t.Errorf("Inject(): got error for interface type: %v", err)
+	}
+	if _, err := Inject[io.Writer](context.Background(), ""); !errors.Is(err, ErrNoProvider) {
+		t.Errorf("Inject(): got error %v for unprovided interface type, want %v", err, ErrNoProvider)
+	}
+}
@@ -0,0 +1,148 @@
+package cliche
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// FormField is an input which Form asks for: a flag of the command, which is
+// set from the answer.
+type FormField struct {
+	// Flag set from the answer, by its first name.
+	Flag string
+
+	// Prompt asking for the value, usually the usage of the flag.
+	Prompt string
+
+	// Choices, if any, among which the answer is selected, by number or by
+	// value.
+	Choices []string
+
+	// Bool fields are answered yes or no.
+	Bool bool
+
+	// Required fields are asked again until answered.
+	Required bool
+
+	// Secret fields are read without echoing the answer, when asked on a
+	// terminal, and their value is not shown.
+	Secret bool
+}
+
+// Form asks on stdio for the value of each of fields whose flag of fs was not
+// given, on the command line or by the environment, one at a time, and sets it
+// from the answer, as the --interactive flag of commands generated with
+// -interactive does. The current value of each flag is shown, and kept when
+// the answer is empty, but for required fields. Choices are listed and
+// selected by number or value, bools answered yes or no, and secrets read
+// without echo. Answers which the flag can't take, or which validate,
+// unless nil, rejects with the flag's name, are reported on the Err of stdio,
+// and asked for again. Input ending before every field is answered is a usage
+// error.
+func Form(stdio IO, fs *flag.FlagSet, fields []FormField, validate func(flag, value string) error) error {
+	given := GivenFlags(fs)
+	in := bufio.NewReader(stdio.In)
+	for _, field := range fields {
+		f := fs.Lookup(field.Flag)
+		if f == nil || given[f.Name] {
+			continue
+		}
+		for {
+			value, err := ask(stdio, in, field, f.Value.String())
+			if err != nil {
+				return err
+			}
+			if value == "" {
+				if field.Required {
+					fmt.Fprintf(stdio.Err, "-%v is required\n", f.Name)
+					continue
+				}
+				break
+			}
+			if validate != nil {
+				err = validate(f.Name, value)
+			}
+			if err == nil {
+				err = fs.Set(f.Name, value)
+			}
+			if err != nil {
+				fmt.Fprintf(stdio.Err, "invalid value for -%v: %v\n", f.Name, err)
+				continue
+			}
+			break
+		}
+	}
+	return nil
+}
+
+// ask writes the prompt of field to the Out of stdio and reads the answer from
+// in, resolving a choice by number, and yes or no for a bool. The empty answer
+// keeps current.
+func ask(stdio IO, in *bufio.Reader, field FormField, current string) (string, error) {
+	shown := current
+	if field.Secret && current != "" {
+		shown = "********"
+	}
+	prompt := fmt.Sprintf("%v (-%v)", strings.TrimSuffix(field.Prompt, "."), field.Flag)
+	if field.Prompt == "" {
+		prompt = "-" + field.Flag
+	}
+	switch {
+	case len(field.Choices) > 0:
+		fmt.Fprintln(stdio.Out, prompt)
+		for i, choice := range field.Choices {
+			fmt.Fprintf(stdio.Out, "  %d) %v\n", i+1, choice)
+		}
+		prompt = "Choose"
+	case field.Bool:
+		shown = "y/N"
+		if b, _ := strconv.ParseBool(current); b {
+			shown = "Y/n"
+		}
+	}
+	if shown != "" {
+		prompt += " [" + shown + "]"
+	}
+	fmt.Fprint(stdio.Out, prompt+": ")
+
+	var restore func()
+	if field.Secret {
+		restore = disableEcho(stdio.In)
+	}
+	line, err := in.ReadString('\n')
+	if restore != nil {
+		restore()
+		// The newline typed after the answer was not echoed.
+		fmt.Fprintln(stdio.Out)
+	}
+	if err != nil && (!errors.Is(err, io.EOF) || line == "") {
+		if errors.Is(err, io.EOF) {
+			return "", Usagef("no answer for -%v: input ended", field.Flag)
+		}
+		return "", err
+	}
+	answer := strings.TrimRight(line, "\r\n")
+	if !field.Secret {
+		answer = strings.TrimSpace(answer)
+	}
+	switch {
+	case answer == "":
+	case len(field.Choices) > 0:
+		if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(field.Choices) {
+			answer = field.Choices[n-1]
+		}
+	case field.Bool:
+		switch strings.ToLower(answer) {
+		case "y", "yes":
+			answer = "true"
+		case "n", "no":
+			answer = "false"
+		}
+	}
+	return answer, nil
+}
@@ -0,0 +1,104 @@
+package cliche
+
+import (
+	"errors"
+	"flag"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestForm(t *testing.T) {
+	fields := []FormField{
+		{Flag: "name", Prompt: "Name to greet.", Required: true},
+		{Flag: "color", Prompt: "Color of the greeting.", Choices: []string{"red", "green", "blue"}},
+		{Flag: "loud", Prompt: "Shout the greeting.", Bool: true},
+		{Flag: "token", Prompt: "API token.", Secret: true},
+		{Flag: "port", Prompt: "Port to listen on."},
+	}
+	validate := func(flag, value string) error {
+		if flag == "port" && value == "0" {
+			return errors.New("must not be 0")
+		}
+		return nil
+	}
+	for tn, tc := range map[string]struct {
+		args    []string
+		input   string
+		want    map[string]string
+		out     string
+		errOut  string
+		wantErr string
+	}{
+		"answered": {
+			input: "world\n2\ny\n s3cret \n8080\n",
+			want:  map[string]string{"name": "world", "color": "green", "loud": "true", "token": " s3cret ", "port": "8080"},
+			out: "Name to greet (-name): " +
+				"Color of the greeting (-color)\n  1) red\n  2) green\n  3) blue\nChoose [red]: " +
+				"Shout the greeting (-loud) [y/N]: " +
+				"API token (-token): " +
+				"Port to listen on (-port) [80]: ",
+		},
+		"defaults": {
+			input: "world\n\n\n\n\n",
+			want:  map[string]string{"name": "world", "color": "red", "loud": "false", "token": "", "port": "80"},
+		},
+		"given": {
+			args: []string{"-name=you", "-color=blue", "-loud", "-token=x", "-port=1"},
+			want: map[string]string{"name": "you", "color": "blue", "loud": "true", "token": "x", "port": "1"},
+			out:  "",
+		},
+		"asked again": {
+			input:  "\nworld\nred\nmaybe\nno\n\n0\nhttp\n81\n",
+			want:   map[string]string{"name": "world", "color": "red", "loud": "false", "token": "", "port": "81"},
+			errOut: "-name is required\ninvalid value for -loud: parse error\ninvalid value for -port: must not be 0\ninvalid value for -port: parse error\n",
+		},
+		"ended": {
+			input:   "world\n",
+			want:    map[string]string{"name": "world", "color": "red", "loud": "false", "token": "", "port": "80"},
+			wantErr: "no answer for -color: input ended",
+		},
+		"last line": {
+			input: "world\nblue\nyes\n\n8000",
+			want:  map[string]string{"name": "world", "color": "blue", "loud": "true", "token": "", "port": "8000"},
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			fs := flag.NewFlagSet("app", flag.ContinueOnError)
+			fs.String("name", "", "")
+			fs.String("color", "red", "")
+			fs.Bool("loud", false, "")
+			fs.String("token", "", "")
+			fs.Int("port", 80, "")
+			if err := fs.Parse(tc.args); err != nil {
+				t.Fatal(err)
+			}
+			stdio, capture := NewCaptureIO()
+			stdio.In = strings.NewReader(tc.input)
+			err := Form(stdio, fs, fields, validate)
+			var gotErr string
+			if err != nil {
+				gotErr = err.Error()
+			}
+			if gotErr != tc.wantErr {
+				t.Errorf("Form(): error mismatch: got: %q want: %q", gotErr, tc.wantErr)
+			}
+			got := make(map[string]string)
+			fs.VisitAll(func(f *flag.Flag) {
+				got[f.Name] = f.Value.String()
+			})
+			if diff := cmp.Diff(got, tc.want); diff != "" {
+				t.Errorf("Form(): values mismatch (-got,+want):\n%v", diff)
+			}
+			if tc.out != "" || tc.args != nil {
+				if diff := cmp.Diff(capture.Out(), tc.out); diff != "" {
+					t.Errorf("Form(): output mismatch (-got,+want):\n%v", diff)
+				}
+			}
+			if diff := cmp.Diff(capture.Err(), tc.errOut); diff != "" {
+				t.Errorf("Form(): errors mismatch (-got,+want):\n%v", diff)
+			}
+		})
+	}
+}
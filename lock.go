@@ -0,0 +1,101 @@
+package cliche
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// LockDir returns the directory in which single-instance locks are kept: the
+// XDG runtime directory when XDG_RUNTIME_DIR is set, and the temporary
+// directory otherwise.
+func LockDir() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return dir
+	}
+	return os.TempDir()
+}
+
+// LockedError is returned by AcquireLock when another process holds the lock.
+type LockedError struct {
+	Name string
+	// Pid of the process holding the lock, or zero when it is unknown.
+	Pid int
+}
+
+func (err *LockedError) Error() string {
+	if err.Pid == 0 {
+		// The holder has yet to record its pid.
+		return fmt.Sprintf("%v: already running", err.Name)
+	}
+	return fmt.Sprintf("%v: already running (pid %d)", err.Name, err.Pid)
+}
+
+// errLocked is returned by lockFile when another open file holds the lock.
+var errLocked = errors.New("file is locked")
+
+// Lock is a single-instance lock held by a running command, so that commands
+// which must not run concurrently, such as destructive ones, don't.
+type Lock struct {
+	f *os.File
+}
+
+// AcquireLock takes the lock with the given name, which is held until it is
+// Released. The lock is an advisory lock, as taken by flock on Unix and
+// LockFileEx on Windows, on a file in LockDir which records the pid of its
+// holder. When another process holds the lock, a *LockedError is returned.
+// The operating system releases the lock when its holder exits, so that locks
+// are never left behind. On Solaris and AIX, which lack flock, the lock is
+// held by the process, which may take it more than once.
+func AcquireLock(name string) (*Lock, error) {
+	path := filepath.Join(LockDir(), name+".lock")
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		if errors.Is(err, errLocked) {
+			pid, _ := lockHolder(path)
+			return nil, &LockedError{Name: name, Pid: pid}
+		}
+		return nil, err
+	}
+	// The pid of the last holder is replaced by ours.
+	if err := f.Truncate(0); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if _, err := fmt.Fprintln(f, os.Getpid()); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &Lock{f: f}, nil
+}
+
+// lockHolder returns the pid recorded in the lock file at path, or zero when
+// it records none, as when the lock has just been taken.
+func lockHolder(path string) (int, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	pid, _ := strconv.Atoi(strings.TrimSpace(string(b)))
+	return pid, nil
+}
+
+// Release the lock, so that other processes may take it. The lock file is
+// left in place, since removing it would race with processes about to lock
+// it. Releasing a nil Lock does nothing.
+func (l *Lock) Release() error {
+	if l == nil {
+		return nil
+	}
+	return errors.Join(unlockFile(l.f), l.f.Close())
+}
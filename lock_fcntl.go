@@ -0,0 +1,33 @@
+//go:build solaris || aix
+
+package cliche
+
+import (
+	"errors"
+	"io"
+	"os"
+	"syscall"
+)
+
+// lockFile takes an exclusive fcntl lock on f, without waiting for another
+// holder to release it. Unlike flock, fcntl locks are held by the process, so
+// a process never finds that it holds the lock itself.
+func lockFile(f *os.File) error {
+	lk := syscall.Flock_t{Type: syscall.F_WRLCK, Whence: io.SeekStart}
+	for {
+		err := syscall.FcntlFlock(f.Fd(), syscall.F_SETLK, &lk)
+		switch {
+		case errors.Is(err, syscall.EINTR):
+			continue
+		case errors.Is(err, syscall.EAGAIN), errors.Is(err, syscall.EACCES):
+			return errLocked
+		}
+		return err
+	}
+}
+
+// unlockFile releases the fcntl lock on f.
+func unlockFile(f *os.File) error {
+	lk := syscall.Flock_t{Type: syscall.F_UNLCK, Whence: io.SeekStart}
+	return syscall.FcntlFlock(f.Fd(), syscall.F_SETLK, &lk)
+}
@@ -0,0 +1,19 @@
+//go:build !unix && !windows
+
+package cliche
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+)
+
+// lockFile fails, since there are no file locks on this platform.
+func lockFile(*os.File) error {
+	return fmt.Errorf("single-instance locks are not supported on %v", runtime.GOOS)
+}
+
+// unlockFile does nothing, since no lock is ever taken.
+func unlockFile(*os.File) error {
+	return nil
+}
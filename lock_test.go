@@ -0,0 +1,98 @@
+package cliche
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAcquireLock(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+
+	lock, err := AcquireLock("deploy")
+	if err != nil {
+		t.Fatalf("AcquireLock(): unexpected error: %v", err)
+	}
+	_, err = AcquireLock("deploy")
+	var locked *LockedError
+	if !errors.As(err, &locked) || locked.Pid != os.Getpid() {
+		t.Fatalf("AcquireLock(): got error %v while held, want LockedError with pid %v", err, os.Getpid())
+	}
+	if want := fmt.Sprintf("deploy: already running (pid %d)", os.Getpid()); err.Error() != want {
+		t.Errorf("AcquireLock(): got error %q, want %q", err, want)
+	}
+
+	other, err := AcquireLock("other")
+	if err != nil {
+		t.Errorf("AcquireLock(): unexpected error taking another lock: %v", err)
+	}
+	other.Release()
+
+	if err := lock.Release(); err != nil {
+		t.Fatalf("Release(): unexpected error: %v", err)
+	}
+	lock, err = AcquireLock("deploy")
+	if err != nil {
+		t.Fatalf("AcquireLock(): unexpected error once released: %v", err)
+	}
+	lock.Release()
+
+	if err := (*Lock)(nil).Release(); err != nil {
+		t.Errorf("Release(): unexpected error from nil Lock: %v", err)
+	}
+}
+
+func TestAcquireLockStale(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_RUNTIME_DIR", dir)
+
+	// Lock files left by processes which have exited are no longer locked,
+	// whatever they record.
+	for tn, content := range map[string]string{
+		"exited":  "2147483647\n",
+		"garbage": "not a pid",
+		"longer":  "2147483647\n2147483647\n",
+	} {
+		t.Run(tn, func(t *testing.T) {
+			path := filepath.Join(dir, "stale.lock")
+			if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			lock, err := AcquireLock("stale")
+			if err != nil {
+				t.Fatalf("AcquireLock(): unexpected error taking over stale lock: %v", err)
+			}
+			defer lock.Release()
+			if got, err := lockHolder(path); err != nil || got != os.Getpid() {
+				t.Errorf("AcquireLock(): lock held by pid %v (%v), want %v", got, err, os.Getpid())
+			}
+		})
+	}
+}
+
+func TestLockedError(t *testing.T) {
+	for _, tc := range []struct {
+		err  *LockedError
+		want string
+	}{
+		{&LockedError{Name: "deploy", Pid: 42}, "deploy: already running (pid 42)"},
+		{&LockedError{Name: "deploy"}, "deploy: already running"},
+	} {
+		if got := tc.err.Error(); got != tc.want {
+			t.Errorf("Error(): got: %q want: %q", got, tc.want)
+		}
+	}
+}
+
+func TestLockDir(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", "/run/user/1000")
+	if got := LockDir(); got != "/run/user/1000" {
+		t.Errorf("LockDir(): got: %q want: %q", got, "/run/user/1000")
+	}
+	t.Setenv("XDG_RUNTIME_DIR", "")
+	if got := LockDir(); got != os.TempDir() {
+		t.Errorf("LockDir(): got: %q want: %q", got, os.TempDir())
+	}
+}
@@ -0,0 +1,51 @@
+//go:build windows
+
+package cliche
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// Flags of LockFileEx, and the error with which it fails when another handle
+// holds the lock.
+const (
+	lockfileFailImmediately               = 0x1
+	lockfileExclusiveLock                 = 0x2
+	errorLockViolation      syscall.Errno = 33
+)
+
+var (
+	procLockFileEx   = syscall.NewLazyDLL("kernel32.dll").NewProc("LockFileEx")
+	procUnlockFileEx = syscall.NewLazyDLL("kernel32.dll").NewProc("UnlockFileEx")
+)
+
+// lockRegion returns the region of a lock file which is locked: a byte well
+// beyond the pid it records, which would otherwise be unreadable by those
+// finding the lock held.
+func lockRegion() *syscall.Overlapped {
+	return &syscall.Overlapped{OffsetHigh: 1}
+}
+
+// lockFile takes an exclusive lock on f with LockFileEx, without waiting for
+// another holder to release it.
+func lockFile(f *os.File) error {
+	r, _, err := procLockFileEx.Call(f.Fd(), lockfileExclusiveLock|lockfileFailImmediately, 0, 1, 0, uintptr(unsafe.Pointer(lockRegion())))
+	switch {
+	case r != 0:
+		return nil
+	case err == errorLockViolation:
+		return errLocked
+	}
+	return err
+}
+
+// unlockFile releases the lock on f.
+func unlockFile(f *os.File) error {
+	r, _, err := procUnlockFileEx.Call(f.Fd(), 0, 1, 0, uintptr(unsafe.Pointer(lockRegion())))
+	if r == 0 {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package cliche
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// ManifestFlag is the flag naming the manifest read by DecodeManifest.
+const ManifestFlag = "from-file"
+
+// DecodeManifest decodes the JSON manifest named by the --from-file flag in
+// args, when it is given, into cmd, a pointer to a command struct, as
+// commands generated with -manifest do before parsing their flags, so that
+// flags given on the command line apply on top of it. Its keys are the names
+// of the struct's fields, or of their json tags, as encoding/json matches
+// them; unknown keys are errors, since they are usually typos. Flags maps the
+// selector of each field bound to a flag to the names by which that flag is
+// given, and the first names of those whose fields the manifest holds are
+// returned, for MarkGiven. Errors reading or decoding the manifest are usage
+// errors.
+func DecodeManifest(stdio IO, fs *flag.FlagSet, args []string, cmd any, flags map[string][]string) ([]string, error) {
+	name, ok := flagArg(fs, args, ManifestFlag)
+	if !ok {
+		return nil, nil
+	}
+	data, err := os.ReadFile(stdio.Path(name))
+	if err != nil {
+		return nil, Usagef("flag -%v: %w", ManifestFlag, err)
+	}
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, Usagef("flag -%v: decoding %v: %w", ManifestFlag, name, err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(cmd); err != nil {
+		return nil, Usagef("flag -%v: decoding %v: %w", ManifestFlag, name, err)
+	}
+	fields := manifestFields(reflect.TypeOf(cmd).Elem(), "", make(map[string]string))
+	var given []string
+	for key := range keys {
+		field, ok := fields[key]
+		if !ok {
+			for k, f := range fields {
+				if strings.EqualFold(k, key) {
+					field = f
+					break
+				}
+			}
+		}
+		if names := flags[field]; len(names) > 0 {
+			given = append(given, names[0])
+		}
+	}
+	return given, nil
+}
+
+// manifestFields adds the fields of struct type t, whose selectors start with
+// prefix, to fields, by the keys under which encoding/json decodes them, and
+// returns it. The fields of embedded structs are promoted, as encoding/json
+// promotes them.
+func manifestFields(t reflect.Type, prefix string, fields map[string]string) map[string]string {
+	for i := range t.NumField() {
+		f := t.Field(i)
+		key, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if key == "-" || !f.IsExported() && !f.Anonymous {
+			continue
+		}
+		ft := f.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && key == "" && ft.Kind() == reflect.Struct {
+			manifestFields(ft, prefix+f.Name+".", fields)
+			continue
+		}
+		if key == "" {
+			key = f.Name
+		}
+		if _, ok := fields[key]; !ok {
+			fields[key] = prefix + f.Name
+		}
+	}
+	return fields
+}
+
+// flagArg returns the value of the flag of fs with name in args, as the flag
+// package would parse them, and whether it was given. The last value given is
+// returned.
+func flagArg(fs *flag.FlagSet, args []string, name string) (value string, ok bool) {
+	for i := 0; i < len(args); i++ {
+		if !isFlag(args[i]) {
+			break
+		}
+		arg, v, hasValue := strings.Cut(strings.TrimLeft(args[i], "-"), "=")
+		switch {
+		case arg == name && hasValue:
+			value, ok = v, true
+		case arg == name && i+1 < len(args):
+			value, ok = args[i+1], true
+			i++
+		case !hasValue && takesValue(fs, arg):
+			i++
+		}
+	}
+	return value, ok
+}
+
+// MarkGiven marks each of the named flags of fs which was not given, on the
+// command line or otherwise, as given, without changing its value, so that it
+// is validated and satisfies requirements as if it were. Commands generated
+// with -manifest mark those set by DecodeManifest, once they have bound the
+// environment, whose variables apply on top of the manifest.
+func MarkGiven(fs *flag.FlagSet, names []string) {
+	given := GivenFlags(fs)
+	for _, name := range names {
+		f := fs.Lookup(name)
+		if f == nil || given[name] {
+			continue
+		}
+		// The flag package only marks flags as given when setting them, so
+		// the value is swapped for one which ignores it meanwhile.
+		value := f.Value
+		f.Value = givenValue{value}
+		fs.Set(name, "")
+		f.Value = value
+	}
+}
+
+// givenValue is a flag.Value which ignores the values it is set to.
+type givenValue struct {
+	flag.Value
+}
+
+func (givenValue) Set(string) error {
+	return nil
+}
@@ -0,0 +1,129 @@
+package cliche
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// Endpoint is embedded by manifested, whose fields are promoted.
+type Endpoint struct {
+	Host string
+	Port int `json:"port"`
+}
+
+// manifested is a command read from manifests.
+type manifested struct {
+	Endpoint
+	Name   string
+	Labels []string `json:"labels"`
+	Debug  bool
+}
+
+func TestDecodeManifest(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"app.json":     `{"host": "example.com", "port": 8080, "name": "app", "labels": ["a", "b"]}`,
+		"typo.json":    `{"nmae": "app", "hots": "example.com"}`,
+		"invalid.json": `{"port": "http"}`,
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	flags := map[string][]string{
+		"Endpoint.Host": {"host"},
+		"Endpoint.Port": {"port", "p"},
+		"Name":          {"name"},
+		"Labels":        {"label"},
+		"Debug":         {"debug"},
+	}
+	for tn, tc := range map[string]struct {
+		args    []string
+		want    manifested
+		given   []string
+		wantErr string
+	}{
+		"none": {
+			args:  []string{"-name=x"},
+			want:  manifested{Name: "x", Endpoint: Endpoint{Port: 80}},
+			given: []string{"name"},
+		},
+		"manifest": {
+			args:  []string{"--from-file", "app.json"},
+			want:  manifested{Endpoint: Endpoint{Host: "example.com", Port: 8080}, Name: "app", Labels: []string{"a", "b"}},
+			given: []string{"host", "label", "name", "port"},
+		},
+		"flags on top": {
+			args:  []string{"-p", "9090", "--from-file=app.json", "-label=c", "--debug"},
+			want:  manifested{Endpoint: Endpoint{Host: "example.com", Port: 9090}, Name: "app", Labels: []string{"c"}, Debug: true},
+			given: []string{"debug", "host", "label", "name", "p", "port"},
+		},
+		"after arguments": {
+			args: []string{"arg", "--from-file=app.json"},
+			want: manifested{Endpoint: Endpoint{Port: 80}},
+		},
+		"missing": {
+			args:    []string{"--from-file=missing.json"},
+			want:    manifested{Endpoint: Endpoint{Port: 80}},
+			wantErr: "flag -from-file: open " + filepath.Join(dir, "missing.json") + ": no such file or directory",
+		},
+		"unknown field": {
+			args:    []string{"--from-file=typo.json"},
+			want:    manifested{Endpoint: Endpoint{Port: 80}},
+			wantErr: `flag -from-file: decoding typo.json: json: unknown field "nmae"`,
+		},
+		"invalid": {
+			args:    []string{"--from-file=invalid.json"},
+			want:    manifested{Endpoint: Endpoint{Port: 80}},
+			wantErr: "flag -from-file: decoding invalid.json: json: cannot unmarshal string into Go struct field manifested.port of type int",
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			var cmd manifested
+			fs := flag.NewFlagSet("app", flag.ContinueOnError)
+			BindFlag(fs, &cmd.Host, "", "host")
+			cmd.Port = 80
+			BindFlag(fs, &cmd.Port, "", "port", "p")
+			BindFlag(fs, &cmd.Name, "", "name")
+			BindSliceFlag(fs, &cmd.Labels, "", "label")
+			BindFlag(fs, &cmd.Debug, "", "debug")
+			fs.String(ManifestFlag, "", "")
+			stdio, _ := NewCaptureIO()
+			stdio.Dir = dir
+
+			names, err := DecodeManifest(stdio, fs, tc.args, &cmd, flags)
+			var gotErr string
+			if err != nil {
+				gotErr = err.Error()
+				if !isUsage(err) {
+					t.Errorf("DecodeManifest(): got error %v, want a usage error", err)
+				}
+			} else if err := fs.Parse(tc.args); err != nil {
+				t.Fatal(err)
+			}
+			if gotErr != tc.wantErr {
+				t.Errorf("DecodeManifest(): error mismatch: got: %q want: %q", gotErr, tc.wantErr)
+			}
+			if diff := cmp.Diff(cmd, tc.want); diff != "" {
+				t.Errorf("DecodeManifest(): mismatch (-got,+want):\n%v", diff)
+			}
+
+			MarkGiven(fs, names)
+			var given []string
+			fs.Visit(func(f *flag.Flag) {
+				if f.Name != ManifestFlag {
+					given = append(given, f.Name)
+				}
+			})
+			sort.Strings(given)
+			if diff := cmp.Diff(given, tc.given); diff != "" {
+				t.Errorf("MarkGiven(): given mismatch (-got,+want):\n%v", diff)
+			}
+		})
+	}
+}
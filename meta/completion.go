@@ -0,0 +1,502 @@
+package meta
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// compgen returns the compgen invocation which completes values according to
+// a complete tag hint. Values without a hint are completed as files, which is
+// what bash would do anyway.
+func compgen(hint string) string {
+	switch hint {
+	case CompleteNone:
+		return ""
+	case CompleteDirs:
+		return `compgen -d -- "$cur"`
+	case CompleteHosts:
+		return `compgen -A hostname -- "$cur"`
+	case CompleteUsers:
+		return `compgen -u -- "$cur"`
+	case CompleteGroups:
+		return `compgen -g -- "$cur"`
+	case CompleteCommands:
+		return `compgen -c -- "$cur"`
+	}
+	return `compgen -f -- "$cur"`
+}
+
+// compgenWords returns the compgen invocation which completes the given
+// words.
+func compgenWords(words []string) string {
+	return `compgen -W "` + strings.Join(words, " ") + `" -- "$cur"`
+}
+
+// reply formats an assignment of the output of a compgen invocation to
+// COMPREPLY.
+func reply(gen string) string {
+	if gen == "" {
+		return "COMPREPLY=()"
+	}
+	return "COMPREPLY=($(" + gen + "))"
+}
+
+// shellIdent replaces the characters of s which may not appear in a shell
+// function name.
+func shellIdent(s string) string {
+	return strings.Map(func(r rune) rune {
+		if isASCIILetter(r) || (r >= '0' && r <= '9') || r == '_' {
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+// completionFlag is a flag of a command, as completed by shell scripts.
+type completionFlag struct {
+	long, short string
+	// desc is the first line of the flag's doc comment, shown beside it by
+	// shells which describe completions.
+	desc string
+	// value is whether the flag takes a value, and hint how it is completed,
+	// unless limited to choices.
+	value   bool
+	hint    string
+	choices []string
+}
+
+// spellings returns the flag as typed on the command line: --long, then -short.
+func (flag completionFlag) spellings() []string {
+	var ret []string
+	if flag.long != "" {
+		ret = append(ret, "--"+flag.long)
+	}
+	if flag.short != "" {
+		ret = append(ret, "-"+flag.short)
+	}
+	return ret
+}
+
+// completionWord is a subcommand or verb name, and the first line of its
+// description.
+type completionWord struct {
+	name, desc string
+}
+
+// completionCommand is a command, as completed by shell scripts.
+type completionCommand struct {
+	name  string
+	desc  string
+	flags []completionFlag
+	// words are the verbs and subcommands of the command, which are completed
+	// in place of its positional arguments when there are any.
+	words []completionWord
+	// hint is how positional arguments are completed, unless the first hinted
+	// one is limited to choices.
+	hint    string
+	choices []string
+}
+
+// names of words.
+func names(words []completionWord) []string {
+	var ret []string
+	for _, word := range words {
+		ret = append(ret, word.name)
+	}
+	return ret
+}
+
+// completionCommands gathers what the scripts complete of cmds, skipping nil
+// ones.
+func completionCommands(cmds []*Command) []completionCommand {
+	var ret []completionCommand
+	for _, cmd := range cmds {
+		if cmd == nil {
+			continue
+		}
+		cc := completionCommand{name: cmd.Name, desc: firstLine(cmd.Description), hint: CompleteFiles}
+		hinted := false
+		for _, input := range cmd.Inputs {
+			// Inputs are completed as the generated code binds them, so
+			// untagged fields and -no-lock are flags too.
+			tag, _ := ParseTag(string(input.Tag))
+			switch {
+			case tag.Inject, tag.Stdin:
+			case tag.Arg != nil:
+				if !hinted && (tag.Complete != "" || tag.Choices != nil) {
+					cc.hint, cc.choices, hinted = tag.Complete, tag.Choices, true
+				}
+			case !tag.Hidden:
+				flag := completionFlag{
+					desc:    firstLine(input.Doc),
+					value:   input.Type != "bool" && !tag.Count,
+					hint:    tag.Complete,
+					choices: tag.Choices,
+				}
+				for _, name := range FlagNames(input, tag) {
+					if len(name) == 1 {
+						flag.short = name
+					} else {
+						flag.long = name
+					}
+				}
+				cc.flags = append(cc.flags, flag)
+				if negated := NegatedName(input, tag); negated != "" {
+					cc.flags = append(cc.flags, completionFlag{long: negated, desc: flag.desc})
+				}
+			}
+		}
+		for _, verb := range cmd.Verbs {
+			cc.words = append(cc.words, completionWord{verb.Name, firstLine(verb.Description)})
+		}
+		for _, child := range cmd.Children {
+			cc.words = append(cc.words, completionWord{child.Name, firstLine(child.Description)})
+		}
+		ret = append(ret, cc)
+	}
+	return ret
+}
+
+// program reports whether cmds are the program prog itself, rather than its
+// subcommands: that is, when there's only one, and it is named prog.
+func program(prog string, cmds []completionCommand) bool {
+	return len(cmds) == 1 && cmds[0].name == prog
+}
+
+// WriteBashCompletion writes to w a bash completion script for the program
+// prog, whose subcommands are cmds. The script completes subcommand and verb
+// names, flag names, and the values of flags and positional arguments as
+// hinted by their complete tag components. When the only command is named
+// prog, it is completed as the program itself, rather than as a subcommand.
+//
+// The script works with bash 3.2, as shipped with macOS: it uses case
+// statements rather than associative arrays, and avoids compopt, mapfile and
+// other bash 4 features.
+func WriteBashCompletion(w io.Writer, prog string, cmds ...*Command) error {
+	ccs := completionCommands(cmds)
+	single := program(prog, ccs)
+	fn := "_" + shellIdent(prog) + "_complete"
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "# bash completion for %v, generated by cliche. Compatible with bash 3.2.\n", prog)
+	fmt.Fprintf(bw, "%v() {\n", fn)
+	if single {
+		fmt.Fprintf(bw, `	local cur prev cmd
+	cur="${COMP_WORDS[COMP_CWORD]}"
+	prev="${COMP_WORDS[COMP_CWORD-1]}"
+	cmd=%q
+`, prog)
+	} else {
+		fmt.Fprint(bw, `	local cur prev cmd i
+	cur="${COMP_WORDS[COMP_CWORD]}"
+	prev="${COMP_WORDS[COMP_CWORD-1]}"
+	cmd=""
+	for ((i = 1; i < COMP_CWORD; i++)); do
+		case "${COMP_WORDS[i]}" in
+		-*) ;;
+		*)
+			cmd="${COMP_WORDS[i]}"
+			break
+			;;
+		esac
+	done
+`)
+	}
+
+	// Values of flags, found by the flag preceding the word being completed.
+	fmt.Fprint(bw, "\tcase \"$cmd:$prev\" in\n")
+	for _, cc := range ccs {
+		for _, flag := range cc.flags {
+			if !flag.value {
+				continue
+			}
+			var patterns []string
+			for _, spelling := range flag.spellings() {
+				patterns = append(patterns, cc.name+":"+spelling)
+			}
+			gen := compgen(flag.hint)
+			if flag.choices != nil {
+				gen = compgenWords(flag.choices)
+			}
+			fmt.Fprintf(bw, "\t%v)\n\t\t%v\n\t\treturn\n\t\t;;\n", strings.Join(patterns, " | "), reply(gen))
+		}
+	}
+	fmt.Fprint(bw, "\tesac\n")
+
+	// Subcommands, then flag names, verbs and positional arguments of each.
+	fmt.Fprint(bw, "\tcase \"$cmd\" in\n")
+	if !single {
+		var names []string
+		for _, cc := range ccs {
+			names = append(names, cc.name)
+		}
+		fmt.Fprintf(bw, "\t\"\")\n\t\t%v\n\t\t;;\n", reply(compgenWords(names)))
+	}
+	for _, cc := range ccs {
+		var flags []string
+		for _, flag := range cc.flags {
+			flags = append(flags, flag.spellings()...)
+		}
+		positional := compgen(cc.hint)
+		switch {
+		case len(cc.words) > 0:
+			positional = compgenWords(names(cc.words))
+		case cc.choices != nil:
+			positional = compgenWords(cc.choices)
+		}
+		fmt.Fprintf(bw, "\t%v)\n\t\tcase \"$cur\" in\n", cc.name)
+		fmt.Fprintf(bw, "\t\t-*) %v ;;\n", reply(compgenWords(flags)))
+		fmt.Fprintf(bw, "\t\t*) %v ;;\n", reply(positional))
+		fmt.Fprint(bw, "\t\tesac\n\t\t;;\n")
+	}
+	fmt.Fprint(bw, "\tesac\n}\n")
+	fmt.Fprintf(bw, "complete -F %v %v\n", fn, prog)
+	return bw.Flush()
+}
+
+// zshAction returns the zsh completion function which completes values
+// according to a complete tag hint.
+func zshAction(hint string) string {
+	switch hint {
+	case CompleteNone:
+		return ""
+	case CompleteDirs:
+		return "_directories"
+	case CompleteHosts:
+		return "_hosts"
+	case CompleteUsers:
+		return "_users"
+	case CompleteGroups:
+		return "_groups"
+	case CompleteCommands:
+		return "_command_names -e"
+	}
+	return "_files"
+}
+
+// zshQuote single-quotes s for zsh.
+func zshQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// zshDescribe returns the zsh commands which complete words, each shown with
+// its description, as tag. Names are escaped as _describe requires, since
+// their first unescaped colon separates them from their description.
+func zshDescribe(indent, tag string, words []completionWord) string {
+	var entries []string
+	for _, word := range words {
+		entry := strings.ReplaceAll(word.name, ":", `\:`)
+		if word.desc != "" {
+			entry += ":" + word.desc
+		}
+		entries = append(entries, zshQuote(entry))
+	}
+	return fmt.Sprintf("%vdescribed=(%v)\n%v_describe -t %vs %v described\n", indent, strings.Join(entries, " "), indent, tag, tag)
+}
+
+// zshCompadd returns the zsh command which completes words, undescribed.
+func zshCompadd(words []string) string {
+	var quoted []string
+	for _, word := range words {
+		quoted = append(quoted, zshQuote(word))
+	}
+	return "compadd -- " + strings.Join(quoted, " ")
+}
+
+// WriteZshCompletion writes to w a zsh completion script for the program
+// prog, completing the same as the script written by WriteBashCompletion.
+// Subcommands, verbs and flags are described by the first lines of their doc
+// comments. The script may be sourced, or installed as _prog in a directory
+// of fpath.
+func WriteZshCompletion(w io.Writer, prog string, cmds ...*Command) error {
+	ccs := completionCommands(cmds)
+	single := program(prog, ccs)
+	fn := "_" + shellIdent(prog)
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "#compdef %v\n# zsh completion for %v, generated by cliche.\n", prog, prog)
+	fmt.Fprintf(bw, "%v() {\n", fn)
+	if single {
+		fmt.Fprintf(bw, `	local cur prev cmd
+	local -a described
+	cur="${words[CURRENT]}"
+	prev="${words[CURRENT-1]}"
+	cmd=%q
+`, prog)
+	} else {
+		fmt.Fprint(bw, `	local cur prev cmd i
+	local -a described
+	cur="${words[CURRENT]}"
+	prev="${words[CURRENT-1]}"
+	cmd=""
+	for ((i = 2; i < CURRENT; i++)); do
+		case "${words[i]}" in
+		-*) ;;
+		*)
+			cmd="${words[i]}"
+			break
+			;;
+		esac
+	done
+`)
+	}
+
+	// Values of flags, found by the flag preceding the word being completed.
+	fmt.Fprint(bw, "\tcase \"$cmd:$prev\" in\n")
+	for _, cc := range ccs {
+		for _, flag := range cc.flags {
+			if !flag.value {
+				continue
+			}
+			var patterns []string
+			for _, spelling := range flag.spellings() {
+				patterns = append(patterns, cc.name+":"+spelling)
+			}
+			fmt.Fprintf(bw, "\t%v)\n", strings.Join(patterns, " | "))
+			action := zshAction(flag.hint)
+			if flag.choices != nil {
+				action = zshCompadd(flag.choices)
+			}
+			if action != "" {
+				fmt.Fprintf(bw, "\t\t%v\n", action)
+			}
+			fmt.Fprint(bw, "\t\treturn\n\t\t;;\n")
+		}
+	}
+	fmt.Fprint(bw, "\tesac\n")
+
+	// Subcommands, then flag names, verbs and positional arguments of each.
+	fmt.Fprint(bw, "\tcase \"$cmd\" in\n")
+	if !single {
+		var cmds []completionWord
+		for _, cc := range ccs {
+			cmds = append(cmds, completionWord{cc.name, cc.desc})
+		}
+		fmt.Fprintf(bw, "\t\"\")\n%v\t\t;;\n", zshDescribe("\t\t", "command", cmds))
+	}
+	for _, cc := range ccs {
+		var flags []completionWord
+		for _, flag := range cc.flags {
+			for _, spelling := range flag.spellings() {
+				flags = append(flags, completionWord{spelling, flag.desc})
+			}
+		}
+		fmt.Fprintf(bw, "\t%v)\n\t\tcase \"$cur\" in\n", cc.name)
+		fmt.Fprintf(bw, "\t\t-*)\n%v\t\t\t;;\n", zshDescribe("\t\t\t", "option", flags))
+		switch positional := zshAction(cc.hint); {
+		case len(cc.words) > 0:
+			fmt.Fprintf(bw, "\t\t*)\n%v\t\t\t;;\n", zshDescribe("\t\t\t", "command", cc.words))
+		case cc.choices != nil:
+			fmt.Fprintf(bw, "\t\t*) %v ;;\n", zshCompadd(cc.choices))
+		case positional == "":
+			fmt.Fprint(bw, "\t\t*) : ;;\n")
+		default:
+			fmt.Fprintf(bw, "\t\t*) %v ;;\n", positional)
+		}
+		fmt.Fprint(bw, "\t\tesac\n\t\t;;\n")
+	}
+	fmt.Fprint(bw, "\tesac\n}\n")
+	// Autoloaded from fpath, the file is the body of the completion function;
+	// sourced, it registers the function.
+	fmt.Fprintf(bw, "if [ \"$funcstack[1]\" = %q ]; then\n\t%v \"$@\"\nelse\n\tcompdef %v %v\nfi\n", fn, fn, fn, prog)
+	return bw.Flush()
+}
+
+// fishArgs returns the arguments to fish's complete builtin which complete
+// values according to a complete tag hint. Files are only completed when
+// asked for, since the script disables them by default.
+func fishArgs(hint string) string {
+	switch hint {
+	case CompleteNone:
+		return ""
+	case CompleteDirs:
+		return "-a '(__fish_complete_directories)'"
+	case CompleteHosts:
+		return "-a '(__fish_print_hostnames)'"
+	case CompleteUsers:
+		return "-a '(__fish_complete_users)'"
+	case CompleteGroups:
+		return "-a '(__fish_complete_groups)'"
+	case CompleteCommands:
+		return "-a '(__fish_complete_command)'"
+	}
+	return "-F"
+}
+
+// fishQuote single-quotes s for fish, in which a backslash escapes a quote or
+// another backslash.
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(s) + "'"
+}
+
+// fishDesc returns the argument to fish's complete builtin which describes a
+// completion, if there's a description.
+func fishDesc(desc string) string {
+	if desc == "" {
+		return ""
+	}
+	return "-d " + fishQuote(desc)
+}
+
+// WriteFishCompletion writes to w a fish completion script for the program
+// prog, completing the same as the script written by WriteBashCompletion.
+// Subcommands, verbs and flags are described by the first lines of their doc
+// comments.
+func WriteFishCompletion(w io.Writer, prog string, cmds ...*Command) error {
+	ccs := completionCommands(cmds)
+	single := program(prog, ccs)
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "# fish completion for %v, generated by cliche.\n", prog)
+	fmt.Fprintf(bw, "complete -c %v -f\n", prog)
+	complete := func(cond string, args ...string) {
+		var parts []string
+		for _, arg := range append([]string{"complete -c " + prog, cond}, args...) {
+			if arg != "" {
+				parts = append(parts, arg)
+			}
+		}
+		fmt.Fprintln(bw, strings.Join(parts, " "))
+	}
+	if !single {
+		for _, cc := range ccs {
+			complete("-n __fish_use_subcommand", "-a "+fishQuote(cc.name), fishDesc(cc.desc))
+		}
+	}
+	for _, cc := range ccs {
+		cond := ""
+		if !single {
+			cond = "-n '__fish_seen_subcommand_from " + cc.name + "'"
+		}
+		for _, flag := range cc.flags {
+			var names []string
+			if flag.long != "" {
+				names = append(names, "-l "+flag.long)
+			}
+			switch {
+			case len(flag.short) == 1:
+				names = append(names, "-s "+flag.short)
+			case flag.short != "":
+				names = append(names, "-o "+flag.short)
+			}
+			switch {
+			case flag.choices != nil:
+				complete(cond, strings.Join(names, " "), "-r", "-a "+fishQuote(strings.Join(flag.choices, " ")), fishDesc(flag.desc))
+			case flag.value:
+				complete(cond, strings.Join(names, " "), "-r", fishArgs(flag.hint), fishDesc(flag.desc))
+			default:
+				complete(cond, strings.Join(names, " "), fishDesc(flag.desc))
+			}
+		}
+		switch {
+		case len(cc.words) > 0:
+			for _, word := range cc.words {
+				complete(cond, "-a "+fishQuote(word.name), fishDesc(word.desc))
+			}
+		case cc.choices != nil:
+			complete(cond, "-a "+fishQuote(strings.Join(cc.choices, " ")))
+		case fishArgs(cc.hint) != "":
+			complete(cond, fishArgs(cc.hint))
+		}
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,267 @@
+package meta
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// completionTestCommands are a described command with verbs and a hidden
+// flag, which is never completed, and one with a completed positional
+// argument, an untagged flag, a flag limited to choices and a lock.
+func completionTestCommands() (*Command, *Command) {
+	remote := &Command{
+		Name:        "remote",
+		Description: "Manage the remotes of the repository.\nSee the manual.",
+		Inputs: []CommandInput{
+			{FieldName: "Host", Tag: "flag:host,H;complete:hosts", Type: "string", Doc: "Host of the remote's server."},
+			{FieldName: "Verbose", Tag: "flag:verbose,v", Type: "bool", Doc: "Verbose output.\nRepeat for more."},
+			{FieldName: "Trace", Tag: "flag:trace;hidden", Type: "bool", Doc: "Trace requests."},
+		},
+		Verbs: []Verb{{Name: "add", Description: "Add a remote."}, {Name: "remove"}},
+	}
+	cp := &Command{
+		Name: "copy",
+		Inputs: []CommandInput{
+			{FieldName: "Owner", Tag: "flag:owner;complete:users", Type: "string"},
+			{FieldName: "Note", Tag: "flag:note;complete:none", Type: "string"},
+			{FieldName: "Mode", Tag: "flag:mode;choices:fast|safe", Type: "string", Doc: "Mode of copying: fast or safe."},
+			{FieldName: "Dest", Tag: "arg:0;complete:dirs", Type: "string"},
+			{FieldName: "DryRun", Type: "bool"},
+			{FieldName: "Unlocked", Tag: "lock", Type: "bool"},
+		},
+	}
+	return remote, cp
+}
+
+func TestWriteBashCompletion(t *testing.T) {
+	remote, cp := completionTestCommands()
+	var b strings.Builder
+	if err := WriteBashCompletion(&b, "my-app", remote, nil, cp); err != nil {
+		t.Fatalf("WriteBashCompletion(): unexpected error: %v", err)
+	}
+	want := `# bash completion for my-app, generated by cliche. Compatible with bash 3.2.
+_my_app_complete() {
+	local cur prev cmd i
+	cur="${COMP_WORDS[COMP_CWORD]}"
+	prev="${COMP_WORDS[COMP_CWORD-1]}"
+	cmd=""
+	for ((i = 1; i < COMP_CWORD; i++)); do
+		case "${COMP_WORDS[i]}" in
+		-*) ;;
+		*)
+			cmd="${COMP_WORDS[i]}"
+			break
+			;;
+		esac
+	done
+	case "$cmd:$prev" in
+	remote:--host | remote:-H)
+		COMPREPLY=($(compgen -A hostname -- "$cur"))
+		return
+		;;
+	copy:--owner)
+		COMPREPLY=($(compgen -u -- "$cur"))
+		return
+		;;
+	copy:--note)
+		COMPREPLY=()
+		return
+		;;
+	copy:--mode)
+		COMPREPLY=($(compgen -W "fast safe" -- "$cur"))
+		return
+		;;
+	esac
+	case "$cmd" in
+	"")
+		COMPREPLY=($(compgen -W "remote copy" -- "$cur"))
+		;;
+	remote)
+		case "$cur" in
+		-*) COMPREPLY=($(compgen -W "--host -H --verbose -v" -- "$cur")) ;;
+		*) COMPREPLY=($(compgen -W "add remove" -- "$cur")) ;;
+		esac
+		;;
+	copy)
+		case "$cur" in
+		-*) COMPREPLY=($(compgen -W "--owner --note --mode --dry-run --no-lock" -- "$cur")) ;;
+		*) COMPREPLY=($(compgen -d -- "$cur")) ;;
+		esac
+		;;
+	esac
+}
+complete -F _my_app_complete my-app
+`
+	if diff := cmp.Diff(b.String(), want); diff != "" {
+		t.Errorf("WriteBashCompletion(): mismatch (-got,+want):\n%v", diff)
+	}
+}
+
+func TestWriteBashCompletionProgram(t *testing.T) {
+	_, cp := completionTestCommands()
+	var b strings.Builder
+	if err := WriteBashCompletion(&b, "copy", cp); err != nil {
+		t.Fatalf("WriteBashCompletion(): unexpected error: %v", err)
+	}
+	// The command is the program itself, so there's no subcommand to find.
+	want := `# bash completion for copy, generated by cliche. Compatible with bash 3.2.
+_copy_complete() {
+	local cur prev cmd
+	cur="${COMP_WORDS[COMP_CWORD]}"
+	prev="${COMP_WORDS[COMP_CWORD-1]}"
+	cmd="copy"
+	case "$cmd:$prev" in
+	copy:--owner)
+		COMPREPLY=($(compgen -u -- "$cur"))
+		return
+		;;
+	copy:--note)
+		COMPREPLY=()
+		return
+		;;
+	copy:--mode)
+		COMPREPLY=($(compgen -W "fast safe" -- "$cur"))
+		return
+		;;
+	esac
+	case "$cmd" in
+	copy)
+		case "$cur" in
+		-*) COMPREPLY=($(compgen -W "--owner --note --mode --dry-run --no-lock" -- "$cur")) ;;
+		*) COMPREPLY=($(compgen -d -- "$cur")) ;;
+		esac
+		;;
+	esac
+}
+complete -F _copy_complete copy
+`
+	if diff := cmp.Diff(b.String(), want); diff != "" {
+		t.Errorf("WriteBashCompletion(): mismatch (-got,+want):\n%v", diff)
+	}
+}
+
+func TestWriteZshCompletion(t *testing.T) {
+	remote, cp := completionTestCommands()
+	var b strings.Builder
+	if err := WriteZshCompletion(&b, "my-app", remote, cp); err != nil {
+		t.Fatalf("WriteZshCompletion(): unexpected error: %v", err)
+	}
+	want := `#compdef my-app
+# zsh completion for my-app, generated by cliche.
+_my_app() {
+	local cur prev cmd i
+	local -a described
+	cur="${words[CURRENT]}"
+	prev="${words[CURRENT-1]}"
+	cmd=""
+	for ((i = 2; i < CURRENT; i++)); do
+		case "${words[i]}" in
+		-*) ;;
+		*)
+			cmd="${words[i]}"
+			break
+			;;
+		esac
+	done
+	case "$cmd:$prev" in
+	remote:--host | remote:-H)
+		_hosts
+		return
+		;;
+	copy:--owner)
+		_users
+		return
+		;;
+	copy:--note)
+		return
+		;;
+	copy:--mode)
+		compadd -- 'fast' 'safe'
+		return
+		;;
+	esac
+	case "$cmd" in
+	"")
+		described=('remote:Manage the remotes of the repository.' 'copy')
+		_describe -t commands command described
+		;;
+	remote)
+		case "$cur" in
+		-*)
+			described=('--host:Host of the remote'\''s server.' '-H:Host of the remote'\''s server.' '--verbose:Verbose output.' '-v:Verbose output.')
+			_describe -t options option described
+			;;
+		*)
+			described=('add:Add a remote.' 'remove')
+			_describe -t commands command described
+			;;
+		esac
+		;;
+	copy)
+		case "$cur" in
+		-*)
+			described=('--owner' '--note' '--mode:Mode of copying: fast or safe.' '--dry-run' '--no-lock')
+			_describe -t options option described
+			;;
+		*) _directories ;;
+		esac
+		;;
+	esac
+}
+if [ "$funcstack[1]" = "_my_app" ]; then
+	_my_app "$@"
+else
+	compdef _my_app my-app
+fi
+`
+	if diff := cmp.Diff(b.String(), want); diff != "" {
+		t.Errorf("WriteZshCompletion(): mismatch (-got,+want):\n%v", diff)
+	}
+}
+
+func TestWriteFishCompletion(t *testing.T) {
+	remote, cp := completionTestCommands()
+	type test struct {
+		prog string
+		cmds []*Command
+		want string
+	}
+	for tn, tc := range map[string]test{
+		"subcommands": {"my-app", []*Command{remote, cp}, `# fish completion for my-app, generated by cliche.
+complete -c my-app -f
+complete -c my-app -n __fish_use_subcommand -a 'remote' -d 'Manage the remotes of the repository.'
+complete -c my-app -n __fish_use_subcommand -a 'copy'
+complete -c my-app -n '__fish_seen_subcommand_from remote' -l host -s H -r -a '(__fish_print_hostnames)' -d 'Host of the remote\'s server.'
+complete -c my-app -n '__fish_seen_subcommand_from remote' -l verbose -s v -d 'Verbose output.'
+complete -c my-app -n '__fish_seen_subcommand_from remote' -a 'add' -d 'Add a remote.'
+complete -c my-app -n '__fish_seen_subcommand_from remote' -a 'remove'
+complete -c my-app -n '__fish_seen_subcommand_from copy' -l owner -r -a '(__fish_complete_users)'
+complete -c my-app -n '__fish_seen_subcommand_from copy' -l note -r
+complete -c my-app -n '__fish_seen_subcommand_from copy' -l mode -r -a 'fast safe' -d 'Mode of copying: fast or safe.'
+complete -c my-app -n '__fish_seen_subcommand_from copy' -l dry-run
+complete -c my-app -n '__fish_seen_subcommand_from copy' -l no-lock
+complete -c my-app -n '__fish_seen_subcommand_from copy' -a '(__fish_complete_directories)'
+`},
+		"program": {"copy", []*Command{cp}, `# fish completion for copy, generated by cliche.
+complete -c copy -f
+complete -c copy -l owner -r -a '(__fish_complete_users)'
+complete -c copy -l note -r
+complete -c copy -l mode -r -a 'fast safe' -d 'Mode of copying: fast or safe.'
+complete -c copy -l dry-run
+complete -c copy -l no-lock
+complete -c copy -a '(__fish_complete_directories)'
+`},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			var b strings.Builder
+			if err := WriteFishCompletion(&b, tc.prog, tc.cmds...); err != nil {
+				t.Fatalf("WriteFishCompletion(): unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(b.String(), tc.want); diff != "" {
+				t.Errorf("WriteFishCompletion(): mismatch (-got,+want):\n%v", diff)
+			}
+		})
+	}
+}
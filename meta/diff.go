@@ -0,0 +1,135 @@
+package meta
+
+import "fmt"
+
+// ChangeKind classifies a difference between two versions of the command line
+// interface described by a Command.
+type ChangeKind string
+
+// Kinds of changes reported by Diff.
+const (
+	// NameChanged indicates that the command itself was renamed.
+	NameChanged ChangeKind = "name"
+	// InputAdded indicates a new input. This is the only kind of change to
+	// inputs which is not breaking.
+	InputAdded ChangeKind = "input-added"
+	// InputRemoved indicates that an input no longer exists.
+	InputRemoved ChangeKind = "input-removed"
+	// TypeChanged indicates that the Go type of an input changed.
+	TypeChanged ChangeKind = "type"
+	// DefaultChanged indicates that the default value of an input changed.
+	DefaultChanged ChangeKind = "default"
+	// FlagChanged indicates that the flag names of an input changed.
+	FlagChanged ChangeKind = "flag"
+	// ArityChanged indicates that the positional arguments consumed by an
+	// input changed.
+	ArityChanged ChangeKind = "arity"
+	// VerbAdded indicates a new verb subcommand, which is not breaking.
+	VerbAdded ChangeKind = "verb-added"
+	// VerbRemoved indicates that a verb subcommand no longer exists.
+	VerbRemoved ChangeKind = "verb-removed"
+)
+
+// Change describes a single difference between two versions of a command.
+type Change struct {
+	Kind ChangeKind
+
+	// Subject of the change, which is the field name for inputs, the verb name
+	// for verbs, and empty for the command itself.
+	Subject string
+
+	// Old and New values of whatever changed, if applicable.
+	Old, New string
+}
+
+// Breaking is true for changes which may break existing invocations of the
+// command.
+func (c Change) Breaking() bool {
+	return c.Kind != InputAdded && c.Kind != VerbAdded
+}
+
+func (c Change) String() string {
+	switch c.Kind {
+	case InputAdded, VerbAdded, InputRemoved, VerbRemoved:
+		return fmt.Sprintf("%v: %v", c.Kind, c.Subject)
+	case NameChanged:
+		return fmt.Sprintf("%v: %q -> %q", c.Kind, c.Old, c.New)
+	}
+	return fmt.Sprintf("%v: %v: %q -> %q", c.Kind, c.Subject, c.Old, c.New)
+}
+
+// Diff reports how the command line interface described by new differs from
+// that described by old, such that maintainers can detect breaking changes
+// between releases. Inputs are matched by field name, and verbs by name.
+// Changes are reported in the order of old's inputs and verbs, followed by
+// additions in the order of new's.
+func Diff(old, new *Command) (changes []Change) {
+	if old == nil || new == nil {
+		return nil
+	}
+	if old.Name != new.Name {
+		changes = append(changes, Change{Kind: NameChanged, Old: old.Name, New: new.Name})
+	}
+
+	newInputs := make(map[string]CommandInput, len(new.Inputs))
+	for _, input := range new.Inputs {
+		newInputs[input.FieldName] = input
+	}
+	oldInputs := make(map[string]bool, len(old.Inputs))
+	for _, o := range old.Inputs {
+		oldInputs[o.FieldName] = true
+		n, ok := newInputs[o.FieldName]
+		if !ok {
+			changes = append(changes, Change{Kind: InputRemoved, Subject: o.FieldName})
+			continue
+		}
+		changes = append(changes, diffInput(o, n)...)
+	}
+	for _, n := range new.Inputs {
+		if !oldInputs[n.FieldName] {
+			changes = append(changes, Change{Kind: InputAdded, Subject: n.FieldName})
+		}
+	}
+
+	newVerbs := make(map[string]bool, len(new.Verbs))
+	for _, verb := range new.Verbs {
+		newVerbs[verb.Name] = true
+	}
+	oldVerbs := make(map[string]bool, len(old.Verbs))
+	for _, verb := range old.Verbs {
+		oldVerbs[verb.Name] = true
+		if !newVerbs[verb.Name] {
+			changes = append(changes, Change{Kind: VerbRemoved, Subject: verb.Name})
+		}
+	}
+	for _, verb := range new.Verbs {
+		if !oldVerbs[verb.Name] {
+			changes = append(changes, Change{Kind: VerbAdded, Subject: verb.Name})
+		}
+	}
+	return
+}
+
+// diffInput reports the differences between two versions of the same input.
+func diffInput(o, n CommandInput) (changes []Change) {
+	field := o.FieldName
+	if o.Type != n.Type {
+		changes = append(changes, Change{TypeChanged, field, o.Type, n.Type})
+	}
+	od, _ := o.Tag.Default()
+	nd, _ := n.Tag.Default()
+	if od != nd {
+		changes = append(changes, Change{DefaultChanged, field, od, nd})
+	}
+	of, _ := o.Tag.Flag()
+	nf, _ := n.Tag.Flag()
+	if ofs, nfs := of.String(), nf.String(); ofs != nfs {
+		changes = append(changes, Change{FlagChanged, field, ofs, nfs})
+	}
+	oa, _ := o.Tag.Arg()
+	na, _ := n.Tag.Arg()
+	if oas, nas := oa.String(), na.String(); oas != nas {
+		changes = append(changes, Change{ArityChanged, field, oas, nas})
+	}
+	return
+}
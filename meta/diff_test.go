@@ -0,0 +1,96 @@
+package meta
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestDiff(t *testing.T) {
+	old := &Command{
+		Name: "tool",
+		Inputs: []CommandInput{
+			{FieldName: "Host", Tag: "flag:host;default:localhost", Type: "string"},
+			{FieldName: "Port", Tag: "flag:port,p;default:80", Type: "int"},
+			{FieldName: "Files", Tag: "arg:[0:]", Type: "[]string"},
+			{FieldName: "Legacy", Tag: "flag:legacy", Type: "bool"},
+		},
+		Verbs: []Verb{{Name: "push"}, {Name: "pull"}},
+	}
+	new := &Command{
+		Name: "tool",
+		Inputs: []CommandInput{
+			{FieldName: "Host", Tag: "flag:host;default:localhost", Type: "string"},
+			{FieldName: "Port", Tag: "flag:port;default:8080", Type: "uint16"},
+			{FieldName: "Files", Tag: "arg:[0:2]", Type: "[]string"},
+			{FieldName: "Verbose", Tag: "flag:verbose", Type: "bool"},
+		},
+		Verbs: []Verb{{Name: "push"}, {Name: "fetch"}},
+	}
+
+	want := []Change{
+		{Kind: TypeChanged, Subject: "Port", Old: "int", New: "uint16"},
+		{Kind: DefaultChanged, Subject: "Port", Old: "80", New: "8080"},
+		{Kind: FlagChanged, Subject: "Port", Old: "flag:port,p", New: "flag:port"},
+		{Kind: ArityChanged, Subject: "Files", Old: "arg:[:]", New: "arg:[:2]"},
+		{Kind: InputRemoved, Subject: "Legacy"},
+		{Kind: InputAdded, Subject: "Verbose"},
+		{Kind: VerbRemoved, Subject: "pull"},
+		{Kind: VerbAdded, Subject: "fetch"},
+	}
+	got := Diff(old, new)
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("Diff(): mismatch(-got,+want):\n%v", diff)
+	}
+
+	var breaking int
+	for _, c := range got {
+		if c.Breaking() {
+			breaking++
+		}
+	}
+	if breaking != 6 {
+		t.Errorf("Breaking(): got %d breaking changes, want 6", breaking)
+	}
+
+	if got := Diff(old, old); len(got) != 0 {
+		t.Errorf("Diff(): got changes between identical commands: %v", got)
+	}
+}
+
+func TestDiffSnapshot(t *testing.T) {
+	// Snapshots of the metadata are serialized as JSON, such that versions can
+	// be compared without access to the source which produced them.
+	cmd := FromFile(file(t, "testdata/simple/simple.go"), "Tester")
+	b, err := json.Marshal(cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var snapshot Command
+	if err := json.Unmarshal(b, &snapshot); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(&snapshot, cmd, cmpopts.IgnoreUnexported(Command{})); diff != "" {
+		t.Errorf("snapshot mismatch(-got,+want):\n%v", diff)
+	}
+	if got := Diff(&snapshot, cmd); len(got) != 0 {
+		t.Errorf("Diff(): got changes against own snapshot: %v", got)
+	}
+}
+
+func TestChangeString(t *testing.T) {
+	for _, tc := range []struct {
+		change Change
+		want   string
+	}{
+		{Change{Kind: NameChanged, Old: "a", New: "b"}, `name: "a" -> "b"`},
+		{Change{Kind: InputRemoved, Subject: "Port"}, "input-removed: Port"},
+		{Change{Kind: DefaultChanged, Subject: "Port", Old: "80", New: "8080"}, `default: Port: "80" -> "8080"`},
+	} {
+		if got := tc.change.String(); got != tc.want {
+			t.Errorf("String(): got: %q want: %q", got, tc.want)
+		}
+	}
+}
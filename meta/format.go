@@ -0,0 +1,176 @@
+package meta
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+)
+
+// tagPair is one key:"value" pair of a struct tag.
+type tagPair struct {
+	key, value string
+}
+
+// splitStructTag splits a struct tag into its key:"value" pairs, following
+// the conventional format read by reflect.StructTag. Not ok when the tag does
+// not follow it.
+func splitStructTag(tag string) ([]tagPair, bool) {
+	var pairs []tagPair
+	for {
+		tag = strings.TrimLeft(tag, " ")
+		if tag == "" {
+			return pairs, true
+		}
+		i := 0
+		for i < len(tag) && tag[i] > ' ' && tag[i] != ':' && tag[i] != '"' && tag[i] != 0x7f {
+			i++
+		}
+		if i == 0 || i+1 >= len(tag) || tag[i] != ':' || tag[i+1] != '"' {
+			return nil, false
+		}
+		key := tag[:i]
+		tag = tag[i+1:]
+
+		// The value runs to the first unescaped quote.
+		i = 1
+		for i < len(tag) && tag[i] != '"' {
+			if tag[i] == '\\' {
+				i++
+			}
+			i++
+		}
+		if i >= len(tag) {
+			return nil, false
+		}
+		value, err := strconv.Unquote(tag[:i+1])
+		if err != nil {
+			return nil, false
+		}
+		pairs = append(pairs, tagPair{key, value})
+		tag = tag[i+1:]
+	}
+}
+
+// joinStructTag formats pairs as a struct tag.
+func joinStructTag(pairs []tagPair) string {
+	var parts []string
+	for _, p := range pairs {
+		parts = append(parts, p.key+":"+strconv.Quote(p.value))
+	}
+	return strings.Join(parts, " ")
+}
+
+// canonicalStructTag returns tag with its cliche tag in canonical form. A
+// legacy tag, which is a bare cliche tag without a key, is given the cliche
+// key. Not ok when there is no cliche tag to rewrite, or it is malformed, since
+// rewriting it would lose the malformed components.
+func canonicalStructTag(tag string) (string, bool) {
+	pairs, ok := splitStructTag(tag)
+	if !ok {
+		// Legacy tags are not in the conventional format, but must parse
+		// cleanly as cliche tags to be taken for one.
+		pt, err := ParseTagStrict(tag)
+		if err != nil || strings.TrimSpace(tag) == "" {
+			return "", false
+		}
+		return joinStructTag([]tagPair{{TagKey, pt.Canonical()}}), true
+	}
+	found := false
+	for i, p := range pairs {
+		if p.key != TagKey {
+			continue
+		}
+		pt, err := ParseTagStrict(p.value)
+		if err != nil {
+			return "", false
+		}
+		pairs[i].value, found = pt.Canonical(), true
+	}
+	if !found {
+		return "", false
+	}
+	return joinStructTag(pairs), true
+}
+
+// tagEdit replaces the struct tag literal between two offsets of a source file.
+type tagEdit struct {
+	start, end int
+	literal    string
+}
+
+// FormatTags rewrites the cliche struct tags in the Go source src into
+// canonical form: components in a fixed order, without extraneous whitespace,
+// and legacy tags without a key given the cliche key. Only the tags which
+// change are rewritten, leaving the rest of the source as it is, so that src
+// is returned unchanged when its tags are already canonical. When src was
+// formatted as by gofmt, so is the result, since rewritten tags may change
+// the alignment of those around them. Malformed tags are left as they are,
+// and reported in the returned error along with the formatted source.
+func FormatTags(filename string, src []byte) ([]byte, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	var edits []tagEdit
+	var problems []string
+	ast.Inspect(f, func(n ast.Node) bool {
+		field, ok := n.(*ast.Field)
+		if !ok || field.Tag == nil {
+			return true
+		}
+		tag, err := strconv.Unquote(field.Tag.Value)
+		if err != nil {
+			return true
+		}
+		canonical, ok := canonicalStructTag(tag)
+		if !ok {
+			if pairs, ok := splitStructTag(tag); ok {
+				for _, p := range pairs {
+					if _, err := ParseTagStrict(p.value); p.key == TagKey && err != nil {
+						problems = append(problems, fmt.Sprintf("%v: %v", fset.Position(field.Tag.Pos()), err))
+					}
+				}
+			}
+			return true
+		}
+		if canonical == tag {
+			return true
+		}
+		edit := tagEdit{fset.Position(field.Tag.Pos()).Offset, fset.Position(field.Tag.End()).Offset, "`" + canonical + "`"}
+		if strings.Contains(canonical, "`") {
+			edit.literal = strconv.Quote(canonical)
+		}
+		edits = append(edits, edit)
+		return true
+	})
+
+	// Fields are visited in the order they appear in the source, so the
+	// edits are in order too.
+	out := src
+	if len(edits) > 0 {
+		var b bytes.Buffer
+		last := 0
+		for _, edit := range edits {
+			b.Write(src[last:edit.start])
+			b.WriteString(edit.literal)
+			last = edit.end
+		}
+		b.Write(src[last:])
+		out = b.Bytes()
+		if formatted, err := format.Source(src); err == nil && bytes.Equal(formatted, src) {
+			if out, err = format.Source(out); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return out, fmt.Errorf("malformed cliche tags:\n\t%v", strings.Join(problems, "\n\t"))
+	}
+	return out, nil
+}
@@ -0,0 +1,100 @@
+package meta
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCanonicalStructTag(t *testing.T) {
+	type test struct {
+		tag    string
+		want   string
+		wantOK bool
+	}
+
+	for tn, tc := range map[string]test{
+		"empty":     {},
+		"no cliche": {tag: `json:"name"`},
+		"canonical": {`cliche:"flag:port,p;default:80"`, `cliche:"flag:port,p;default:80"`, true},
+		"reordered": {`cliche:" default: 80 ;flag: port , p"`, `cliche:"flag:port,p;default:80"`, true},
+		"others kept": {
+			`json:"port,omitempty"   cliche:"default:80;arg:0" yaml:"port"`,
+			`json:"port,omitempty" cliche:"arg:0;default:80" yaml:"port"`,
+			true,
+		},
+		"legacy":       {`default:World;arg:0`, `cliche:"arg:0;default:World"`, true},
+		"malformed":    {tag: `cliche:"arg:[a];flag:x"`},
+		"unknown":      {tag: `cliche:"falg:x"`},
+		"not a tag":    {tag: `nonsense here`},
+		"unterminated": {tag: `cliche:"flag:x`},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			got, ok := canonicalStructTag(tc.tag)
+			if ok != tc.wantOK {
+				t.Errorf("canonicalStructTag(%q): ok mismatch: got: %v want: %v", tc.tag, ok, tc.wantOK)
+			}
+			if got != tc.want {
+				t.Errorf("canonicalStructTag(%q): got: %q want: %q", tc.tag, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFormatTags(t *testing.T) {
+	src := `package tags
+
+type Tags struct {
+	// Port to listen on.
+	Port int ` + "`json:\"port\" cliche:\" default:80 ; flag:port\"`" + `
+	Name string ` + "`arg:0`" + `
+	Bad  string ` + "`cliche:\"arg:[x]\"`" + `
+	Done bool
+}
+`
+	want := `package tags
+
+type Tags struct {
+	// Port to listen on.
+	Port int ` + "`json:\"port\" cliche:\"flag:port;default:80\"`" + `
+	Name string ` + "`cliche:\"arg:0\"`" + `
+	Bad  string ` + "`cliche:\"arg:[x]\"`" + `
+	Done bool
+}
+`
+	got, err := FormatTags("tags.go", []byte(src))
+	if err == nil || !strings.Contains(err.Error(), "tags.go:7:14") {
+		t.Errorf("FormatTags(): got error %v, want one reporting the malformed tag", err)
+	}
+	if diff := cmp.Diff(string(got), want); diff != "" {
+		t.Errorf("FormatTags(): mismatch (-got,+want):\n%v", diff)
+	}
+
+	// Source which is not formatted as by gofmt is left as it is, and is
+	// only formatted when it was before.
+	for tn, tc := range map[string]struct {
+		src, want string
+	}{
+		"no tags":   {"package tags\nvar  x = 1\n", "package tags\nvar  x = 1\n"},
+		"canonical": {"package tags\ntype T struct{ X int `cliche:\"arg:0\"` }\nvar  x = 1\n", "package tags\ntype T struct{ X int `cliche:\"arg:0\"` }\nvar  x = 1\n"},
+		"gofmt": {
+			"package tags\n\ntype T struct {\n\tX int `cliche:\" arg:0\"` // x\n\tY int `cliche:\"arg:1\"`  // y\n}\n",
+			"package tags\n\ntype T struct {\n\tX int `cliche:\"arg:0\"` // x\n\tY int `cliche:\"arg:1\"` // y\n}\n",
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			got, err := FormatTags("tags.go", []byte(tc.src))
+			if err != nil {
+				t.Fatalf("FormatTags(): unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(string(got), tc.want); diff != "" {
+				t.Errorf("FormatTags(): mismatch (-got,+want):\n%v", diff)
+			}
+		})
+	}
+
+	if _, err := FormatTags("broken.go", []byte("package")); err == nil {
+		t.Error("FormatTags(): wanted error for source which does not parse, got nil")
+	}
+}
@@ -0,0 +1,1282 @@
+package meta
+
+import (
+	"bytes"
+	_ "embed"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"log/slog"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/iancoleman/strcase"
+)
+
+// RuntimeImportPath is the import path of the runtime package used by
+// generated code.
+const RuntimeImportPath = "idontfixcomputers.com/cliche"
+
+// PFlagImportPath is the import path of the flag package with which generated
+// FlagSet functions build their flag sets.
+const PFlagImportPath = "github.com/spf13/pflag"
+
+//go:embed command.go.tmpl
+var commandTemplate string
+
+var generated = template.Must(template.New("file").Funcs(template.FuncMap{
+	"quote": strconv.Quote,
+	"last": func(selector string) string {
+		return selector[strings.LastIndex(selector, ".")+1:]
+	},
+	"method": func(name string, pointer bool) Verb {
+		return Verb{Method: name, PointerReceiver: pointer}
+	},
+	"add": func(a, b int) int {
+		return a + b
+	},
+	"sub": func(a, b int) int {
+		return a - b
+	},
+}).Parse(commandTemplate))
+
+// genFlag is an input bound to a flag in generated code.
+type genFlag struct {
+	// Field is the selector of the input on the command.
+	Field string
+	// Type of the input, and Elem the type of its elements when it is a
+	// slice, which makes the flag repeatable.
+	Type, Elem string
+	// Key is the type of the keys of a map, which also makes the flag
+	// repeatable, with each entry given as a key and an Elem separated by Sep.
+	// For slices, Sep separates several values given at once, and Policy is
+	// the expression of the cliche.SlicePolicy by which they are taken, when
+	// not the default.
+	Key, Sep, Policy string
+	// Names of the flag, long first.
+	Names []string
+	// Former names of the flag, from which it was migrated.
+	Former []string
+	// Negated is the name of the negated form of a negatable bool flag.
+	Negated string
+	// Count is true when the flag counts the times it is given.
+	Count bool
+	// Hidden is true when the flag is left out of help, and Deprecated when
+	// its use is warned about, with DeprecatedNote.
+	Hidden, Deprecated bool
+	DeprecatedNote     string
+	Usage              string
+	// Default, when HasDefault.
+	Default    string
+	HasDefault bool
+	Validator  string
+	Required   bool
+	// Parser is the expression of the function which parses values, when
+	// cliche.Parse does not.
+	Parser string
+	// Env names the environment variable from which the flag takes its
+	// value when it is not given.
+	Env string
+	// Prompt asks for the flag in an interactive form, among Choices, if
+	// any, without echoing the answer when Secret.
+	Prompt  string
+	Choices []string
+	Secret  bool
+}
+
+// genArg is an input bound to positional arguments in generated code.
+type genArg struct {
+	Field, Name, Type, Elem string
+	// Kind is "scalar", "slice", "array" or "map". Maps are bound from pairs
+	// of arguments, the first of each a Key and the second an Elem.
+	Kind string
+	Key  string
+	// Start and End of the half-open range of arguments, with an End of -1
+	// when all remaining arguments are consumed, of which every Step-th is.
+	Start, End, Step int
+	// Default, when HasDefault.
+	Default    string
+	HasDefault bool
+	Validator  string
+	Required   bool
+	// Parser is as for genFlag.
+	Parser string
+}
+
+// genInject is an input populated by a registered provider in generated code.
+type genInject struct {
+	Field, Type, Name string
+}
+
+// genStdin is an input read from standard input in generated code.
+type genStdin struct {
+	Field string
+	// Format is as for the stdin tag component, and Kind is "decode" for
+	// structured formats, or "reader", "string" or "bytes" for raw input.
+	Format, Kind string
+}
+
+// genRename is a former flag name, which is migrated to the current one.
+type genRename struct {
+	Old, New string
+}
+
+// genDeprecation is a name of a deprecated flag, whose use is warned about
+// with Note.
+type genDeprecation struct {
+	Name, Note string
+}
+
+// genLock is the single-instance lock held while a generated command runs.
+type genLock struct {
+	// Field is the selector of the input which skips the lock when set, and
+	// Name the name of the lock.
+	Field, Name string
+}
+
+// genGlobal is a flag declared global by subcommands, which a command accepts
+// on their behalf in generated code.
+type genGlobal struct {
+	// Names of the flag, long first.
+	Names []string
+	// Kind is "Bool" or "Count" for flags which may be given without a
+	// value, and empty otherwise.
+	Kind  string
+	Usage string
+}
+
+// genEnv is an environment variable listed by the env command of generated
+// code.
+type genEnv struct {
+	// Name of the variable, Flag that which it sets, after the path of the
+	// command declaring it, and Default and Usage those of the flag.
+	Name, Flag, Default, Usage string
+}
+
+// genImport is an import of generated code.
+type genImport struct {
+	Name, Path string
+}
+
+// Std is true when the import is of a standard library package, whose paths
+// have no dot in their first element.
+func (imp genImport) Std() bool {
+	first, _, _ := strings.Cut(imp.Path, "/")
+	return !strings.Contains(first, ".")
+}
+
+// generation is the data with which the command template is executed.
+type generation struct {
+	Package, Name, Type string
+	// Qual qualifies the types of the package declaring Type, when the code
+	// is generated into another package, which imports it.
+	Qual string
+	// Flag is that of the cliche command which generated the code, naming
+	// the types wrapped.
+	Flag string
+	// Func is the name of the generated function running the command, and
+	// HelpConst the name of the constant holding its help, which is returned
+	// along with its Page by the function HelpFunc.
+	Func, HelpConst, HelpFunc string
+	Help                      string
+	Page                      helpPage
+	// Children are generated along with the command, which dispatches to
+	// them, unless External: already generated in another package, whose
+	// function Func is qualified by the name under which it is imported.
+	Children []*generation
+	External bool
+	// Globals are the flags declared global by the subcommands of the
+	// command, which it accepts on their behalf, and Forward the first names
+	// of those accepted by the command as a subcommand, which its parent
+	// passes on to it.
+	Globals []genGlobal
+	Forward []string
+	// Field is the selector of the field of the parent command declaring the
+	// command as its subcommand, which holds a pointer to it when Pointer,
+	// and With the name of the generated function running the command held
+	// by the field.
+	Field, With string
+	Pointer     bool
+	// Default is the verb or subcommand run when none is named.
+	Default  string
+	Imports  []genImport
+	Allocate []string
+	Flags    []genFlag
+	Args     []genArg
+	Injects  []genInject
+	Stdins   []genStdin
+	Renames  []genRename
+	// Deprecations are the names of deprecated flags, with their notes.
+	Deprecations []genDeprecation
+	// Counts holds the letters naming counting flags, which may be repeated
+	// in one argument.
+	Counts string
+	Lock   *genLock
+	// DryRun and Verbosity are the selectors of the inputs requesting a dry
+	// run and setting the verbosity, whose values are carried by the context
+	// with which the command and its subcommands run.
+	DryRun, Verbosity string
+	// Required is true when any flag or argument is required.
+	Required bool
+	// MaxArgs is the number of positional arguments accepted, or -1 when
+	// there is no limit.
+	MaxArgs  int
+	Verbs    []Verb
+	VerbList string
+	// HelpVerb is true when the command has verbs or subcommands, none named
+	// help, so that help names one of them to show the help of.
+	HelpVerb bool
+	Runnable bool
+	// PointerReceiver is true when Run is declared on a pointer receiver.
+	PointerReceiver bool
+	Main            bool
+	// Root is true for the command run by the program, rather than by its
+	// parent, which rewrites its arguments before parsing them.
+	Root bool
+	// ResponseFiles is true when the command expands @file arguments before
+	// parsing them, which only the root of a tree does.
+	ResponseFiles bool
+	// Abbreviate is true when the command accepts unique prefixes of the
+	// names of its flags, verbs and subcommands.
+	Abbreviate bool
+	// FlagSetFunc is the name of the generated function binding the flags
+	// of the command to a pflag.FlagSet, when one is generated.
+	FlagSetFunc string
+	// EnvVerb is true when the command has an env command, which lists
+	// EnvVars, those bound to the flags of its tree.
+	EnvVerb bool
+	EnvVars []genEnv
+	// SelfUpdateVerb is true when the command has a selfupdate command.
+	SelfUpdateVerb bool
+	// Pipes is true when the command runs pipelines of commands.
+	Pipes bool
+	// Timed is true when the command runs bounded by the timeout carried by
+	// its context, which the root command takes from its --timeout flag.
+	Timed bool
+	// Scheduled is true when the command runs on the schedule carried by its
+	// context, which the root command takes from its schedule flags.
+	Scheduled bool
+	// Interactive is true when the command takes an --interactive flag,
+	// asking for its Form flags.
+	Interactive bool
+	// Manifest is true when the command takes a --from-file flag, naming a
+	// manifest of its inputs.
+	Manifest bool
+	// ShowConfig is true when the command takes a --show-config flag,
+	// showing the values of its flags.
+	ShowConfig bool
+	// ColorFlag is true when the command, as the root of a program, takes a
+	// --color flag choosing whether its output is colored.
+	ColorFlag bool
+}
+
+// Shorthand is the single letter by which pflag gives the flag, when it has
+// one besides its long name.
+func (f genFlag) Shorthand() string {
+	if len(f.Names) == 2 && len(f.Names[1]) == 1 {
+		return f.Names[1]
+	}
+	return ""
+}
+
+// Given returns the names by which the flag may have been given on the command
+// line: its own, and its negated form.
+func (f genFlag) Given() []string {
+	if f.Negated == "" {
+		return f.Names
+	}
+	return append(f.Names[:len(f.Names):len(f.Names)], f.Negated)
+}
+
+// Validated is true when any flag of the command has a validator, which is
+// called with its value when it is given.
+func (gen *generation) Validated() bool {
+	for _, f := range gen.Flags {
+		if f.Validator != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Envs is true when any flag of the command is bound to an environment
+// variable.
+func (gen *generation) Envs() bool {
+	for _, f := range gen.Flags {
+		if f.Env != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// RequiredFlags is true when any flag of the command is required.
+func (gen *generation) RequiredFlags() bool {
+	for _, f := range gen.Flags {
+		if f.Required {
+			return true
+		}
+	}
+	return false
+}
+
+// withFlagSets has FlagSet functions generated for the command and its
+// subcommands generated along with it, other than those which only dispatch
+// to their own.
+func (gen *generation) withFlagSets() {
+	if gen.Type != "" {
+		gen.FlagSetFunc = "New" + gen.Type + "FlagSet"
+	}
+	for _, child := range gen.Children {
+		if !child.External {
+			child.withFlagSets()
+		}
+	}
+}
+
+// withAbbreviations has the command and its subcommands generated along with
+// it accept abbreviations.
+func (gen *generation) withAbbreviations() {
+	gen.Abbreviate = true
+	for _, child := range gen.Children {
+		if !child.External {
+			child.withAbbreviations()
+		}
+	}
+}
+
+// withTimeouts has the command and its subcommands generated along with it run
+// bounded by the timeout of the root command.
+func (gen *generation) withTimeouts() {
+	gen.Timed = true
+	for _, child := range gen.Children {
+		if !child.External {
+			child.withTimeouts()
+		}
+	}
+}
+
+// withSchedules has the command and its subcommands generated along with it run
+// on the schedule of the root command.
+func (gen *generation) withSchedules() {
+	gen.Scheduled = true
+	for _, child := range gen.Children {
+		if !child.External {
+			child.withSchedules()
+		}
+	}
+}
+
+// Commands returns the names of the verbs and subcommands of the command,
+// which abbreviations of its first argument are expanded to.
+func (gen *generation) Commands() []string {
+	var names []string
+	for _, verb := range gen.Verbs {
+		names = append(names, verb.Name)
+	}
+	for _, child := range gen.Children {
+		names = append(names, child.Name)
+	}
+	return names
+}
+
+// funcName returns the name of the generated function running the command:
+// RunT for a command wrapping type T, and otherwise named after the command.
+func (meta *Command) funcName() string {
+	if meta.Type == "" {
+		return "Run" + strcase.ToCamel(meta.Name)
+	}
+	return "Run" + meta.Type
+}
+
+// lowerFirst returns s with its first letter in lower case.
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	r := []rune(s)
+	r[0] = unicode.ToLower(r[0])
+	return string(r)
+}
+
+// firstLine of s.
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
+
+// elemType returns the element type of a slice or array type expression, and
+// whether it is a slice.
+func elemType(typ string) (elem string, slice bool, ok bool) {
+	expr, err := parser.ParseExpr(typ)
+	if err != nil {
+		return "", false, false
+	}
+	at, ok := expr.(*ast.ArrayType)
+	if !ok {
+		return "", false, false
+	}
+	return typ[at.Elt.Pos()-1:], at.Len == nil, true
+}
+
+// mapType returns the key and element types of a map type expression.
+func mapType(typ string) (key, elem string, ok bool) {
+	expr, err := parser.ParseExpr(typ)
+	if err != nil {
+		return "", "", false
+	}
+	mt, ok := expr.(*ast.MapType)
+	if !ok {
+		return "", "", false
+	}
+	return typ[mt.Key.Pos()-1 : mt.Key.End()-1], typ[mt.Value.Pos()-1:], true
+}
+
+// timeParser returns the expression of the function which parses the values
+// of a time input with a layout or zone, or nothing for other inputs.
+func timeParser(tag ParsedTag) string {
+	if tag.Layout == "" && tag.Timezone == "" {
+		return ""
+	}
+	return fmt.Sprintf("cliche.TimeParser(%q, %q)", TimeLayout(tag.Layout), tag.Timezone)
+}
+
+// choiceParser returns the expression of the parser of values of type typ for
+// an input limited to choices, which checks them before parsing with parser,
+// or cliche.Parse when it is empty. Without choices, parser is returned as is.
+func choiceParser(parser, typ string, choices []string) string {
+	if len(choices) == 0 {
+		return parser
+	}
+	if parser == "" {
+		parser = "cliche.Parse[" + typ + "]"
+	}
+	var quoted []string
+	for _, choice := range choices {
+		quoted = append(quoted, strconv.Quote(choice))
+	}
+	return fmt.Sprintf("cliche.ChoiceParser(%v, %v)", parser, strings.Join(quoted, ", "))
+}
+
+// choicesNote is appended to the usage of an input limited to choices.
+func choicesNote(choices []string) string {
+	if len(choices) == 0 {
+		return ""
+	}
+	return " (one of " + strings.Join(choices, ", ") + ")"
+}
+
+// SliceNote is appended to the usage of a flag bound to a slice, whose values
+// are taken as the SlicePolicy named policy says, separated by sep. Flags
+// whose policy is repeat, as by default, need none.
+func SliceNote(policy, sep string) string {
+	if sep == "" {
+		sep = ","
+	}
+	switch policy {
+	case "split":
+		return fmt.Sprintf(" (values separated by %q, each use replacing the last)", sep)
+	case "both":
+		return fmt.Sprintf(" (values separated by %q)", sep)
+	}
+	return ""
+}
+
+// sliceNote is SliceNote for input, under the command's SlicePolicy, when it is
+// a flag bound to a slice.
+func (meta *Command) sliceNote(input CommandInput, tag ParsedTag) string {
+	if _, slice, ok := elemType(input.Type); !ok || !slice || input.Type == "[]byte" || tag.Arg != nil || tag.Inject || tag.Stdin {
+		return ""
+	}
+	return SliceNote(meta.SlicePolicy, tag.Separator)
+}
+
+// envNote is appended to the usage of a flag bound to an environment variable.
+func envNote(tag ParsedTag) string {
+	if tag.Env == "" {
+		return ""
+	}
+	return " (env " + tag.Env + ")"
+}
+
+// envVars returns the environment variables bound to the flags of the command
+// and its subcommands, which is a subcommand of the command at path parent, if
+// any, in the order they are declared.
+func (meta *Command) envVars(parent string) []genEnv {
+	path := strings.TrimSpace(parent + " " + meta.Name)
+	var vars []genEnv
+	for _, input := range meta.Inputs {
+		tag, _ := ParseTag(string(input.Tag))
+		if tag.Env == "" {
+			continue
+		}
+		vars = append(vars, genEnv{Name: tag.Env, Flag: path + " -" + FlagNames(input, tag)[0], Default: tag.Default, Usage: firstLine(input.Doc)})
+	}
+	for _, child := range meta.Children {
+		vars = append(vars, child.envVars(path)...)
+	}
+	return vars
+}
+
+// runtimeFlag is a flag which a command registers on behalf of an option with
+// which it is generated, rather than of one of its inputs.
+type runtimeFlag struct {
+	// Option is the name of the option, and Name that of the flag, whose
+	// value is of type Value, if it takes one.
+	Option, Name, Value string
+	Doc                 string
+}
+
+// runtimeFlags returns the flags which the command registers on behalf of its
+// options. Only the root of the tree has those of -timeout and -schedule.
+func (meta *Command) runtimeFlags() []runtimeFlag {
+	var flags []runtimeFlag
+	if meta.interactive() {
+		flags = append(flags, runtimeFlag{Option: "interactive", Name: "interactive", Doc: "Ask for the flags not given with a form."})
+	}
+	if meta.manifest() {
+		flags = append(flags, runtimeFlag{Option: "manifest", Name: "from-file", Value: "file", Doc: "Read inputs from this JSON manifest, under the flags given."})
+	}
+	if meta.showConfig() {
+		flags = append(flags, runtimeFlag{Option: "showconfig", Name: "show-config", Doc: "Show the value of each flag and where it comes from, rather than running."})
+	}
+	if meta.Timeout {
+		flags = append(flags, runtimeFlag{Option: "timeout", Name: "timeout", Value: "duration", Doc: "Stop the command after this duration; default is no limit."})
+	}
+	if meta.Schedule {
+		flags = append(flags,
+			runtimeFlag{Option: "schedule", Name: "every", Value: "interval", Doc: "Run repeatedly, this interval apart, rather than once."},
+			runtimeFlag{Option: "schedule", Name: "jitter", Value: "duration", Doc: "Add up to this duration to each interval, chosen at random."},
+			runtimeFlag{Option: "schedule", Name: "until", Value: "time", Doc: "With -every, start no runs after this RFC 3339 time or duration from now."},
+			runtimeFlag{Option: "schedule", Name: "keep-going", Doc: "With -every, keep running after a run fails."})
+	}
+	return flags
+}
+
+// interactive is true when the command takes an --interactive flag: when it is
+// generated with the option, runs, and has flags to ask for.
+func (meta *Command) interactive() bool {
+	return meta.Interactive && meta.runsWith(formInput)
+}
+
+// manifest is true when the command takes a --from-file flag: when it is
+// generated with the option, runs, and has flags which the manifest sets.
+func (meta *Command) manifest() bool {
+	return meta.Manifest && meta.runsWith(flagInput)
+}
+
+// showConfig is true when the command takes a --show-config flag: when it is
+// generated with the option, runs, and has flags to show.
+func (meta *Command) showConfig() bool {
+	return meta.ShowConfig && meta.runsWith(flagInput)
+}
+
+// colorFlag is true when the command, as the root of a program generated into
+// package main, takes a --color flag choosing whether its output is colored,
+// as it does unless it declares a flag of that name, or accepts a global one.
+func (meta *Command) colorFlag(parent string) bool {
+	if parent != "" || meta.outputPackage() != "main" {
+		return false
+	}
+	inputs := meta.Inputs
+	if globals, err := meta.liftedGlobals(); err == nil {
+		inputs = append(inputs[:len(inputs):len(inputs)], globals...)
+	}
+	for _, input := range inputs {
+		tag, _ := ParseTag(string(input.Tag))
+		if flagInput(input) && containsString(FlagNames(input, tag), "color") {
+			return false
+		}
+	}
+	return true
+}
+
+// runsWith is true when the command runs, by itself or a verb, and has an
+// input for which keep returns true.
+func (meta *Command) runsWith(keep func(CommandInput) bool) bool {
+	if !meta.runnable && len(meta.Verbs) == 0 {
+		return false
+	}
+	for _, input := range meta.Inputs {
+		if keep(input) {
+			return true
+		}
+	}
+	return false
+}
+
+// flagInput is true when input is bound to a flag.
+func flagInput(input CommandInput) bool {
+	tag, _ := ParseTag(string(input.Tag))
+	return !tag.Excluded && tag.Arg == nil && !tag.Inject && !tag.Stdin
+}
+
+// formInput is true when input is a flag asked for by interactive forms: one
+// which is neither hidden, deprecated, nor counted.
+func formInput(input CommandInput) bool {
+	tag, _ := ParseTag(string(input.Tag))
+	return !tag.Excluded && tag.Arg == nil && !tag.Inject && !tag.Stdin && !tag.Hidden && !tag.Deprecated && !tag.Count
+}
+
+// deprecationNote is appended to the usage of a deprecated flag.
+func deprecationNote(tag ParsedTag) string {
+	switch {
+	case !tag.Deprecated:
+		return ""
+	case tag.DeprecatedNote == "":
+		return " (deprecated)"
+	}
+	return " (deprecated, " + tag.DeprecatedNote + ")"
+}
+
+// argUsage returns the name of the positional arguments bound to input as
+// shown in usage, such as name, [name] or [name...], and whether it is bound
+// to positional arguments at all.
+func argUsage(input CommandInput) (string, bool) {
+	tag, _ := ParseTag(string(input.Tag))
+	if tag.Arg == nil || tag.Inject || tag.Stdin {
+		return "", false
+	}
+	name := strcase.ToKebab(input.FieldName[strings.LastIndex(input.FieldName, ".")+1:])
+	_, slice, many := elemType(input.Type)
+	switch {
+	case tag.Pairs:
+		return "[" + name + "...]", true
+	case many && slice && input.Type != "[]byte":
+		return "[" + name + "...]", true
+	case many && input.Type != "[]byte":
+		return name + "...", true
+	case tag.Default != "":
+		return "[" + name + "]", true
+	}
+	return name, true
+}
+
+// FlagNames returns the names of the flag bound to input, long first: those of
+// its flag tag component, or else no-lock for the field controlling the lock,
+// or else its field name in kebab-case.
+func FlagNames(input CommandInput, tag ParsedTag) []string {
+	switch {
+	case tag.Flag.ShortOnly():
+		return []string{tag.Flag.Short}
+	case tag.Flag != nil && tag.Flag.Short != "":
+		return []string{tag.Flag.Long, tag.Flag.Short}
+	case tag.Flag != nil:
+		return []string{tag.Flag.Long}
+	case tag.Lock:
+		return []string{"no-lock"}
+	}
+	return []string{strcase.ToKebab(input.FieldName[strings.LastIndex(input.FieldName, ".")+1:])}
+}
+
+// NegatedName returns the name of the negated form of the flag bound to input
+// when it is negatable: no- followed by its long name. It is empty otherwise,
+// and when the flag has no long name.
+func NegatedName(input CommandInput, tag ParsedTag) string {
+	if !tag.Negatable || tag.Flag.ShortOnly() {
+		return ""
+	}
+	return "no-" + FlagNames(input, tag)[0]
+}
+
+// external is true when child, a subcommand of the command, is run from
+// another package, in which it is generated.
+func (meta *Command) external(child *Command) bool {
+	return child.ImportPath != "" && child.ImportPath != meta.ImportPath
+}
+
+// outputPackage returns the name of the package into which the command is
+// generated.
+func (meta *Command) outputPackage() string {
+	if meta.OutputPackage != "" {
+		return meta.OutputPackage
+	}
+	return meta.Package
+}
+
+// packageImports adds the imports of the packages of the command and its
+// subcommands to imports, by path, unless already present.
+func (meta *Command) packageImports(imports map[string]string) {
+	for path, name := range meta.imports {
+		if _, ok := imports[path]; !ok {
+			imports[path] = name
+		}
+	}
+	for _, child := range meta.Children {
+		if !meta.external(child) {
+			child.packageImports(imports)
+		}
+	}
+}
+
+// offeredImports returns the imports offered to the code generated for the
+// command, by path, with the name under which each is imported, or the empty
+// string for its default name: those the template may use, for a main
+// package too when main, and every import of the command's package, so that
+// the types of its inputs resolve.
+func (meta *Command) offeredImports(main bool) map[string]string {
+	imports := map[string]string{
+		"context": "", "errors": "", "flag": "", "fmt": "", "io": "", "strings": "",
+		RuntimeImportPath: "",
+	}
+	if main {
+		imports["os"], imports["os/signal"] = "", ""
+	}
+	if meta.PFlag {
+		imports[PFlagImportPath] = ""
+	}
+	meta.packageImports(imports)
+	return imports
+}
+
+// sourceName returns the name under which code generated into OutputPackage
+// imports the package declaring the command's types: its own name, unless
+// another import takes it. Without an OutputPackage, it is empty.
+func (meta *Command) sourceName() string {
+	if meta.OutputPackage == "" {
+		return ""
+	}
+	taken := make(map[string]bool)
+	for path, name := range meta.offeredImports(meta.OutputPackage == "main") {
+		taken[genImport{Name: name, Path: path}.name()] = true
+	}
+	name := meta.Package
+	for i := 2; taken[name]; i++ {
+		name = meta.Package + strconv.Itoa(i)
+	}
+	return name
+}
+
+// exported is true when every element of selector is an exported identifier,
+// as those set from outside the package declaring them must be.
+func exported(selector string) bool {
+	for _, name := range strings.Split(selector, ".") {
+		if !token.IsExported(name) {
+			return false
+		}
+	}
+	return true
+}
+
+// qualify returns the type expression typ as written outside the package
+// declaring it, with the types it names from that package qualified by source,
+// the name under which the package is imported. Types named by unexported
+// identifiers can't be referred to from outside, and are an error.
+func qualify(typ, source string) (string, error) {
+	expr, err := parser.ParseExpr(typ)
+	if err != nil {
+		return "", err
+	}
+	var unexported []string
+	var visit func(ast.Node) bool
+	visit = func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.SelectorExpr:
+			// Qualified by the package declaring it already.
+			return false
+		case *ast.Field:
+			// The names of fields and parameters are not types.
+			ast.Inspect(n.Type, visit)
+			return false
+		case *ast.Ident:
+			if types.Universe.Lookup(n.Name) != nil {
+				return false
+			}
+			if !n.IsExported() {
+				unexported = append(unexported, n.Name)
+			}
+			n.Name = source + "." + n.Name
+		}
+		return true
+	}
+	ast.Inspect(expr, visit)
+	if len(unexported) > 0 {
+		return "", fmt.Errorf("type %v is unexported", strings.Join(unexported, ", "))
+	}
+	return types.ExprString(expr), nil
+}
+
+// generation prepares the data with which the command template is executed,
+// for a command which is a subcommand of the command at path parent, if any.
+// When the command is generated into another package than that declaring its
+// types, source is the name under which that package is imported.
+func (meta *Command) generation(parent, source string) (*generation, error) {
+	gen := &generation{
+		Package:         meta.outputPackage(),
+		Name:            meta.Name,
+		Type:            meta.Type,
+		Flag:            "-type=" + meta.Type,
+		Func:            meta.funcName(),
+		HelpConst:       lowerFirst(strings.TrimPrefix(meta.funcName(), "Run")) + "Help",
+		HelpFunc:        strings.TrimPrefix(meta.funcName(), "Run") + "Help",
+		Help:            meta.helpText(parent),
+		Page:            meta.helpPage(parent, 80),
+		Verbs:           meta.Verbs,
+		Default:         meta.Default,
+		Runnable:        meta.runnable,
+		PointerReceiver: meta.PointerReceiver,
+		Main:            meta.outputPackage() == "main" && parent == "",
+		Root:            parent == "",
+		Interactive:     meta.interactive(),
+		Manifest:        meta.manifest(),
+		ShowConfig:      meta.showConfig(),
+		ColorFlag:       meta.colorFlag(parent),
+	}
+	var errs []error
+	if source != "" {
+		gen.Qual = source + "."
+		gen.Flag = "-type=" + meta.ImportPath + "." + meta.Type
+		if parent == "" && meta.Package == "main" {
+			errs = append(errs, &ValidationError{Pos: meta.Pos, Err: fmt.Errorf("package main can't be imported by package %v", meta.outputPackage())})
+		}
+		if parent == "" && meta.ImportPath == "" {
+			errs = append(errs, &ValidationError{Pos: meta.Pos, Err: fmt.Errorf("the import path of package %v is unknown", meta.Package)})
+		}
+		if meta.Type != "" && !token.IsExported(meta.Type) {
+			errs = append(errs, &ValidationError{Pos: meta.Pos, Err: fmt.Errorf("type %v is unexported, so can't be wrapped from package %v", meta.Type, meta.outputPackage())})
+		}
+		if meta.Field != nil && !exported(meta.Field.FieldName) {
+			errs = append(errs, &ValidationError{Pos: meta.Field.TagPos, Err: fmt.Errorf("field %v is unexported, so can't be set from another package", meta.Field.FieldName)})
+		}
+	}
+	if meta.Field != nil && meta.Type != "" {
+		gen.Field, gen.With = meta.Field.FieldName, lowerFirst(meta.funcName())
+		gen.Pointer = strings.HasPrefix(meta.Field.Type, "*")
+	}
+	var verbs, names []string
+	for _, verb := range meta.Verbs {
+		verbs = append(verbs, verb.Name)
+	}
+
+	globals, err := meta.liftedGlobals()
+	if err != nil {
+		errs = append(errs, err)
+	}
+	for _, input := range globals {
+		tag, _ := ParseTag(string(input.Tag))
+		g := genGlobal{Names: FlagNames(input, tag), Usage: firstLine(input.Doc)}
+		switch {
+		case tag.Count:
+			g.Kind = "Count"
+			for _, name := range g.Names {
+				if len(name) == 1 {
+					gen.Counts += name
+				}
+			}
+		case input.Type == "bool":
+			g.Kind = "Bool"
+		}
+		gen.Globals = append(gen.Globals, g)
+	}
+	path := strings.TrimSpace(parent + " " + meta.Name)
+	external := make(map[*generation]*Command)
+	for _, child := range meta.Children {
+		verbs = append(verbs, child.Name)
+		var g *generation
+		if meta.external(child) {
+			g = &generation{Name: child.Name, External: true}
+			external[g] = child
+		} else {
+			if source != "" {
+				names = append(names, child.ImportPath+"."+child.Type)
+			} else {
+				names = append(names, child.Type)
+			}
+			if g, err = child.generation(path, source); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+		}
+		// The child accepts the globals declared in its tree.
+		accepted, _ := GlobalInputs(child.tree()...)
+		for _, input := range accepted {
+			for i, lifted := range globals {
+				if globalKey(lifted) == globalKey(input) {
+					g.Forward = append(g.Forward, gen.Globals[i].Names[0])
+				}
+			}
+		}
+		gen.Children = append(gen.Children, g)
+	}
+	if meta.Env && parent == "" {
+		verbs = append(verbs, "env")
+	}
+	if meta.SelfUpdate && parent == "" {
+		verbs = append(verbs, "selfupdate")
+	}
+	gen.VerbList = strings.Join(verbs, ", ")
+	gen.HelpVerb = len(verbs) > 0 && !containsString(verbs, "help")
+	switch {
+	case meta.Type == "" && len(external) > 0:
+		gen.Flag = "index"
+	case meta.Type == "":
+		gen.Flag = "-types=" + strings.Join(names, ",")
+	}
+
+	allocated := make(map[string]bool)
+	for _, input := range meta.Inputs {
+		for _, selector := range input.Allocate {
+			if !allocated[selector] {
+				allocated[selector] = true
+				gen.Allocate = append(gen.Allocate, selector)
+			}
+		}
+		tag, _ := ParseTag(string(input.Tag))
+		name := strcase.ToKebab(input.FieldName[strings.LastIndex(input.FieldName, ".")+1:])
+		if source != "" {
+			if !exported(input.FieldName) {
+				errs = append(errs, &ValidationError{Pos: input.TagPos, Err: fmt.Errorf("field %v is unexported, so can't be set from another package", input.FieldName)})
+				continue
+			}
+			if input.Validator != "" && !token.IsExported(input.Validator) {
+				errs = append(errs, &ValidationError{Pos: input.TagPos, Err: fmt.Errorf("field %v: validator %v is unexported, so can't be called from another package", input.FieldName, input.Validator)})
+				continue
+			}
+			typ, err := qualify(input.Type, source)
+			if err != nil {
+				errs = append(errs, &ValidationError{Pos: input.TagPos, Err: fmt.Errorf("field %v: %w, so can't be referred to from another package", input.FieldName, err)})
+				continue
+			}
+			input.Type = typ
+		}
+
+		switch {
+		case tag.Inject:
+			gen.Injects = append(gen.Injects, genInject{Field: input.FieldName, Type: input.Type, Name: tag.InjectName})
+
+		case tag.Stdin:
+			in := genStdin{Field: input.FieldName, Format: tag.StdinFormat, Kind: "decode"}
+			if tag.StdinFormat == StdinRaw {
+				switch input.Type {
+				case "io.Reader":
+					in.Kind = "reader"
+				case "string":
+					in.Kind = "string"
+				case "[]byte":
+					in.Kind = "bytes"
+				default:
+					errs = append(errs, &ValidationError{Pos: input.TagPos, Err: fmt.Errorf("field %v: raw stdin can't be read into type %v", input.FieldName, input.Type)})
+					continue
+				}
+			}
+			gen.Stdins = append(gen.Stdins, in)
+
+		case tag.Arg != nil:
+			arg := genArg{Field: input.FieldName, Name: name, Type: input.Type, Kind: "scalar",
+				Default: tag.Default, HasDefault: tag.Default != "", Validator: input.Validator, Required: tag.Required,
+				Parser: timeParser(tag)}
+			arg.Start, arg.End = ArgRange(input, tag.Arg)
+			arg.Step = tag.Arg.Stride()
+			if key, elem, ok := mapType(input.Type); ok && tag.Pairs {
+				arg.Key, arg.Elem, arg.Kind = key, elem, "map"
+			} else if elem, slice, ok := elemType(input.Type); ok && input.Type != "[]byte" {
+				arg.Elem, arg.Kind = elem, "array"
+				if slice {
+					arg.Kind = "slice"
+				}
+			} else if tag.Arg.End != 0 {
+				errs = append(errs, &ValidationError{Pos: input.TagPos, Err: fmt.Errorf("field %v: a range of arguments can't be bound to type %v", input.FieldName, input.Type)})
+				continue
+			}
+			if arg.Elem != "" {
+				arg.Parser = choiceParser(arg.Parser, arg.Elem, tag.Choices)
+			} else {
+				arg.Parser = choiceParser(arg.Parser, arg.Type, tag.Choices)
+			}
+			gen.Args = append(gen.Args, arg)
+
+		default:
+			f := genFlag{Field: input.FieldName, Type: input.Type, Names: FlagNames(input, tag), Negated: NegatedName(input, tag), Count: tag.Count,
+				Hidden: tag.Hidden, Deprecated: tag.Deprecated, DeprecatedNote: tag.DeprecatedNote, Usage: firstLine(input.Doc),
+				Default: tag.Default, HasDefault: tag.Default != "", Validator: input.Validator, Required: tag.Required,
+				Parser: timeParser(tag), Env: tag.Env, Prompt: firstLine(input.Doc), Choices: tag.Choices, Secret: tag.Secret}
+			f.Usage = strings.TrimSpace(f.Usage + choicesNote(tag.Choices) + meta.sliceNote(input, tag) + deprecationNote(tag) + envNote(tag))
+			if f.Required {
+				f.Usage = strings.TrimSpace(f.Usage + " (required)")
+			}
+			if tag.Lock {
+				gen.Lock = &genLock{Field: input.FieldName, Name: tag.LockName}
+				if gen.Lock.Name == "" {
+					gen.Lock.Name = meta.Name
+				}
+			}
+			if tag.DryRun {
+				gen.DryRun = input.FieldName
+			}
+			if tag.Verbosity {
+				gen.Verbosity = input.FieldName
+			}
+			if key, elem, ok := mapType(input.Type); ok {
+				f.Key, f.Elem, f.Sep = key, elem, tag.Separator
+				if f.Sep == "" {
+					f.Sep = MapSeparator
+				}
+			} else if elem, slice, ok := elemType(input.Type); ok && input.Type != "[]byte" {
+				if !slice {
+					errs = append(errs, &ValidationError{Pos: input.TagPos, Err: fmt.Errorf("field %v: array type %v can't be bound to a flag", input.FieldName, input.Type)})
+					continue
+				}
+				f.Elem, f.Sep = elem, tag.Separator
+				switch meta.SlicePolicy {
+				case "split":
+					f.Policy = "cliche.SliceSplit"
+				case "both":
+					f.Policy = "cliche.SliceBoth"
+				}
+			}
+			if f.Elem != "" {
+				f.Parser = choiceParser(f.Parser, f.Elem, tag.Choices)
+			} else {
+				f.Parser = choiceParser(f.Parser, f.Type, tag.Choices)
+			}
+			if f.Count {
+				for _, name := range f.Names {
+					if len(name) == 1 {
+						gen.Counts += name
+					}
+				}
+			}
+			for _, old := range tag.Migrate {
+				gen.Renames = append(gen.Renames, genRename{Old: old, New: f.Names[0]})
+			}
+			f.Former = tag.Migrate
+			if f.Deprecated {
+				names := f.Names
+				if f.Negated != "" {
+					names = append(names[:len(names):len(names)], f.Negated)
+				}
+				for _, name := range names {
+					gen.Deprecations = append(gen.Deprecations, genDeprecation{Name: name, Note: f.DeprecatedNote})
+				}
+			}
+			gen.Flags = append(gen.Flags, f)
+		}
+	}
+	// The subcommands declared by fields of embedded structs are held by
+	// those structs.
+	for _, child := range meta.Children {
+		if child.Field == nil {
+			continue
+		}
+		for _, selector := range child.Field.Allocate {
+			if !allocated[selector] {
+				allocated[selector] = true
+				gen.Allocate = append(gen.Allocate, selector)
+			}
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	for _, f := range gen.Flags {
+		gen.Required = gen.Required || f.Required
+	}
+	for _, arg := range gen.Args {
+		gen.Required = gen.Required || arg.Required
+		if arg.End < 0 {
+			gen.MaxArgs = -1
+			break
+		}
+		if arg.End > gen.MaxArgs {
+			gen.MaxArgs = arg.End
+		}
+	}
+
+	// Every import of the command's package is offered to the generated code.
+	// Those which go unused are pruned once the code is rendered.
+	imports := meta.offeredImports(gen.Main)
+	taken := make(map[string]bool)
+	for path, name := range imports {
+		taken[genImport{Name: name, Path: path}.name()] = true
+	}
+	if source != "" {
+		imp := genImport{Path: meta.ImportPath}
+		if imp.name() != source {
+			imp.Name = source
+		}
+		imports[imp.Path] = imp.Name
+		taken[source] = true
+	}
+	// The packages of external subcommands are imported under their names,
+	// unless those are taken.
+	for _, g := range gen.Children {
+		child, ok := external[g]
+		if !ok {
+			continue
+		}
+		imp := genImport{Path: child.ImportPath}
+		name := child.outputPackage()
+		for i := 2; taken[name]; i++ {
+			name = child.outputPackage() + strconv.Itoa(i)
+		}
+		taken[name] = true
+		if imp.name() != name {
+			imp.Name = name
+		}
+		imports[imp.Path] = imp.Name
+		g.Func = name + "." + child.funcName()
+	}
+	for path, name := range imports {
+		gen.Imports = append(gen.Imports, genImport{Name: name, Path: path})
+	}
+	sort.Slice(gen.Imports, func(i, j int) bool {
+		return gen.Imports[i].Path < gen.Imports[j].Path
+	})
+	return gen, nil
+}
+
+// name under which the package is imported. Without an explicit name, the
+// last element of the path is assumed to be the package's name, less any major
+// version suffix.
+func (imp genImport) name() string {
+	if imp.Name != "" {
+		return imp.Name
+	}
+	name := path.Base(imp.Path)
+	if strings.HasPrefix(name, "v") {
+		if _, err := strconv.Atoi(name[1:]); err == nil && path.Dir(imp.Path) != "." {
+			name = path.Base(path.Dir(imp.Path))
+		}
+	}
+	if i := strings.LastIndex(name, ".v"); i > 0 {
+		name = name[:i]
+	}
+	return name
+}
+
+// pruneImports removes the imports of gen which are not used by src, the code
+// generated from it.
+func (gen *generation) pruneImports(src []byte) error {
+	f, err := parser.ParseFile(token.NewFileSet(), "", src, parser.SkipObjectResolution)
+	if err != nil {
+		return fmt.Errorf("generated code does not parse: %w", err)
+	}
+	used := make(map[string]bool)
+	ast.Inspect(f, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if x, ok := sel.X.(*ast.Ident); ok {
+				used[x.Name] = true
+			}
+		}
+		return true
+	})
+	var imports []genImport
+	for _, imp := range gen.Imports {
+		if name := imp.name(); name == "_" || name == "." || used[name] {
+			imports = append(imports, imp)
+		}
+	}
+	gen.Imports = imports
+	return nil
+}
+
+// Generate writes Go source to w which wraps the command's type as a command
+// line program, by executing the cliche command template. The source declares
+// a function named RunType, which binds the command's inputs from arguments,
+// standard input and registered providers before running it. When the command
+// belongs to package main, a main function running it is declared too, and
+// RunType takes a --color flag choosing whether output is colored, unless the
+// command declares a flag of that name. With PFlag, a NewTypeFlagSet function
+// binding the flags of each command type to a pflag.FlagSet is declared as
+// well. With ResponseFiles, RunType expands @file arguments before parsing
+// them, and with Abbreviate, every command accepts abbreviations. With Env,
+// RunType runs an env command, listing the environment variables read by the
+// program, and with SelfUpdate, a selfupdate command, updating it. With Pipes,
+// it runs pipelines of commands in one process. With Timeout, it takes a
+// --timeout flag bounding whichever command runs, and with Schedule, flags
+// running it on a schedule. With Interactive, its commands take an
+// --interactive flag asking for their flags with a form, with Manifest, a
+// --from-file flag naming a JSON manifest of their inputs, and with
+// ShowConfig, a --show-config flag showing the values of their flags. With an
+// OutputPackage, the source belongs to that package, and imports the package
+// declaring the command's types. The Command is validated first, and any
+// problems returned. Warnings are logged.
+func (meta *Command) Generate(w io.Writer) error {
+	if err := meta.Validate(); err != nil {
+		return err
+	}
+	for _, warning := range meta.Warnings() {
+		slog.Warn(warning.Error())
+	}
+	gen, err := meta.generation("", meta.sourceName())
+	if err != nil {
+		return err
+	}
+	if meta.PFlag {
+		gen.Flag += " -pflag"
+		gen.withFlagSets()
+	}
+	if meta.ResponseFiles {
+		gen.Flag += " -argfiles"
+		gen.ResponseFiles = true
+	}
+	if meta.SlicePolicy != "" && meta.SlicePolicy != "repeat" {
+		gen.Flag += " -slices=" + meta.SlicePolicy
+	}
+	if meta.Abbreviate {
+		gen.Flag += " -abbrev"
+		gen.withAbbreviations()
+	}
+	if meta.Env {
+		gen.Flag += " -env"
+		gen.EnvVerb = true
+		gen.EnvVars = meta.envVars("")
+	}
+	if meta.SelfUpdate {
+		gen.Flag += " -selfupdate"
+		gen.SelfUpdateVerb = true
+	}
+	if meta.Pipes {
+		gen.Flag += " -pipes"
+		gen.Pipes = true
+	}
+	if meta.Timeout {
+		gen.Flag += " -timeout"
+		gen.withTimeouts()
+	}
+	if meta.Schedule {
+		gen.Flag += " -schedule"
+		gen.withSchedules()
+	}
+	if meta.Interactive {
+		gen.Flag += " -interactive"
+	}
+	if meta.Manifest {
+		gen.Flag += " -manifest"
+	}
+	if meta.ShowConfig {
+		gen.Flag += " -showconfig"
+	}
+	if meta.Lenient {
+		gen.Flag += " -strict=false"
+	}
+	// The template is executed twice: first to find out which imports are
+	// used, and again without those which are not.
+	var src bytes.Buffer
+	if err := generated.Execute(&src, gen); err != nil {
+		return err
+	}
+	if err := gen.pruneImports(src.Bytes()); err != nil {
+		return err
+	}
+	src.Reset()
+	if err := generated.Execute(&src, gen); err != nil {
+		return err
+	}
+	out, err := format.Source(src.Bytes())
+	if err != nil {
+		return fmt.Errorf("generated code does not format: %w", err)
+	}
+	_, err = w.Write(out)
+	return err
+}
@@ -0,0 +1,1001 @@
+package meta
+
+import (
+	"go/build"
+	"go/parser"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGenerate(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		path, typ string
+		want      []string
+	}{
+		"flags": {"testdata/simple/simple.go", "Tester", []string{
+			"func RunTester(ctx context.Context, stdio cliche.IO, args []string) (err error) {",
+			`cliche.BindFlag(fs, &cmd.String, "String command input.", "string")`,
+			`cliche.BindSliceFlag(fs, &cmd.MoreInts, "MoreInts for the command.", "more-ints")`,
+			"err = cliche.MapExitCodes(cmd, err)",
+			"if err := cliche.ParseFlags(stdio, fs, cmd, testerHelp, args); err != nil {",
+			"return cliche.Recover(ctx, run)",
+			"func TesterHelp() cliche.HelpPage {\n\treturn cliche.HelpPage{\n\t\tText:     testerHelp,",
+		}},
+		"verbs": {"testdata/verbs/verbs.go", "Remote", []string{
+			`case "fetch-all":`,
+			"run, args = func(ctx context.Context) error { return cmd.RunFetchAll(ctx) }, args[1:]",
+			"run, args = cmd.RunPush, args[1:]",
+			`ctx = cliche.WithCommand(ctx, "fetch-all")`,
+			`return cliche.Usagef("expected a command: one of %v", "fetch-all, push")`,
+		}},
+		"value receiver": {"testdata/value/value.go", "Valuable", []string{
+			"run := func(ctx context.Context) error { return cmd.Run(ctx) }",
+			"if cmd.Name, err = cliche.Parse[string](args[0]); err != nil {",
+			`return cliche.Usagef("argument 1 (%v): %w", "name", err)`,
+		}},
+		"array": {"testdata/fixed/fixed.go", "Move", []string{
+			"if len(args) < 3 {",
+			`return cliche.Usagef("argument %v: expected 2 values, got %d", "squares", len(args)-1)`,
+			`return cliche.Usagef("argument %d (%v): %w", i+1, "squares", err)`,
+		}},
+		"inject": {"testdata/inject/inject.go", "Fetcher", []string{
+			`"net/http"`,
+			`if cmd.Client, err = cliche.Inject[*http.Client](ctx, ""); err != nil {`,
+		}},
+		"embedded": {"testdata/embedded/embedded.go", "Migrate", []string{
+			"cmd.Logging = new(Logging)",
+			`cliche.BindFlag(fs, &cmd.Connection.Host, "Host to connect to.", "db-host")`,
+			`"db-hostname": "db-host",`,
+		}},
+		"required": {"testdata/required/required.go", "Copy", []string{
+			"given := make(map[string]bool)\n\tfs.Visit(func(f *flag.Flag) {\n\t\tgiven[f.Name] = true\n\t})\n",
+			`if !given["token"] && !given["t"] {`,
+			`missing = append(missing, "-token")`,
+			`if len(args) <= 1 {`,
+			`missing = append(missing, "destination")`,
+			"return &cliche.MissingError{Inputs: missing}",
+		}},
+		"subcommands": {"testdata/tree/tree.go", "Tool", []string{
+			"func RunTool(ctx context.Context, stdio cliche.IO, args []string) (err error) {",
+			"cmd.Remote = new(Remote)\n\t\t\treturn runRemote(cliche.WithParent(ctx, cmd), stdio, cmd.Remote, args[1:])",
+			"return runStatus(cliche.WithParent(ctx, cmd), stdio, &cmd.Status, args[1:])",
+			`return cliche.Usagef("expected a command: one of %v", "remote, st")`,
+			"func RunRemote(ctx context.Context, stdio cliche.IO, args []string) error {\n\treturn runRemote(ctx, stdio, new(Remote), args)\n}",
+			"func runRemote(ctx context.Context, stdio cliche.IO, cmd *Remote, args []string) (err error) {",
+			"return runAdd(cliche.WithParent(ctx, cmd), stdio, &cmd.Add, args[1:])",
+			`const addHelp = "Usage: tree remote add [flags] name url\n\nAdd adds a remote repository.\n`,
+			"cliche.ShowHelp(stdio, cmd, toolHelp)\n\t\treturn flag.ErrHelp",
+			"if len(args) > 0 {\n\t\tif args[0] == \"help\" {\n\t\t\tif len(args) == 1 {\n\t\t\t\tcliche.ShowHelp(stdio, cmd, toolHelp)",
+			"func runStatus(ctx context.Context, stdio cliche.IO, cmd *Status, args []string) (err error) {",
+		}},
+		"times": {"testdata/times/times.go", "Report", []string{
+			`cliche.BindFlagFunc(fs, &cmd.Since, cliche.TimeParser("2006-01-02", "Local"), "Since is the first day reported on.", "since")`,
+			`cliche.BindFlag(fs, &cmd.Until, "Until is when the report ends.", "until")`,
+			`if cmd.Every, err = cliche.Parse[time.Duration]("24h"); err != nil {`,
+			`cliche.BindSliceFlagFunc(fs, &cmd.Skip, cliche.TimeParser("2006-01-02", ""), "Skip days, such as holidays.", "skip")`,
+			`v, err := cliche.TimeParser("02/01/2006", "Europe/London")(args[i])`,
+		}},
+		"strided": {"testdata/strided/strided.go", "Setenv", []string{
+			"for i := 0; i < len(args); i += 2 {",
+			"for i := 1; i < len(args); i += 2 {",
+			"cmd.Values = append(cmd.Values, v)",
+		}},
+		"pairs": {"testdata/pairs/pairs.go", "Set", []string{
+			"for i := 1; i < len(args); i += 2 {",
+			`return cliche.Usagef("argument %v: missing value for key %q", "values", args[i])`,
+			"k, err := cliche.Parse[string](args[i])",
+			"v, err := cliche.Parse[int](args[i+1])",
+			"cmd.Values = make(map[string]int)",
+			"cmd.Values[k] = v",
+		}},
+		"map flags": {"testdata/labels/labels.go", "Deploy", []string{
+			`if cmd.Labels, err = cliche.ParseMap[string, string]("team=infra", "="); err != nil {`,
+			`cliche.BindMapFlag(fs, &cmd.Labels, "=", "Labels of the service, as key=value.", "label", "l")`,
+			`cliche.BindMapFlag(fs, &cmd.Limits, ":", "Limits on resources, as resource:amount.", "limit")`,
+			`for _, s := range strings.Split("us-east,eu-west", ",") {`,
+			`cliche.BindSliceFlagSep(fs, &cmd.Regions, ",", nil, "Regions to deploy to, separated by commas.", "region")`,
+		}},
+		"short only": {"testdata/short/short.go", "Search", []string{
+			`cliche.BindFlag(fs, &cmd.Count, "Count matches, rather than listing them.", "c")`,
+			`cliche.BindFlag(fs, &cmd.Context, "Context lines shown around each match.", "C")`,
+			`cliche.BindFlag(fs, &cmd.Pattern, "Pattern searched for. (required)", "pattern", "e")`,
+			`\n  -c\tCount matches, rather than listing them.\n  -C int\tContext lines shown around each match. (default 2)\n`,
+		}},
+		"negatable": {"testdata/negated/negated.go", "Build", []string{
+			`cliche.BindFlag(fs, &cmd.Color, "Color the output.", "color", "c")`,
+			`cliche.BindNegatedFlag(fs, &cmd.Color, "Negates -color.", "no-color")`,
+			`cliche.BindNegatedFlag(fs, &cmd.Cache, "Negates -cache.", "no-cache")`,
+			`\n  -color, -c, -no-color\tColor the output. (default true)\n`,
+			`\n  -verbose, -v\tVerbose output.\n`,
+			`if !given["sign"] && !given["no-sign"] {`,
+		}},
+		"count": {"testdata/counted/counted.go", "Sync", []string{
+			`cliche.BindCountFlag(fs, &cmd.Verbose, "Verbose output, more so each time it is given.", "verbose", "v")`,
+			`cliche.BindCountFlag(fs, &cmd.Quiet, "Quiet output, less so each time it is given.", "q")`,
+			`cliche.BindFlag(fs, &cmd.Retries, "Retries of each failed transfer.", "retries", "r")`,
+			`args = cliche.ExpandCountFlags(fs, args)`,
+			`\n  -verbose, -v\tVerbose output, more so each time it is given.\n`,
+			`\n  -retries, -r int\tRetries of each failed transfer. (default 3)\n`,
+		}},
+		"choices": {"testdata/choices/choices.go", "Paint", []string{
+			`cliche.BindFlagFunc(fs, &cmd.Color, cliche.ChoiceParser(cliche.Parse[string], "red", "green", "blue"), "Color of the paint. (one of red, green, blue)", "color", "c")`,
+			`if cmd.Color, err = cliche.ChoiceParser(cliche.Parse[string], "red", "green", "blue")("red"); err != nil {`,
+			`cliche.BindSliceFlagSep(fs, &cmd.Finishes, ",", cliche.ChoiceParser(cliche.Parse[string], "matte", "gloss"), "Finishes applied, in order. (one of matte, gloss)", "finish")`,
+			`cliche.ChoiceParser(cliche.Parse[time.Duration], "1h", "2h")`,
+			`if cmd.Surface, err = cliche.ChoiceParser(cliche.Parse[string], "wall", "door")(args[0]); err != nil {`,
+			`\n  surface\tSurface painted. (one of wall, door)\n`,
+			`\n  -color, -c string\tColor of the paint. (one of red, green, blue) (default red)\n`,
+		}},
+		"hidden and deprecated": {"testdata/hidden/hidden.go", "Serve", []string{
+			`cliche.BindFlag(fs, &cmd.Debug, "Debug endpoints served.", "debug")`,
+			`cliche.BindFlag(fs, &cmd.Addr, "Addr listened on, as host:port. (deprecated, use --port instead)", "addr")`,
+			"cliche.WarnDeprecated(fs, map[string]string{\n\t\t\"addr\":      \"use --port instead\",\n\t\t\"legacy\":    \"\",\n\t\t\"no-legacy\": \"\",\n\t}, stdio.Err)",
+			`\n\nFlags:\n  -port, -p int\tPort listened on. (default 8080)\n  -addr string\tAddr listened on, as host:port. (deprecated, use --port instead)\n  -legacy, -no-legacy\tLegacy routes served. (deprecated) (default true)\n"`,
+		}},
+		"lock": {"testdata/locked/locked.go", "Purge", []string{
+			`cliche.BindFlag(fs, &cmd.NoLock, "NoLock runs the purge even while another is running.", "no-lock")`,
+			`if lock, err = cliche.AcquireLock("purge-data"); err != nil {`,
+			"err = errors.Join(err, lock.Release())",
+		}},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			cmd := FromFile(file(t, tc.path), tc.typ)
+			if cmd == nil {
+				t.Fatal("FromFile(): got nil Command")
+			}
+			var b strings.Builder
+			if err := cmd.Generate(&b); err != nil {
+				t.Fatalf("Generate(): unexpected error: %v", err)
+			}
+			got := b.String()
+			if _, err := parser.ParseFile(token.NewFileSet(), "", got, 0); err != nil {
+				t.Fatalf("Generate(): code does not parse: %v\n%v", err, got)
+			}
+			if !strings.HasPrefix(got, "// Code generated by cliche -type="+tc.typ+"; DO NOT EDIT.\n") {
+				t.Errorf("Generate(): missing generated code header:\n%v", got)
+			}
+			for _, want := range tc.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("Generate(): code does not contain %q:\n%v", want, got)
+				}
+			}
+			if strings.Contains(got, "func main()") {
+				t.Errorf("Generate(): main function generated outside of package main")
+			}
+		})
+	}
+}
+
+func TestGenerateFlagSet(t *testing.T) {
+	cmd := FromFile(file(t, "testdata/pflagged/pflagged.go"), "Deploy")
+	if cmd == nil {
+		t.Fatal("FromFile(): got nil Command")
+	}
+	cmd.PFlag = true
+	var b strings.Builder
+	if err := cmd.Generate(&b); err != nil {
+		t.Fatalf("Generate(): unexpected error: %v", err)
+	}
+	got := b.String()
+	if _, err := parser.ParseFile(token.NewFileSet(), "", got, 0); err != nil {
+		t.Fatalf("Generate(): code does not parse: %v\n%v", err, got)
+	}
+	for _, want := range []string{
+		"// Code generated by cliche -type=Deploy -pflag; DO NOT EDIT.\n",
+		`"github.com/spf13/pflag"`,
+		"func NewDeployFlagSet(cmd *Deploy) (pfs *pflag.FlagSet, bind func() error, err error) {",
+		`cliche.BindFlag(fs, &cmd.Region, "Region deployed to. (required)", "region", "r")`,
+		"pf = pflag.PFlagFromGoFlag(fs.Lookup(\"region\"))\n\tpf.Shorthand = \"r\"\n\tpfs.AddFlag(pf)",
+		"pf = pflag.PFlagFromGoFlag(fs.Lookup(\"no-color\"))\n\tpfs.AddFlag(pf)",
+		`pf.Name, pf.Shorthand, pf.Deprecated = "instances", "", "use --replicas instead"`,
+		"if pfs.Changed(\"region\") {\n\t\t\tif err := cmd.CheckRegion(pfs.Lookup(\"region\").Value.String()); err != nil {",
+		`return cliche.Usagef("flag --region: %w", err)`,
+		`if !pfs.Changed("region") {`,
+		`if !pfs.Changed("approve") && !pfs.Changed("no-approve") {`,
+		"pf = pflag.PFlagFromGoFlag(fs.Lookup(\"zone\"))\n\tpf.Deprecated = \"use --region instead\"\n\tpfs.AddFlag(pf)",
+		"pf = pflag.PFlagFromGoFlag(fs.Lookup(\"trace\"))\n\tpf.Hidden = true\n\tpfs.AddFlag(pf)",
+		"return pfs, bind, nil",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Generate(): code does not contain %q:\n%v", want, got)
+		}
+	}
+
+	cmd.PFlag = false
+	b.Reset()
+	if err := cmd.Generate(&b); err != nil {
+		t.Fatalf("Generate(): unexpected error: %v", err)
+	}
+	if got := b.String(); strings.Contains(got, "NewDeployFlagSet") {
+		t.Errorf("Generate(): code binds a pflag.FlagSet when not asked to:\n%v", got)
+	}
+}
+
+func TestGenerateLenient(t *testing.T) {
+	cmd := FromFile(file(t, "testdata/lenient/lenient.go"), "Greet")
+	if cmd == nil {
+		t.Fatal("FromFile(): got nil Command")
+	}
+	var b strings.Builder
+	if err := cmd.Generate(&b); err == nil || !strings.Contains(err.Error(), "did you mean default:?") {
+		t.Errorf("Generate(): got error %v, want one suggesting default:", err)
+	}
+
+	cmd.Lenient = true
+	b.Reset()
+	if err := cmd.Generate(&b); err != nil {
+		t.Fatalf("Generate(): unexpected error: %v", err)
+	}
+	if want := "// Code generated by cliche -type=Greet -strict=false; DO NOT EDIT.\n"; !strings.Contains(b.String(), want) {
+		t.Errorf("Generate(): code does not contain %q:\n%v", want, b.String())
+	}
+}
+
+func TestGenerateResponseFiles(t *testing.T) {
+	for _, cmd := range []*Command{
+		FromFile(file(t, "testdata/simple/simple.go"), "Tester"),
+		NewParent("suite",
+			FromFile(file(t, "testdata/suite/suite.go"), "Fetch"),
+			FromFile(file(t, "testdata/suite/suite.go"), "Push")),
+	} {
+		cmd.ResponseFiles = true
+		var b strings.Builder
+		if err := cmd.Generate(&b); err != nil {
+			t.Fatalf("Generate(): unexpected error: %v", err)
+		}
+		got := b.String()
+		if _, err := parser.ParseFile(token.NewFileSet(), "", got, 0); err != nil {
+			t.Fatalf("Generate(): code does not parse: %v\n%v", err, got)
+		}
+		want := "\texpanded, err := cliche.ExpandResponseFiles(args)\n" +
+			"\tif err != nil {\n\t\treturn cliche.NewUsageError(err)\n\t}\n" +
+			"\targs = expanded\n"
+		// Only the root of the tree expands them.
+		if n := strings.Count(got, want); n != 1 {
+			t.Errorf("Generate(): code expands response files %d times, want once:\n%v", n, got)
+		}
+		if !strings.Contains(got, " -argfiles; DO NOT EDIT.") {
+			t.Errorf("Generate(): header does not note -argfiles:\n%v", got)
+		}
+	}
+}
+
+func TestGenerateSlicePolicy(t *testing.T) {
+	cmd := FromFile(file(t, "testdata/simple/simple.go"), "Tester")
+	if cmd == nil {
+		t.Fatal("FromFile(): got nil Command")
+	}
+	cmd.SlicePolicy = "split"
+	var b strings.Builder
+	if err := cmd.Generate(&b); err != nil {
+		t.Fatalf("Generate(): unexpected error: %v", err)
+	}
+	got := b.String()
+	for _, want := range []string{
+		"// Code generated by cliche -type=Tester -slices=split; DO NOT EDIT.\n",
+		`cliche.BindSliceFlagPolicy(fs, &cmd.MoreInts, cliche.SliceSplit, "", nil, "MoreInts for the command. (values separated by \",\", each use replacing the last)", "more-ints")`,
+		`-more-ints []int\tMoreInts for the command. (values separated by \",\", each use replacing the last)`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Generate(): code does not contain %q:\n%v", want, got)
+		}
+	}
+}
+
+func TestGenerateAbbreviations(t *testing.T) {
+	cmd := FromFile(file(t, "testdata/tree/tree.go"), "Tool")
+	if cmd == nil {
+		t.Fatal("FromFile(): got nil Command")
+	}
+	cmd.Abbreviate = true
+	var b strings.Builder
+	if err := cmd.Generate(&b); err != nil {
+		t.Fatalf("Generate(): unexpected error: %v", err)
+	}
+	got := b.String()
+	if _, err := parser.ParseFile(token.NewFileSet(), "", got, 0); err != nil {
+		t.Fatalf("Generate(): code does not parse: %v\n%v", err, got)
+	}
+	for _, want := range []string{
+		"// Code generated by cliche -type=Tool -abbrev; DO NOT EDIT.\n",
+		"\tabbreviated, err := cliche.AbbreviateFlags(fs, args)\n",
+		`if name, err := cliche.AbbreviateCommand(args[0], []string{"remote", "st"}); err != nil {`,
+		`if name, err := cliche.AbbreviateCommand(args[0], []string{"add", "rm"}); err != nil {`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Generate(): code does not contain %q:\n%v", want, got)
+		}
+	}
+}
+
+func TestGenerateEnv(t *testing.T) {
+	parent := NewParent("environ",
+		FromFile(file(t, "testdata/environ/environ.go"), "Serve"),
+		FromFile(file(t, "testdata/environ/environ.go"), "Status"))
+	parent.Env = true
+	var b strings.Builder
+	if err := parent.Generate(&b); err != nil {
+		t.Fatalf("Generate(): unexpected error: %v", err)
+	}
+	got := b.String()
+	if _, err := parser.ParseFile(token.NewFileSet(), "", got, 0); err != nil {
+		t.Fatalf("Generate(): code does not parse: %v\n%v", err, got)
+	}
+	for _, want := range []string{
+		"// Code generated by cliche -types=Serve,Status -env; DO NOT EDIT.\n",
+		"\tif err := cliche.BindEnv(ctx, fs, map[string][]string{\n\t\t\"SERVE_PORT\": {\"port\", \"p\"},\n\t\t\"SERVE_LOG\":  {\"log\", \"no-log\"},\n\t}); err != nil {\n\t\treturn err\n\t}\n",
+		"\tif args[0] == \"env\" {\n\t\treturn cliche.ShowEnv(stdio, []cliche.EnvVar{\n" +
+			"\t\t\t{Name: \"SERVE_PORT\", Flag: \"environ serve -port\", Default: \"8080\", Usage: \"Port to listen on.\"},\n" +
+			"\t\t\t{Name: \"SERVE_LOG\", Flag: \"environ serve -log\", Usage: \"Log each request.\"},\n" +
+			"\t\t\t{Name: \"STATUS_ADDR\", Flag: \"environ status -addr\", Usage: \"Address of the server.\"},\n\t\t})\n\t}\n",
+		`return cliche.Usagef("expected a command: one of %v", "serve, status, env")`,
+		`  env\tList the environment variables the program reads.\n`,
+		`  -port, -p int\tPort to listen on. (env SERVE_PORT) (default 8080)\n`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Generate(): code does not contain %q:\n%v", want, got)
+		}
+	}
+	// Only the root command lists the environment.
+	if n := strings.Count(got, "cliche.ShowEnv("); n != 1 {
+		t.Errorf("Generate(): env command generated %d times, want once:\n%v", n, got)
+	}
+}
+
+func TestGenerateSelfUpdate(t *testing.T) {
+	parent := NewParent("environ",
+		FromFile(file(t, "testdata/environ/environ.go"), "Serve"),
+		FromFile(file(t, "testdata/environ/environ.go"), "Status"))
+	parent.SelfUpdate = true
+	var b strings.Builder
+	if err := parent.Generate(&b); err != nil {
+		t.Fatalf("Generate(): unexpected error: %v", err)
+	}
+	got := b.String()
+	for _, want := range []string{
+		"// Code generated by cliche -types=Serve,Status -selfupdate; DO NOT EDIT.\n",
+		"\tif args[0] == \"selfupdate\" {\n\t\treturn cliche.SelfUpdate(ctx, stdio, args[1:])\n\t}\n",
+		`return cliche.Usagef("expected a command: one of %v", "serve, status, selfupdate")`,
+		`  selfupdate\tUpdate the program to its latest release.\n`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Generate(): code does not contain %q:\n%v", want, got)
+		}
+	}
+	// Only the root command updates the program.
+	if n := strings.Count(got, "cliche.SelfUpdate("); n != 1 {
+		t.Errorf("Generate(): selfupdate command generated %d times, want once:\n%v", n, got)
+	}
+}
+
+func TestGeneratePipes(t *testing.T) {
+	parent := NewParent("piped",
+		FromFile(file(t, "testdata/piped/piped.go"), "List"),
+		FromFile(file(t, "testdata/piped/piped.go"), "Count"))
+	parent.Pipes = true
+	var b strings.Builder
+	if err := parent.Generate(&b); err != nil {
+		t.Fatalf("Generate(): unexpected error: %v", err)
+	}
+	got := b.String()
+	for _, want := range []string{
+		"// Code generated by cliche -types=List,Count -pipes; DO NOT EDIT.\n",
+		"\tif cliche.Piped(args) {\n\t\treturn cliche.RunPipeline(ctx, stdio, RunPiped, args)\n\t}\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Generate(): code does not contain %q:\n%v", want, got)
+		}
+	}
+	// Only the root command splits pipelines.
+	if n := strings.Count(got, "cliche.RunPipeline("); n != 1 {
+		t.Errorf("Generate(): pipelines run %d times, want once:\n%v", n, got)
+	}
+}
+
+func TestGenerateTimeout(t *testing.T) {
+	parent := NewParent("timed",
+		FromFile(file(t, "testdata/timed/timed.go"), "Sleep"),
+		FromFile(file(t, "testdata/timed/timed.go"), "Wait"))
+	parent.Timeout = true
+	var b strings.Builder
+	if err := parent.Generate(&b); err != nil {
+		t.Fatalf("Generate(): unexpected error: %v", err)
+	}
+	got := b.String()
+	for _, want := range []string{
+		"// Code generated by cliche -types=Sleep,Wait -timeout; DO NOT EDIT.\n",
+		"\tvar timeout cliche.Timeout\n\ttimeout.RegisterFlags(fs)\n",
+		"\ttrace.Flags(fs, nil)\n\tctx = cliche.WithTimeout(ctx, timeout)\n",
+		"\treturn cliche.Recover(ctx, cliche.Timed(cmd, run))\n",
+		`Runtime flags:\n  -timeout duration\tStop the command after this duration; default is no limit.\n`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Generate(): code does not contain %q:\n%v", want, got)
+		}
+	}
+	// Only the root command takes the flag, and both subcommands are timed.
+	if n := strings.Count(got, "timeout.RegisterFlags(fs)"); n != 1 {
+		t.Errorf("Generate(): timeout flag registered %d times, want once:\n%v", n, got)
+	}
+	if n := strings.Count(got, "cliche.Timed(cmd, run)"); n != 2 {
+		t.Errorf("Generate(): %d commands timed, want 2:\n%v", n, got)
+	}
+}
+
+func TestGenerateSchedule(t *testing.T) {
+	parent := NewParent("timed",
+		FromFile(file(t, "testdata/timed/timed.go"), "Sleep"),
+		FromFile(file(t, "testdata/timed/timed.go"), "Wait"))
+	parent.Timeout, parent.Schedule = true, true
+	var b strings.Builder
+	if err := parent.Generate(&b); err != nil {
+		t.Fatalf("Generate(): unexpected error: %v", err)
+	}
+	got := b.String()
+	for _, want := range []string{
+		"// Code generated by cliche -types=Sleep,Wait -timeout -schedule; DO NOT EDIT.\n",
+		"\tvar schedule cliche.Schedule\n\tschedule.RegisterFlags(fs)\n",
+		"\tctx = cliche.WithTimeout(ctx, timeout)\n\tctx = cliche.WithSchedule(ctx, schedule)\n",
+		// Each run of the schedule is timed.
+		"\treturn cliche.Recover(ctx, cliche.Scheduled(cliche.Timed(cmd, run)))\n",
+		`  -every interval\tRun repeatedly, this interval apart, rather than once.\n`,
+		`  -keep-going\tWith -every, keep running after a run fails.\n`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Generate(): code does not contain %q:\n%v", want, got)
+		}
+	}
+	if n := strings.Count(got, "schedule.RegisterFlags(fs)"); n != 1 {
+		t.Errorf("Generate(): schedule flags registered %d times, want once:\n%v", n, got)
+	}
+}
+
+func TestGenerateInteractive(t *testing.T) {
+	cmd := FromFile(file(t, "testdata/form/form.go"), "Greet")
+	cmd.Interactive = true
+	var b strings.Builder
+	if err := cmd.Generate(&b); err != nil {
+		t.Fatalf("Generate(): unexpected error: %v", err)
+	}
+	got := b.String()
+	for _, want := range []string{
+		"// Code generated by cliche -type=Greet -interactive; DO NOT EDIT.\n",
+		"\tvar interactive bool\n\tfs.BoolVar(&interactive, \"interactive\", false, \"\")\n",
+		"\tif interactive {\n\t\terr := cliche.Form(stdio, fs, []cliche.FormField{\n" +
+			"\t\t\t{Flag: \"name\", Prompt: \"Name to greet.\", Required: true},\n" +
+			"\t\t\t{Flag: \"color\", Prompt: \"Color of the greeting.\", Choices: []string{\"red\", \"green\", \"blue\"}},\n" +
+			"\t\t\t{Flag: \"loud\", Prompt: \"Loud shouts the greeting.\", Bool: true},\n" +
+			"\t\t\t{Flag: \"token\", Prompt: \"Token to sign the greeting with.\", Secret: true},\n" +
+			"\t\t\t{Flag: \"times\", Prompt: \"Times to greet.\"},\n" +
+			"\t\t}, func(flag, value string) error {\n\t\t\tswitch flag {\n\t\t\tcase \"times\":\n\t\t\t\treturn cmd.CheckTimes(value)\n\t\t\t}\n\t\t\treturn nil\n\t\t})\n",
+		`Runtime flags:\n  -interactive\tAsk for the flags not given with a form.\n`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Generate(): code does not contain %q:\n%v", want, got)
+		}
+	}
+}
+
+func TestGenerateManifest(t *testing.T) {
+	cmd := FromFile(file(t, "testdata/form/form.go"), "Greet")
+	cmd.Manifest = true
+	var b strings.Builder
+	if err := cmd.Generate(&b); err != nil {
+		t.Fatalf("Generate(): unexpected error: %v", err)
+	}
+	got := b.String()
+	for _, want := range []string{
+		"// Code generated by cliche -type=Greet -manifest; DO NOT EDIT.\n",
+		"\tfs.String(cliche.ManifestFlag, \"\", \"\")\n",
+		"\tmanifest, err := cliche.DecodeManifest(stdio, fs, args, cmd, map[string][]string{\n\t\t\"Name\":    {\"name\"},\n",
+		"\tcliche.MarkGiven(fs, manifest)\n\targs = fs.Args()\n",
+		`Runtime flags:\n  -from-file file\tRead inputs from this JSON manifest, under the flags given.\n`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Generate(): code does not contain %q:\n%v", want, got)
+		}
+	}
+}
+
+func TestGenerateShowConfig(t *testing.T) {
+	cmd := FromFile(file(t, "testdata/form/form.go"), "Greet")
+	cmd.ShowConfig = true
+	var b strings.Builder
+	if err := cmd.Generate(&b); err != nil {
+		t.Fatalf("Generate(): unexpected error: %v", err)
+	}
+	got := b.String()
+	for _, want := range []string{
+		"// Code generated by cliche -type=Greet -showconfig; DO NOT EDIT.\n",
+		"\tvar showConfig bool\n\tfs.BoolVar(&showConfig, \"show-config\", false, \"\")\n",
+		"\tparsed := cliche.GivenFlags(fs)\n",
+		"\tif showConfig {\n\t\treturn cliche.ShowConfig(stdio, fs, parsed, nil, []cliche.ConfigInput{\n" +
+			"\t\t\t{Flags: []string{\"name\"}},\n",
+		"\t\t\t{Flags: []string{\"token\"}, Env: \"FORM_TOKEN\", Secret: true},\n",
+		`Runtime flags:\n  -show-config\tShow the value of each flag and where it comes from, rather than running.\n`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Generate(): code does not contain %q:\n%v", want, got)
+		}
+	}
+}
+
+func TestGenerateColor(t *testing.T) {
+	const flag = "\tcolor := cliche.ColorAuto\n\tfs.Func(\"color\", \"\", func(s string) (err error) {\n\t\tcolor, err = cliche.ParseColorMode(s)\n"
+	for tn, tc := range map[string]struct {
+		cmd  *Command
+		main bool
+		want bool
+	}{
+		"main":       {FromFile(file(t, "testdata/colored/colored.go"), "Paint"), true, true},
+		"library":    {FromFile(file(t, "testdata/colored/colored.go"), "Paint"), false, false},
+		"color flag": {FromFile(file(t, "testdata/form/form.go"), "Greet"), true, false},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			if tc.main {
+				tc.cmd.ImportPath, tc.cmd.OutputPackage = "example.com/"+tc.cmd.Package, "main"
+			}
+			var b strings.Builder
+			if err := tc.cmd.Generate(&b); err != nil {
+				t.Fatalf("Generate(): unexpected error: %v", err)
+			}
+			got := b.String()
+			if strings.Contains(got, flag) != tc.want || strings.Contains(got, "\tstdio = cliche.ColorIO(stdio, color)\n") != tc.want {
+				t.Errorf("Generate(): got code with a --color flag %v, want %v:\n%v", !tc.want, tc.want, got)
+			}
+		})
+	}
+}
+
+func TestGenerateParent(t *testing.T) {
+	parent := NewParent("suite",
+		FromFile(file(t, "testdata/suite/suite.go"), "Fetch"),
+		FromFile(file(t, "testdata/suite/suite.go"), "Push"))
+	parent.Default = "fetch"
+	var b strings.Builder
+	if err := parent.Generate(&b); err != nil {
+		t.Fatalf("Generate(): unexpected error: %v", err)
+	}
+	got := b.String()
+	if _, err := parser.ParseFile(token.NewFileSet(), "", got, 0); err != nil {
+		t.Fatalf("Generate(): code does not parse: %v\n%v", err, got)
+	}
+	for _, want := range []string{
+		"// Code generated by cliche -types=Fetch,Push; DO NOT EDIT.\n",
+		`"time"`,
+		"func RunSuite(ctx context.Context, stdio cliche.IO, args []string) error {",
+		`ctx = cliche.WithIO(cliche.WithCommand(ctx, "suite"), stdio)`,
+		`case "fetch":`,
+		"return RunFetch(ctx, stdio, args[1:])",
+		"if len(args) == 0 {\n\t\targs = []string{\"fetch\"}\n\t}",
+		"if args[0] == \"help\" {\n\t\tif len(args) == 1 {\n\t\t\tcliche.ShowHelp(stdio, nil, suiteHelp)\n\t\t\treturn flag.ErrHelp\n\t\t}\n\t\targs = append(args[1:], \"-h\")\n\t}",
+		"if cliche.HelpRequested(args) {\n\t\tcliche.ShowHelp(stdio, cmd, fetchHelp)\n\t\treturn flag.ErrHelp\n\t}",
+		`return cliche.Usagef("expected a command: one of %v", "fetch, push")`,
+		"func RunFetch(ctx context.Context, stdio cliche.IO, args []string) (err error) {",
+		"func RunPush(ctx context.Context, stdio cliche.IO, args []string) (err error) {",
+		`const suiteHelp = "Usage: suite [flags] command\n\nsuite is a test for cliche commands made of several types.\n\nCommands:\n  fetch\tFetch is a cliche command which fetches from a remote.\n  push\tPush is a cliche command which pushes to a remote.\n"`,
+		`const pushHelp = "Usage: suite push [flags]\n\nPush is a cliche command which pushes to a remote.\n\nFlags:\n  -force, -f\tForce the push.\n"`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Generate(): code does not contain %q:\n%v", want, got)
+		}
+	}
+	// Only the root command rewrites its arguments, before its subcommands
+	// are dispatched.
+	if n := strings.Count(got, "cliche.RewriteArgs(ctx, args)"); n != 1 {
+		t.Errorf("Generate(): arguments rewritten %d times, want once:\n%v", n, got)
+	}
+}
+
+func TestGenerateGlobals(t *testing.T) {
+	parent := NewParent("globals",
+		FromFile(file(t, "testdata/globals/globals.go"), "Build"),
+		FromFile(file(t, "testdata/globals/globals.go"), "Clean"))
+	var b strings.Builder
+	if err := parent.Generate(&b); err != nil {
+		t.Fatalf("Generate(): unexpected error: %v", err)
+	}
+	got := b.String()
+	if _, err := parser.ParseFile(token.NewFileSet(), "", got, 0); err != nil {
+		t.Fatalf("Generate(): code does not parse: %v\n%v", err, got)
+	}
+	for _, want := range []string{
+		"\tvar globals cliche.Globals\n" +
+			"\tglobals.CountVar(fs, \"Verbose logs more.\", \"verbose\", \"v\")\n" +
+			"\tglobals.Var(fs, \"Config file to read.\", \"config\")\n\n" +
+			"\targs = cliche.ExpandCountFlags(fs, args)\n",
+		`return RunBuild(ctx, stdio, append(globals.Args("verbose"), args[1:]...))`,
+		`return RunClean(ctx, stdio, append(globals.Args("verbose", "config"), args[1:]...))`,
+		`Global flags:\n  -verbose, -v\tVerbose logs more.\n  -config string\tConfig file to read. (default build.yaml)\n`,
+		"\tctx = cliche.WithDryRun(ctx, cmd.DryRun)\n" +
+			"\tctx = cliche.WithVerbosity(ctx, cliche.Verbosity(cmd.Verbose))\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Generate(): code does not contain %q:\n%v", want, got)
+		}
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		path, typ string
+		wantErr   string
+	}{
+		"invalid":    {"testdata/validators/validators.go", "Dialer", "has no validator method CheckMissing(string) error"},
+		"array flag": {"testdata/arrays/arrays.go", "Pairs", "array type [2]string can't be bound to a flag"},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			cmd := FromFile(file(t, tc.path), tc.typ)
+			if cmd == nil {
+				t.Fatal("FromFile(): got nil Command")
+			}
+			var b strings.Builder
+			err := cmd.Generate(&b)
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("Generate(): got error %v, want one containing %q", err, tc.wantErr)
+			}
+			if b.Len() != 0 {
+				t.Errorf("Generate(): wrote code despite error:\n%v", b.String())
+			}
+		})
+	}
+}
+
+func TestGenerateOutputPackage(t *testing.T) {
+	cmd := FromFile(file(t, "testdata/simple/simple.go"), "Tester")
+	if cmd == nil {
+		t.Fatal("FromFile(): got nil Command")
+	}
+	cmd.ImportPath, cmd.OutputPackage = "example.com/simple", "main"
+	var b strings.Builder
+	if err := cmd.Generate(&b); err != nil {
+		t.Fatalf("Generate(): unexpected error: %v", err)
+	}
+	for _, want := range []string{
+		"// Code generated by cliche -type=example.com/simple.Tester; DO NOT EDIT.",
+		"package main",
+		"\t\"example.com/simple\"\n",
+		"cmd := new(simple.Tester)",
+		"func main() {",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("Generate(): code lacks %q:\n%v", want, b.String())
+		}
+	}
+
+	for tn, tc := range map[string]struct {
+		change  func(cmd *Command)
+		wantErr string
+	}{
+		"main":          {func(cmd *Command) { cmd.Package = "main" }, "package main can't be imported by package main"},
+		"no import":     {func(cmd *Command) { cmd.ImportPath = "" }, "the import path of package simple is unknown"},
+		"type":          {func(cmd *Command) { cmd.Type = "tester" }, "type tester is unexported"},
+		"field":         {func(cmd *Command) { cmd.Inputs[0].FieldName = "string" }, "field string is unexported"},
+		"type of field": {func(cmd *Command) { cmd.Inputs[0].Type = "[]mode" }, "field String: type mode is unexported"},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			cmd := FromFile(file(t, "testdata/simple/simple.go"), "Tester")
+			cmd.ImportPath, cmd.OutputPackage = "example.com/simple", "main"
+			tc.change(cmd)
+			_, err := cmd.generation("", cmd.sourceName())
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("generation(): got error %v, want one containing %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestQualify(t *testing.T) {
+	for _, tc := range []struct {
+		typ, want string
+	}{
+		{"string", "string"},
+		{"Mode", "pkg.Mode"},
+		{"[]*Mode", "[]*pkg.Mode"},
+		{"map[string]Mode", "map[string]pkg.Mode"},
+		{"[Size]time.Duration", "[pkg.Size]time.Duration"},
+		{"func(mode Mode) error", "func(mode pkg.Mode) error"},
+		{"Set[Mode]", "pkg.Set[pkg.Mode]"},
+	} {
+		got, err := qualify(tc.typ, "pkg")
+		if err != nil || got != tc.want {
+			t.Errorf("qualify(%q): got %q, %v want %q", tc.typ, got, err, tc.want)
+		}
+	}
+	if got, err := qualify("map[string]mode", "pkg"); err == nil {
+		t.Errorf("qualify(): got %q, want an error for an unexported type", got)
+	}
+}
+
+func TestGenImportName(t *testing.T) {
+	for _, tc := range []struct {
+		imp  genImport
+		want string
+	}{
+		{genImport{Path: "net/http"}, "http"},
+		{genImport{Name: "yml", Path: "gopkg.in/yaml.v3"}, "yml"},
+		{genImport{Path: "gopkg.in/yaml.v3"}, "yaml"},
+		{genImport{Path: "example.com/mod/v2"}, "mod"},
+		{genImport{Path: "idontfixcomputers.com/cliche"}, "cliche"},
+	} {
+		if got := tc.imp.name(); got != tc.want {
+			t.Errorf("name(%v): got: %q want: %q", tc.imp.Path, got, tc.want)
+		}
+	}
+}
+
+func TestArgUsage(t *testing.T) {
+	var got []string
+	for _, input := range []CommandInput{
+		{FieldName: "Host", Tag: "arg:0", Type: "string"},
+		{FieldName: "Port", Tag: "arg:1;default:80", Type: "int"},
+		{FieldName: "Pair", Tag: "arg:2", Type: "[2]string", Arity: 2},
+		{FieldName: "Rest", Tag: "arg:[4:]", Type: "[]string"},
+		{FieldName: "Vars", Tag: "arg:[5:];pairs", Type: "map[string]string"},
+		{FieldName: "Flag", Tag: "flag:flag", Type: "string"},
+	} {
+		if usage, ok := argUsage(input); ok {
+			got = append(got, usage)
+		}
+	}
+	if diff := cmp.Diff(got, []string{"host", "[port]", "pair...", "[rest...]", "[vars...]"}); diff != "" {
+		t.Errorf("argUsage(): mismatch (-got,+want):\n%v", diff)
+	}
+}
+
+// TestGenerateBuilds generates the command of each testdata package into a
+// module of its own, alongside a copy of the package, and checks that the
+// module builds and vets cleanly with the go command.
+func TestGenerateBuilds(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping build of generated code in short mode")
+	}
+	gocmd, err := exec.LookPath("go")
+	if err != nil {
+		t.Skipf("go command not found: %v", err)
+	}
+	root, err := filepath.Abs("..")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mod := t.TempDir()
+	gomod := "module example.com/generated\n\ngo 1.22\n\n" +
+		"require (\n\t" + RuntimeImportPath + " v0.0.0\n\t" + PFlagImportPath + " v1.0.10\n)\n\n" +
+		"replace " + RuntimeImportPath + " => " + filepath.ToSlash(root) + "\n"
+	if err := os.WriteFile(filepath.Join(mod, "go.mod"), []byte(gomod), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	sum, err := os.ReadFile(filepath.Join(root, "go.sum"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(mod, "go.sum"), sum, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Packages whose commands are not expected to generate, such as arrays and
+	// validators, are covered by TestGenerateErrors instead. Tags are strict,
+	// but for those of lenient.
+	for _, tc := range []struct {
+		dir  string
+		opts Options
+	}{
+		{"choices", Options{Type: "Paint"}},
+		{"cleanup", Options{Type: "Tidy"}},
+		{"counted", Options{Type: "Sync"}},
+		{"fixed", Options{Type: "Move"}},
+		{"colored", Options{Type: "Paint"}},
+		{"custom", Options{Type: "Special"}},
+		{"docs", Options{Type: "Documented"}},
+		{"embedded", Options{Type: "Migrate"}},
+		{"environ", Options{Types: "Serve,Status", Env: true, SelfUpdate: true}},
+		{"excluded", Options{Type: "Partial"}},
+		{"form", Options{Type: "Greet", Interactive: true, Manifest: true, ShowConfig: true}},
+		{"globals", Options{Types: "Build,Clean"}},
+		{"hidden", Options{Type: "Serve"}},
+		{"inject", Options{Type: "Fetcher"}},
+		{"labels", Options{Type: "Deploy", SlicePolicy: "both"}},
+		{"lenient", Options{Type: "Greet", Strict: false}},
+		{"locked", Options{Type: "Purge"}},
+		{"multiname", Options{Type: "Endpoint"}},
+		{"negated", Options{Type: "Build"}},
+		{"pairs", Options{Type: "Set"}},
+		{"pflagged", Options{Type: "Deploy", PFlag: true}},
+		{"piped", Options{Types: "List,Count", Pipes: true}},
+		{"required", Options{Type: "Copy"}},
+		{"scoped", Options{Type: "Shadowed"}},
+		{"short", Options{Type: "Search"}},
+		{"simple", Options{Type: "Tester", ResponseFiles: true}},
+		{"split", Options{Type: "Divided"}},
+		{"strided", Options{Type: "Setenv"}},
+		{"suite", Options{Types: "Fetch,Push", Default: "fetch"}},
+		{"times", Options{Type: "Report"}},
+		{"timed", Options{Types: "Sleep,Wait", Timeout: true, Schedule: true}},
+		{"tree", Options{Type: "Tool", Abbreviate: true}},
+		{"value", Options{Type: "Valuable"}},
+		{"verbs", Options{Type: "Remote"}},
+	} {
+		if tc.dir != "lenient" {
+			tc.opts.Strict = true
+		}
+		src := filepath.Join("testdata", tc.dir)
+		cmd, err := tc.opts.Compile(src)
+		if err != nil {
+			t.Errorf("Compile(%v): unexpected error: %v", src, err)
+			continue
+		}
+		dst := filepath.Join(mod, tc.dir)
+		if err := os.Mkdir(dst, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		pkg, err := build.ImportDir(src, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, name := range pkg.GoFiles {
+			if strings.HasSuffix(name, "_cliche.go") {
+				continue
+			}
+			b, err := os.ReadFile(filepath.Join(src, name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(dst, name), b, 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		generate(t, cmd, filepath.Join(dst, "generated_cliche.go"))
+
+		// Each command generates into another package too, a main package
+		// importing the one declaring its types.
+		cmd, err = tc.opts.Compile(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cmd.ImportPath, cmd.OutputPackage = "example.com/generated/"+tc.dir, "main"
+		if err := os.Mkdir(dst+"main", 0o755); err != nil {
+			t.Fatal(err)
+		}
+		generate(t, cmd, filepath.Join(dst+"main", "generated_cliche.go"))
+	}
+
+	for _, args := range [][]string{{"build", "./..."}, {"vet", "./..."}} {
+		cmd := exec.Command(gocmd, args...)
+		cmd.Dir = mod
+		// The module's requirements are all in the module cache, or replaced
+		// by this one, so nothing need be downloaded.
+		cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "GOPROXY=off", "GOSUMDB=off", "GOWORK=off")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Errorf("go %v: %v\n%s", strings.Join(args, " "), err, out)
+		}
+	}
+	if t.Failed() {
+		return
+	}
+
+	// Some behavior is that of generated main packages, which are run with
+	// stdout and stderr piped, as tests of it.
+	bin := t.TempDir()
+	runMain := func(dir, stdin string, args ...string) (stdout, stderr string, code int) {
+		exe := filepath.Join(bin, dir)
+		if _, err := os.Stat(exe); err != nil {
+			build := exec.Command(gocmd, "build", "-o", exe, "./"+dir+"main")
+			build.Dir = mod
+			build.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "GOPROXY=off", "GOSUMDB=off", "GOWORK=off")
+			if out, err := build.CombinedOutput(); err != nil {
+				t.Fatalf("go build %v: %v\n%s", dir, err, out)
+			}
+		}
+		var out, errs strings.Builder
+		run := exec.Command(exe, args...)
+		run.Stdin, run.Stdout, run.Stderr = strings.NewReader(stdin), &out, &errs
+		err := run.Run()
+		if exit, ok := err.(*exec.ExitError); ok {
+			code = exit.ExitCode()
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		return out.String(), errs.String(), code
+	}
+	for _, tc := range []struct {
+		dir            string
+		args           []string
+		stdout, stderr string
+		code           int
+	}{
+		{"timed", []string{"--timeout=10ms", "sleep", "--for=1m"}, "", "timed: timed out after 10ms: context deadline exceeded\n", 1},
+		{"timed", []string{"--timeout=1m", "sleep", "--for=1ms"}, "done\n", "", 0},
+		{"timed", []string{"--timeout=10ms", "wait", "--for=50ms"}, "done\n", "", 0},
+		{"timed", []string{"sleep", "--timeout=10ms"}, "", "", 2},
+		{"timed", []string{"--every=1ms", "--until=1h", "--timeout=10ms", "sleep", "--for=1m"}, "", "timed: timed out after 10ms: context deadline exceeded\n", 1},
+		{"timed", []string{"--until=1h", "sleep"}, "", "timed: schedule has no interval\n", 1},
+		{"piped", []string{"list", "a", "b", "c", "|", "count"}, "3\n", "", 0},
+		{"piped", []string{"list", "a", "b", "|", "count", "|", "count"}, "", "piped: decoding stdin as NDJSON: document 1: json: cannot unmarshal number into Go value of type piped.Item\n", 1},
+		{"environ", []string{"selfupdate"}, "", "environ: no release source: injecting *cliche.Updater: no provider registered\n", 1},
+		{"colored", nil, "green\n", "", 0},
+		{"colored", []string{"--color=never"}, "green\n", "", 0},
+		{"colored", []string{"--color=always"}, "\x1b[32mgreen\x1b[0m\n", "", 0},
+		{"colored", []string{"--color=sometimes"}, "", "", 2},
+		{"form", []string{"--name=you"}, "hello you in red (loud=false, signed=false)\n", "", 0},
+		{"form", []string{"--interactive", "--name=you", "--color=blue", "--loud", "--token=x", "--times=2"}, "hello you in blue (loud=true, signed=true)\nhello you in blue (loud=true, signed=true)\n", "", 0},
+		{"form", []string{"--interactive", "--name=you"}, "Color of the greeting (-color)\n  1) red\n  2) green\n  3) blue\nChoose [red]: ", "", 2},
+	} {
+		stdout, stderr, code := runMain(tc.dir, "", tc.args...)
+		name := strings.Join(append([]string{tc.dir}, tc.args...), " ")
+		if code != tc.code {
+			t.Errorf("%v: got exit code %d, want %d; stderr:\n%v", name, code, tc.code, stderr)
+		}
+		if stdout != tc.stdout {
+			t.Errorf("%v: stdout mismatch: got: %q want: %q", name, stdout, tc.stdout)
+		}
+		// Usage errors are followed by usage, which is not checked.
+		if tc.code != 2 && stderr != tc.stderr {
+			t.Errorf("%v: stderr mismatch: got: %q want: %q", name, stderr, tc.stderr)
+		}
+	}
+	// Commands run on a schedule run until it ends.
+	if stdout, stderr, code := runMain("timed", "", "--every=1ms", "--until=200ms", "sleep", "--for=1ms"); code != 0 || strings.Count(stdout, "done\n") < 2 {
+		t.Errorf("timed --every=1ms --until=200ms sleep: got exit code %d and stdout %q, want 0 and several runs; stderr:\n%v", code, stdout, stderr)
+	}
+	// Bad flags are reported once, by main, after the usage of the command.
+	if _, stderr, code := runMain("timed", "", "sleep", "--bogus"); code != 2 || strings.Count(stderr, "flag provided but not defined") != 1 || !strings.HasSuffix(stderr, "\ntimed: flag provided but not defined: -bogus\n") {
+		t.Errorf("timed sleep --bogus: got exit code %d, want 2 and the error once; stderr:\n%v", code, stderr)
+	}
+	// Manifests are read under the flags given, and count as given.
+	manifest := filepath.Join(t.TempDir(), "greet.json")
+	if err := os.WriteFile(manifest, []byte(`{"Name": "world", "color": "green", "Times": 2}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if stdout, stderr, code := runMain("form", "", "--from-file="+manifest, "--times=1"); code != 0 || stdout != "hello world in green (loud=false, signed=false)\n" {
+		t.Errorf("form --from-file: got exit code %d and stdout %q, want 0 and a green greeting of world; stderr:\n%v", code, stdout, stderr)
+	}
+	if stdout, stderr, code := runMain("form", "\n\n", "--from-file", manifest, "--interactive"); code != 0 || !strings.HasPrefix(stdout, "Loud shouts") {
+		t.Errorf("form --from-file --interactive: got exit code %d and stdout %q, want 0 and only flags not in the manifest asked for; stderr:\n%v", code, stdout, stderr)
+	}
+	// Flags not given are asked for by forms, answered on stdin, and checked
+	// as they are answered.
+	stdout, stderr, code := runMain("form", "\nworld\n3\nyes\ns3cret\n0\n2\n", "--interactive")
+	want := "Name to greet (-name): Name to greet (-name): " +
+		"Color of the greeting (-color)\n  1) red\n  2) green\n  3) blue\nChoose [red]: " +
+		"Loud shouts the greeting (-loud) [y/N]: " +
+		"Token to sign the greeting with (-token): " +
+		"Times to greet (-times) [1]: Times to greet (-times) [1]: " +
+		"hello world in blue (loud=true, signed=true)\nhello world in blue (loud=true, signed=true)\n"
+	if code != 0 || stdout != want || stderr != "-name is required\ninvalid value for -times: must greet at least once\n" {
+		t.Errorf("form --interactive: got exit code %d and stdout %q, want 0 and %q; stderr:\n%v", code, stdout, want, stderr)
+	}
+	// Configuration is shown with the source of each value, and secrets
+	// redacted.
+	t.Setenv("FORM_TOKEN", "s3cret")
+	config := "FLAG    VALUE       SOURCE\n" +
+		"-name   world       config\n" +
+		"-color  green       config\n" +
+		"-loud   true        flag\n" +
+		"-token  (redacted)  env FORM_TOKEN\n" +
+		"-times  3           flag\n" +
+		"-v      0           default\n"
+	if stdout, stderr, code := runMain("form", "", "--from-file="+manifest, "--times=3", "--loud", "--show-config"); code != 0 || stdout != config {
+		t.Errorf("form --show-config: got exit code %d and stdout %q, want 0 and %q; stderr:\n%v", code, stdout, config, stderr)
+	}
+}
+
+// generate writes the code generated for cmd to the file at name.
+func generate(t *testing.T, cmd *Command, name string) {
+	t.Helper()
+	f, err := os.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Generate(f); err != nil {
+		t.Errorf("Generate(%v): unexpected error: %v", name, err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,288 @@
+package meta
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// helpPage is the content of the help of a command, from which it is rendered
+// for the terminal or as a man page.
+type helpPage struct {
+	// Path of the command, and Synopsis of its flags, subcommand and
+	// positional arguments.
+	Path, Synopsis string
+	// Docs are the doc comments of the command, rendered as text.
+	Docs      []string
+	Commands  []helpEntry
+	Arguments []helpEntry
+	Groups    []helpGroup
+}
+
+// helpEntry lists a verb or subcommand, positional argument or flag in help.
+type helpEntry struct {
+	// Term is the name of the entry. Value names the type of the value of a
+	// flag which takes one.
+	Term, Value string
+	// Doc is the first line of the entry's doc comment, noting whether a flag
+	// is required or has a default.
+	Doc string
+}
+
+// helpGroup lists the flags of a group under its Heading.
+type helpGroup struct {
+	Heading string
+	Flags   []helpEntry
+}
+
+// helpPage collects the help of the command: usage, its doc comments, wrapped
+// at width columns, its verbs or subcommands, positional arguments and flags.
+// Flags are listed by group, in the order given by InputGroups. The help of a
+// subcommand is that of parent, the path of its parent command, and leaves the
+// doc comment of the package to the parent.
+func (meta *Command) helpPage(parent string, width int) helpPage {
+	page := helpPage{Path: strings.TrimSpace(parent + " " + meta.Name), Synopsis: "[flags]"}
+	if len(meta.Verbs) > 0 || len(meta.Children) > 0 {
+		page.Synopsis += " command"
+	}
+	for _, input := range meta.Inputs {
+		if usage, ok := argUsage(input); ok {
+			page.Synopsis += " " + usage
+			tag, _ := ParseTag(string(input.Tag))
+			doc := strings.TrimSpace(firstLine(input.Doc) + choicesNote(tag.Choices))
+			page.Arguments = append(page.Arguments, helpEntry{Term: usage, Doc: doc})
+		}
+	}
+	docs := []string{meta.HelpText(width), meta.DescriptionText(width)}
+	if parent != "" {
+		docs = docs[1:]
+	}
+	for _, doc := range docs {
+		if doc != "" {
+			page.Docs = append(page.Docs, doc)
+		}
+	}
+	for _, verb := range meta.Verbs {
+		page.Commands = append(page.Commands, helpEntry{Term: verb.Name, Doc: firstLine(verb.Description)})
+	}
+	for _, child := range meta.Children {
+		// A command made of several types, as an index may run, is
+		// described by its package.
+		desc := child.Description
+		if desc == "" {
+			desc = child.Help
+		}
+		page.Commands = append(page.Commands, helpEntry{Term: child.Name, Doc: firstLine(desc)})
+	}
+	if meta.Env && parent == "" {
+		page.Commands = append(page.Commands, helpEntry{Term: "env", Doc: "List the environment variables the program reads."})
+	}
+	if meta.SelfUpdate && parent == "" {
+		page.Commands = append(page.Commands, helpEntry{Term: "selfupdate", Doc: "Update the program to its latest release."})
+	}
+	for _, group := range meta.InputGroups() {
+		hg := helpGroup{Heading: "Flags"}
+		if group.Name != "" {
+			hg.Heading = group.Name
+		}
+		for _, input := range group.Inputs {
+			if entry, ok := meta.flagEntry(input); ok {
+				hg.Flags = append(hg.Flags, entry)
+			}
+		}
+		if len(hg.Flags) > 0 {
+			page.Groups = append(page.Groups, hg)
+		}
+	}
+	// Globals are listed by the commands which accept them for their
+	// subcommands, as well as by those declaring them.
+	globals, _ := meta.liftedGlobals()
+	hg := helpGroup{Heading: "Global flags"}
+	for _, input := range globals {
+		if entry, ok := meta.flagEntry(input); ok {
+			hg.Flags = append(hg.Flags, entry)
+		}
+	}
+	if len(hg.Flags) > 0 {
+		page.Groups = append(page.Groups, hg)
+	}
+	hg = helpGroup{Heading: "Runtime flags"}
+	if meta.colorFlag(parent) {
+		hg.Flags = append(hg.Flags, helpEntry{Term: "-color", Value: "mode", Doc: "Color output: auto, always or never; default auto."})
+	}
+	for _, f := range meta.runtimeFlags() {
+		hg.Flags = append(hg.Flags, helpEntry{Term: "-" + f.Name, Value: f.Value, Doc: f.Doc})
+	}
+	if len(hg.Flags) > 0 {
+		page.Groups = append(page.Groups, hg)
+	}
+	return page
+}
+
+// flagEntry lists input in help, unless it is not a flag or is hidden.
+func (meta *Command) flagEntry(input CommandInput) (helpEntry, bool) {
+	tag, _ := ParseTag(string(input.Tag))
+	if tag.Arg != nil || tag.Inject || tag.Stdin || tag.Hidden {
+		return helpEntry{}, false
+	}
+	names := FlagNames(input, tag)
+	if negated := NegatedName(input, tag); negated != "" {
+		names = append(names, negated)
+	}
+	entry := helpEntry{Term: "-" + strings.Join(names, ", -")}
+	if input.Type != "bool" && !tag.Count {
+		entry.Value = input.Type
+	}
+	usage := firstLine(input.Doc) + choicesNote(tag.Choices) + meta.sliceNote(input, tag) + deprecationNote(tag) + envNote(tag)
+	switch {
+	case tag.Required:
+		usage += " (required)"
+	case tag.Default != "":
+		usage += " (default " + tag.Default + ")"
+	}
+	entry.Doc = strings.TrimSpace(usage)
+	return entry, true
+}
+
+// helpText renders the generated help of the command, which is a subcommand
+// of the command at path parent, if any, for the terminal.
+func (meta *Command) helpText(parent string) string {
+	page := meta.helpPage(parent, 80)
+	var b strings.Builder
+	fmt.Fprintf(&b, "Usage: %v %v\n", page.Path, page.Synopsis)
+	for _, doc := range page.Docs {
+		b.WriteString("\n" + doc + "\n")
+	}
+	if len(page.Commands) > 0 {
+		b.WriteString("\nCommands:\n")
+		for _, entry := range page.Commands {
+			fmt.Fprintf(&b, "  %v\t%v\n", entry.Term, entry.Doc)
+		}
+	}
+	if len(page.Arguments) > 0 {
+		b.WriteString("\nArguments:\n")
+		for _, entry := range page.Arguments {
+			fmt.Fprintf(&b, "  %v\t%v\n", entry.Term, entry.Doc)
+		}
+	}
+	for _, group := range page.Groups {
+		b.WriteString("\n" + group.Heading + ":\n")
+		for _, entry := range group.Flags {
+			b.WriteString("  " + entry.Term)
+			if entry.Value != "" {
+				b.WriteString(" " + entry.Value)
+			}
+			if entry.Doc != "" {
+				b.WriteString("\t" + entry.Doc)
+			}
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// manPage renders the generated help of the command, which is a subcommand of
+// the command at path parent, if any, as a man page in section 1.
+func (meta *Command) manPage(parent string) string {
+	page := meta.helpPage(parent, -1)
+	title := strings.ReplaceAll(page.Path, " ", "-")
+	var b strings.Builder
+	fmt.Fprintf(&b, ".TH %v 1\n", strings.ToUpper(roffTerm(title)))
+	b.WriteString(".SH NAME\n" + roffTerm(title))
+	if desc := firstLine(meta.Description); desc != "" {
+		b.WriteString(` \- ` + roffText(desc))
+	}
+	fmt.Fprintf(&b, "\n.SH SYNOPSIS\n.B %v\n%v\n", roffTerm(page.Path), roffText(page.Synopsis))
+	if len(page.Docs) > 0 {
+		b.WriteString(".SH DESCRIPTION\n")
+		for i, doc := range page.Docs {
+			for j, para := range strings.Split(doc, "\n\n") {
+				if i > 0 || j > 0 {
+					b.WriteString(".PP\n")
+				}
+				b.WriteString(roffText(para) + "\n")
+			}
+		}
+	}
+	manEntries(&b, ".SH COMMANDS", page.Commands)
+	manEntries(&b, ".SH ARGUMENTS", page.Arguments)
+	for i, group := range page.Groups {
+		heading := ".SH OPTIONS"
+		if i > 0 || group.Heading != "Flags" {
+			if i == 0 {
+				b.WriteString(".SH OPTIONS\n")
+			}
+			heading = ".SS " + roffText(group.Heading)
+		}
+		manEntries(&b, heading, group.Flags)
+	}
+	return b.String()
+}
+
+// manEntries writes entries to b as a list of tagged paragraphs, following
+// the heading, unless there are none.
+func manEntries(b *strings.Builder, heading string, entries []helpEntry) {
+	if len(entries) == 0 {
+		return
+	}
+	b.WriteString(heading + "\n")
+	for _, entry := range entries {
+		b.WriteString(".TP\n" + `\fB` + roffTerm(entry.Term) + `\fR`)
+		if entry.Value != "" {
+			b.WriteString(` \fI` + roffText(entry.Value) + `\fR`)
+		}
+		b.WriteString("\n" + roffText(entry.Doc) + "\n")
+	}
+}
+
+// roffText escapes s as text of a man page: its backslashes, and the periods
+// and apostrophes which would otherwise begin a request at the start of a
+// line.
+func roffText(s string) string {
+	lines := strings.Split(strings.ReplaceAll(s, `\`, `\e`), "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'") {
+			lines[i] = `\&` + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// roffTerm escapes s as roffText does, and its hyphens as the minus signs of
+// flags and command names.
+func roffTerm(s string) string {
+	return strings.ReplaceAll(roffText(s), "-", `\-`)
+}
+
+// WriteHelp writes to w the help which the generated command, and each of its
+// subcommands in turn, shows when asked with -help. It lets the authors of
+// commands preview their help without generating any code.
+func (meta *Command) WriteHelp(w io.Writer) error {
+	return meta.writePages(w, "", "\n", (*Command).helpText)
+}
+
+// WriteManPages writes to w a man page in section 1 for the command, and one
+// for each of its subcommands in turn, rendered from the same content as its
+// help.
+func (meta *Command) WriteManPages(w io.Writer) error {
+	return meta.writePages(w, "", "", (*Command).manPage)
+}
+
+// writePages writes to w the page rendered by render for the command, which
+// is a subcommand of the command at path parent, if any, then those of its
+// subcommands, each preceded by sep.
+func (meta *Command) writePages(w io.Writer, parent, sep string, render func(*Command, string) string) error {
+	if _, err := io.WriteString(w, render(meta, parent)); err != nil {
+		return err
+	}
+	path := strings.TrimSpace(parent + " " + meta.Name)
+	for _, child := range meta.Children {
+		if _, err := io.WriteString(w, sep); err != nil {
+			return err
+		}
+		if err := child.writePages(w, path, sep, render); err != nil {
+			return err
+		}
+	}
+	return nil
+}
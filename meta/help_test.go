@@ -0,0 +1,107 @@
+package meta
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestHelpText(t *testing.T) {
+	cmd := FromFile(file(t, "testdata/embedded/embedded.go"), "Migrate")
+	if cmd == nil {
+		t.Fatal("FromFile(): got nil Command")
+	}
+	want := `Usage: embedded [flags]
+
+embedded is a test for cliche commands which embed shared options.
+
+Migrate is a cliche command which embeds shared options.
+
+Flags:
+  -dry-run	Dry run only.
+  -level string	Level of logs.
+
+Database:
+  -db-host string	Host to connect to.
+  -db-verbose	Verbose connection logging.
+`
+	if diff := cmp.Diff(cmd.helpText(""), want); diff != "" {
+		t.Errorf("helpText(): mismatch (-got,+want):\n%v", diff)
+	}
+}
+
+func TestManPage(t *testing.T) {
+	cmd := FromFile(file(t, "testdata/embedded/embedded.go"), "Migrate")
+	if cmd == nil {
+		t.Fatal("FromFile(): got nil Command")
+	}
+	want := `.TH EMBEDDED 1
+.SH NAME
+embedded \- Migrate is a cliche command which embeds shared options.
+.SH SYNOPSIS
+.B embedded
+[flags]
+.SH DESCRIPTION
+embedded is a test for cliche commands which embed shared options.
+.PP
+Migrate is a cliche command which embeds shared options.
+.SH OPTIONS
+.TP
+\fB\-dry\-run\fR
+Dry run only.
+.TP
+\fB\-level\fR \fIstring\fR
+Level of logs.
+.SS Database
+.TP
+\fB\-db\-host\fR \fIstring\fR
+Host to connect to.
+.TP
+\fB\-db\-verbose\fR
+Verbose connection logging.
+`
+	if diff := cmp.Diff(cmd.manPage(""), want); diff != "" {
+		t.Errorf("manPage(): mismatch (-got,+want):\n%v", diff)
+	}
+}
+
+func TestRoffText(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		in, want string
+	}{
+		"plain":      {"Plain text.", "Plain text."},
+		"backslash":  {`C:\dir`, `C:\edir`},
+		"request":    {"first\n.second\n'third", "first\n\\&.second\n\\&'third"},
+		"mid period": {"a.b", "a.b"},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			if got := roffText(tc.in); got != tc.want {
+				t.Errorf("roffText(%q): got: %q want: %q", tc.in, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestWriteHelp(t *testing.T) {
+	parent := NewParent("suite",
+		FromFile(file(t, "testdata/suite/suite.go"), "Fetch"),
+		FromFile(file(t, "testdata/suite/suite.go"), "Push"))
+	var help, man strings.Builder
+	if err := parent.WriteHelp(&help); err != nil {
+		t.Fatalf("WriteHelp(): unexpected error: %v", err)
+	}
+	want := parent.helpText("") + "\n" + parent.Children[0].helpText("suite") + "\n" + parent.Children[1].helpText("suite")
+	if diff := cmp.Diff(help.String(), want); diff != "" {
+		t.Errorf("WriteHelp(): mismatch (-got,+want):\n%v", diff)
+	}
+	if err := parent.WriteManPages(&man); err != nil {
+		t.Fatalf("WriteManPages(): unexpected error: %v", err)
+	}
+	if got := strings.Count(man.String(), ".TH "); got != 3 {
+		t.Errorf("WriteManPages(): got %v pages, want 3:\n%v", got, man.String())
+	}
+	if !strings.Contains(man.String(), ".TH SUITE\\-PUSH 1\n") {
+		t.Errorf("WriteManPages(): missing page of suite push:\n%v", man.String())
+	}
+}
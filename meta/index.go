@@ -0,0 +1,174 @@
+package meta
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"go/build"
+	"go/token"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// NewIndex creates a Command named name, to be generated in package pkg, which
+// runs each of cmds as a subcommand by its name. The cmds are those found by
+// ScanModule, which are run from the packages in which they are generated.
+func NewIndex(name, pkg string, cmds ...*Command) *Command {
+	return &Command{Name: name, Package: pkg, Children: cmds}
+}
+
+// ScanModule finds the commands generated by the cliche go:generate directives
+// in the packages of the module rooted at dir, compiled as the directives would
+// compile them, and each with its ImportPath set: that of the package into
+// which it is generated, which for commands generated with -internal is the
+// package of their own beneath InternalDir. The go command only lets an index
+// beneath the directory holding that import it. Packages main, whose commands
+// can't be imported, are skipped, as are nested modules, and directories which
+// the go command ignores: testdata, vendor, and those beginning with . or _.
+func ScanModule(dir string) ([]*Command, error) {
+	mod, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return nil, err
+	}
+	modPath := modulePath(mod)
+	if modPath == "" {
+		return nil, fmt.Errorf("%v: no module path", filepath.Join(dir, "go.mod"))
+	}
+
+	var cmds []*Command
+	err = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return err
+		}
+		if p != dir {
+			if name := d.Name(); name == "testdata" || name == "vendor" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") {
+				return filepath.SkipDir
+			}
+			if _, err := os.Stat(filepath.Join(p, "go.mod")); err == nil {
+				return filepath.SkipDir
+			}
+		}
+		pkg, err := build.ImportDir(p, 0)
+		if err != nil {
+			var noGo *build.NoGoError
+			if !errors.As(err, &noGo) {
+				slog.Warn("Failed finding package files", slog.String("dir", p), slog.Any("error", err))
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		importPath := path.Join(modPath, filepath.ToSlash(rel))
+		for _, name := range pkg.GoFiles {
+			file := filepath.Join(p, name)
+			directives, err := generateDirectives(file)
+			if err != nil {
+				return err
+			}
+			if len(directives) > 0 && pkg.Name == "main" {
+				slog.Info("Skipping commands of package main, which can't be imported", slog.String("file", file))
+				break
+			}
+			for _, d := range directives {
+				cmd, opts, err := fromDirective(p, d.args)
+				if err != nil {
+					return fmt.Errorf("%v: %w", file, err)
+				}
+				cmd.ImportPath = importPath
+				if opts.Internal {
+					cmd.ImportPath = path.Join(importPath, InternalDir, cmd.OutputPackage)
+				}
+				cmds = append(cmds, cmd)
+			}
+		}
+		return nil
+	})
+	return cmds, err
+}
+
+// modulePath returns the path of the module declared by the contents of its
+// go.mod file, or the empty string when there is none.
+func modulePath(mod []byte) string {
+	for _, line := range strings.Split(string(mod), "\n") {
+		rest, ok := strings.CutPrefix(strings.TrimSpace(line), "module")
+		if !ok || rest == "" || (rest[0] != ' ' && rest[0] != '\t') {
+			continue
+		}
+		rest, _, _ = strings.Cut(rest, "//")
+		rest = strings.TrimSpace(rest)
+		if p, err := strconv.Unquote(rest); err == nil {
+			return p
+		}
+		return rest
+	}
+	return ""
+}
+
+// directive is a go:generate directive running cliche to generate a command.
+type directive struct {
+	pos token.Position
+	// args given to cliche.
+	args []string
+}
+
+// generateDirectives returns each of the go:generate directives of the file at
+// p which generate a command, as opposed to running one of cliche's
+// subcommands.
+func generateDirectives(p string) ([]directive, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var directives []directive
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line, ok := strings.CutPrefix(scanner.Text(), "//go:generate ")
+		if !ok {
+			continue
+		}
+		words := strings.Fields(line)
+		for i, word := range words {
+			if path.Base(word) != "cliche" {
+				continue
+			}
+			if args := words[i+1:]; len(args) > 0 && strings.HasPrefix(args[0], "-") {
+				directives = append(directives, directive{token.Position{Filename: p, Line: n, Column: 1}, args})
+			}
+			break
+		}
+	}
+	return directives, scanner.Err()
+}
+
+// fromDirective compiles the command which cliche, given args by a
+// go:generate directive in the package in directory dir, generates, and
+// returns it with the options which the args set. The types are found
+// anywhere in the package, even when the directive names one file.
+func fromDirective(dir string, args []string) (*Command, Options, error) {
+	var opts Options
+	fs := flag.NewFlagSet("cliche", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	opts.RegisterFlags(fs)
+	// The verbosity of cliche itself is of no consequence to the command.
+	for _, verbosity := range []string{"q", "quiet", "v", "verbose"} {
+		fs.Bool(verbosity, false, "")
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, opts, fmt.Errorf("go:generate cliche %v: %w", strings.Join(args, " "), err)
+	}
+	cmd, err := opts.Compile(dir)
+	if err != nil {
+		return nil, opts, fmt.Errorf("go:generate cliche %v: %w", strings.Join(args, " "), err)
+	}
+	return cmd, opts, nil
+}
@@ -0,0 +1,95 @@
+package meta
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestScanModule(t *testing.T) {
+	cmds, err := ScanModule("testdata/index")
+	if err != nil {
+		t.Fatalf("ScanModule(): unexpected error: %v", err)
+	}
+	type found struct {
+		Name, Package, ImportPath, Default string
+		Children                           int
+	}
+	var got []found
+	for _, cmd := range cmds {
+		got = append(got, found{cmd.Name, cmd.Package, cmd.ImportPath, cmd.Default, len(cmd.Children)})
+	}
+	want := []found{
+		{"greet", "greet", "example.com/index/greet", "", 0},
+		{"quiet", "quiet", "example.com/index/quiet/internal/clichegen/quiet", "", 0},
+		{"updown", "suite", "example.com/index/suite", "up", 2},
+		{"wave", "greet", "example.com/index/tools/greet", "", 0},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("ScanModule(): mismatch (-got,+want):\n%v", diff)
+	}
+
+	if _, err := ScanModule("testdata"); err == nil {
+		t.Error("ScanModule(): wanted error for directory without go.mod, got nil")
+	}
+}
+
+func TestGenerateIndex(t *testing.T) {
+	cmds, err := ScanModule("testdata/index")
+	if err != nil {
+		t.Fatalf("ScanModule(): unexpected error: %v", err)
+	}
+	idx := NewIndex("tool", "main", cmds...)
+	var b strings.Builder
+	if err := idx.Generate(&b); err != nil {
+		t.Fatalf("Generate(): unexpected error: %v", err)
+	}
+	got := b.String()
+	if _, err := parser.ParseFile(token.NewFileSet(), "", got, 0); err != nil {
+		t.Fatalf("Generate(): code does not parse: %v\n%v", err, got)
+	}
+	for _, want := range []string{
+		"// Code generated by cliche index; DO NOT EDIT.\n",
+		"\t\"example.com/index/greet\"\n",
+		"\t\"example.com/index/quiet/internal/clichegen/quiet\"\n",
+		"\t\"example.com/index/suite\"\n",
+		"\tgreet2 \"example.com/index/tools/greet\"\n",
+		"func RunTool(ctx context.Context, stdio cliche.IO, args []string) error {",
+		"return greet.RunGreet(ctx, stdio, args[1:])",
+		"return quiet.RunHush(ctx, stdio, args[1:])",
+		"return suite.RunUpdown(ctx, stdio, args[1:])",
+		"return greet2.RunWave(ctx, stdio, args[1:])",
+		`\n  greet\tGreet greets someone.\n  quiet\tHush hushes someone.\n  updown\tsuite is a test for cliche indexes of commands made of several types.\n  wave\tWave waves at someone.\n`,
+		"func main() {",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Generate(): code does not contain %q:\n%v", want, got)
+		}
+	}
+	for _, unwanted := range []string{"func RunGreet(", "func RunUp("} {
+		if strings.Contains(got, unwanted) {
+			t.Errorf("Generate(): code generated in another package contains %q:\n%v", unwanted, got)
+		}
+	}
+}
+
+func TestModulePath(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		mod, want string
+	}{
+		"plain":     {"module example.com/mod\n\ngo 1.20\n", "example.com/mod"},
+		"quoted":    {"// comment\nmodule \"example.com/mod\"\n", "example.com/mod"},
+		"commented": {"module example.com/mod // the module\n", "example.com/mod"},
+		"missing":   {"go 1.20\n", ""},
+		"modulex":   {"modulex example.com/mod\n", ""},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			if got := modulePath([]byte(tc.mod)); got != tc.want {
+				t.Errorf("modulePath(%q): got: %q want: %q", tc.mod, got, tc.want)
+			}
+		})
+	}
+}
@@ -5,17 +5,23 @@ package meta
 import (
 	"fmt"
 	"go/ast"
+	"go/build"
 	"go/doc"
+	"go/doc/comment"
 	"go/parser"
 	"go/token"
+	"go/types"
 	"io"
 	"log/slog"
+	"os"
+	"path/filepath"
 	"reflect"
-	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/iancoleman/strcase"
+	"golang.org/x/tools/go/packages"
 )
 
 // CommandInput contains details about how a Command's inputs should be
@@ -25,6 +31,29 @@ type CommandInput struct {
 	Tag       Tag
 	Doc       string
 	Type      string
+
+	// Arity is the exact number of positional arguments consumed by the input,
+	// when it is fixed by the input's type, as for arrays. Zero otherwise.
+	Arity int
+
+	// Validator is the name of the method on the command type which validates
+	// the input's value, with the signature func(string) error. It is named
+	// by the validate tag component, or by convention as ValidateArgField for
+	// positional arguments.
+	Validator string
+
+	// Allocate lists the embedded pointer fields on the path to an input of
+	// an embedded struct, outermost first, such as Logging for Logging.Level
+	// when Logging is embedded as *Logging. They must be allocated before the
+	// input is set.
+	Allocate []string
+
+	// Pos is the position in the source of the field declaring the input.
+	Pos token.Position
+
+	// TagPos is the position in the source of the field's struct tag. It is
+	// not valid when the field has no tag.
+	TagPos token.Position
 }
 
 // Command compiles details about how a type should be wrapped for cliche from
@@ -38,9 +67,24 @@ type Command struct {
 	// Package name from which the  Command is sourced.
 	Package string
 
+	// ImportPath of the package, set when the Command is run from another
+	// package, as the subcommands of an index found by ScanModule are, or
+	// generated into one.
+	ImportPath string
+
+	// OutputPackage is the name of the package into which the command is
+	// generated, when that is not Package, the package declaring its types.
+	// The generated code then imports that package from ImportPath, and can
+	// only set the exported fields of its types.
+	OutputPackage string
+
 	// Type name of the  Command implementation.
 	Type string
 
+	// PointerReceiver is true when the Run method of Type is declared on a
+	// pointer receiver, meaning it must be invoked through a *Type.
+	PointerReceiver bool
+
 	// Help output for the  Command. This will be displayed along with usage
 	// information on the command line. By default, sourced from doc comment for
 	// the package in which the wrapped Command will live.
@@ -50,101 +94,608 @@ type Command struct {
 	// default, sourced from the doc comment on the wrapped  Command type.
 	Description string
 
+	// Closer is true when Type has a Close() error method, which should be
+	// called once Run returns.
+	Closer bool
+
+	// Shutdowner is true when Type has a Shutdown(ctx context.Context) error
+	// method, which should be called once Run returns.
+	Shutdowner bool
+
+	// Verbs are sibling subcommands implemented by RunVerb methods on Type.
+	// They share the inputs of the command.
+	Verbs []Verb
+
 	// Inputs describe the handling of struct fields on the wrapped Command
 	// implementation as inputs on the command line. The inputs are derived from
 	// struct tags, when set.
 	Inputs []CommandInput
 
-	typ string
+	// Children are subcommands, each wrapping its own type, which are
+	// dispatched by the first positional argument. They are declared by
+	// fields tagged subcommand, or given to NewParent, in which case the
+	// command has no Type of its own.
+	Children []*Command
+
+	// Field is the input of the parent command whose field declares the
+	// command as its subcommand, which the parent sets to the command it
+	// runs. It is nil for commands which are not declared by fields, such as
+	// those given to NewParent.
+	Field *CommandInput
+
+	// Default names the verb or subcommand run when none is named, by a
+	// command which can't run itself. Without one, help is shown instead.
+	Default string
+
+	// PFlag is true when functions binding the flags of the command and its
+	// subcommands to a pflag.FlagSet are generated too, for programs built
+	// on github.com/spf13/pflag to embed them.
+	PFlag bool
+
+	// ResponseFiles is true when the command expands arguments of the form
+	// @file into the arguments held by the file, as cliche.ExpandResponseFiles
+	// does, before parsing any of them.
+	ResponseFiles bool
+
+	// Abbreviate is true when the command and its subcommands accept unique
+	// prefixes of the long names of their flags, and of the names of their
+	// verbs and subcommands, as cliche.ExpandAbbreviations does. Commands
+	// which take positional arguments of their own take them as given.
+	Abbreviate bool
+
+	// Env is true when the command has an env subcommand too, which lists
+	// the environment variables read by the program: those bound to the
+	// flags of the command and its subcommands, and those read by cliche
+	// itself, with the flag each sets, where its value comes from and its
+	// default.
+	Env bool
+
+	// SelfUpdate is true when the command has a selfupdate subcommand too,
+	// which updates the program to its latest release, as cliche.SelfUpdate
+	// does.
+	SelfUpdate bool
+
+	// Pipes is true when the command runs pipelines of its subcommands in
+	// one process, as cliche.RunPipeline does, when its arguments are
+	// separated by cliche.PipeSeparator.
+	Pipes bool
+
+	// Timeout is true when the command takes a --timeout flag, bounding how
+	// long whichever of it and its subcommands runs, as cliche.RunTimed
+	// does. Commands implementing cliche.Untimed are not bound.
+	Timeout bool
+
+	// Schedule is true when the command takes --every, --jitter, --until and
+	// --keep-going flags, running whichever of it and its subcommands runs
+	// repeatedly on that schedule, as cliche.RunScheduled does.
+	Schedule bool
+
+	// Interactive is true when the command takes an --interactive flag,
+	// asking for each of its flags not given on the command line with a
+	// form, as cliche.Form does. Options.Compile sets it for every command of
+	// the tree.
+	Interactive bool
+
+	// Manifest is true when the command takes a --from-file flag, naming a
+	// JSON manifest decoded into it before its flags are parsed, as
+	// cliche.DecodeManifest does. Options.Compile sets it for every command
+	// of the tree.
+	Manifest bool
+
+	// ShowConfig is true when the command takes a --show-config flag, showing
+	// the final value of each of its flags and where it comes from, rather
+	// than running, as cliche.ShowConfig does. Options.Compile sets it for
+	// every command of the tree.
+	ShowConfig bool
+
+	// SlicePolicy names the cliche.SlicePolicy by which the flags of the
+	// command bound to slices take their values: repeat, as by default,
+	// split or both. Options.Compile sets it for every command of the tree.
+	SlicePolicy string
+
+	// Lenient is true when components of the struct tags of the command and
+	// its subcommands which are not part of the cliche tag grammar, which
+	// are usually typos, are reported by Warnings rather than Validate.
+	Lenient bool
+
+	// Pos is the position in the source of the declaration of Type.
+	Pos token.Position
+
+	typ  string
+	fset *token.FileSet
+
+	// compiled is set once the declaration of typ has been compiled.
+	compiled bool
+
+	// structs declared in the package, by name, from which embedded fields
+	// are flattened.
+	structs map[string]*ast.StructType
+
+	// runnable is true when the type has a Run method, rather than only verbs.
+	runnable bool
+
+	// imports of the package's files, by path, with the name under which
+	// each is imported, or the empty string for its default name.
+	imports map[string]string
+
+	// Parsed forms of the Help and Description doc comments, retained so that
+	// they may be rendered for outputs other than the terminal.
+	help, description *comment.Doc
+	printer           *comment.Printer
+}
+
+// renderText renders a parsed doc comment as plain text, with lines wrapped at
+// width. A negative width disables wrapping, so each paragraph occupies a
+// single line.
+func renderText(p *comment.Printer, d *comment.Doc, width int) string {
+	if d == nil {
+		return ""
+	}
+	if p == nil {
+		p = &comment.Printer{}
+	}
+	pp := *p
+	pp.TextWidth = width
+	return strings.TrimSpace(string(pp.Text(d)))
+}
+
+// renderMarkdown renders a parsed doc comment as Markdown. Headings are
+// rendered without the IDs which pkg.go.dev links to, such as {#hdr-Usage},
+// which most Markdown renderers would show as they are.
+func renderMarkdown(p *comment.Printer, d *comment.Doc) string {
+	if d == nil {
+		return ""
+	}
+	if p == nil {
+		p = &comment.Printer{}
+	}
+	pp := *p
+	pp.HeadingID = func(*comment.Heading) string { return "" }
+	return strings.TrimSpace(string(pp.Markdown(d)))
+}
+
+// HelpText renders the Help for display on a terminal, wrapping lines at width
+// columns. Lists, headings and code blocks in the doc comment are preserved. A
+// negative width disables wrapping.
+func (meta *Command) HelpText(width int) string {
+	if meta == nil {
+		return ""
+	}
+	return renderText(meta.printer, meta.help, width)
+}
+
+// HelpMarkdown renders the Help as Markdown.
+func (meta *Command) HelpMarkdown() string {
+	if meta == nil {
+		return ""
+	}
+	return renderMarkdown(meta.printer, meta.help)
+}
+
+// DescriptionText renders the Description for display on a terminal, wrapping
+// lines at width columns. A negative width disables wrapping.
+func (meta *Command) DescriptionText(width int) string {
+	if meta == nil {
+		return ""
+	}
+	return renderText(meta.printer, meta.description, width)
+}
+
+// DescriptionMarkdown renders the Description as Markdown.
+func (meta *Command) DescriptionMarkdown() string {
+	if meta == nil {
+		return ""
+	}
+	return renderMarkdown(meta.printer, meta.description)
+}
+
+// Verb describes a subcommand implemented by a method named like RunVerb on a
+// command type. For example, a RunPush method is exposed as "app push".
+type Verb struct {
+	// Name of the subcommand on the command line, in kebab-case.
+	Name string
+
+	// Method on the command type which implements the subcommand.
+	Method string
+
+	// PointerReceiver is true when Method is declared on a pointer receiver.
+	PointerReceiver bool
+
+	// Description of the subcommand, sourced from the method's doc comment.
+	Description string
+}
+
+// InputGroup is a named set of inputs which are listed together under a
+// heading in help output.
+type InputGroup struct {
+	// Name of the group, as set with the group tag component. Empty for inputs
+	// which are not explicitly grouped.
+	Name   string
+	Inputs []CommandInput
+}
+
+// InputGroups partitions the command's Inputs by group, in the order in which
+// they are listed in help. Inputs are ordered by the weight of their order tag
+// component, lowest first, and groups by the lowest weight among their inputs.
+// Otherwise, inputs without a group come first, followed by each named group in
+// order of first appearance, and declaration order is preserved within each
+// group.
+func (meta *Command) InputGroups() []InputGroup {
+	if meta == nil || len(meta.Inputs) == 0 {
+		return nil
+	}
+	groups := []InputGroup{{}}
+	index := map[string]int{"": 0}
+	for _, input := range meta.Inputs {
+		name, _ := input.Tag.Group()
+		i, ok := index[name]
+		if !ok {
+			i = len(groups)
+			index[name] = i
+			groups = append(groups, InputGroup{Name: name})
+		}
+		groups[i].Inputs = append(groups[i].Inputs, input)
+	}
+	if len(groups[0].Inputs) == 0 {
+		groups = groups[1:]
+	}
+
+	weight := func(input CommandInput) int {
+		w, _ := input.Tag.Order()
+		return w
+	}
+	lightest := make(map[string]int)
+	for _, group := range groups {
+		sort.SliceStable(group.Inputs, func(i, j int) bool {
+			return weight(group.Inputs[i]) < weight(group.Inputs[j])
+		})
+		lightest[group.Name] = weight(group.Inputs[0])
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		return lightest[groups[i].Name] < lightest[groups[j].Name]
+	})
+	return groups
+}
+
+// globalKey identifies a global input across commands: by its long flag name
+// if it has one, or else its short one, and by its field name otherwise.
+func globalKey(input CommandInput) string {
+	if flag, ok := input.Tag.Flag(); ok {
+		if flag.ShortOnly() {
+			return flag.Short
+		}
+		return flag.Long
+	}
+	return input.FieldName
+}
+
+// GlobalInputs collects the inputs tagged global from all of cmds, to be
+// registered once on the root command. Inputs which are declared global on
+// several commands are deduplicated, but only if their declarations agree on
+// type and default; otherwise an error is returned. Inputs are returned in order
+// of first appearance.
+func GlobalInputs(cmds ...*Command) ([]CommandInput, error) {
+	type declaration struct {
+		input CommandInput
+		cmd   string
+	}
+	var globals []CommandInput
+	declared := make(map[string]declaration)
+	for _, cmd := range cmds {
+		if cmd == nil {
+			continue
+		}
+		for _, input := range cmd.Inputs {
+			if !input.Tag.Global() {
+				continue
+			}
+			key := globalKey(input)
+			prev, ok := declared[key]
+			if !ok {
+				declared[key] = declaration{input, cmd.Name}
+				globals = append(globals, input)
+				continue
+			}
+			prevDef, _ := prev.input.Tag.Default()
+			def, _ := input.Tag.Default()
+			if prev.input.Type != input.Type || prevDef != def {
+				return nil, fmt.Errorf("global input %q declared differently by commands %q and %q",
+					key, prev.cmd, cmd.Name)
+			}
+		}
+	}
+	return globals, nil
+}
+
+// liftedGlobals returns the inputs declared global by the subcommands of the
+// command, recursively, which it accepts on their behalf, leaving out those
+// which it declares global itself.
+func (meta *Command) liftedGlobals() ([]CommandInput, error) {
+	var descendants []*Command
+	for _, child := range meta.Children {
+		if child != nil {
+			descendants = append(descendants, child.tree()...)
+		}
+	}
+	globals, err := GlobalInputs(descendants...)
+	if err != nil {
+		return nil, err
+	}
+	own := make(map[string]bool)
+	for _, input := range meta.Inputs {
+		if input.Tag.Global() {
+			own[globalKey(input)] = true
+		}
+	}
+	var lifted []CommandInput
+	for _, input := range globals {
+		if !own[globalKey(input)] {
+			lifted = append(lifted, input)
+		}
+	}
+	return lifted, nil
+}
+
+// arrayArity returns the number of positional arguments consumed by a field of
+// fixed-size array type, bound to arguments by tag. Fields of other types, or
+// not bound to positional arguments, have an arity of zero. Validate checks
+// that the arguments in tag fill the array exactly.
+func arrayArity(typ ast.Expr, tag Tag) int {
+	at, ok := typ.(*ast.ArrayType)
+	if !ok || at.Len == nil {
+		return 0
+	}
+	if _, ok := tag.Arg(); !ok {
+		return 0
+	}
+	lit, ok := at.Len.(*ast.BasicLit)
+	if !ok || lit.Kind != token.INT {
+		// The length is a constant expression, which can't be evaluated
+		// without type checking.
+		slog.Info(fmt.Sprintf("Cannot determine arity of array %v", types.ExprString(typ)))
+		return 0
+	}
+	n, err := strconv.ParseInt(lit.Value, 0, 0)
+	if err != nil {
+		return 0
+	}
+	return int(n)
+}
+
+// fieldTag returns the cliche struct tag of field, which is called name in
+// logs. If the field has a cliche struct tag, it is used for setting flags,
+// handling args, and / or setting default values. The reflect package has some
+// built-in struct tag parsing logic. No reason not to use that.
+func fieldTag(field *ast.Field, name string) Tag {
+	var stag reflect.StructTag
+	if field.Tag != nil {
+		tv := field.Tag.Value
+		slog.Info(fmt.Sprintf("Field %v has tag: %v", name, tv))
+		// The token contained by the AST is still a quoted string.
+		utv, err := strconv.Unquote(tv)
+		if err == nil {
+			stag = reflect.StructTag(utv)
+		} else {
+			slog.Warn(fmt.Sprintf("Couldn't unquote struct tag %q: %v", tv, err))
+		}
+	}
+
+	var tag Tag
+	if t, ok := stag.Lookup(TagKey); ok {
+		tag = Tag(t)
+		slog.Info(fmt.Sprintf("Field %v has cliche tag %q", name, tag))
+	} else {
+		slog.Info(fmt.Sprintf("Field %v has no cliche tag", name))
+	}
+	if _, err := ParseTagStrict(string(tag)); err != nil {
+		slog.Warn(fmt.Sprintf("Field %v has a malformed cliche tag: %v", name, err))
+	}
+	return tag
+}
+
+// embeddedInputs compiles the inputs of the struct embedded by field, which is
+// declared in structs, and applies the overrides in the embedding field's tag:
+// inputs named by omit are dropped, long flag names are prefixed, and group
+// replaces the group of every input. Field names of the inputs are qualified
+// by the embedded type's name, as in Options.Verbose. Embedded structs from
+// other packages can't be resolved from source, and are skipped.
+func embeddedInputs(fset *token.FileSet, field *ast.Field, structs map[string]*ast.StructType) []CommandInput {
+	expr := field.Type
+	star, pointer := expr.(*ast.StarExpr)
+	if pointer {
+		expr = star.X
+	}
+	ident, ok := expr.(*ast.Ident)
+	if !ok {
+		slog.Info(fmt.Sprintf("Skipping embedded field of type %v declared in another package", types.ExprString(field.Type)))
+		return nil
+	}
+	embedded, ok := structs[ident.Name]
+	if !ok {
+		slog.Info(fmt.Sprintf("Skipping embedded field of type %v, which is not a struct declared in this package", ident.Name))
+		return nil
+	}
+	tag := fieldTag(field, ident.Name)
+	if tag.Excluded() {
+		slog.Info(fmt.Sprintf("Skipping excluded embedded field %v", ident.Name))
+		return nil
+	}
+	if _, ok := tag.Subcommand(); ok {
+		// An embedded subcommand is not flattened, but compiled as a field.
+		input := CommandInput{FieldName: ident.Name, Tag: tag, Type: types.ExprString(field.Type)}
+		if fset != nil {
+			input.Pos = fset.Position(field.Type.Pos())
+			input.TagPos = fset.Position(field.Tag.Pos())
+		}
+		return []CommandInput{input}
+	}
+
+	// Removing the type while its fields are compiled stops a cycle of
+	// embedded pointers from recursing forever.
+	delete(structs, ident.Name)
+	inner := compileInputs(fset, embedded, structs)
+	structs[ident.Name] = embedded
+
+	omit := make(map[string]bool)
+	fields, _ := tag.Omit()
+	for _, name := range fields {
+		omit[name] = true
+	}
+	prefix, _ := tag.Prefix()
+	group, _ := tag.Group()
+	order, _ := tag.Order()
+
+	var inputs []CommandInput
+	for _, input := range inner {
+		if omit[input.FieldName] {
+			slog.Info(fmt.Sprintf("Omitting field %v embedded by %v", input.FieldName, ident.Name))
+			continue
+		}
+		input.FieldName = ident.Name + "." + input.FieldName
+		var allocate []string
+		if pointer {
+			allocate = append(allocate, ident.Name)
+		}
+		for _, inner := range input.Allocate {
+			allocate = append(allocate, ident.Name+"."+inner)
+		}
+		input.Allocate = allocate
+		if prefix != "" || group != "" || order != 0 {
+			input.Tag = overrideTag(input.Tag, prefix, group, order)
+		}
+		inputs = append(inputs, input)
+	}
+	return inputs
 }
 
-func compileInputs(st *ast.StructType) (inputs []CommandInput) {
+// overrideTag returns tag with prefix prepended to its long flag names, and
+// its group replaced by group, when those are set. Order weighs the inputs
+// which have no order of their own. A tag which does not parse is returned
+// unchanged.
+func overrideTag(tag Tag, prefix, group string, order int) Tag {
+	pt, err := ParseTag(string(tag))
+	if err != nil {
+		return tag
+	}
+	if prefix != "" && pt.Flag != nil {
+		pt.Flag = pt.Flag.WithPrefix(prefix)
+		for i, name := range pt.Migrate {
+			pt.Migrate[i] = prefix + name
+		}
+	}
+	if group != "" {
+		pt.Group = group
+	}
+	if pt.Order == 0 {
+		pt.Order = order
+	}
+	return Tag(pt.Canonical())
+}
+
+func compileInputs(fset *token.FileSet, st *ast.StructType, structs map[string]*ast.StructType) (inputs []CommandInput) {
 	if st == nil || st.Fields == nil {
 		return
 	}
-	var name string
 	for _, field := range st.Fields.List {
-		// Both nameless fields and fields with multiple names are skipped.
-		// Maybe someday it will be worth unwinding the ambiguity of what to do
-		// in these cases. That day is not today.
-		n := len(field.Names)
-		switch {
-		case n == 0:
-			// A field has no name.
-			slog.Info(fmt.Sprintf("Skipping nameless field of type %v", field.Type))
-			continue
-		case n > 1:
-			slog.Warn(fmt.Sprintf("Skipping field with multiple names: %v;  cannot handle this case.", field.Names))
-			continue
-		default:
-			if !field.Names[0].IsExported() {
-				// If the only name is unexported, ignore this field as well.
-				slog.Info(fmt.Sprintf("Skipping unexported field %v", field.Names[0]))
+		// Nameless fields are flattened, when they embed a struct declared in
+		// the package. Fields with multiple names, as in "Host, Port string",
+		// are compiled as one input per name.
+		var idents []*ast.Ident
+		var names []string
+		for _, ident := range field.Names {
+			if !ident.IsExported() {
+				slog.Info(fmt.Sprintf("Skipping unexported field %v", ident))
 				continue
 			}
-			name = field.Names[0].Name
-			slog.Info(fmt.Sprintf("Compiling field named %q", name))
+			idents = append(idents, ident)
+			names = append(names, ident.Name)
+		}
+		if len(field.Names) == 0 {
+			inputs = append(inputs, embeddedInputs(fset, field, structs)...)
+			continue
+		}
+		if len(names) == 0 {
+			continue
 		}
+		name := strings.Join(names, ", ")
+		slog.Info(fmt.Sprintf("Compiling field named %q", name))
 
 		// If the field has a doc comment, capture it for the command usage
 		// output.
 		var doc string
 		if field.Doc != nil {
-			doc = field.Doc.Text()
+			doc = strings.TrimSpace(field.Doc.Text())
 			slog.Info(fmt.Sprintf("Field %v has doc comment: %q", name, doc))
 		} else {
 			slog.Info(fmt.Sprintf("Field %v has no doc comment", name))
 		}
 
-		// If the field has an  struct tag, capture and parse it for setting
-		// flags, handling args, and / or setting default values. The reflect
-		// package has some built-in struct tag parsing logic. No reason not to
-		// use that.
-		var stag reflect.StructTag
-		if field.Tag != nil {
-			tv := field.Tag.Value
-			slog.Info(fmt.Sprintf("Field %v has tag: %v", field, tv))
-			// The token contained by the AST is still a quoted string.
-			utv, err := strconv.Unquote(tv)
-			if err == nil {
-				stag = reflect.StructTag(utv)
-			} else {
-				slog.Warn(fmt.Sprintf("Couldn't unquote struct tag %q: %v", tv, err))
-			}
+		tag := fieldTag(field, name)
+		if tag.Excluded() {
+			// As with encoding/json, a tag of "-" keeps an exported field out
+			// of the command line entirely.
+			slog.Info(fmt.Sprintf("Skipping excluded field %v", name))
+			continue
 		}
-
-		var tag Tag
-		if t, ok := stag.Lookup(""); ok {
-			tag = Tag(t)
-			slog.Info(fmt.Sprintf("Field %v has  tag %q", name, tag))
-		} else {
-			slog.Info(fmt.Sprintf("Field %v has no  tag", name))
+		if len(field.Names) > 1 && tag != "" {
+			// A flag or argument can't be shared between names, so fields
+			// with several names get no tag by rule.
+			slog.Warn(fmt.Sprintf("Ignoring cliche tag %q on field with multiple names %v", tag, name))
+			tag = ""
 		}
 
-		inputs = append(inputs, CommandInput{
-			FieldName: name,
-			Tag:       tag,
-			Doc:       doc,
-			Type:      fmt.Sprintf("%s", field.Type),
-		})
+		arity := arrayArity(field.Type, tag)
+		for _, ident := range idents {
+			input := CommandInput{
+				FieldName: ident.Name,
+				Tag:       tag,
+				Doc:       doc,
+				Type:      types.ExprString(field.Type),
+				Arity:     arity,
+			}
+			if fset != nil {
+				input.Pos = fset.Position(ident.Pos())
+				if field.Tag != nil {
+					input.TagPos = fset.Position(field.Tag.Pos())
+				}
+			}
+			inputs = append(inputs, input)
+		}
 	}
 	return
 }
 
 // Compile the AST of a Go file into command metadata. Designed to be used as
-// an argument to ast.Inspect.
+// an argument to ast.Inspect. Only package-level declarations are considered;
+// types declared within function bodies are not the command, even when their
+// names match. Should the type be declared more than once, only the first
+// declaration is compiled.
 func (meta *Command) Compile(n ast.Node) bool {
 	if meta == nil || n == nil {
 		return false
 	}
 	switch x := n.(type) {
+	case *ast.FuncDecl, *ast.FuncLit:
+		// Types declared in function bodies are out of scope.
+		return false
 	case *ast.TypeSpec:
 		if x.Name == nil || x.Name.Name != meta.typ {
 			// This is not the type we are looking for.
 			break
 		}
+		if meta.compiled {
+			slog.Warn("Type is declared more than once; ignoring all but the first",
+				slog.String("type", meta.typ))
+			return false
+		}
+		meta.compiled = true
+		if meta.fset != nil {
+			meta.Pos = meta.fset.Position(x.Pos())
+		}
 		if st, ok := x.Type.(*ast.StructType); ok {
-			meta.Inputs = append(meta.Inputs, compileInputs(st)...)
+			// The type can't usefully embed itself.
+			delete(meta.structs, meta.typ)
+			meta.Inputs = append(meta.Inputs, compileInputs(meta.fset, st, meta.structs)...)
 			// We've got what we came for.
 			return false
 		}
@@ -166,8 +717,6 @@ func commandName(pkg string) string {
 	return strcase.ToKebab(name)
 }
 
-var whitespaceRunsRe = regexp.MustCompile(`\s+`)
-
 func sanitizeHelp(doc, pkg, cmd string) string {
 	var ok bool
 	if doc, ok = strings.CutPrefix(doc, "Package "); !ok {
@@ -183,10 +732,133 @@ func sanitizeHelp(doc, pkg, cmd string) string {
 	if strings.HasPrefix(doc, pkg) {
 		doc = strings.Replace(doc, pkg, cmd, 1)
 	}
-	return strings.TrimSpace(whitespaceRunsRe.ReplaceAllLiteralString(doc, " "))
+	return strings.TrimSpace(doc)
+}
+
+// Signatures of the methods, at least one of which a type must have to be
+// wrapped as a cliche command.
+const (
+	runSignature  = "Run(ctx context.Context) error"
+	verbSignature = "RunVerb(ctx context.Context) error"
+)
+
+// signature of a method, formatted as it would be declared, less the func
+// keyword and receiver name.
+func signature(fn *ast.FuncDecl) string {
+	var recv string
+	if fn.Recv != nil && len(fn.Recv.List) == 1 {
+		recv = fmt.Sprintf("(%v) ", types.ExprString(fn.Recv.List[0].Type))
+	}
+	sig, _ := strings.CutPrefix(types.ExprString(fn.Type), "func")
+	return recv + fn.Name.Name + sig
+}
+
+// isRun is true when fn is a Run method with the expected signature.
+func isRun(fn *ast.FuncDecl) bool {
+	return fn.Name.Name == "Run" && hasRunSignature(fn)
+}
+
+// verbName returns the subcommand name for fn, if it is a RunVerb method with
+// the same signature expected of Run.
+func verbName(fn *ast.FuncDecl) (string, bool) {
+	verb, ok := strings.CutPrefix(fn.Name.Name, "Run")
+	if !ok || verb == "" || !ast.IsExported(verb) || !hasRunSignature(fn) {
+		return "", false
+	}
+	return strcase.ToKebab(verb), true
+}
+
+// fieldTypes formats the types of a parameter or result list, omitting any
+// names. Each name in a field counts as one entry in the list.
+func fieldTypes(fields *ast.FieldList) []string {
+	if fields == nil {
+		return nil
+	}
+	var ret []string
+	for _, field := range fields.List {
+		typ := types.ExprString(field.Type)
+		n := len(field.Names)
+		if n == 0 {
+			n = 1
+		}
+		for i := 0; i < n; i++ {
+			ret = append(ret, typ)
+		}
+	}
+	return ret
+}
+
+// funcSignature formats a function type without parameter names, such as
+// "func(context.Context) error", so that signatures can be compared.
+func funcSignature(ft *ast.FuncType) string {
+	sig := "func(" + strings.Join(fieldTypes(ft.Params), ", ") + ")"
+	results := fieldTypes(ft.Results)
+	switch len(results) {
+	case 0:
+		return sig
+	case 1:
+		return sig + " " + results[0]
+	default:
+		return sig + " (" + strings.Join(results, ", ") + ")"
+	}
+}
+
+// hasRunSignature is true when fn takes only a context, and returns only an
+// error.
+func hasRunSignature(fn *ast.FuncDecl) bool {
+	return funcSignature(fn.Type) == "func(context.Context) error"
+}
+
+// hasMethod is true when typ has a method with the given name and signature,
+// as formatted by funcSignature.
+func hasMethod(typ *doc.Type, name, sig string) bool {
+	for _, m := range typ.Methods {
+		if m.Decl != nil && m.Name == name && funcSignature(m.Decl.Type) == sig {
+			return true
+		}
+	}
+	return false
+}
+
+// findVerbs returns the RunVerb methods declared on typ. Descriptions are
+// left as raw doc comment text.
+func findVerbs(typ *doc.Type) (verbs []Verb) {
+	for _, m := range typ.Methods {
+		if m.Decl == nil {
+			continue
+		}
+		name, ok := verbName(m.Decl)
+		if !ok {
+			continue
+		}
+		verbs = append(verbs, Verb{
+			Name:            name,
+			Method:          m.Name,
+			PointerReceiver: strings.HasPrefix(m.Recv, "*"),
+			Description:     m.Doc,
+		})
+	}
+	return
+}
+
+// findRun locates a suitable Run method on typ, reporting whether it is
+// declared on a pointer receiver. When no suitable method exists, the
+// signatures of the methods which were found are returned instead.
+func findRun(typ *doc.Type) (pointer, ok bool, found []string) {
+	for _, m := range typ.Methods {
+		if m.Decl == nil {
+			continue
+		}
+		if isRun(m.Decl) {
+			return strings.HasPrefix(m.Recv, "*"), true, nil
+		}
+		found = append(found, signature(m.Decl))
+	}
+	return false, false, found
 }
 
-type namedReader interface {
+// NamedReader is a file-like source of Go code, such as an *os.File.
+type NamedReader interface {
 	io.Reader
 	Name() string
 }
@@ -195,33 +867,227 @@ const importPath = "idontfixcomputers.com/cliche/fakenotused"
 
 // FromFile parses a Go AST from a file-like object and generates a Command for
 // a type matching typeName. If errors are encountered, nil is returned.
-func FromFile(from namedReader, typeName string) *Command {
-	filename := from.Name()
-	src, err := io.ReadAll(from)
+func FromFile(from NamedReader, typeName string) *Command {
+	return FromFiles(typeName, from)
+}
+
+// FromFiles parses Go ASTs from several file-like objects, which together make
+// up all or part of a single package, and generates a Command for a type
+// matching typeName. This is useful when the type, its methods, and the
+// package documentation are spread across files. If errors are encountered,
+// nil is returned.
+func FromFiles(typeName string, from ...NamedReader) *Command {
+	// First, we must parse the files into ASTs. The ParseComments mode is used
+	// to include comments during parsing.
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, r := range from {
+		filename := r.Name()
+		src, err := io.ReadAll(r)
+		if err != nil {
+			slog.Error("Failed reading", slog.String("file", filename), slog.Any("error", err))
+			return nil
+		}
+		f, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+		if err != nil || f == nil {
+			slog.Warn("Failed creating AST from file",
+				slog.String("file", filename), slog.Any("error", err))
+			return nil
+		}
+		if len(files) > 0 && f.Name.Name != files[0].Name.Name {
+			slog.Warn("Files belong to different packages",
+				slog.String("file", filename), slog.String("package", f.Name.Name),
+				slog.String("want", files[0].Name.Name))
+			return nil
+		}
+		files = append(files, f)
+	}
+	if len(files) == 0 {
+		slog.Warn("No files to parse", slog.String("type", typeName))
+		return nil
+	}
+	return fromAST(fset, files, typeName, nil)
+}
+
+// FromDir parses the Go files of the package in the directory at path, and
+// generates a Command for a type matching typeName wherever in the package it
+// is declared. Files are selected as the go command would select them for the
+// current platform: tests and files excluded by build constraints are skipped.
+// Code generated by cliche, in files named like t_cliche.go, is skipped too.
+// If errors are encountered, nil is returned.
+func FromDir(path, typeName string) *Command {
+	pkg, err := build.ImportDir(path, 0)
 	if err != nil {
-		slog.Error("Failed reading", slog.Any("error", err))
+		slog.Warn("Failed finding package files", slog.String("dir", path), slog.Any("error", err))
 		return nil
 	}
+	var from []NamedReader
+	for _, name := range pkg.GoFiles {
+		if strings.HasSuffix(name, "_cliche.go") {
+			continue
+		}
+		f, err := os.Open(filepath.Join(pkg.Dir, name))
+		if err != nil {
+			slog.Error("Failed opening", slog.String("file", name), slog.Any("error", err))
+			return nil
+		}
+		defer f.Close()
+		from = append(from, f)
+	}
+	return FromFiles(typeName, from...)
+}
 
-	// First, we must parse the file into an AST. The ParseComments mode is used
-	// to include comments during parsing.
-	fset := token.NewFileSet()
-	f, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
-	if err != nil || f == nil {
-		slog.Warn("Failed creating AST from file",
-			slog.String("file", filename), slog.Any("error", err))
+// FromPackage loads the package with the import path pkgPath, as resolved
+// from the current directory by golang.org/x/tools/go/packages, and generates
+// a Command for a type matching typeName from its files as FromDir does. The
+// Command's ImportPath is that of the package. If errors are encountered, nil
+// is returned.
+func FromPackage(pkgPath, typeName string) *Command {
+	pkgs, err := packages.Load(&packages.Config{Mode: packages.NeedName | packages.NeedFiles}, pkgPath)
+	if err != nil {
+		slog.Warn("Failed loading package", slog.String("package", pkgPath), slog.Any("error", err))
+		return nil
+	}
+	if len(pkgs) != 1 {
+		slog.Warn("Package pattern matched other than one package", slog.String("package", pkgPath), slog.Int("matched", len(pkgs)))
+		return nil
+	}
+	pkg := pkgs[0]
+	if len(pkg.Errors) > 0 {
+		slog.Warn("Failed loading package", slog.String("package", pkgPath), slog.Any("error", pkg.Errors[0]))
 		return nil
 	}
+	var from []NamedReader
+	for _, name := range pkg.GoFiles {
+		if strings.HasSuffix(name, "_cliche.go") {
+			continue
+		}
+		f, err := os.Open(name)
+		if err != nil {
+			slog.Error("Failed opening", slog.String("file", name), slog.Any("error", err))
+			return nil
+		}
+		defer f.Close()
+		from = append(from, f)
+	}
+	cmd := FromFiles(typeName, from...)
+	if cmd != nil {
+		cmd.ImportPath = pkg.PkgPath
+	}
+	return cmd
+}
+
+// NewParent creates a Command named name, which runs each of children as a
+// subcommand named after its type in kebab-case. The children are expected to
+// be declared in the same package, whose doc comment is the parent's Help, and
+// whose ImportPath, if any, is the parent's.
+func NewParent(name string, children ...*Command) *Command {
+	parent := &Command{Name: name, Children: children}
+	for _, child := range children {
+		if child == nil {
+			continue
+		}
+		child.Name = strcase.ToKebab(child.Type)
+		if parent.Package == "" {
+			parent.Package, parent.ImportPath = child.Package, child.ImportPath
+			parent.Help, parent.help, parent.printer = child.Help, child.help, child.printer
+		}
+	}
+	return parent
+}
+
+// topLevelTypes returns the package-level declarations of types named name in
+// files.
+func topLevelTypes(files []*ast.File, name string) (specs []*ast.TypeSpec) {
+	for _, f := range files {
+		for _, decl := range f.Decls {
+			gd, ok := decl.(*ast.GenDecl)
+			if !ok || gd.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gd.Specs {
+				if ts, ok := spec.(*ast.TypeSpec); ok && ts.Name.Name == name {
+					specs = append(specs, ts)
+				}
+			}
+		}
+	}
+	return
+}
+
+// packageStructs returns the struct types declared at package level in files,
+// by name.
+func packageStructs(files []*ast.File) map[string]*ast.StructType {
+	structs := make(map[string]*ast.StructType)
+	for _, f := range files {
+		for _, decl := range f.Decls {
+			gd, ok := decl.(*ast.GenDecl)
+			if !ok || gd.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gd.Specs {
+				if ts, ok := spec.(*ast.TypeSpec); ok {
+					if st, ok := ts.Type.(*ast.StructType); ok {
+						structs[ts.Name.Name] = st
+					}
+				}
+			}
+		}
+	}
+	return structs
+}
+
+// packageImports returns the imports of files, by path, with the name under
+// which each is imported.
+func packageImports(files []*ast.File) map[string]string {
+	imports := make(map[string]string)
+	for _, f := range files {
+		for _, spec := range f.Imports {
+			path, err := strconv.Unquote(spec.Path.Value)
+			if err != nil {
+				continue
+			}
+			var name string
+			if spec.Name != nil {
+				name = spec.Name.Name
+			}
+			imports[path] = name
+		}
+	}
+	return imports
+}
+
+// fromAST generates a Command for a type matching typeName from the parsed
+// files of a single package. Within lists the types of the commands of which
+// it is a subcommand, outermost first.
+func fromAST(fset *token.FileSet, files []*ast.File, typeName string, within []string) *Command {
+	var filenames []string
+	for _, f := range files {
+		filenames = append(filenames, fset.Position(f.Package).Filename)
+	}
 
 	// Next, do a pass over the AST with interpreter from the go/doc package,
 	// which goes to great lengths to compute doc comments. No reason to
 	// reimplement that logic. Mode PreserveAST is used so that the AST is not
 	// modified during doc generation, so that the same AST can be reused by our
 	// own parser, below.
-	pkg, err := doc.NewFromFiles(fset, []*ast.File{f}, importPath, doc.PreserveAST)
+	pkg, err := doc.NewFromFiles(fset, files, importPath, doc.PreserveAST)
 	if err != nil {
-		slog.Warn("Failed to compute documentation from AST from file",
-			slog.String("file", filename), slog.Any("error", err))
+		slog.Warn("Failed to compute documentation from AST from files",
+			slog.Any("files", filenames), slog.Any("error", err))
+		return nil
+	}
+
+	// A package can't declare a type twice, but the files given to us may not
+	// be a whole, well-formed package. Rather than guess which declaration is
+	// meant, refuse.
+	if decls := topLevelTypes(files, typeName); len(decls) > 1 {
+		var positions []string
+		for _, decl := range decls {
+			positions = append(positions, fset.Position(decl.Pos()).String())
+		}
+		slog.Error("Type is declared more than once",
+			slog.String("type", typeName), slog.Any("positions", positions))
 		return nil
 	}
 
@@ -238,22 +1104,116 @@ func FromFile(from namedReader, typeName string) *Command {
 	}
 	if ourType == nil {
 		// The type we are looking for does not exist in the AST.
-		slog.Warn("Type not found in file",
-			slog.String("file", filename), slog.String("type", typeName))
+		slog.Warn("Type not found in files",
+			slog.Any("files", filenames), slog.String("type", typeName))
 		return nil
 	}
 
+	pointer, ok, found := findRun(ourType)
+	cmdActual := commandName(pkg.Name)
+
+	// Doc comments are parsed with go/doc/comment, so that their structure
+	// survives into help output. The plain text forms are rendered without line
+	// wrapping; callers wanting otherwise can use HelpText and friends.
+	help := pkg.Parser().Parse(sanitizeHelp(pkg.Doc, pkg.Name, cmdActual))
+	description := pkg.Parser().Parse(ourType.Doc)
+
 	// Finally, create the metadata struct and allow it to parse the AST from
 	// the node the doc package found for our type.
-	cmdActual := commandName(pkg.Name)
 	meta := &Command{
-		Name:        cmdActual,
-		Package:     pkg.Name,
-		Type:        ourType.Name,
-		Help:        sanitizeHelp(pkg.Doc, pkg.Name, cmdActual),
-		Description: strings.TrimSpace(ourType.Doc),
+		Name:            cmdActual,
+		Package:         pkg.Name,
+		Type:            ourType.Name,
+		typ:             ourType.Name,
+		fset:            fset,
+		PointerReceiver: pointer,
+		Closer:          hasMethod(ourType, "Close", "func() error"),
+		Shutdowner:      hasMethod(ourType, "Shutdown", "func(context.Context) error"),
+		help:            help,
+		description:     description,
+		printer:         pkg.Printer(),
+		structs:         packageStructs(files),
+		runnable:        ok,
+		imports:         packageImports(files),
 		// Inputs are generated during Compile().
 	}
+	for _, verb := range findVerbs(ourType) {
+		verb.Description = renderText(meta.printer, pkg.Parser().Parse(verb.Description), -1)
+		meta.Verbs = append(meta.Verbs, verb)
+	}
+	meta.Help = meta.HelpText(-1)
+	meta.Description = meta.DescriptionText(-1)
 	ast.Inspect(ourType.Decl, meta.Compile)
+
+	// Fields tagged subcommand are not inputs, but declare the children of
+	// the command, which are compiled from the same files.
+	var inputs []CommandInput
+	for _, input := range meta.Inputs {
+		if _, ok := input.Tag.Subcommand(); !ok {
+			input.Validator = findValidator(ourType, input)
+			inputs = append(inputs, input)
+			continue
+		}
+		if child := subcommand(fset, files, input, append(within[:len(within):len(within)], meta.Type)); child != nil {
+			meta.Children = append(meta.Children, child)
+		}
+	}
+	meta.Inputs = inputs
+
+	// The type is only usable as a command if it can be Run, either directly,
+	// through one or more verbs, or through its subcommands.
+	if !ok && len(meta.Verbs) == 0 && len(meta.Children) == 0 {
+		slog.Error("Type has no suitable Run method",
+			slog.Any("files", filenames), slog.String("type", typeName),
+			slog.Any("expected", []string{runSignature, verbSignature}), slog.Any("found", found))
+		return nil
+	}
 	return meta
 }
+
+// subcommand compiles the command declared by a field tagged subcommand, which
+// is compiled as input, from files. It is named by the tag, or after the
+// field. Within lists the types of the commands of which it is a subcommand,
+// outermost first, none of which may be its type. If errors are encountered,
+// nil is returned.
+func subcommand(fset *token.FileSet, files []*ast.File, input CommandInput, within []string) *Command {
+	typ := strings.TrimPrefix(input.Type, "*")
+	for _, outer := range within {
+		if outer == typ {
+			slog.Error("Subcommand is a command of which it is a subcommand",
+				slog.String("field", input.FieldName), slog.String("type", typ))
+			return nil
+		}
+	}
+	child := fromAST(fset, files, typ, within)
+	if child == nil {
+		slog.Error("Subcommand is not a command type of the package",
+			slog.String("field", input.FieldName), slog.String("type", input.Type))
+		return nil
+	}
+	child.Field = &input
+	child.Name, _ = input.Tag.Subcommand()
+	if child.Name == "" {
+		child.Name = strcase.ToKebab(input.FieldName[strings.LastIndex(input.FieldName, ".")+1:])
+	}
+	return child
+}
+
+// findValidator returns the name of the method of typ which validates input,
+// if any.
+func findValidator(typ *doc.Type, input CommandInput) string {
+	if method, ok := input.Tag.Validator(); ok {
+		if hasMethod(typ, method, "func(string) error") {
+			return method
+		}
+		slog.Warn("Validator method not found",
+			slog.String("field", input.FieldName), slog.String("method", method))
+		return ""
+	}
+	if _, ok := input.Tag.Arg(); ok {
+		if method := "ValidateArg" + input.FieldName[strings.LastIndex(input.FieldName, ".")+1:]; hasMethod(typ, method, "func(string) error") {
+			return method
+		}
+	}
+	return ""
+}
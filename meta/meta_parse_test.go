@@ -2,6 +2,10 @@ package meta
 
 import (
 	"embed"
+	"go/ast"
+	"go/doc"
+	"go/parser"
+	"go/token"
 	"io/fs"
 	"testing"
 
@@ -41,6 +45,14 @@ func file(tb testing.TB, path string) *testFile {
 	}
 }
 
+// ignorePositions when comparing Commands, since most tests don't care where
+// in the source things are.
+var ignorePositions = []cmp.Option{
+	cmpopts.IgnoreUnexported(Command{}),
+	cmpopts.IgnoreFields(Command{}, "Pos"),
+	cmpopts.IgnoreFields(CommandInput{}, "Pos", "TagPos"),
+}
+
 func TestFromFile(t *testing.T) {
 	type test struct {
 		path string
@@ -51,19 +63,621 @@ func TestFromFile(t *testing.T) {
 	for _, tc := range []test{
 		{
 			"testdata/simple/simple.go", "Tester", &Command{
-				Name:        "simple",
-				Package:     "simple",
-				Type:        "Tester",
-				Help:        "simple is a simple test for cliche. It contains a single Command with no tags.",
-				Description: "Tester is a cliche command which exercises default inputs.",
+				Name:            "simple",
+				Package:         "simple",
+				Type:            "Tester",
+				PointerReceiver: true,
+				Help:            "simple is a simple test for cliche. It contains a single Command with no tags.",
+				Description:     "Tester is a cliche command which exercises default inputs.",
+				Inputs: []CommandInput{
+					{FieldName: "String", Doc: "String command input.", Type: "string"},
+					{FieldName: "Int", Doc: "Int command input.", Type: "int"},
+					{FieldName: "Float", Doc: "Float command input.", Type: "float64"},
+					{FieldName: "Boolean", Doc: "Boolean command input.", Type: "bool"},
+					{FieldName: "MoreStrings", Doc: "MoreStrings for the command.", Type: "[]string"},
+					{FieldName: "MoreInts", Doc: "MoreInts for the command.", Type: "[]int"},
+					{FieldName: "MoreFloats", Doc: "MoreFloats for the command.", Type: "[]float64"},
+					{FieldName: "MoreBooleans", Doc: "MoreBoolans for the command.", Type: "[]bool"},
+				},
+			},
+		},
+		{
+			"testdata/docs/docs.go", "Documented", &Command{
+				Name:            "docs",
+				Package:         "docs",
+				Type:            "Documented",
+				PointerReceiver: true,
+				Help: `docs is a test for cliche help rendering. Its doc comment contains structure which should survive into help output.
+
+# Usage
+
+Inputs are provided as:
+  - flags, and
+  - positional arguments.
+
+For example:
+
+	docs -name=World`,
+				Description: "Documented is a cliche command whose doc comment spans several lines.",
+			},
+		},
+		{
+			"testdata/value/value.go", "Valuable", &Command{
+				Name:        "value",
+				Package:     "value",
+				Type:        "Valuable",
+				Help:        "value is a test for cliche commands with a value receiver Run method.",
+				Description: "Valuable is a cliche command which is Run by value.",
+				Inputs: []CommandInput{
+					{FieldName: "Name", Tag: "arg:0", Doc: "Name to greet.", Type: "string"},
+				},
+			},
+		},
+		{
+			"testdata/verbs/verbs.go", "Remote", &Command{
+				Name:        "verbs",
+				Package:     "verbs",
+				Type:        "Remote",
+				Help:        "verbs is a test for cliche commands with several verbs.",
+				Description: "Remote is a cliche command which is Run through its verbs.",
+				Verbs: []Verb{
+					{Name: "fetch-all", Method: "RunFetchAll", Description: "RunFetchAll fetches everything from the remote."},
+					{Name: "push", Method: "RunPush", PointerReceiver: true, Description: "RunPush pushes to the remote."},
+				},
+				Inputs: []CommandInput{
+					{FieldName: "URL", Doc: "URL of the remote.", Type: "string"},
+				},
+			},
+		},
+		{
+			"testdata/cleanup/cleanup.go", "Tidy", &Command{
+				Name:            "cleanup",
+				Package:         "cleanup",
+				Type:            "Tidy",
+				PointerReceiver: true,
+				Closer:          true,
+				Shutdowner:      true,
+				Help:            "cleanup is a test for cliche commands which hold resources.",
+				Description:     "Tidy is a cliche command which cleans up after itself.",
+			},
+		},
+		{
+			"testdata/inject/inject.go", "Fetcher", &Command{
+				Name:            "inject",
+				Package:         "inject",
+				Type:            "Fetcher",
+				PointerReceiver: true,
+				Help:            "inject is a test for cliche commands with injected dependencies.",
+				Description:     "Fetcher is a cliche command which is handed an HTTP client.",
+				Inputs: []CommandInput{
+					{FieldName: "URL", Tag: "arg:0", Doc: "URL to fetch.", Type: "string"},
+					{FieldName: "Client", Tag: "inject", Doc: "Client used to fetch the URL.", Type: "*http.Client"},
+				},
+			},
+		},
+		{
+			"testdata/excluded/excluded.go", "Partial", &Command{
+				Name:            "excluded",
+				Package:         "excluded",
+				Type:            "Partial",
+				PointerReceiver: true,
+				Help:            "excluded is a test for cliche commands with fields excluded from the command line.",
+				Description:     "Partial is a cliche command which keeps some exported fields to itself.",
+				Inputs: []CommandInput{
+					{FieldName: "Name", Doc: "Name of the thing.", Type: "string"},
+				},
+			},
+		},
+		{
+			"testdata/arrays/arrays.go", "Pairs", &Command{
+				Name:            "arrays",
+				Package:         "arrays",
+				Type:            "Pairs",
+				PointerReceiver: true,
+				Help:            "arrays is a test for cliche commands with fixed-size array inputs.",
+				Description:     "Pairs is a cliche command which takes positional arguments into arrays.",
+				Inputs: []CommandInput{
+					{FieldName: "First", Tag: "arg:[0:2]", Doc: "First pair of arguments.", Type: "[2]string", Arity: 2},
+					{FieldName: "Second", Tag: "arg:2", Doc: "Second pair of arguments.", Type: "[2]string", Arity: 2},
+					{FieldName: "Rest", Tag: "arg:[4:]", Doc: "Rest of the arguments, three of them.", Type: "[three]string"},
+					{FieldName: "Flags", Doc: "Flags is not bound to positional arguments.", Type: "[2]string"},
+				},
+			},
+		},
+		{
+			"testdata/custom/custom.go", "Special", &Command{
+				Name:            "custom",
+				Package:         "custom",
+				Type:            "Special",
+				PointerReceiver: true,
+				Help:            "custom is a test for cliche commands which document themselves.",
+				Description:     "Special is a cliche command with unusual documentation needs.",
+			},
+		},
+		{
+			"testdata/validators/validators.go", "Dialer", &Command{
+				Name:            "validators",
+				Package:         "validators",
+				Type:            "Dialer",
+				PointerReceiver: true,
+				Help:            "validators is a test for cliche commands which validate positional arguments.",
+				Description:     "Dialer is a cliche command which checks its arguments before Run.",
+				Inputs: []CommandInput{
+					{FieldName: "Host", Tag: "arg:0;validate:CheckHost", Doc: "Host to dial.", Type: "string", Validator: "CheckHost"},
+					{FieldName: "Port", Tag: "arg:1", Doc: "Port to dial.", Type: "string", Validator: "ValidateArgPort"},
+					{FieldName: "Proto", Tag: "arg:2", Doc: "Proto to dial with.", Type: "string"},
+					{FieldName: "Missing", Tag: "arg:3;validate:CheckMissing", Doc: "Missing validator, which is not found.", Type: "string"},
+					{FieldName: "Route.Gateway", Tag: "arg:4", Doc: "Gateway to dial through.", Type: "string", Validator: "ValidateArgGateway"},
+				},
+			},
+		},
+		{
+			"testdata/scoped/scoped.go", "Shadowed", &Command{
+				Name:            "scoped",
+				Package:         "scoped",
+				Type:            "Shadowed",
+				PointerReceiver: true,
+				Help:            "scoped is a test for cliche commands whose type name is reused in narrower scopes.",
+				Description:     "Shadowed is a cliche command with a name reused within functions.",
+				Inputs: []CommandInput{
+					{FieldName: "Right", Doc: "Right is the only input.", Type: "string"},
+				},
+			},
+		},
+		{
+			"testdata/multiname/multiname.go", "Endpoint", &Command{
+				Name:            "multiname",
+				Package:         "multiname",
+				Type:            "Endpoint",
+				PointerReceiver: true,
+				Help:            "multiname is a test for cliche commands with fields declaring several names at once.",
+				Description:     "Endpoint is a cliche command with multi-name fields.",
+				Inputs: []CommandInput{
+					{FieldName: "Host", Doc: "Host and Port to connect to.", Type: "string"},
+					{FieldName: "Port", Doc: "Host and Port to connect to.", Type: "string"},
+					{FieldName: "Scheme", Doc: "Scheme is tagged, and so is secret, but the tag can't apply to both.", Type: "string"},
+					{FieldName: "Secret", Doc: "Scheme is tagged, and so is secret, but the tag can't apply to both.", Type: "string"},
+					{FieldName: "Timeout", Doc: "Only the exported name of these counts.", Type: "int"},
+				},
 			},
 		},
+		{
+			"testdata/embedded/embedded.go", "Migrate", &Command{
+				Name:            "embedded",
+				Package:         "embedded",
+				Type:            "Migrate",
+				PointerReceiver: true,
+				Help:            "embedded is a test for cliche commands which embed shared options.",
+				Description:     "Migrate is a cliche command which embeds shared options.",
+				Inputs: []CommandInput{
+					{FieldName: "Connection.Host", Tag: "flag:db-host;group:Database;migrate:db-hostname", Doc: "Host to connect to.", Type: "string"},
+					{FieldName: "Connection.Verbose", Tag: "flag:db-verbose;group:Database", Doc: "Verbose connection logging.", Type: "bool"},
+					{FieldName: "Logging.Level", Tag: "flag:level;order:10", Doc: "Level of logs.", Type: "string", Allocate: []string{"Logging"}},
+					{FieldName: "DryRun", Tag: "flag:dry-run", Doc: "Dry run only.", Type: "bool"},
+				},
+			},
+		},
+		{
+			"testdata/locked/locked.go", "Purge", &Command{
+				Name:            "locked",
+				Package:         "locked",
+				Type:            "Purge",
+				PointerReceiver: true,
+				Help:            "locked is a test for cliche commands which must not run concurrently.",
+				Description:     "Purge is a cliche command which deletes everything, so holds a lock.",
+				Inputs: []CommandInput{
+					{FieldName: "Force", Tag: "flag:force,f", Doc: "Force deletion without asking.", Type: "bool"},
+					{FieldName: "NoLock", Tag: "lock:purge-data", Doc: "NoLock runs the purge even while another is running.", Type: "bool"},
+				},
+			},
+		},
+		{
+			"testdata/required/required.go", "Copy", &Command{
+				Name:            "required",
+				Package:         "required",
+				Type:            "Copy",
+				PointerReceiver: true,
+				Help:            "required is a test for cliche commands with inputs which must be given.",
+				Description:     "Copy is a cliche command which needs to be told what to copy, and how.",
+				Inputs: []CommandInput{
+					{FieldName: "Token", Tag: "flag:token,t;required", Doc: "Token with which to authenticate.", Type: "string"},
+					{FieldName: "Mode", Tag: "flag:mode;default:fast", Doc: "Mode of the copy.", Type: "string"},
+					{FieldName: "Source", Tag: "arg:0;required", Doc: "Source to copy from.", Type: "string"},
+					{FieldName: "Destination", Tag: "arg:1;required", Doc: "Destination to copy to.", Type: "string"},
+				},
+			},
+		},
+		{
+			"testdata/tree/tree.go", "Tool", &Command{
+				Name:        "tree",
+				Package:     "tree",
+				Type:        "Tool",
+				Help:        "tree is a test for cliche commands with nested subcommands.",
+				Description: "Tool is a cliche command made only of subcommands.",
+				Children: []*Command{
+					{
+						Name:            "remote",
+						Package:         "tree",
+						Type:            "Remote",
+						PointerReceiver: true,
+						Help:            "tree is a test for cliche commands with nested subcommands.",
+						Description:     "Remote lists remote repositories.",
+						Inputs: []CommandInput{
+							{FieldName: "Verbose", Tag: "flag:verbose,v", Doc: "Verbose listing, with URLs.", Type: "bool"},
+						},
+						Field: &CommandInput{FieldName: "Remote", Tag: "subcommand", Doc: "Remote repositories.", Type: "*Remote"},
+						Children: []*Command{
+							{
+								Name:            "add",
+								Package:         "tree",
+								Type:            "Add",
+								PointerReceiver: true,
+								Help:            "tree is a test for cliche commands with nested subcommands.",
+								Description:     "Add adds a remote repository.",
+								Inputs: []CommandInput{
+									{FieldName: "Name", Tag: "arg:0", Doc: "Name of the remote.", Type: "string"},
+									{FieldName: "URL", Tag: "arg:1", Doc: "URL of the remote.", Type: "string"},
+								},
+								Field: &CommandInput{FieldName: "Add", Tag: "subcommand", Type: "Add"},
+							},
+							{
+								Name:            "rm",
+								Package:         "tree",
+								Type:            "Remove",
+								PointerReceiver: true,
+								Help:            "tree is a test for cliche commands with nested subcommands.",
+								Description:     "Remove removes a remote repository.",
+								Inputs: []CommandInput{
+									{FieldName: "Name", Tag: "arg:0", Doc: "Name of the remote.", Type: "string"},
+								},
+								Field: &CommandInput{FieldName: "Remove", Tag: "subcommand:rm", Doc: "Removing remotes.", Type: "Remove"},
+							},
+						},
+					},
+					{
+						Name:            "st",
+						Package:         "tree",
+						Type:            "Status",
+						PointerReceiver: true,
+						Help:            "tree is a test for cliche commands with nested subcommands.",
+						Description:     "Status shows the status of the working tree.",
+						Inputs: []CommandInput{
+							{FieldName: "Short", Tag: "flag:short,s", Doc: "Short format.", Type: "bool"},
+						},
+						Field: &CommandInput{FieldName: "Status", Tag: "subcommand:st", Doc: "Status of the working tree.", Type: "Status"},
+					},
+				},
+			},
+		},
+		{
+			"testdata/tree/tree.go", "Loop", &Command{
+				Name:            "tree",
+				Package:         "tree",
+				Type:            "Loop",
+				PointerReceiver: true,
+				Help:            "tree is a test for cliche commands with nested subcommands.",
+				Description:     "Loop is a cliche command which is its own subcommand, which is dropped.",
+			},
+		},
+		{"testdata/norun/norun.go", "Unrunnable", nil},
 	} {
 		t.Run(tc.path, func(t *testing.T) {
 			got := FromFile(file(t, tc.path), tc.typ)
-			if diff := cmp.Diff(got, tc.want, cmpopts.IgnoreUnexported(Command{})); diff != "" {
+			if diff := cmp.Diff(got, tc.want, ignorePositions...); diff != "" {
 				t.Errorf("FromFile(): mismatch(-got,+want):\n%v", diff)
 			}
 		})
 	}
 }
+
+func TestFromFiles(t *testing.T) {
+	want := &Command{
+		Name:            "split",
+		Package:         "split",
+		Type:            "Divided",
+		PointerReceiver: true,
+		Help:            "split is a test for cliche commands declared across several files.",
+		Description:     "Divided is a cliche command whose declaration and methods live apart.",
+		Inputs: []CommandInput{
+			{FieldName: "Name", Doc: "Name of the thing.", Type: "string"},
+		},
+	}
+	got := FromFiles("Divided",
+		file(t, "testdata/split/doc.go"),
+		file(t, "testdata/split/split.go"),
+		file(t, "testdata/split/run.go"))
+	if diff := cmp.Diff(got, want, ignorePositions...); diff != "" {
+		t.Errorf("FromFiles(): mismatch(-got,+want):\n%v", diff)
+	}
+
+	// Without the file declaring Run, the type is not a command.
+	if got := FromFiles("Divided", file(t, "testdata/split/doc.go"), file(t, "testdata/split/split.go")); got != nil {
+		t.Errorf("FromFiles(): got %+v without Run, want nil", got)
+	}
+
+	// Files from different packages can't be combined.
+	if got := FromFiles("Divided", file(t, "testdata/split/split.go"), file(t, "testdata/simple/simple.go")); got != nil {
+		t.Errorf("FromFiles(): got %+v from mixed packages, want nil", got)
+	}
+
+	// A type declared in two files is ambiguous.
+	if got := FromFiles("Twice", file(t, "testdata/twice/twice.go"), file(t, "testdata/twice/again.go")); got != nil {
+		t.Errorf("FromFiles(): got %+v from duplicate declarations, want nil", got)
+	}
+
+	if got := FromFiles("Divided"); got != nil {
+		t.Errorf("FromFiles(): got %+v from no files, want nil", got)
+	}
+}
+
+func TestFromDir(t *testing.T) {
+	want := &Command{
+		Name:            "split",
+		Package:         "split",
+		Type:            "Divided",
+		PointerReceiver: true,
+		Help:            "split is a test for cliche commands declared across several files.",
+		Description:     "Divided is a cliche command whose declaration and methods live apart.",
+		Inputs: []CommandInput{
+			{FieldName: "Name", Doc: "Name of the thing.", Type: "string"},
+		},
+	}
+	// Tests, generated code, and files excluded by build constraints each
+	// declare Divided again, and must be skipped.
+	got := FromDir("testdata/split", "Divided")
+	if diff := cmp.Diff(got, want, ignorePositions...); diff != "" {
+		t.Errorf("FromDir(): mismatch(-got,+want):\n%v", diff)
+	}
+
+	if got := FromDir("testdata/missing", "Divided"); got != nil {
+		t.Errorf("FromDir(): got %+v from missing directory, want nil", got)
+	}
+}
+
+func TestNewParent(t *testing.T) {
+	fetch := FromFile(file(t, "testdata/suite/suite.go"), "Fetch")
+	push := FromFile(file(t, "testdata/suite/suite.go"), "Push")
+	parent := NewParent("suite", fetch, push)
+	if parent.Package != "suite" || parent.Type != "" {
+		t.Errorf("NewParent(): got package %q and type %q, want package suite without a type", parent.Package, parent.Type)
+	}
+	if want := "suite is a test for cliche commands made of several types."; parent.Help != want {
+		t.Errorf("NewParent(): got help %q, want %q", parent.Help, want)
+	}
+	var names []string
+	for _, child := range parent.Children {
+		names = append(names, child.Name)
+	}
+	if diff := cmp.Diff(names, []string{"fetch", "push"}); diff != "" {
+		t.Errorf("NewParent(): children mismatch (-got,+want):\n%v", diff)
+	}
+	if err := parent.Validate(); err != nil {
+		t.Errorf("Validate(): unexpected error: %v", err)
+	}
+}
+
+func TestFromPackage(t *testing.T) {
+	got := FromPackage("idontfixcomputers.com/cliche/examples/hello", "Hello")
+	if got == nil {
+		t.Fatal("FromPackage(): got nil Command")
+	}
+	if got.Package != "main" || got.Type != "Hello" || got.ImportPath != "idontfixcomputers.com/cliche/examples/hello" {
+		t.Errorf("FromPackage(): got package %q type %q at %q, want main Hello at its import path", got.Package, got.Type, got.ImportPath)
+	}
+
+	// The files of the package are selected as FromDir selects them.
+	got = FromPackage("./testdata/split", "Divided")
+	if got == nil || got.Description != "Divided is a cliche command whose declaration and methods live apart." || len(got.Inputs) != 1 {
+		t.Errorf("FromPackage(): got %+v, want Divided as FromDir compiles it", got)
+	}
+
+	if got := FromPackage("idontfixcomputers.com/cliche/missing", "Hello"); got != nil {
+		t.Errorf("FromPackage(): got %+v from missing package, want nil", got)
+	}
+}
+
+func TestCompileScope(t *testing.T) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "scoped.go", file(t, "testdata/scoped/scoped.go"), parser.ParseComments)
+	if err != nil {
+		t.Fatalf("ParseFile(): unexpected error: %v", err)
+	}
+	// Declarations in function bodies precede the package-level one in the
+	// file, so only skipping them finds the right one.
+	meta := &Command{typ: "Shadowed"}
+	ast.Inspect(f, meta.Compile)
+	want := []CommandInput{{FieldName: "Right", Doc: "Right is the only input.", Type: "string"}}
+	if diff := cmp.Diff(meta.Inputs, want, ignorePositions...); diff != "" {
+		t.Errorf("Compile(): mismatch (-got,+want):\n%v", diff)
+	}
+}
+
+func TestCommandHelpRendering(t *testing.T) {
+	cmd := FromFile(file(t, "testdata/docs/docs.go"), "Documented")
+	if cmd == nil {
+		t.Fatal("FromFile(): got nil Command")
+	}
+
+	wantText := `docs is a test for cliche help
+rendering. Its doc comment contains
+structure which should survive into help
+output.
+
+# Usage
+
+Inputs are provided as:
+  - flags, and
+  - positional arguments.
+
+For example:
+
+	docs -name=World`
+	if diff := cmp.Diff(cmd.HelpText(40), wantText); diff != "" {
+		t.Errorf("HelpText(): mismatch(-got,+want):\n%v", diff)
+	}
+
+	wantMarkdown := `docs is a test for cliche help rendering. Its doc comment contains structure which should survive into help output.
+
+### Usage
+
+Inputs are provided as:
+
+  - flags, and
+  - positional arguments.
+
+For example:
+
+	docs -name=World`
+	if diff := cmp.Diff(cmd.HelpMarkdown(), wantMarkdown); diff != "" {
+		t.Errorf("HelpMarkdown(): mismatch(-got,+want):\n%v", diff)
+	}
+}
+
+func TestCommandInputGroups(t *testing.T) {
+	cmd := &Command{
+		Inputs: []CommandInput{
+			{FieldName: "Host", Tag: "flag:host;group:Networking"},
+			{FieldName: "Verbose", Tag: "flag:verbose"},
+			{FieldName: "Cert", Tag: "flag:cert;group:TLS"},
+			{FieldName: "Port", Tag: "flag:port;group:Networking"},
+			{FieldName: "Name"},
+		},
+	}
+	want := []InputGroup{
+		{Inputs: []CommandInput{
+			{FieldName: "Verbose", Tag: "flag:verbose"},
+			{FieldName: "Name"},
+		}},
+		{Name: "Networking", Inputs: []CommandInput{
+			{FieldName: "Host", Tag: "flag:host;group:Networking"},
+			{FieldName: "Port", Tag: "flag:port;group:Networking"},
+		}},
+		{Name: "TLS", Inputs: []CommandInput{
+			{FieldName: "Cert", Tag: "flag:cert;group:TLS"},
+		}},
+	}
+	if diff := cmp.Diff(cmd.InputGroups(), want); diff != "" {
+		t.Errorf("InputGroups(): mismatch(-got,+want):\n%v", diff)
+	}
+}
+
+func TestCommandInputGroupsOrder(t *testing.T) {
+	cmd := &Command{
+		Inputs: []CommandInput{
+			{FieldName: "Verbose", Tag: "flag:verbose;order:1"},
+			{FieldName: "Name"},
+			{FieldName: "Host", Tag: "flag:host;group:Networking"},
+			{FieldName: "Token", Tag: "flag:token;group:Auth;order:-1"},
+			{FieldName: "Port", Tag: "flag:port;group:Networking;order:-2"},
+			{FieldName: "Cert", Tag: "flag:cert;group:TLS"},
+		},
+	}
+	want := []InputGroup{
+		{Name: "Networking", Inputs: []CommandInput{
+			{FieldName: "Port", Tag: "flag:port;group:Networking;order:-2"},
+			{FieldName: "Host", Tag: "flag:host;group:Networking"},
+		}},
+		{Name: "Auth", Inputs: []CommandInput{
+			{FieldName: "Token", Tag: "flag:token;group:Auth;order:-1"},
+		}},
+		{Inputs: []CommandInput{
+			{FieldName: "Name"},
+			{FieldName: "Verbose", Tag: "flag:verbose;order:1"},
+		}},
+		{Name: "TLS", Inputs: []CommandInput{
+			{FieldName: "Cert", Tag: "flag:cert;group:TLS"},
+		}},
+	}
+	if diff := cmp.Diff(cmd.InputGroups(), want); diff != "" {
+		t.Errorf("InputGroups(): mismatch(-got,+want):\n%v", diff)
+	}
+}
+
+func TestFindRun(t *testing.T) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "norun.go", `package norun
+
+import "context"
+
+type Unrunnable struct{}
+
+func (cmd *Unrunnable) Run(ctx context.Context, args []string) error { return nil }
+
+func (Unrunnable) Stop() {}
+`, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg, err := doc.NewFromFiles(fset, []*ast.File{f}, importPath, doc.PreserveAST)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, ok, found := findRun(pkg.Types[0])
+	if ok {
+		t.Errorf("findRun(): got ok, want not ok")
+	}
+	want := []string{
+		"(*Unrunnable) Run(ctx context.Context, args []string) error",
+		"(Unrunnable) Stop()",
+	}
+	if diff := cmp.Diff(found, want); diff != "" {
+		t.Errorf("findRun(): found mismatch(-got,+want):\n%v", diff)
+	}
+}
+
+func TestGlobalInputs(t *testing.T) {
+	verbose := CommandInput{FieldName: "Verbose", Tag: "flag:verbose;global", Type: "bool"}
+	config := CommandInput{FieldName: "Config", Tag: "global;default:config.yaml", Type: "string"}
+	push := &Command{Name: "push", Inputs: []CommandInput{
+		verbose,
+		{FieldName: "Force", Tag: "flag:force", Type: "bool"},
+	}}
+	pull := &Command{Name: "pull", Inputs: []CommandInput{
+		{FieldName: "Loud", Tag: "flag:verbose;global", Type: "bool"},
+		config,
+	}}
+
+	got, err := GlobalInputs(push, nil, pull)
+	if err != nil {
+		t.Fatalf("GlobalInputs(): unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(got, []CommandInput{verbose, config}); diff != "" {
+		t.Errorf("GlobalInputs(): mismatch(-got,+want):\n%v", diff)
+	}
+
+	conflicting := &Command{Name: "status", Inputs: []CommandInput{
+		{FieldName: "Config", Tag: "global;default:other.yaml", Type: "string"},
+	}}
+	if _, err := GlobalInputs(push, pull, conflicting); err == nil {
+		t.Errorf("GlobalInputs(): got no error for conflicting declarations")
+	}
+}
+
+func TestFromFilePositions(t *testing.T) {
+	cmd := FromFile(file(t, "testdata/inject/inject.go"), "Fetcher")
+	if cmd == nil {
+		t.Fatal("FromFile(): got nil Command")
+	}
+	pos := func(line, column int) token.Position {
+		return token.Position{Filename: "testdata/inject/inject.go", Line: line, Column: column}
+	}
+	type positions struct {
+		Pos, TagPos token.Position
+	}
+	want := []positions{
+		{pos(14, 2), pos(14, 13)},
+		{pos(16, 2), pos(16, 22)},
+	}
+	var got []positions
+	for _, input := range cmd.Inputs {
+		got = append(got, positions{input.Pos, input.TagPos})
+	}
+	ignoreOffset := cmpopts.IgnoreFields(token.Position{}, "Offset")
+	if diff := cmp.Diff(got, want, ignoreOffset); diff != "" {
+		t.Errorf("FromFile(): input positions mismatch(-got,+want):\n%v", diff)
+	}
+	if diff := cmp.Diff(cmd.Pos, pos(12, 6), ignoreOffset); diff != "" {
+		t.Errorf("FromFile(): type position mismatch(-got,+want):\n%v", diff)
+	}
+}
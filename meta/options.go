@@ -0,0 +1,278 @@
+package meta
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"go/build"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/iancoleman/strcase"
+	"golang.org/x/tools/go/packages"
+)
+
+// Options are those with which cliche generates a command, as given by the
+// flags of a go:generate directive.
+type Options struct {
+	// Type is the name of the type to wrap, or Types the comma-separated names
+	// of those to wrap as subcommands of one command. Exactly one is given.
+	// Types of another package are named by its import path, as in
+	// example.com/lib/tool.Options, and are wrapped by a command generated
+	// into the package of the target. Those of Types are of one package.
+	Type, Types string
+
+	// Name of the command. By default, it is the name of the package, or of
+	// its directory for package main.
+	Name string
+
+	// Output is the file to which the command is written. By default, it is
+	// chosen by OutputFile.
+	Output string
+
+	// Default names the verb or subcommand run when none is named, as for
+	// Command.Default.
+	Default string
+
+	// PFlag is true when functions binding flags to a pflag.FlagSet are
+	// generated too, as for Command.PFlag.
+	PFlag bool
+
+	// ResponseFiles is true when @file arguments are expanded into the
+	// arguments held by the file, as for Command.ResponseFiles.
+	ResponseFiles bool
+
+	// Abbreviate is true when unique prefixes of the names of flags, verbs
+	// and subcommands are accepted, as for Command.Abbreviate.
+	Abbreviate bool
+
+	// Env is true when an env subcommand listing the environment variables
+	// read by the program is generated, as for Command.Env.
+	Env bool
+
+	// SelfUpdate is true when a selfupdate subcommand updating the program is
+	// generated, as for Command.SelfUpdate.
+	SelfUpdate bool
+
+	// Pipes is true when pipelines of commands are run in one process, as for
+	// Command.Pipes.
+	Pipes bool
+
+	// Timeout is true when the program takes a --timeout flag bounding how
+	// long any command runs, as for Command.Timeout.
+	Timeout bool
+
+	// Schedule is true when the program takes flags running any command
+	// repeatedly on a schedule, as for Command.Schedule.
+	Schedule bool
+
+	// Interactive is true when commands take an --interactive flag asking
+	// for their flags with a form, as for Command.Interactive.
+	Interactive bool
+
+	// Manifest is true when commands take a --from-file flag naming a JSON
+	// manifest of their inputs, as for Command.Manifest.
+	Manifest bool
+
+	// ShowConfig is true when commands take a --show-config flag showing the
+	// values of their flags, as for Command.ShowConfig.
+	ShowConfig bool
+
+	// SlicePolicy names the policy by which flags bound to slices take their
+	// values, as for Command.SlicePolicy.
+	SlicePolicy string
+
+	// Strict is true when unknown tag components are errors, as they are by
+	// default, rather than warnings, as for Command.Lenient.
+	Strict bool
+
+	// Internal is true when the command is generated into a package of its
+	// own, in the directory InternalDir/pkg beneath that of the target, where
+	// pkg is named after the command by InternalPackage. The package imports
+	// that declaring the types, keeping generated code out of it.
+	Internal bool
+}
+
+// InternalDir is the directory, relative to that of the target, beneath which
+// commands are generated into packages of their own with Options.Internal.
+const InternalDir = "internal/clichegen"
+
+// InternalPackage returns the name of the package into which the command named
+// name is generated with Options.Internal: the name in lower case, less
+// anything which is not a letter or digit, as mytool for my-tool.
+func InternalPackage(name string) string {
+	pkg := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, name)
+	if pkg == "" || !unicode.IsLetter([]rune(pkg)[0]) || token.IsKeyword(pkg) {
+		pkg = "cmd" + pkg
+	}
+	return pkg
+}
+
+// RegisterFlags registers the flags setting the options on fs, with their
+// defaults.
+func (o *Options) RegisterFlags(fs *flag.FlagSet) {
+	fs.StringVar(&o.Type, "type", "", "name of the type to wrap, or import/path.Type for that of another package; required unless -types is set")
+	fs.StringVar(&o.Types, "types", "", "comma-separated names of types to wrap as subcommands of one command")
+	fs.StringVar(&o.Output, "output", "", "output file; default <dir>/<type>_cliche.go")
+	fs.StringVar(&o.Name, "name", "", "name of the command; default is the package name, or the directory name for package main")
+	fs.StringVar(&o.Default, "default", "", "verb or subcommand run when none is named; default is to show help")
+	fs.BoolVar(&o.PFlag, "pflag", false, "also generate functions binding the flags of each command to a pflag.FlagSet")
+	fs.BoolVar(&o.ResponseFiles, "argfiles", false, "expand @file arguments into the arguments held by the file, one per line")
+	fs.BoolVar(&o.Abbreviate, "abbrev", false, "accept unique prefixes of the names of flags, verbs and subcommands")
+	fs.BoolVar(&o.Env, "env", false, "also generate an env command, listing the environment variables the program reads")
+	fs.BoolVar(&o.SelfUpdate, "selfupdate", false, "also generate a selfupdate command, updating the program to its latest release")
+	fs.BoolVar(&o.Pipes, "pipes", false, "run pipelines of commands, separated by '|' arguments, in one process")
+	fs.BoolVar(&o.Timeout, "timeout", false, "take a --timeout flag bounding how long any command runs")
+	fs.BoolVar(&o.Schedule, "schedule", false, "take --every, --jitter, --until and --keep-going flags running any command repeatedly")
+	fs.BoolVar(&o.Interactive, "interactive", false, "take an --interactive flag asking for the flags of a command with a form")
+	fs.BoolVar(&o.Manifest, "manifest", false, "take a --from-file flag naming a JSON manifest of the inputs of a command")
+	fs.BoolVar(&o.ShowConfig, "showconfig", false, "take a --show-config flag showing the value and source of each flag of a command")
+	fs.StringVar(&o.SlicePolicy, "slices", "repeat", "how slice flags take their values: repeat, split or both")
+	fs.BoolVar(&o.Strict, "strict", true, "reject struct tags with unknown components; when false, only warn of them")
+	fs.BoolVar(&o.Internal, "internal", false, "generate into a package of its own, in "+InternalDir+"/<command> beneath the directory")
+}
+
+// importedType splits typ into the import path of the package declaring it and
+// its name, when it names the type of another package as path.Type. The path
+// is empty otherwise.
+func importedType(typ string) (pkgPath, name string) {
+	i := strings.LastIndex(typ, ".")
+	if i < 0 {
+		return "", typ
+	}
+	return typ[:i], typ[i+1:]
+}
+
+// Compile the command which the options generate from target, which is either
+// a Go file or the directory of a package. The types are found in the file,
+// or anywhere in the package of the directory, unless they are of another
+// package, which is then loaded by FromPackage.
+func (o *Options) Compile(target string) (*Command, error) {
+	if (o.Type == "") == (o.Types == "") {
+		return nil, errors.New("one of -type or -types is required")
+	}
+	switch o.SlicePolicy {
+	case "", "repeat", "split", "both":
+	default:
+		return nil, fmt.Errorf("invalid -slices %q: must be one of repeat, split, both", o.SlicePolicy)
+	}
+	dir := target
+	if fi, err := os.Stat(target); err == nil && !fi.IsDir() {
+		dir = filepath.Dir(target)
+	}
+
+	var cmds []*Command
+	var imported string
+	for i, typ := range strings.Split(o.Type+o.Types, ",") {
+		typ = strings.TrimSpace(typ)
+		pkgPath, name := importedType(typ)
+		if i > 0 && pkgPath != imported {
+			return nil, fmt.Errorf("types %v are not all of one package", o.Types)
+		}
+		imported = pkgPath
+		var cmd *Command
+		switch {
+		case pkgPath != "":
+			if cmd = FromPackage(pkgPath, name); cmd == nil {
+				return nil, fmt.Errorf("no command type %v found in package %v", name, pkgPath)
+			}
+		case dir == target:
+			cmd = FromDir(dir, typ)
+		default:
+			f, err := os.Open(target)
+			if err != nil {
+				return nil, err
+			}
+			cmd = FromFile(f, typ)
+			f.Close()
+		}
+		if cmd == nil {
+			return nil, fmt.Errorf("no command type %v found in %v", typ, target)
+		}
+		cmds = append(cmds, cmd)
+	}
+	cmd := cmds[0]
+	if o.Types != "" {
+		cmd = NewParent(cmd.Name, cmds...)
+	}
+	if imported != "" {
+		// The command is generated into the package of the target, which
+		// imports that of its types.
+		pkg, err := build.ImportDir(dir, 0)
+		if err != nil {
+			return nil, err
+		}
+		cmd.OutputPackage = pkg.Name
+	}
+	switch {
+	case o.Name != "":
+		cmd.Name = o.Name
+	case cmd.outputPackage() == "main":
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return nil, err
+		}
+		cmd.Name = strcase.ToKebab(filepath.Base(abs))
+	}
+	if o.Internal {
+		if cmd.ImportPath == "" {
+			pkgs, err := packages.Load(&packages.Config{Mode: packages.NeedName, Dir: dir}, ".")
+			if err != nil {
+				return nil, err
+			}
+			if len(pkgs) != 1 || len(pkgs[0].Errors) > 0 {
+				return nil, fmt.Errorf("no package to import found in %v", dir)
+			}
+			cmd.ImportPath = pkgs[0].PkgPath
+		}
+		cmd.OutputPackage = InternalPackage(cmd.Name)
+	}
+	cmd.Default = o.Default
+	cmd.PFlag = o.PFlag
+	cmd.ResponseFiles = o.ResponseFiles
+	cmd.Abbreviate = o.Abbreviate
+	cmd.Env = o.Env
+	cmd.SelfUpdate = o.SelfUpdate
+	cmd.Pipes = o.Pipes
+	cmd.Timeout = o.Timeout
+	cmd.Schedule = o.Schedule
+	for _, c := range cmd.tree() {
+		c.SlicePolicy = o.SlicePolicy
+		c.Interactive = o.Interactive
+		c.Manifest = o.Manifest
+		c.ShowConfig = o.ShowConfig
+	}
+	cmd.Lenient = !o.Strict
+	return cmd, nil
+}
+
+// OutputFile returns the file to which cmd, compiled from target by Compile,
+// is written: Output, when given, or a file in the directory of target named
+// after the type, as t_cliche.go for type T or path.T, or for Types, after the
+// command, as name_cliche.go. With Internal, the file is in the directory of
+// the command's package beneath that of target instead.
+func (o *Options) OutputFile(cmd *Command, target string) string {
+	dir := target
+	if fi, err := os.Stat(target); err == nil && !fi.IsDir() {
+		dir = filepath.Dir(target)
+	}
+	if o.Internal {
+		dir = filepath.Join(dir, filepath.FromSlash(InternalDir), cmd.OutputPackage)
+	}
+	switch {
+	case o.Output != "":
+		return o.Output
+	case o.Types != "":
+		return filepath.Join(dir, strcase.ToSnake(cmd.Name)+"_cliche.go")
+	}
+	_, typ := importedType(o.Type)
+	return filepath.Join(dir, strings.ToLower(typ)+"_cliche.go")
+}
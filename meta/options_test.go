@@ -0,0 +1,128 @@
+package meta
+
+import (
+	"flag"
+	"io"
+	"path/filepath"
+	"testing"
+)
+
+func TestOptionsCompile(t *testing.T) {
+	type test struct {
+		args        []string
+		target      string
+		wantName    string
+		wantLenient bool
+		wantPFlag   bool
+		wantOutput  string
+		wantErr     bool
+		wantArgs    bool
+		wantAbbrev  bool
+	}
+
+	for tn, tc := range map[string]test{
+		"directory": {[]string{"-type=Greet"}, "testdata/lenient", "lenient", false, false, "testdata/lenient/greet_cliche.go", false, false, false},
+		"file":      {[]string{"-type=Greet", "-strict=false"}, "testdata/lenient/lenient.go", "lenient", true, false, "testdata/lenient/greet_cliche.go", false, false, false},
+		"options": {
+			[]string{"-types=Greet", "-name=hi", "-pflag", "-argfiles", "-abbrev", "-output=out.go"}, "testdata/lenient", "hi", false, true, "out.go", false, true, true,
+		},
+		"types and type": {args: []string{"-type=Greet", "-types=Greet"}, target: "testdata/lenient", wantErr: true},
+		"missing type":   {args: []string{"-type=Missing"}, target: "testdata/lenient", wantErr: true},
+		"bad slices":     {args: []string{"-type=Greet", "-slices=some"}, target: "testdata/lenient", wantErr: true},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			var opts Options
+			fs := flag.NewFlagSet("cliche", flag.ContinueOnError)
+			fs.SetOutput(io.Discard)
+			opts.RegisterFlags(fs)
+			if err := fs.Parse(tc.args); err != nil {
+				t.Fatalf("Parse(): unexpected error: %v", err)
+			}
+			cmd, err := opts.Compile(tc.target)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Compile(): got error %v, want error: %v", err, tc.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if cmd.Name != tc.wantName || cmd.Lenient != tc.wantLenient || cmd.PFlag != tc.wantPFlag {
+				t.Errorf("Compile(): got name %q, lenient %v, pflag %v, want %q, %v, %v",
+					cmd.Name, cmd.Lenient, cmd.PFlag, tc.wantName, tc.wantLenient, tc.wantPFlag)
+			}
+			if cmd.ResponseFiles != tc.wantArgs {
+				t.Errorf("Compile(): got response files %v, want %v", cmd.ResponseFiles, tc.wantArgs)
+			}
+			if cmd.Abbreviate != tc.wantAbbrev {
+				t.Errorf("Compile(): got abbreviations %v, want %v", cmd.Abbreviate, tc.wantAbbrev)
+			}
+			if got := opts.OutputFile(cmd, tc.target); got != filepath.FromSlash(tc.wantOutput) {
+				t.Errorf("OutputFile(): got: %v want: %v", got, tc.wantOutput)
+			}
+		})
+	}
+}
+
+func TestOptionsCompileInternal(t *testing.T) {
+	opts := Options{Types: "Build,Clean", Name: "my-build", Internal: true}
+	cmd, err := opts.Compile("testdata/globals")
+	if err != nil {
+		t.Fatalf("Compile(): unexpected error: %v", err)
+	}
+	if cmd.ImportPath != "idontfixcomputers.com/cliche/meta/testdata/globals" || cmd.OutputPackage != "mybuild" {
+		t.Errorf("Compile(): got package at %q into %v, want testdata/globals into mybuild", cmd.ImportPath, cmd.OutputPackage)
+	}
+	if got, want := opts.OutputFile(cmd, "testdata/globals"), filepath.FromSlash("testdata/globals/internal/clichegen/mybuild/my_build_cliche.go"); got != want {
+		t.Errorf("OutputFile(): got: %v want: %v", got, want)
+	}
+	if err := cmd.Generate(io.Discard); err != nil {
+		t.Errorf("Generate(): unexpected error: %v", err)
+	}
+}
+
+func TestInternalPackage(t *testing.T) {
+	for name, want := range map[string]string{
+		"tool":    "tool",
+		"my-tool": "mytool",
+		"Tool_2":  "tool2",
+		"3d":      "cmd3d",
+		"go-to":   "cmdgoto",
+		"-":       "cmd",
+	} {
+		if got := InternalPackage(name); got != want {
+			t.Errorf("InternalPackage(%q): got: %q want: %q", name, got, want)
+		}
+	}
+}
+
+func TestOptionsCompileImported(t *testing.T) {
+	const pkgPath = "idontfixcomputers.com/cliche/meta/testdata/globals"
+	for tn, tc := range map[string]struct {
+		opts       Options
+		wantOutput string
+		wantErr    bool
+	}{
+		"type":  {opts: Options{Type: pkgPath + ".Build"}, wantOutput: "testdata/lenient/build_cliche.go"},
+		"types": {opts: Options{Types: pkgPath + ".Build," + pkgPath + ".Clean"}, wantOutput: "testdata/lenient/globals_cliche.go"},
+		"mixed": {opts: Options{Types: pkgPath + ".Build,Greet"}, wantErr: true},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			cmd, err := tc.opts.Compile("testdata/lenient")
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Compile(): got error %v, want error: %v", err, tc.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if cmd.ImportPath != pkgPath || cmd.Package != "globals" || cmd.OutputPackage != "lenient" {
+				t.Errorf("Compile(): got package %v at %q into %v, want globals at %q into lenient",
+					cmd.Package, cmd.ImportPath, cmd.OutputPackage, pkgPath)
+			}
+			if got := tc.opts.OutputFile(cmd, "testdata/lenient"); got != filepath.FromSlash(tc.wantOutput) {
+				t.Errorf("OutputFile(): got: %v want: %v", got, tc.wantOutput)
+			}
+			if err := cmd.Generate(io.Discard); err != nil {
+				t.Errorf("Generate(): unexpected error: %v", err)
+			}
+		})
+	}
+}
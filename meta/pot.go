@@ -0,0 +1,61 @@
+package meta
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// poQuote formats s as a PO string, splitting it across lines after each
+// newline as is conventional for multi-line messages.
+func poQuote(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\t", `\t`, "\n", `\n`)
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) <= 1 {
+		return `"` + r.Replace(s) + `"`
+	}
+	var b strings.Builder
+	b.WriteString(`""`)
+	for _, line := range lines {
+		b.WriteString("\n\"" + r.Replace(line) + `"`)
+	}
+	return b.String()
+}
+
+// writePOEntry writes a single untranslated entry to a PO template.
+func writePOEntry(w *bufio.Writer, comment, ctxt, msgid string) {
+	if msgid == "" {
+		return
+	}
+	fmt.Fprintf(w, "\n#. %v\nmsgctxt %v\nmsgid %v\nmsgstr \"\"\n", comment, poQuote(ctxt), poQuote(msgid))
+}
+
+// WritePOT writes the translatable strings of cmds to w as a gettext PO
+// template, from which translators can produce catalogs for each language.
+// Each message is keyed with a context naming the command and, where
+// applicable, the input or verb from which it comes, such as "tool:help",
+// "tool:input:Port" or "tool:verb:push".
+func WritePOT(w io.Writer, cmds ...*Command) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprint(bw, "# Translatable strings for cliche commands.\nmsgid \"\"\nmsgstr \"\"\n\"Content-Type: text/plain; charset=UTF-8\\n\"\n")
+	for _, cmd := range cmds {
+		if cmd == nil {
+			continue
+		}
+		writePOEntry(bw, "Help for the "+cmd.Name+" command.", cmd.Name+":help", cmd.Help)
+		writePOEntry(bw, "Description of the "+cmd.Name+" command.", cmd.Name+":description", cmd.Description)
+		for _, input := range cmd.Inputs {
+			writePOEntry(bw, "Help for the "+input.FieldName+" input of the "+cmd.Name+" command.",
+				cmd.Name+":input:"+input.FieldName, input.Doc)
+		}
+		for _, verb := range cmd.Verbs {
+			writePOEntry(bw, "Description of the "+cmd.Name+" "+verb.Name+" command.",
+				cmd.Name+":verb:"+verb.Name, verb.Description)
+		}
+	}
+	return bw.Flush()
+}
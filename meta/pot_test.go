@@ -0,0 +1,57 @@
+package meta
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWritePOT(t *testing.T) {
+	cmd := &Command{
+		Name:        "tool",
+		Help:        "tool does \"things\".\n\nIt does them well.",
+		Description: "Tool is a command.",
+		Inputs: []CommandInput{
+			{FieldName: "Port", Doc: "Port to listen on."},
+			{FieldName: "Undocumented"},
+		},
+		Verbs: []Verb{{Name: "push", Description: "RunPush pushes."}},
+	}
+
+	var b strings.Builder
+	if err := WritePOT(&b, cmd, nil); err != nil {
+		t.Fatalf("WritePOT(): unexpected error: %v", err)
+	}
+	want := `# Translatable strings for cliche commands.
+msgid ""
+msgstr ""
+"Content-Type: text/plain; charset=UTF-8\n"
+
+#. Help for the tool command.
+msgctxt "tool:help"
+msgid ""
+"tool does \"things\".\n"
+"\n"
+"It does them well."
+msgstr ""
+
+#. Description of the tool command.
+msgctxt "tool:description"
+msgid "Tool is a command."
+msgstr ""
+
+#. Help for the Port input of the tool command.
+msgctxt "tool:input:Port"
+msgid "Port to listen on."
+msgstr ""
+
+#. Description of the tool push command.
+msgctxt "tool:verb:push"
+msgid "RunPush pushes."
+msgstr ""
+`
+	if diff := cmp.Diff(b.String(), want); diff != "" {
+		t.Errorf("WritePOT(): mismatch(-got,+want):\n%v", diff)
+	}
+}
@@ -0,0 +1,98 @@
+package meta
+
+import (
+	"encoding/json"
+	"io"
+	"path/filepath"
+)
+
+// SARIF rules by which problems are reported, one for those which stop a
+// command from being generated, and one for warnings.
+const (
+	sarifInvalid      = "invalid-command"
+	sarifQuestionable = "questionable-command"
+)
+
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver struct {
+		Name  string      `json:"name"`
+		Rules []sarifRule `json:"rules"`
+	} `json:"driver"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation struct {
+		ArtifactLocation struct {
+			URI string `json:"uri"`
+		} `json:"artifactLocation"`
+		Region *sarifRegion `json:"region,omitempty"`
+	} `json:"physicalLocation"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+// WriteSARIF writes problems, as found by Vet, to w as a SARIF 2.1.0 log of a
+// single run of cliche, so that code review systems and editors which ingest
+// SARIF can annotate the fields and directives they are found at. Problems
+// are errors, or warnings when Warning is set, and are located by the file,
+// line and column of their positions, when valid.
+func WriteSARIF(w io.Writer, problems []*ValidationError) error {
+	run := sarifRun{Results: []sarifResult{}}
+	run.Tool.Driver.Name = "cliche"
+	run.Tool.Driver.Rules = []sarifRule{
+		{sarifInvalid, sarifMessage{"The command can't be generated."}},
+		{sarifQuestionable, sarifMessage{"The command contradicts itself or has declarations with no effect."}},
+	}
+	for _, problem := range problems {
+		result := sarifResult{RuleID: sarifInvalid, Level: "error", Message: sarifMessage{problem.Err.Error()}}
+		if problem.Warning {
+			result.RuleID, result.Level = sarifQuestionable, "warning"
+		}
+		if problem.Pos.Filename != "" {
+			var loc sarifLocation
+			loc.PhysicalLocation.ArtifactLocation.URI = filepath.ToSlash(problem.Pos.Filename)
+			if problem.Pos.IsValid() {
+				loc.PhysicalLocation.Region = &sarifRegion{problem.Pos.Line, problem.Pos.Column}
+			}
+			result.Locations = append(result.Locations, loc)
+		}
+		run.Results = append(run.Results, result)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{
+		Version: "2.1.0",
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Runs:    []sarifRun{run},
+	})
+}
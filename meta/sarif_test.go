@@ -0,0 +1,72 @@
+package meta
+
+import (
+	"encoding/json"
+	"errors"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWriteSARIF(t *testing.T) {
+	problems := []*ValidationError{
+		{Pos: token.Position{Filename: "tool/tool.go", Line: 12, Column: 2}, Err: errors.New("field Name: bad tag")},
+		{Pos: token.Position{Filename: "tool/tool.go", Line: 20, Column: 9}, Err: errors.New("field Force: is a required bool flag"), Warning: true},
+		{Err: errors.New("command name \"Tool\" is not valid")},
+	}
+	var b strings.Builder
+	if err := WriteSARIF(&b, problems); err != nil {
+		t.Fatalf("WriteSARIF(): unexpected error: %v", err)
+	}
+
+	var log struct {
+		Version string
+		Runs    []struct {
+			Tool struct {
+				Driver struct {
+					Name  string
+					Rules []struct{ ID string }
+				}
+			}
+			Results []struct {
+				RuleID, Level string
+				Message       struct{ Text string }
+				Locations     []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct{ URI string }
+						Region           struct{ StartLine, StartColumn int }
+					}
+				}
+			}
+		}
+	}
+	if err := json.Unmarshal([]byte(b.String()), &log); err != nil {
+		t.Fatalf("WriteSARIF(): log is not JSON: %v\n%v", err, b.String())
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 || log.Runs[0].Tool.Driver.Name != "cliche" {
+		t.Fatalf("WriteSARIF(): unexpected log:\n%v", b.String())
+	}
+	type result struct {
+		Rule, Level, Text, URI string
+		Line, Column           int
+	}
+	var got []result
+	for _, r := range log.Runs[0].Results {
+		res := result{Rule: r.RuleID, Level: r.Level, Text: r.Message.Text}
+		for _, loc := range r.Locations {
+			res.URI = loc.PhysicalLocation.ArtifactLocation.URI
+			res.Line, res.Column = loc.PhysicalLocation.Region.StartLine, loc.PhysicalLocation.Region.StartColumn
+		}
+		got = append(got, res)
+	}
+	want := []result{
+		{"invalid-command", "error", "field Name: bad tag", "tool/tool.go", 12, 2},
+		{"questionable-command", "warning", "field Force: is a required bool flag", "tool/tool.go", 20, 9},
+		{"invalid-command", "error", `command name "Tool" is not valid`, "", 0, 0},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("WriteSARIF(): mismatch (-got,+want):\n%v", diff)
+	}
+}
@@ -1,10 +1,12 @@
 package meta
 
 import (
+	"errors"
 	"fmt"
-	"regexp"
+	"go/token"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // ArgSpec describes parsed positional arguments as defined in a facile struct
@@ -15,6 +17,19 @@ type ArgSpec struct {
 	// End index, exclusive. A negative value indicates that all remaining
 	// arguments beginning with Start should be consumed.
 	End int
+	// Step between the arguments of a range which are consumed, such that a
+	// Step of 2 consumes every other argument. Zero consumes every argument,
+	// as does one.
+	Step int
+}
+
+// Stride returns the step between the arguments consumed, which is at least
+// one.
+func (spec *ArgSpec) Stride() int {
+	if spec == nil || spec.Step < 1 {
+		return 1
+	}
+	return spec.Step
 }
 
 // String representation of the arg spec. Will be equivalent to the parsed tag,
@@ -23,16 +38,25 @@ func (spec *ArgSpec) String() string {
 	if spec == nil {
 		return ""
 	}
-	if spec.Start == 0 && spec.End == -1 {
-		return "arg:[:]"
+	if spec.End == 0 {
+		// A single argument, rather than a range.
+		return fmt.Sprintf("arg:%d", spec.Start)
+	}
+	var start, end string
+	if spec.Start != 0 {
+		start = strconv.Itoa(spec.Start)
+	}
+	if spec.End > 0 {
+		end = strconv.Itoa(spec.End)
 	}
-	if spec.Start == spec.End {
-		return fmt.Sprint(spec.Start)
+	if spec.Step > 1 {
+		return fmt.Sprintf("arg:[%v:%v:%d]", start, end, spec.Step)
 	}
-	return fmt.Sprintf("[%d:%d]", spec.Start, spec.End)
+	return fmt.Sprintf("arg:[%v:%v]", start, end)
 }
 
-// FlagSpec describes parsed flags as defined in a facile struct tag.
+// FlagSpec describes parsed flags as defined in a facile struct tag. A flag
+// declared by a single letter, as in flag:v, has only a Short name.
 type FlagSpec struct {
 	Long  string
 	Short string
@@ -44,8 +68,11 @@ func (spec *FlagSpec) String() string {
 	if spec == nil {
 		return ""
 	}
-	if spec.Posixy() {
+	switch {
+	case spec.Posixy():
 		return fmt.Sprintf("flag:%v,%v", spec.Long, spec.Short)
+	case spec.ShortOnly():
+		return fmt.Sprintf("flag:%v", spec.Short)
 	}
 	return fmt.Sprintf("flag:%v", spec.Long)
 }
@@ -59,6 +86,30 @@ func (spec *FlagSpec) Posixy() bool {
 	return spec.Long != "" && len(spec.Short) == 1
 }
 
+// ShortOnly is true when the flag spec represents a flag which has only a
+// short flag form, such as -v.
+func (spec *FlagSpec) ShortOnly() bool {
+	if spec == nil {
+		return false
+	}
+	return spec.Long == "" && len(spec.Short) == 1
+}
+
+// WithPrefix returns the spec of the flag with prefix prepended to its long
+// name. A prefix can't be applied to a short name, so the short name of a
+// posixy flag is dropped, and that of a short-only flag becomes the end of its
+// long name, as with -v becoming --db-v.
+func (spec *FlagSpec) WithPrefix(prefix string) *FlagSpec {
+	if spec.ShortOnly() {
+		return &FlagSpec{Long: prefix + spec.Short}
+	}
+	return &FlagSpec{Long: prefix + spec.Long}
+}
+
+// TagKey is the struct tag key under which cliche tags are declared, as in
+// `cliche:"flag:name;default:World"`.
+const TagKey = "cliche"
+
 // Tag as on members of a struct which will be used to define cliche command
 // inputs.
 type Tag string
@@ -88,72 +139,171 @@ func (tag Tag) decompose() (arg, def, flag string) {
 	return
 }
 
-var argRe = regexp.MustCompile(`(\d+)|\[([^:]+)?(\:)?([^\]]+)?\]`)
+// Excluded is true when the tag is exactly "-", which excludes the field from
+// the command's inputs altogether.
+func (tag Tag) Excluded() bool {
+	return strings.TrimSpace(string(tag)) == "-"
+}
 
-// parseArg parses the arg value from a cliche struct tag.
-func parseArg(tval string, spec *ArgSpec) bool {
-	tval = strings.TrimSpace(tval)
-	if tval == "" {
-		return false
+// component returns the value of the named component of a tag, and whether
+// the component is present at all. Components which act as markers, having no
+// value, are present with an empty value.
+func (tag Tag) component(name string) (string, bool) {
+	for _, c := range strings.Split(string(tag), ";") {
+		c = strings.TrimSpace(c)
+		if c == name {
+			return "", true
+		}
+		if v, ok := strings.CutPrefix(c, name+":"); ok {
+			return strings.TrimSpace(v), true
+		}
 	}
+	return "", false
+}
 
-	m := argRe.FindStringSubmatch(tval)
-	if len(m) != 5 {
-		// Better to return early than panic. This should only happen if argRe
-		// is modified.
-		return false
+// TagError describes a component of a Tag which could not be parsed.
+type TagError struct {
+	// Component of the tag which failed to parse, such as "arg" or "flag".
+	Component string
+	// Value of the component, as it appears in the tag.
+	Value string
+	// Reason the value could not be parsed.
+	Reason string
+}
+
+func (err *TagError) Error() string {
+	if err.Value == "" {
+		return fmt.Sprintf("invalid tag component %v: %v", err.Component, err.Reason)
+	}
+	return fmt.Sprintf("invalid tag component %v:%q: %v", err.Component, err.Value, err.Reason)
+}
+
+// parseIndex parses a single, non-negative positional argument index.
+func parseIndex(s string) (int, string) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, "missing index"
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return 0, fmt.Sprintf("non-numeric index %q", s)
+		}
+	}
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Sprintf("index %q out of range", s)
+	}
+	return i, ""
+}
+
+// parseArg parses the arg value from a cliche struct tag. The reason for a
+// failure is returned when the value is invalid.
+func parseArg(tval string, spec *ArgSpec) string {
+	tval = strings.TrimSpace(tval)
+	if tval == "" {
+		return "empty value"
 	}
 
 	// Handle plain number (not slice index) notation.
-	if iconv, err := strconv.Atoi(m[1]); m[1] != "" && err == nil {
-		spec.Start = iconv
-		return true
+	if !strings.HasPrefix(tval, "[") {
+		if strings.ContainsAny(tval, "[]") {
+			return "unbalanced brackets"
+		}
+		i, reason := parseIndex(tval)
+		if reason != "" {
+			return reason
+		}
+		spec.Start = i
+		return ""
 	}
 
 	// Handle the slice index notation.
-	s, r, e := m[2], m[3], m[4]
-	if s == "" && r == "" && e == "" {
+	inner, ok := strings.CutSuffix(tval[1:], "]")
+	if !ok || strings.ContainsAny(inner, "[]") {
+		return "unbalanced brackets"
+	}
+	if strings.TrimSpace(inner) == "" {
 		// Empty brackets are verboten.
-		return false
+		return "empty brackets"
 	}
 
-	var err error
-	var iconv int
-	if s == "" {
-		spec.Start = 0
-	} else if iconv, err = strconv.Atoi(s); err == nil {
-		spec.Start = iconv
+	s, e, isRange := strings.Cut(inner, ":")
+	if !isRange {
+		i, reason := parseIndex(s)
+		if reason != "" {
+			return reason
+		}
+		spec.Start = i
+		return ""
 	}
 
-	if r == "" {
-		// This arg spec is not a range. Nothing else needs doing.
-		return (err == nil)
+	var start int
+	if s = strings.TrimSpace(s); s != "" {
+		i, reason := parseIndex(s)
+		if reason != "" {
+			return "range start: " + reason
+		}
+		start = i
 	}
 
-	if e == "" {
-		// No end of the range, so consume all remaining.
-		spec.End = -1
-	} else if iconv, err = strconv.Atoi(e); err == nil {
-		spec.End = iconv
+	// An optional step follows the end, as in Go's full slice expressions
+	// but meaning what it does in Python's.
+	e, st, strided := strings.Cut(e, ":")
+	var step int
+	if st = strings.TrimSpace(st); strided && st != "" {
+		i, reason := parseIndex(st)
+		if reason != "" {
+			return "range step: " + reason
+		}
+		if i == 0 {
+			return "range step must be positive"
+		}
+		step = i
 	}
 
-	return (err == nil && // range end is a number
-		spec.Start >= 0 && // range start is not negative
-		((spec.End == -1) || (spec.End > spec.Start))) // end is larger than start, unless unbounded
+	// No end of the range means consume all remaining.
+	end := -1
+	if e = strings.TrimSpace(e); e != "" {
+		i, reason := parseIndex(e)
+		if reason != "" {
+			return "range end: " + reason
+		}
+		if i <= start {
+			return fmt.Sprintf("range end %d must be greater than range start %d", i, start)
+		}
+		end = i
+	}
+
+	spec.Start, spec.End = start, end
+	if step > 1 {
+		spec.Step = step
+	}
+	return ""
 }
 
-// Arg returns the argument specification from a Tag, if any.
-func (tag Tag) Arg() (*ArgSpec, bool) {
+// ParseArg returns the argument specification from a Tag. If the Tag has no
+// arg component, both return values are nil. If the arg component is
+// malformed, a *TagError describing the problem is returned.
+func (tag Tag) ParseArg() (*ArgSpec, error) {
 	arg, _, _ := tag.decompose()
 	if arg == "" {
-		return nil, false
+		return nil, nil
 	}
 
 	var ret ArgSpec
-	if parseArg(arg, &ret) {
-		return &ret, true
+	if reason := parseArg(arg, &ret); reason != "" {
+		return nil, &TagError{Component: "arg", Value: arg, Reason: reason}
 	}
-	return nil, false
+	return &ret, nil
+}
+
+// Arg returns the argument specification from a Tag, if any.
+func (tag Tag) Arg() (*ArgSpec, bool) {
+	spec, err := tag.ParseArg()
+	if err != nil || spec == nil {
+		return nil, false
+	}
+	return spec, true
 }
 
 // Default returns the string representation of the default value as specified
@@ -166,37 +316,853 @@ func (tag Tag) Default() (string, bool) {
 	return "", false
 }
 
-var flagRe = regexp.MustCompile(`^([a-zA-Z][a-zA-Z0-9_-]+)(?:,\s*([a-zA-z]))?$`)
+// isFlagNameChar is true for runes which may appear in a long flag name after
+// the leading letter.
+func isFlagNameChar(r rune) bool {
+	return isASCIILetter(r) || (r >= '0' && r <= '9') || r == '_' || r == '-'
+}
+
+func isASCIILetter(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
 
-// parseFlag parses the flag value from a cliche struct tag.
-func parseFlag(tval string, spec *FlagSpec) bool {
+// parseFlag parses the flag value from a cliche struct tag. A lone name of a
+// single letter declares a short-only flag. The reason for a failure is
+// returned when the value is invalid.
+func parseFlag(tval string, spec *FlagSpec) string {
 	tval = strings.TrimSpace(tval)
 	if tval == "" {
-		return false
+		return "empty value"
 	}
-	m := flagRe.FindStringSubmatch(tval)
-	if m == nil {
-		return false
+
+	long, short, posixy := strings.Cut(tval, ",")
+	long = strings.TrimSpace(long)
+	if long == "" {
+		return "missing long flag name"
 	}
-	if len(m) != 3 {
-		// This should only happen when flagRe is modified.
-		return false
+	for i, r := range long {
+		if i == 0 && !isASCIILetter(r) {
+			return fmt.Sprintf("flag name %q must begin with a letter", long)
+		}
+		if !isFlagNameChar(r) {
+			return fmt.Sprintf("invalid character %q in flag name %q", r, long)
+		}
 	}
-	spec.Long = m[1]
-	spec.Short = m[2]
-	return true
+	if len(long) < 2 && !posixy {
+		// A single letter is the name of a short-only flag.
+		spec.Short = long
+		return ""
+	}
+	if len(long) < 2 {
+		return fmt.Sprintf("flag name %q must be at least two characters", long)
+	}
+
+	if posixy {
+		short = strings.TrimSpace(short)
+		if len(short) != 1 || !isASCIILetter(rune(short[0])) {
+			return fmt.Sprintf("short flag %q must be a single letter", short)
+		}
+	}
+
+	spec.Long = long
+	spec.Short = short
+	return ""
 }
 
-// Flag returns the flag specifications from a Tag, if any.
-func (tag Tag) Flag() (*FlagSpec, bool) {
+// ParseFlag returns the flag specification from a Tag. If the Tag has no flag
+// component, both return values are nil. If the flag component is malformed, a
+// *TagError describing the problem is returned.
+func (tag Tag) ParseFlag() (*FlagSpec, error) {
 	_, _, flag := tag.decompose()
 	if flag == "" {
-		return nil, false
+		return nil, nil
 	}
 
 	var ret FlagSpec
-	if parseFlag(flag, &ret) {
-		return &ret, true
+	if reason := parseFlag(flag, &ret); reason != "" {
+		return nil, &TagError{Component: "flag", Value: flag, Reason: reason}
+	}
+	return &ret, nil
+}
+
+// Flag returns the flag specifications from a Tag, if any.
+func (tag Tag) Flag() (*FlagSpec, bool) {
+	spec, err := tag.ParseFlag()
+	if err != nil || spec == nil {
+		return nil, false
+	}
+	return spec, true
+}
+
+// Group returns the name of the help section under which the input should be
+// listed, as specified in the struct tag.
+func (tag Tag) Group() (string, bool) {
+	if group, _ := tag.component("group"); group != "" {
+		return group, true
+	}
+	return "", false
+}
+
+// Order returns the weight by which the input is ordered in help, as specified
+// in the struct tag. Inputs of lower weight are listed first, and those without
+// an order component weigh 0. Not ok unless the weight is an integer.
+func (tag Tag) Order() (int, bool) {
+	weight, ok := tag.component("order")
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.Atoi(weight)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+// Inject returns the name of the provider which populates the input, as
+// specified in the struct tag. Injected inputs are not bound from the command
+// line. A bare inject component selects the unnamed provider for the input's
+// type, which is indicated by an empty name.
+func (tag Tag) Inject() (string, bool) {
+	return tag.component("inject")
+}
+
+// Stdin formats which may be specified in a struct tag.
+const (
+	// StdinRaw binds standard input itself to the input, which should be of
+	// type io.Reader.
+	StdinRaw = ""
+	// StdinJSON decodes a single JSON document from standard input into the
+	// input.
+	StdinJSON = "json"
+	// StdinNDJSON decodes newline-delimited JSON documents from standard input,
+	// appending each to the input, which should be a slice.
+	StdinNDJSON = "ndjson"
+)
+
+// Stdin returns the format in which the input is read from standard input, as
+// specified in the struct tag. A bare stdin component yields StdinRaw. Unknown
+// formats are not ok.
+func (tag Tag) Stdin() (string, bool) {
+	format, ok := tag.component("stdin")
+	if !ok {
+		return "", false
+	}
+	switch format {
+	case StdinRaw, StdinJSON, StdinNDJSON:
+		return format, true
+	}
+	return "", false
+}
+
+// Required is true when the tag marks the input as one which must be given on
+// the command line, as a flag or positional argument, for the command to run.
+// A negatable flag may be given in either form. A scalar positional argument
+// without a default must always be given anyway, so for one it changes only the
+// error when it is missing: a MissingError listing it with the other missing
+// inputs.
+func (tag Tag) Required() bool {
+	_, ok := tag.component("required")
+	return ok
+}
+
+// Pairs is true when the tag marks the input's range of positional arguments
+// as alternating keys and values, which are bound to a map.
+func (tag Tag) Pairs() bool {
+	_, ok := tag.component("pairs")
+	return ok
+}
+
+// Negatable is true when the tag marks a bool flag as also given in negated
+// form, as --no-color for --color, which sets it to false.
+func (tag Tag) Negatable() bool {
+	_, ok := tag.component("negatable")
+	return ok
+}
+
+// Count is true when the tag marks an integer flag as counting the times it is
+// given, as -v -v or -vv for a verbosity of 2.
+func (tag Tag) Count() bool {
+	_, ok := tag.component("count")
+	return ok
+}
+
+// Hidden is true when the tag marks a flag as left out of help and completion,
+// though it is still parsed.
+func (tag Tag) Hidden() bool {
+	_, ok := tag.component("hidden")
+	return ok
+}
+
+// Secret is true when the tag marks a flag whose value is kept from view: not
+// echoed when typed into an interactive form, and redacted when shown.
+func (tag Tag) Secret() bool {
+	_, ok := tag.component("secret")
+	return ok
+}
+
+// Deprecated returns the note shown along with the warning printed when a
+// deprecated flag is used, such as "use --new-flag instead", as specified in
+// the struct tag. A bare deprecated component yields an empty note. Not ok
+// unless the flag is deprecated.
+func (tag Tag) Deprecated() (string, bool) {
+	return tag.component("deprecated")
+}
+
+// Env returns the name of the environment variable from which a flag takes its
+// value when it is not given on the command line, such as APP_PORT, as
+// specified in the struct tag. Not ok unless the name is made of letters,
+// digits and underscores, beginning with a letter or underscore.
+func (tag Tag) Env() (string, bool) {
+	name, ok := tag.component("env")
+	if !ok || !validEnvName(name) {
+		return "", false
+	}
+	return name, true
+}
+
+// validEnvName is true for names of environment variables which may be bound
+// to flags, as may be set by any shell.
+func validEnvName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		if !isASCIILetter(r) && r != '_' && (i == 0 || r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// Global is true when the tag marks the input as belonging to the root command,
+// rather than to the subcommand on which it is declared.
+func (tag Tag) Global() bool {
+	_, ok := tag.component("global")
+	return ok
+}
+
+// DryRun is true when the tag marks a bool flag as requesting a dry run, which
+// the command's Run method reads from its context with cliche.DryRunFrom.
+func (tag Tag) DryRun() bool {
+	_, ok := tag.component("dryrun")
+	return ok
+}
+
+// Verbosity is true when the tag marks an integer flag, such as a count, as
+// setting the verbosity which the command's Run method reads from its context
+// with cliche.VerbosityFrom.
+func (tag Tag) Verbosity() bool {
+	_, ok := tag.component("verbosity")
+	return ok
+}
+
+// Subcommand returns the name of the subcommand declared by the field, as
+// specified in the struct tag. The field's type is a command type of the same
+// package, which is run when the subcommand is named by the first positional
+// argument. A bare subcommand component yields an empty name, for a subcommand
+// named after the field. Not ok unless the name is usable as a command name.
+func (tag Tag) Subcommand() (string, bool) {
+	name, ok := tag.component("subcommand")
+	if !ok || (name != "" && !validName(name)) {
+		return "", false
+	}
+	return name, true
+}
+
+// ParsedTag holds every component of a cliche struct tag.
+type ParsedTag struct {
+	// Excluded is true when the tag is "-", in which case no other components
+	// are set.
+	Excluded bool
+
+	Arg *ArgSpec
+	// Pairs is true when the arguments of Arg are bound as keys and values.
+	Pairs bool
+	Flag  *FlagSpec
+	// Negatable is true when the bool flag may also be given as no- followed
+	// by its long name.
+	Negatable bool
+	// Count is true when the integer flag counts the times it is given.
+	Count bool
+	// Hidden is true when the flag is left out of help and completion.
+	Hidden bool
+	// Secret is true when the value of the flag is kept from view.
+	Secret bool
+	// Deprecated is true when using the flag prints a warning, which may
+	// carry a DeprecatedNote.
+	Deprecated     bool
+	DeprecatedNote string
+	Required       bool
+	Default        string
+	// Env names the environment variable from which the flag takes its
+	// value when it is not given.
+	Env      string
+	Group    string
+	Order    int
+	Global   bool
+	Complete string
+	Timezone string
+	// Layout of timestamps, as written in the tag; see TimeLayout.
+	Layout string
+	// Separator of the values of a slice flag, or of the keys and values of
+	// a map flag when not MapSeparator.
+	Separator string
+
+	// Migrate lists former long names of the flag, which are still accepted.
+	Migrate []string
+
+	// Validator names the method which validates the input's value.
+	Validator string
+	// Choices lists the values the input may take, when limited.
+	Choices []string
+
+	// Prefix is prepended to the long flag names of the inputs of an
+	// embedded struct.
+	Prefix string
+
+	// Omit lists the fields of an embedded struct which are not inputs.
+	Omit []string
+
+	// Inject is true when the tag has an inject component, which may select a
+	// provider by InjectName.
+	Inject     bool
+	InjectName string
+
+	// Stdin is true when the tag has a stdin component, which may select a
+	// format by StdinFormat.
+	Stdin       bool
+	StdinFormat string
+
+	// Lock is true when the tag has a lock component, which may name the lock
+	// by LockName.
+	Lock     bool
+	LockName string
+
+	// Subcommand is true when the tag has a subcommand component, which may
+	// name the subcommand by SubcommandName.
+	Subcommand     bool
+	SubcommandName string
+
+	// DryRun is true when the bool flag requests a dry run, and Verbosity
+	// when the integer flag sets the verbosity, either carried by the
+	// context with which the command runs.
+	DryRun    bool
+	Verbosity bool
+}
+
+// knownComponents are the names of all components of the cliche tag grammar.
+var knownComponents = []string{"arg", "choices", "complete", "count", "default", "deprecated", "dryrun", "env", "flag", "global", "group", "hidden", "inject", "layout", "lock", "migrate", "negatable", "omit", "order", "pairs", "prefix", "required", "secret", "sep", "stdin", "subcommand", "tz", "validate", "verbosity"}
+
+// editDistance between a and b, counting insertions, deletions, substitutions
+// and transpositions of adjacent characters as one edit each.
+func editDistance(a, b string) int {
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = d[i-1][j] + 1
+			if v := d[i][j-1] + 1; v < d[i][j] {
+				d[i][j] = v
+			}
+			if v := d[i-1][j-1] + cost; v < d[i][j] {
+				d[i][j] = v
+			}
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				if v := d[i-2][j-2] + 1; v < d[i][j] {
+					d[i][j] = v
+				}
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
+
+// suggestComponent returns the known component most similar to name, if any
+// is similar enough to plausibly be what was meant.
+func suggestComponent(name string) (string, bool) {
+	best, bestDistance := "", 3
+	for _, known := range knownComponents {
+		if d := editDistance(strings.ToLower(name), known); d < bestDistance {
+			best, bestDistance = known, d
+		}
+	}
+	return best, best != ""
+}
+
+// unknownComponents returns an error for each component of tag which is not
+// part of the cliche tag grammar.
+func (tag Tag) unknownComponents() (errs []error) {
+	for _, c := range strings.Split(string(tag), ";") {
+		c = strings.TrimSpace(c)
+		if c == "" {
+			continue
+		}
+		name, value, _ := strings.Cut(c, ":")
+		name = strings.TrimSpace(name)
+		var known bool
+		for _, k := range knownComponents {
+			if name == k {
+				known = true
+				break
+			}
+		}
+		if known {
+			continue
+		}
+		reason := "unknown component"
+		if suggestion, ok := suggestComponent(name); ok {
+			reason = fmt.Sprintf("unknown component; did you mean %v:?", suggestion)
+		}
+		errs = append(errs, &TagError{Component: name, Value: strings.TrimSpace(value), Reason: reason})
+	}
+	return
+}
+
+// ParseTag parses all components of a cliche struct tag at once. Any malformed
+// components are reported together in the returned error, and are left unset
+// in the returned ParsedTag. Unrecognized components are ignored.
+func ParseTag(s string) (ParsedTag, error) {
+	ret, errs := parseTag(Tag(s))
+	return ret, errors.Join(errs...)
+}
+
+// parseTag parses all components of tag, returning an error for each which is
+// malformed.
+func parseTag(tag Tag) (ParsedTag, []error) {
+	if tag.Excluded() {
+		return ParsedTag{Excluded: true}, nil
+	}
+
+	var ret ParsedTag
+	var errs []error
+	var err error
+	if ret.Arg, err = tag.ParseArg(); err != nil {
+		errs = append(errs, err)
+	}
+	ret.Pairs = tag.Pairs()
+	if ret.Flag, err = tag.ParseFlag(); err != nil {
+		errs = append(errs, err)
+	}
+	ret.Negatable = tag.Negatable()
+	ret.Count = tag.Count()
+	ret.Hidden = tag.Hidden()
+	ret.Secret = tag.Secret()
+	ret.DeprecatedNote, ret.Deprecated = tag.Deprecated()
+	ret.Required = tag.Required()
+	ret.Default, _ = tag.Default()
+	if name, ok := tag.component("env"); ok {
+		if ret.Env, ok = tag.Env(); !ok {
+			errs = append(errs, &TagError{Component: "env", Value: name, Reason: "not a valid environment variable name"})
+		}
+	}
+	ret.Group, _ = tag.Group()
+	if weight, ok := tag.component("order"); ok {
+		if ret.Order, ok = tag.Order(); !ok {
+			errs = append(errs, &TagError{Component: "order", Value: weight, Reason: "not an integer"})
+		}
+	}
+	ret.Global = tag.Global()
+	ret.DryRun = tag.DryRun()
+	ret.Verbosity = tag.Verbosity()
+	if hint, ok := tag.component("complete"); ok {
+		if ret.Complete, ok = tag.Complete(); !ok {
+			errs = append(errs, &TagError{Component: "complete", Value: hint, Reason: "unknown completion hint"})
+		}
+	}
+	if tz, ok := tag.component("tz"); ok {
+		if ret.Timezone, ok = tag.Timezone(); !ok {
+			errs = append(errs, &TagError{Component: "tz", Value: tz, Reason: "unknown time zone"})
+		}
+	}
+	if _, ok := tag.component("layout"); ok {
+		if ret.Layout, ok = tag.Layout(); !ok {
+			errs = append(errs, &TagError{Component: "layout", Reason: "empty layout"})
+		}
+	}
+	if sep, ok := tag.component("sep"); ok {
+		if ret.Separator, ok = tag.Separator(); !ok {
+			errs = append(errs, &TagError{Component: "sep", Value: sep, Reason: "empty separator"})
+		}
+	}
+	if names, ok := tag.component("migrate"); ok {
+		if ret.Migrate, ok = tag.Migrate(); !ok {
+			errs = append(errs, &TagError{Component: "migrate", Value: names, Reason: "invalid former flag name"})
+		}
+	}
+	if method, ok := tag.component("validate"); ok {
+		if ret.Validator, ok = tag.Validator(); !ok {
+			errs = append(errs, &TagError{Component: "validate", Value: method, Reason: "not an exported method name"})
+		}
+	}
+	if choices, ok := tag.component("choices"); ok {
+		if ret.Choices, ok = tag.Choices(); !ok {
+			errs = append(errs, &TagError{Component: "choices", Value: choices, Reason: "empty or repeated choice"})
+		}
+	}
+	if prefix, ok := tag.component("prefix"); ok {
+		if ret.Prefix, ok = tag.Prefix(); !ok {
+			errs = append(errs, &TagError{Component: "prefix", Value: prefix, Reason: "not the start of a long flag name"})
+		}
+	}
+	if fields, ok := tag.component("omit"); ok {
+		if ret.Omit, ok = tag.Omit(); !ok {
+			errs = append(errs, &TagError{Component: "omit", Value: fields, Reason: "invalid field name"})
+		}
+	}
+	ret.InjectName, ret.Inject = tag.Inject()
+	if format, ok := tag.component("stdin"); ok {
+		if ret.StdinFormat, ret.Stdin = tag.Stdin(); !ret.Stdin {
+			errs = append(errs, &TagError{Component: "stdin", Value: format, Reason: "unknown format"})
+		}
+	}
+	if name, ok := tag.component("lock"); ok {
+		if ret.LockName, ret.Lock = tag.Lock(); !ret.Lock {
+			errs = append(errs, &TagError{Component: "lock", Value: name, Reason: "not a valid lock name"})
+		}
+	}
+	if name, ok := tag.component("subcommand"); ok {
+		if ret.SubcommandName, ret.Subcommand = tag.Subcommand(); !ret.Subcommand {
+			errs = append(errs, &TagError{Component: "subcommand", Value: name, Reason: "not a valid command name"})
+		}
+	}
+	return ret, errs
+}
+
+// ParseTagStrict is like ParseTag, but additionally reports components which
+// are not part of the cliche tag grammar, which are usually typos. Where
+// possible, the error suggests the component which was likely meant.
+func ParseTagStrict(s string) (ParsedTag, error) {
+	ret, errs := parseTag(Tag(s))
+	if !ret.Excluded {
+		errs = append(errs, Tag(s).unknownComponents()...)
+	}
+	return ret, errors.Join(errs...)
+}
+
+// Canonical re-emits the parsed tag in normalized form: components in a fixed
+// order, without extraneous whitespace, and with positional arguments in
+// their shortest notation. Canonical forms of equivalent tags are identical.
+func (pt ParsedTag) Canonical() string {
+	if pt.Excluded {
+		return "-"
+	}
+	var components []string
+	if pt.Arg != nil {
+		components = append(components, pt.Arg.String())
+	}
+	if pt.Pairs {
+		components = append(components, "pairs")
+	}
+	if pt.Flag != nil {
+		components = append(components, pt.Flag.String())
+	}
+	if pt.Negatable {
+		components = append(components, "negatable")
+	}
+	if pt.Count {
+		components = append(components, "count")
+	}
+	if pt.Hidden {
+		components = append(components, "hidden")
+	}
+	if pt.Secret {
+		components = append(components, "secret")
+	}
+	if pt.Deprecated {
+		components = append(components, withValue("deprecated", pt.DeprecatedNote))
+	}
+	if pt.Required {
+		components = append(components, "required")
+	}
+	if pt.Default != "" {
+		components = append(components, "default:"+pt.Default)
+	}
+	if pt.Env != "" {
+		components = append(components, "env:"+pt.Env)
+	}
+	if pt.Group != "" {
+		components = append(components, "group:"+pt.Group)
+	}
+	if pt.Order != 0 {
+		components = append(components, "order:"+strconv.Itoa(pt.Order))
+	}
+	if pt.Global {
+		components = append(components, "global")
+	}
+	if pt.DryRun {
+		components = append(components, "dryrun")
+	}
+	if pt.Verbosity {
+		components = append(components, "verbosity")
+	}
+	if pt.Complete != "" {
+		components = append(components, "complete:"+pt.Complete)
+	}
+	if pt.Timezone != "" {
+		components = append(components, "tz:"+pt.Timezone)
+	}
+	if pt.Layout != "" {
+		components = append(components, "layout:"+pt.Layout)
+	}
+	if pt.Separator != "" {
+		components = append(components, "sep:"+pt.Separator)
+	}
+	if len(pt.Migrate) > 0 {
+		components = append(components, "migrate:"+strings.Join(pt.Migrate, ","))
+	}
+	if pt.Validator != "" {
+		components = append(components, "validate:"+pt.Validator)
+	}
+	if len(pt.Choices) > 0 {
+		components = append(components, "choices:"+strings.Join(pt.Choices, "|"))
+	}
+	if pt.Prefix != "" {
+		components = append(components, "prefix:"+pt.Prefix)
+	}
+	if len(pt.Omit) > 0 {
+		components = append(components, "omit:"+strings.Join(pt.Omit, ","))
+	}
+	if pt.Inject {
+		components = append(components, withValue("inject", pt.InjectName))
+	}
+	if pt.Stdin {
+		components = append(components, withValue("stdin", pt.StdinFormat))
+	}
+	if pt.Lock {
+		components = append(components, withValue("lock", pt.LockName))
+	}
+	if pt.Subcommand {
+		components = append(components, withValue("subcommand", pt.SubcommandName))
+	}
+	return strings.Join(components, ";")
+}
+
+// withValue formats a component which may or may not have a value.
+func withValue(name, value string) string {
+	if value == "" {
+		return name
+	}
+	return name + ":" + value
+}
+
+// Completion hints which may be specified with the complete tag component.
+// Each maps to a well-known completion action of the supported shells.
+const (
+	// CompleteNone disables completion for the input.
+	CompleteNone = "none"
+	// CompleteFiles completes file paths.
+	CompleteFiles = "files"
+	// CompleteDirs completes directory paths.
+	CompleteDirs = "dirs"
+	// CompleteHosts completes host names.
+	CompleteHosts = "hosts"
+	// CompleteUsers completes user names.
+	CompleteUsers = "users"
+	// CompleteGroups completes group names.
+	CompleteGroups = "groups"
+	// CompleteCommands completes the names of commands on the PATH.
+	CompleteCommands = "commands"
+)
+
+// Complete returns the shell completion hint for the input, as specified in
+// the struct tag. Unknown hints are not ok.
+func (tag Tag) Complete() (string, bool) {
+	hint, _ := tag.component("complete")
+	switch hint {
+	case CompleteNone, CompleteFiles, CompleteDirs, CompleteHosts, CompleteUsers, CompleteGroups, CompleteCommands:
+		return hint, true
+	}
+	return "", false
+}
+
+// Timezone returns the name of the time zone in which timestamps without an
+// explicit offset are interpreted for the input, as specified in the struct
+// tag. The name is "Local", "UTC", or an IANA time zone name such as
+// "America/New_York". Zones which cannot be loaded are not ok.
+func (tag Tag) Timezone() (string, bool) {
+	tz, _ := tag.component("tz")
+	switch strings.ToLower(tz) {
+	case "":
+		return "", false
+	case "local":
+		return "Local", true
+	case "utc":
+		return "UTC", true
+	}
+	if _, err := time.LoadLocation(tz); err != nil {
+		return "", false
+	}
+	return tz, true
+}
+
+// Layout returns the layout in which timestamps are given for the input, as
+// specified in the struct tag. It is either a layout of the time package, such
+// as "2006-01-02 15:04", or the name of one of its layout constants, such as
+// "DateOnly". Empty layouts are not ok.
+func (tag Tag) Layout() (string, bool) {
+	layout, _ := tag.component("layout")
+	return layout, layout != ""
+}
+
+// timeLayouts are the layout constants of the time package, by name.
+var timeLayouts = map[string]string{
+	"Layout":      time.Layout,
+	"ANSIC":       time.ANSIC,
+	"UnixDate":    time.UnixDate,
+	"RubyDate":    time.RubyDate,
+	"RFC822":      time.RFC822,
+	"RFC822Z":     time.RFC822Z,
+	"RFC850":      time.RFC850,
+	"RFC1123":     time.RFC1123,
+	"RFC1123Z":    time.RFC1123Z,
+	"RFC3339":     time.RFC3339,
+	"RFC3339Nano": time.RFC3339Nano,
+	"Kitchen":     time.Kitchen,
+	"Stamp":       time.Stamp,
+	"StampMilli":  time.StampMilli,
+	"StampMicro":  time.StampMicro,
+	"StampNano":   time.StampNano,
+	"DateTime":    time.DateTime,
+	"DateOnly":    time.DateOnly,
+	"TimeOnly":    time.TimeOnly,
+}
+
+// TimeLayout returns the layout of the time package named by a layout tag
+// component, or the layout itself when it names none. Empty layouts are
+// RFC 3339.
+func TimeLayout(layout string) string {
+	if layout == "" {
+		return time.RFC3339
+	}
+	if named, ok := timeLayouts[layout]; ok {
+		return named
+	}
+	return layout
+}
+
+// MapSeparator separates the keys and values of map flags, unless a sep tag
+// component gives another.
+const MapSeparator = "="
+
+// Separator returns the separator of the values given at once to a slice
+// flag, or of the keys and values of a map flag, as specified in the struct
+// tag. Empty separators are not ok.
+func (tag Tag) Separator() (string, bool) {
+	sep, _ := tag.component("sep")
+	return sep, sep != ""
+}
+
+// Migrate returns the former long names of a flag, as specified in the struct
+// tag, which are still accepted so that renaming a flag does not break
+// existing invocations. Several names are separated by commas. Not ok when any
+// name is not a valid long flag name.
+func (tag Tag) Migrate() ([]string, bool) {
+	value, _ := tag.component("migrate")
+	if value == "" {
+		return nil, false
+	}
+	var names []string
+	for _, name := range strings.Split(value, ",") {
+		var spec FlagSpec
+		if parseFlag(name, &spec) != "" || spec.Short != "" {
+			return nil, false
+		}
+		names = append(names, spec.Long)
+	}
+	return names, true
+}
+
+// Validator returns the name of the method on the command type which validates
+// the input's value, as specified in the struct tag. The method must have the
+// signature func(string) error. Not ok unless the name is that of an exported
+// method.
+func (tag Tag) Validator() (string, bool) {
+	method, _ := tag.component("validate")
+	if !token.IsIdentifier(method) || !token.IsExported(method) {
+		return "", false
+	}
+	return method, true
+}
+
+// Choices returns the values which the input may take, as specified in the
+// struct tag, separated by |, as in choices:red|green|blue. Values given on
+// the command line, and the input's default, must be one of them. Not ok
+// unless there is at least one, and none is empty or repeated.
+func (tag Tag) Choices() ([]string, bool) {
+	value, _ := tag.component("choices")
+	if value == "" {
+		return nil, false
+	}
+	var choices []string
+	seen := make(map[string]bool)
+	for _, choice := range strings.Split(value, "|") {
+		choice = strings.TrimSpace(choice)
+		if choice == "" || seen[choice] {
+			return nil, false
+		}
+		seen[choice] = true
+		choices = append(choices, choice)
+	}
+	return choices, true
+}
+
+// Prefix returns the string prepended to the long flag names of the inputs of
+// an embedded struct, as specified in the struct tag of the embedding field.
+// For example, prefix:db- exposes the embedded flag --host as --db-host. Not
+// ok unless the prefix is itself a valid start of a long flag name.
+func (tag Tag) Prefix() (string, bool) {
+	prefix, _ := tag.component("prefix")
+	if prefix == "" {
+		return "", false
+	}
+	for i, r := range prefix {
+		if (i == 0 && !isASCIILetter(r)) || !isFlagNameChar(r) {
+			return "", false
+		}
+	}
+	return prefix, true
+}
+
+// Omit returns the names of the fields of an embedded struct which are not
+// exposed as inputs, as specified in the struct tag of the embedding field.
+// Several names are separated by commas. Not ok when any name is not that of
+// an exported field.
+func (tag Tag) Omit() ([]string, bool) {
+	value, _ := tag.component("omit")
+	if value == "" {
+		return nil, false
+	}
+	var fields []string
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if !token.IsIdentifier(field) || !token.IsExported(field) {
+			return nil, false
+		}
+		fields = append(fields, field)
+	}
+	return fields, true
+}
+
+// Lock returns the name of the single-instance lock which the command holds
+// while it runs, as specified in the struct tag of a bool field. Setting the
+// field, which is the --no-lock flag unless named otherwise, skips the lock. A
+// bare lock component yields an empty name, for a lock named after the
+// command. Not ok unless the name is usable as a command name, since it names
+// a file.
+func (tag Tag) Lock() (string, bool) {
+	name, ok := tag.component("lock")
+	if !ok || (name != "" && !validName(name)) {
+		return "", false
 	}
-	return nil, false
+	return name, true
 }
@@ -1,7 +1,10 @@
 package meta
 
 import (
+	"errors"
 	"testing"
+	"time"
+	_ "time/tzdata" // Time zones are needed regardless of the host.
 
 	"github.com/google/go-cmp/cmp"
 )
@@ -17,13 +20,18 @@ func TestTagArg(t *testing.T) {
 
 	for tn, tc := range map[string]test{
 		"empty":                            {},
-		"plain index":                      {"arg:42", &ArgSpec{42, 0}, true},
-		"slice index":                      {"arg:[42]", &ArgSpec{42, 0}, true},
-		"range between":                    {"arg:[2:4]", &ArgSpec{2, 4}, true},
-		"range between from explicit zero": {"arg:[0:4]", &ArgSpec{0, 4}, true},
-		"range consume all to":             {"arg:[:4]", &ArgSpec{0, 4}, true},
-		"range consume all from":           {"arg:[2:]", &ArgSpec{2, -1}, true},
-		"range consume all":                {"arg:[:]", &ArgSpec{0, -1}, true},
+		"plain index":                      {"arg:42", &ArgSpec{42, 0, 0}, true},
+		"slice index":                      {"arg:[42]", &ArgSpec{42, 0, 0}, true},
+		"range between":                    {"arg:[2:4]", &ArgSpec{2, 4, 0}, true},
+		"range between from explicit zero": {"arg:[0:4]", &ArgSpec{0, 4, 0}, true},
+		"range consume all to":             {"arg:[:4]", &ArgSpec{0, 4, 0}, true},
+		"range consume all from":           {"arg:[2:]", &ArgSpec{2, -1, 0}, true},
+		"range consume all":                {"arg:[:]", &ArgSpec{0, -1, 0}, true},
+		"range every other":                {"arg:[0::2]", &ArgSpec{0, -1, 2}, true},
+		"range every third between":        {"arg:[1:7:3]", &ArgSpec{1, 7, 3}, true},
+		"range empty step":                 {"arg:[2::]", &ArgSpec{2, -1, 0}, true},
+		"range step of one":                {"arg:[2::1]", &ArgSpec{2, -1, 0}, true},
+		"range zero step not ok":           {"arg:[::0]", nil, false},
 		"explicitly unset not ok":          {"arg:", nil, false},
 		"range same not ok":                {"arg:[2:2]", nil, false},
 		"range end before start not ok":    {"arg:[4:2]", nil, false},
@@ -50,6 +58,248 @@ func BenchmarkTagArg(b *testing.B) {
 	}
 }
 
+func TestTagParseArg(t *testing.T) {
+	type test struct {
+		tag        Tag
+		want       *ArgSpec
+		wantReason string
+	}
+
+	for tn, tc := range map[string]test{
+		"empty":                  {},
+		"plain index":            {"arg:42", &ArgSpec{42, 0, 0}, ""},
+		"whitespace tolerated":   {"arg:[ 2 : 4 ]", &ArgSpec{2, 4, 0}, ""},
+		"unbalanced open":        {"arg:[2:4", nil, "unbalanced brackets"},
+		"unbalanced close":       {"arg:2:4]", nil, "unbalanced brackets"},
+		"nested brackets":        {"arg:[[2]]", nil, "unbalanced brackets"},
+		"empty brackets":         {"arg:[]", nil, "empty brackets"},
+		"non-numeric index":      {"arg:two", nil, `non-numeric index "two"`},
+		"negative index":         {"arg:[-1]", nil, `non-numeric index "-1"`},
+		"non-numeric range end":  {"arg:[2:a]", nil, `range end: non-numeric index "a"`},
+		"non-numeric range from": {"arg:[a:2]", nil, `range start: non-numeric index "a"`},
+		"index out of range":     {"arg:99999999999999999999", nil, `index "99999999999999999999" out of range`},
+		"range end before start": {"arg:[4:2]", nil, "range end 2 must be greater than range start 4"},
+		"non-numeric range step": {"arg:[::x]", nil, `range step: non-numeric index "x"`},
+		"zero range step":        {"arg:[1::0]", nil, "range step must be positive"},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			got, err := tc.tag.ParseArg()
+			var gotReason string
+			if err != nil {
+				var terr *TagError
+				if !errors.As(err, &terr) {
+					t.Fatalf("ParseArg(): error is not a *TagError: %v", err)
+				}
+				if terr.Component != "arg" {
+					t.Errorf("ParseArg(): error component mismatch: got: %q want: %q", terr.Component, "arg")
+				}
+				gotReason = terr.Reason
+			}
+			if gotReason != tc.wantReason {
+				t.Errorf("ParseArg(): error reason mismatch: got: %q want: %q", gotReason, tc.wantReason)
+			}
+			if diff := cmp.Diff(got, tc.want); diff != "" {
+				t.Errorf("ParseArg(): mismatch (-got,+want):\n%v", diff)
+			}
+		})
+	}
+}
+
+func FuzzTagParseArg(f *testing.F) {
+	for _, seed := range []string{"42", "[42]", "[2:4]", "[:]", "[2:]", "[:4]", "[]", "[2:a]", "[[", "]:[", "-1"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		got, err := Tag("arg:" + s).ParseArg()
+		if err != nil {
+			if got != nil {
+				t.Errorf("ParseArg(%q): got spec %+v along with error: %v", s, got, err)
+			}
+			return
+		}
+		if got == nil {
+			return
+		}
+		if got.Start < 0 {
+			t.Errorf("ParseArg(%q): negative start: %+v", s, got)
+		}
+		if got.End != 0 && got.End != -1 && got.End <= got.Start {
+			t.Errorf("ParseArg(%q): end not after start: %+v", s, got)
+		}
+	})
+}
+
+func TestTagExcluded(t *testing.T) {
+	for tag, want := range map[Tag]bool{
+		"":           false,
+		"-":          true,
+		" - ":        true,
+		"-;flag:foo": false,
+		"default:-":  false,
+		"flag:foo;-": false,
+		"arg:0":      false,
+	} {
+		if got := tag.Excluded(); got != want {
+			t.Errorf("Excluded(%q): got: %v want: %v", tag, got, want)
+		}
+	}
+}
+
+func TestTagGlobal(t *testing.T) {
+	for tag, want := range map[Tag]bool{
+		"":                    false,
+		"global":              true,
+		"flag:verbose;global": true,
+		" global ;flag:v":     true,
+		"globally":            false,
+		"default:global":      false,
+	} {
+		if got := tag.Global(); got != want {
+			t.Errorf("Global(%q): got: %v want: %v", tag, got, want)
+		}
+	}
+}
+
+func TestTagDryRun(t *testing.T) {
+	for tag, want := range map[Tag]bool{
+		"":                    false,
+		"dryrun":              true,
+		"flag:dry-run;dryrun": true,
+		" dryrun ;flag:n":     true,
+		"dry-run":             false,
+		"default:dryrun":      false,
+	} {
+		if got := tag.DryRun(); got != want {
+			t.Errorf("DryRun(%q): got: %v want: %v", tag, got, want)
+		}
+	}
+}
+
+func TestTagVerbosity(t *testing.T) {
+	for tag, want := range map[Tag]bool{
+		"":                         false,
+		"verbosity":                true,
+		"flag:v;count;verbosity":   true,
+		" verbosity ;flag:verbose": true,
+		"verbose":                  false,
+		"default:verbosity":        false,
+	} {
+		if got := tag.Verbosity(); got != want {
+			t.Errorf("Verbosity(%q): got: %v want: %v", tag, got, want)
+		}
+	}
+}
+
+func TestTagRequired(t *testing.T) {
+	for tag, want := range map[Tag]bool{
+		"":                   false,
+		"required":           true,
+		"flag:host;required": true,
+		" required ;arg:0":   true,
+		"requirement":        false,
+		"default:required":   false,
+	} {
+		if got := tag.Required(); got != want {
+			t.Errorf("Required(%q): got: %v want: %v", tag, got, want)
+		}
+	}
+}
+
+func TestTagPairs(t *testing.T) {
+	for tag, want := range map[Tag]bool{
+		"":                false,
+		"arg:[1:];pairs":  true,
+		" pairs ;arg:[:]": true,
+		"pairing":         false,
+		"default:pairs":   false,
+	} {
+		if got := tag.Pairs(); got != want {
+			t.Errorf("Pairs(%q): got: %v want: %v", tag, got, want)
+		}
+	}
+}
+
+func TestTagCount(t *testing.T) {
+	for tag, want := range map[Tag]bool{
+		"":                     false,
+		"flag:verbose,v;count": true,
+		" count ;default:1":    true,
+		"counter":              false,
+		"default:count":        false,
+	} {
+		if got := tag.Count(); got != want {
+			t.Errorf("Count(%q): got: %v want: %v", tag, got, want)
+		}
+	}
+}
+
+func TestTagHidden(t *testing.T) {
+	for tag, want := range map[Tag]bool{
+		"":                  false,
+		"flag:debug;hidden": true,
+		" hidden ;flag:x":   true,
+		"hide":              false,
+		"default:hidden":    false,
+	} {
+		if got := tag.Hidden(); got != want {
+			t.Errorf("Hidden(%q): got: %v want: %v", tag, got, want)
+		}
+	}
+}
+
+func TestTagSecret(t *testing.T) {
+	for tag, want := range map[Tag]bool{
+		"":                  false,
+		"flag:token;secret": true,
+		" secret ;flag:x":   true,
+		"secrets":           false,
+		"default:secret":    false,
+	} {
+		if got := tag.Secret(); got != want {
+			t.Errorf("Secret(%q): got: %v want: %v", tag, got, want)
+		}
+	}
+}
+
+func TestTagDeprecated(t *testing.T) {
+	type test struct {
+		tag    Tag
+		want   string
+		wantOK bool
+	}
+
+	for tn, tc := range map[string]test{
+		"empty":   {},
+		"bare":    {"flag:addr;deprecated", "", true},
+		"noted":   {"deprecated: use --port instead ", "use --port instead", true},
+		"default": {"default:deprecated", "", false},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			got, ok := tc.tag.Deprecated()
+			if ok != tc.wantOK {
+				t.Errorf("Deprecated(): ok mismatch: got: %v want: %v", ok, tc.wantOK)
+			}
+			if got != tc.want {
+				t.Errorf("Deprecated(): got: %q want: %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestTagNegatable(t *testing.T) {
+	for tag, want := range map[Tag]bool{
+		"":                      false,
+		"flag:color;negatable":  true,
+		" negatable ;default:1": true,
+		"negate":                false,
+		"default:negatable":     false,
+	} {
+		if got := tag.Negatable(); got != want {
+			t.Errorf("Negatable(%q): got: %v want: %v", tag, got, want)
+		}
+	}
+}
+
 func TestTagDecompose(t *testing.T) {
 	type values [3]string
 	type test struct {
@@ -123,6 +373,7 @@ func TestTagFlag(t *testing.T) {
 		"empty":                   {},
 		"go style":                {"flag:foo", &FlagSpec{"foo", ""}, true},
 		"posix style":             {"flag:foo,F", &FlagSpec{"foo", "F"}, true},
+		"short only":              {"flag:v", &FlagSpec{"", "v"}, true},
 		"two short flags not ok":  {"flag:f,b", nil, false},
 		"two long flags not ok":   {"flag:foo,bar", nil, false},
 		"explicitly unset not ok": {`default:`, nil, false},
@@ -139,8 +390,789 @@ func TestTagFlag(t *testing.T) {
 	}
 }
 
+func TestTagParseFlag(t *testing.T) {
+	type test struct {
+		tag        Tag
+		want       *FlagSpec
+		wantReason string
+	}
+
+	for tn, tc := range map[string]test{
+		"empty":                  {},
+		"posix style":            {"flag:foo, F", &FlagSpec{"foo", "F"}, ""},
+		"missing long name":      {"flag:,F", nil, "missing long flag name"},
+		"leading digit":          {"flag:1foo", nil, `flag name "1foo" must begin with a letter`},
+		"bad character":          {"flag:fo!o", nil, `invalid character '!' in flag name "fo!o"`},
+		"short only":             {"flag: v ", &FlagSpec{"", "v"}, ""},
+		"short only not letter":  {"flag:_", nil, `flag name "_" must begin with a letter`},
+		"too short":              {"flag:f,b", nil, `flag name "f" must be at least two characters`},
+		"short flag too long":    {"flag:foo,bar", nil, `short flag "bar" must be a single letter`},
+		"short flag not letter":  {"flag:foo,_", nil, `short flag "_" must be a single letter`},
+		"short flag missing":     {"flag:foo,", nil, `short flag "" must be a single letter`},
+		"too many names":         {"flag:foo,F,G", nil, `short flag "F,G" must be a single letter`},
+		"non-ascii letter":       {"flag:föo", nil, `invalid character 'ö' in flag name "föo"`},
+		"whitespace in the name": {"flag:fo o", nil, `invalid character ' ' in flag name "fo o"`},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			got, err := tc.tag.ParseFlag()
+			var gotReason string
+			if err != nil {
+				var terr *TagError
+				if !errors.As(err, &terr) {
+					t.Fatalf("ParseFlag(): error is not a *TagError: %v", err)
+				}
+				if terr.Component != "flag" {
+					t.Errorf("ParseFlag(): error component mismatch: got: %q want: %q", terr.Component, "flag")
+				}
+				gotReason = terr.Reason
+			}
+			if gotReason != tc.wantReason {
+				t.Errorf("ParseFlag(): error reason mismatch: got: %q want: %q", gotReason, tc.wantReason)
+			}
+			if diff := cmp.Diff(got, tc.want); diff != "" {
+				t.Errorf("ParseFlag(): mismatch (-got,+want):\n%v", diff)
+			}
+		})
+	}
+}
+
+func FuzzTagParseFlag(f *testing.F) {
+	for _, seed := range []string{"foo", "foo,F", "f", "f,b", "foo,bar", ",", "föo", "a-b_c"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		got, err := Tag("flag:" + s).ParseFlag()
+		if err != nil {
+			if got != nil {
+				t.Errorf("ParseFlag(%q): got spec %+v along with error: %v", s, got, err)
+			}
+			return
+		}
+		if got == nil {
+			return
+		}
+		if len(got.Long) < 2 && !got.ShortOnly() {
+			t.Errorf("ParseFlag(%q): long flag too short: %+v", s, got)
+		}
+		if got.Short != "" && !got.Posixy() && !got.ShortOnly() {
+			t.Errorf("ParseFlag(%q): short flag is not a single letter: %+v", s, got)
+		}
+	})
+}
+
 func BenchmarkTagFlag(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		_, _ = benchmarkTag.Flag()
 	}
 }
+
+func TestTagGroup(t *testing.T) {
+	type test struct {
+		tag    Tag
+		want   string
+		wantOK bool
+	}
+
+	for tn, tc := range map[string]test{
+		"empty":                   {},
+		"value":                   {"group:Networking", "Networking", true},
+		"value with spaces":       {"group: Advanced Options ", "Advanced Options", true},
+		"among others":            {"flag:port;group:Networking;default:80", "Networking", true},
+		"explicitly unset not ok": {"group:", "", false},
+		"marker not ok":           {"group", "", false},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			got, ok := tc.tag.Group()
+			if ok != tc.wantOK {
+				t.Errorf("Group(): ok mismatch: got: %v want: %v", got, tc.wantOK)
+			}
+			if diff := cmp.Diff(got, tc.want); diff != "" {
+				t.Errorf("Group(): mismatch (-got,+want):\n%v", diff)
+			}
+		})
+	}
+}
+
+func BenchmarkTagGroup(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_, _ = benchmarkTag.Group()
+	}
+}
+
+func TestTagOrder(t *testing.T) {
+	type test struct {
+		tag    Tag
+		want   int
+		wantOK bool
+	}
+
+	for tn, tc := range map[string]test{
+		"empty":          {},
+		"value":          {"order:10", 10, true},
+		"negative":       {"flag:token;order: -5 ", -5, true},
+		"zero":           {"order:0", 0, true},
+		"not an integer": {"order:first", 0, false},
+		"marker not ok":  {"order", 0, false},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			got, ok := tc.tag.Order()
+			if ok != tc.wantOK {
+				t.Errorf("Order(): ok mismatch: got: %v want: %v", got, tc.wantOK)
+			}
+			if diff := cmp.Diff(got, tc.want); diff != "" {
+				t.Errorf("Order(): mismatch (-got,+want):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestTagInject(t *testing.T) {
+	type test struct {
+		tag    Tag
+		want   string
+		wantOK bool
+	}
+
+	for tn, tc := range map[string]test{
+		"empty":        {},
+		"marker":       {"inject", "", true},
+		"named":        {"inject:primary", "primary", true},
+		"named spaces": {"inject: primary ", "primary", true},
+		"among others": {"group:Clients;inject", "", true},
+		"prefix only":  {"injected", "", false},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			got, ok := tc.tag.Inject()
+			if ok != tc.wantOK {
+				t.Errorf("Inject(): ok mismatch: got: %v want: %v", got, tc.wantOK)
+			}
+			if diff := cmp.Diff(got, tc.want); diff != "" {
+				t.Errorf("Inject(): mismatch (-got,+want):\n%v", diff)
+			}
+		})
+	}
+}
+
+func BenchmarkTagInject(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_, _ = benchmarkTag.Inject()
+	}
+}
+
+func TestTagStdin(t *testing.T) {
+	type test struct {
+		tag    Tag
+		want   string
+		wantOK bool
+	}
+
+	for tn, tc := range map[string]test{
+		"empty":              {},
+		"raw":                {"stdin", StdinRaw, true},
+		"json":               {"stdin:json", StdinJSON, true},
+		"ndjson":             {"stdin: ndjson", StdinNDJSON, true},
+		"unknown format":     {"stdin:yaml", "", false},
+		"explicitly unset":   {"stdin:", StdinRaw, true},
+		"among other inputs": {"group:Input;stdin:json", StdinJSON, true},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			got, ok := tc.tag.Stdin()
+			if ok != tc.wantOK {
+				t.Errorf("Stdin(): ok mismatch: got: %v want: %v", got, tc.wantOK)
+			}
+			if diff := cmp.Diff(got, tc.want); diff != "" {
+				t.Errorf("Stdin(): mismatch (-got,+want):\n%v", diff)
+			}
+		})
+	}
+}
+
+func BenchmarkTagStdin(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_, _ = benchmarkTag.Stdin()
+	}
+}
+
+func TestArgSpecString(t *testing.T) {
+	for _, tc := range []struct {
+		spec *ArgSpec
+		want string
+	}{
+		{nil, ""},
+		{&ArgSpec{0, 0, 0}, "arg:0"},
+		{&ArgSpec{42, 0, 0}, "arg:42"},
+		{&ArgSpec{0, -1, 0}, "arg:[:]"},
+		{&ArgSpec{2, -1, 0}, "arg:[2:]"},
+		{&ArgSpec{0, 4, 0}, "arg:[:4]"},
+		{&ArgSpec{2, 4, 0}, "arg:[2:4]"},
+		{&ArgSpec{1, -1, 2}, "arg:[1::2]"},
+		{&ArgSpec{0, 6, 3}, "arg:[:6:3]"},
+		{&ArgSpec{0, -1, 1}, "arg:[:]"},
+	} {
+		if got := tc.spec.String(); got != tc.want {
+			t.Errorf("String(%+v): got: %q want: %q", tc.spec, got, tc.want)
+		}
+	}
+}
+
+func TestFlagSpecString(t *testing.T) {
+	for _, tc := range []struct {
+		spec              *FlagSpec
+		want              string
+		posixy, shortOnly bool
+	}{
+		{nil, "", false, false},
+		{&FlagSpec{"foo", ""}, "flag:foo", false, false},
+		{&FlagSpec{"foo", "f"}, "flag:foo,f", true, false},
+		{&FlagSpec{"", "v"}, "flag:v", false, true},
+	} {
+		if got := tc.spec.String(); got != tc.want {
+			t.Errorf("String(%+v): got: %q want: %q", tc.spec, got, tc.want)
+		}
+		if got := tc.spec.Posixy(); got != tc.posixy {
+			t.Errorf("Posixy(%+v): got: %v want: %v", tc.spec, got, tc.posixy)
+		}
+		if got := tc.spec.ShortOnly(); got != tc.shortOnly {
+			t.Errorf("ShortOnly(%+v): got: %v want: %v", tc.spec, got, tc.shortOnly)
+		}
+	}
+}
+
+func TestFlagSpecWithPrefix(t *testing.T) {
+	for _, tc := range []struct {
+		spec, want *FlagSpec
+	}{
+		{&FlagSpec{"host", ""}, &FlagSpec{"db-host", ""}},
+		{&FlagSpec{"host", "H"}, &FlagSpec{"db-host", ""}},
+		{&FlagSpec{"", "v"}, &FlagSpec{"db-v", ""}},
+	} {
+		if diff := cmp.Diff(tc.spec.WithPrefix("db-"), tc.want); diff != "" {
+			t.Errorf("WithPrefix(%+v): mismatch (-got,+want):\n%v", tc.spec, diff)
+		}
+	}
+}
+
+func TestParseTag(t *testing.T) {
+	type test struct {
+		tag       string
+		want      ParsedTag
+		canonical string
+		wantErr   bool
+	}
+
+	for tn, tc := range map[string]test{
+		"empty":    {},
+		"excluded": {"-", ParsedTag{Excluded: true}, "-", false},
+		"everything": {
+			" stdin:json ; inject:primary;validate:CheckPort;migrate: old-port ,older_port;tz:utc;complete:hosts;global ; group: Networking;default: 80 ;flag: port , p;arg: [ 0 : 2 ] ",
+			ParsedTag{
+				Arg:         &ArgSpec{0, 2, 0},
+				Flag:        &FlagSpec{"port", "p"},
+				Default:     "80",
+				Group:       "Networking",
+				Global:      true,
+				Complete:    "hosts",
+				Timezone:    "UTC",
+				Migrate:     []string{"old-port", "older_port"},
+				Validator:   "CheckPort",
+				Inject:      true,
+				InjectName:  "primary",
+				Stdin:       true,
+				StdinFormat: "json",
+			},
+			"arg:[:2];flag:port,p;default:80;group:Networking;global;complete:hosts;tz:UTC;migrate:old-port,older_port;validate:CheckPort;inject:primary;stdin:json",
+			false,
+		},
+		"markers": {
+			"lock;stdin;inject", ParsedTag{Inject: true, Stdin: true, Lock: true}, "inject;stdin;lock", false,
+		},
+		"required": {
+			"required;flag:host", ParsedTag{Flag: &FlagSpec{"host", ""}, Required: true}, "flag:host;required", false,
+		},
+		"pairs": {
+			"pairs;arg:[1:]", ParsedTag{Arg: &ArgSpec{1, -1, 0}, Pairs: true}, "arg:[1:];pairs", false,
+		},
+		"negatable": {
+			"default:true;negatable;flag:color", ParsedTag{Flag: &FlagSpec{"color", ""}, Negatable: true, Default: "true"}, "flag:color;negatable;default:true", false,
+		},
+		"count": {
+			"count;flag:v;default:1", ParsedTag{Flag: &FlagSpec{"", "v"}, Count: true, Default: "1"}, "flag:v;count;default:1", false,
+		},
+		"lock": {
+			"lock: deploy ;flag:force-unlock", ParsedTag{Flag: &FlagSpec{"force-unlock", ""}, Lock: true, LockName: "deploy"}, "flag:force-unlock;lock:deploy", false,
+		},
+		"time": {
+			"layout: 2006-01-02 15:04 ;tz:local;flag:since", ParsedTag{Flag: &FlagSpec{"since", ""}, Timezone: "Local", Layout: "2006-01-02 15:04"}, "flag:since;tz:Local;layout:2006-01-02 15:04", false,
+		},
+		"separator": {
+			"sep: : ;flag:label", ParsedTag{Flag: &FlagSpec{"label", ""}, Separator: ":"}, "flag:label;sep::", false,
+		},
+		"short only": {
+			"flag: v ", ParsedTag{Flag: &FlagSpec{"", "v"}}, "flag:v", false,
+		},
+		"env": {
+			"env: APP_PORT ;default:80;flag:port", ParsedTag{Flag: &FlagSpec{"port", ""}, Default: "80", Env: "APP_PORT"}, "flag:port;default:80;env:APP_PORT", false,
+		},
+		"order": {
+			"order:-1;group:Auth;flag:token", ParsedTag{Flag: &FlagSpec{"token", ""}, Group: "Auth", Order: -1}, "flag:token;group:Auth;order:-1", false,
+		},
+		"subcommand": {
+			"subcommand: add ", ParsedTag{Subcommand: true, SubcommandName: "add"}, "subcommand:add", false,
+		},
+		"embedding": {
+			"omit: Debug , Trace;prefix:db-;group:Database",
+			ParsedTag{Group: "Database", Prefix: "db-", Omit: []string{"Debug", "Trace"}},
+			"group:Database;prefix:db-;omit:Debug,Trace",
+			false,
+		},
+		"unknown ignored": {
+			"nonsense:CANTFINDTHIS!;flag:foo", ParsedTag{Flag: &FlagSpec{"foo", ""}}, "flag:foo", false,
+		},
+		"dry run and verbosity": {
+			"verbosity;flag:verbose,v;count;dryrun", ParsedTag{Flag: &FlagSpec{"verbose", "v"}, Count: true, DryRun: true, Verbosity: true}, "flag:verbose,v;count;dryrun;verbosity", false,
+		},
+		"hidden and deprecated": {
+			"deprecated:use --port instead;hidden;flag:addr", ParsedTag{Flag: &FlagSpec{"addr", ""}, Hidden: true, Deprecated: true, DeprecatedNote: "use --port instead"}, "flag:addr;hidden;deprecated:use --port instead", false,
+		},
+		"secret": {
+			"secret;env:APP_TOKEN;flag:token", ParsedTag{Flag: &FlagSpec{"token", ""}, Secret: true, Env: "APP_TOKEN"}, "flag:token;secret;env:APP_TOKEN", false,
+		},
+		"bare deprecated": {
+			"flag:addr;deprecated", ParsedTag{Flag: &FlagSpec{"addr", ""}, Deprecated: true}, "flag:addr;deprecated", false,
+		},
+		"choices": {
+			"choices: red | green|blue ;default:red;flag:color", ParsedTag{Flag: &FlagSpec{"color", ""}, Default: "red", Choices: []string{"red", "green", "blue"}}, "flag:color;default:red;choices:red|green|blue", false,
+		},
+		"malformed components reported": {
+			"arg:[2:a];flag:f,b;stdin:yaml;default:42;prefix:-x;omit:lower;lock:Deploy;order:1st;subcommand:Add;layout:;sep:;choices:a||b;env:1PORT", ParsedTag{Default: "42"}, "default:42", true,
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			got, err := ParseTag(tc.tag)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("ParseTag(): error mismatch: got: %v wantErr: %v", err, tc.wantErr)
+			}
+			if diff := cmp.Diff(got, tc.want); diff != "" {
+				t.Errorf("ParseTag(): mismatch (-got,+want):\n%v", diff)
+			}
+			if canonical := got.Canonical(); canonical != tc.canonical {
+				t.Errorf("Canonical(): got: %q want: %q", canonical, tc.canonical)
+			}
+		})
+	}
+}
+
+func TestParseTagErrors(t *testing.T) {
+	_, err := ParseTag("arg:[2:a];flag:f,b;complete:everything;stdin:yaml")
+	var components []string
+	for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+		var terr *TagError
+		if errors.As(e, &terr) {
+			components = append(components, terr.Component)
+		}
+	}
+	if diff := cmp.Diff(components, []string{"arg", "flag", "complete", "stdin"}); diff != "" {
+		t.Errorf("ParseTag(): error components mismatch (-got,+want):\n%v", diff)
+	}
+}
+
+func FuzzParseTagCanonical(f *testing.F) {
+	for _, seed := range []string{
+		"-",
+		"arg:[2:];flag:foo,F;default:bar",
+		"group:x;global;inject;stdin:ndjson",
+		"nonsense;arg:1",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		parsed, err := ParseTag(s)
+		if err != nil {
+			return
+		}
+		canonical := parsed.Canonical()
+		reparsed, err := ParseTag(canonical)
+		if err != nil {
+			t.Fatalf("ParseTag(%q): canonical form %q does not parse: %v", s, canonical, err)
+		}
+		if again := reparsed.Canonical(); again != canonical {
+			t.Errorf("Canonical(): not stable for %q: got %q, then %q", s, canonical, again)
+		}
+	})
+}
+
+func BenchmarkParseTag(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_, _ = ParseTag(string(benchmarkTag))
+	}
+}
+
+func TestParseTagStrict(t *testing.T) {
+	type test struct {
+		tag        string
+		want       ParsedTag
+		wantErrors []string
+	}
+
+	for tn, tc := range map[string]test{
+		"empty":    {},
+		"excluded": {"-", ParsedTag{Excluded: true}, nil},
+		"known":    {"flag:foo;default:bar;global;", ParsedTag{Flag: &FlagSpec{"foo", ""}, Default: "bar", Global: true}, nil},
+		"typo": {
+			"falg:foo", ParsedTag{},
+			[]string{`invalid tag component falg:"foo": unknown component; did you mean flag:?`},
+		},
+		"case typo": {
+			"Default:foo", ParsedTag{},
+			[]string{`invalid tag component Default:"foo": unknown component; did you mean default:?`},
+		},
+		"marker typo": {
+			"globl", ParsedTag{},
+			[]string{`invalid tag component globl: unknown component; did you mean global:?`},
+		},
+		"nonsense": {
+			"arg:0;nonsense:CANTFINDTHIS!", ParsedTag{Arg: &ArgSpec{0, 0, 0}},
+			[]string{`invalid tag component nonsense:"CANTFINDTHIS!": unknown component`},
+		},
+		"malformed and unknown": {
+			"flag:f,b;dfault:1", ParsedTag{},
+			[]string{
+				`invalid tag component flag:"f,b": flag name "f" must be at least two characters`,
+				`invalid tag component dfault:"1": unknown component; did you mean default:?`,
+			},
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			got, err := ParseTagStrict(tc.tag)
+			var gotErrors []string
+			if err != nil {
+				for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+					gotErrors = append(gotErrors, e.Error())
+				}
+			}
+			if diff := cmp.Diff(gotErrors, tc.wantErrors); diff != "" {
+				t.Errorf("ParseTagStrict(): errors mismatch (-got,+want):\n%v", diff)
+			}
+			if diff := cmp.Diff(got, tc.want); diff != "" {
+				t.Errorf("ParseTagStrict(): mismatch (-got,+want):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestTagComplete(t *testing.T) {
+	type test struct {
+		tag    Tag
+		want   string
+		wantOK bool
+	}
+
+	for tn, tc := range map[string]test{
+		"empty":        {},
+		"none":         {"complete:none", CompleteNone, true},
+		"files":        {"complete:files", CompleteFiles, true},
+		"dirs":         {"complete:dirs", CompleteDirs, true},
+		"hosts":        {"flag:host;complete: hosts", CompleteHosts, true},
+		"users":        {"complete:users", CompleteUsers, true},
+		"groups":       {"complete:groups", CompleteGroups, true},
+		"commands":     {"complete:commands", CompleteCommands, true},
+		"unknown":      {"complete:everything", "", false},
+		"marker":       {"complete", "", false},
+		"explicit nil": {"complete:", "", false},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			got, ok := tc.tag.Complete()
+			if ok != tc.wantOK {
+				t.Errorf("Complete(): ok mismatch: got: %v want: %v", got, tc.wantOK)
+			}
+			if diff := cmp.Diff(got, tc.want); diff != "" {
+				t.Errorf("Complete(): mismatch (-got,+want):\n%v", diff)
+			}
+		})
+	}
+}
+
+func BenchmarkTagComplete(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_, _ = benchmarkTag.Complete()
+	}
+}
+
+func TestTagTimezone(t *testing.T) {
+	type test struct {
+		tag    Tag
+		want   string
+		wantOK bool
+	}
+
+	for tn, tc := range map[string]test{
+		"empty":        {},
+		"local":        {"tz:local", "Local", true},
+		"utc":          {"tz: UTC", "UTC", true},
+		"named":        {"tz:America/New_York", "America/New_York", true},
+		"unknown":      {"tz:Mars/Olympus_Mons", "", false},
+		"explicit nil": {"tz:", "", false},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			got, ok := tc.tag.Timezone()
+			if ok != tc.wantOK {
+				t.Errorf("Timezone(): ok mismatch: got: %v want: %v", got, tc.wantOK)
+			}
+			if diff := cmp.Diff(got, tc.want); diff != "" {
+				t.Errorf("Timezone(): mismatch (-got,+want):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestTagLayout(t *testing.T) {
+	type test struct {
+		tag    Tag
+		want   string
+		wantOK bool
+	}
+
+	for tn, tc := range map[string]test{
+		"empty":        {},
+		"named":        {"layout:DateOnly", "DateOnly", true},
+		"custom":       {"layout: 02 Jan 2006 15:04 ", "02 Jan 2006 15:04", true},
+		"explicit nil": {"layout:", "", false},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			got, ok := tc.tag.Layout()
+			if ok != tc.wantOK {
+				t.Errorf("Layout(): ok mismatch: got: %v want: %v", got, tc.wantOK)
+			}
+			if diff := cmp.Diff(got, tc.want); diff != "" {
+				t.Errorf("Layout(): mismatch (-got,+want):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestTagSeparator(t *testing.T) {
+	type test struct {
+		tag    Tag
+		want   string
+		wantOK bool
+	}
+
+	for tn, tc := range map[string]test{
+		"empty":        {},
+		"colon":        {"flag:label;sep::", ":", true},
+		"padded":       {"sep: => ", "=>", true},
+		"comma":        {"sep:,", ",", true},
+		"explicit nil": {"sep:", "", false},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			got, ok := tc.tag.Separator()
+			if ok != tc.wantOK {
+				t.Errorf("Separator(): ok mismatch: got: %v want: %v", got, tc.wantOK)
+			}
+			if diff := cmp.Diff(got, tc.want); diff != "" {
+				t.Errorf("Separator(): mismatch (-got,+want):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestTimeLayout(t *testing.T) {
+	for _, tc := range []struct {
+		layout, want string
+	}{
+		{"", time.RFC3339},
+		{"DateOnly", "2006-01-02"},
+		{"Kitchen", "3:04PM"},
+		{"02/01/2006", "02/01/2006"},
+	} {
+		if got := TimeLayout(tc.layout); got != tc.want {
+			t.Errorf("TimeLayout(%q): got: %q want: %q", tc.layout, got, tc.want)
+		}
+	}
+}
+
+func TestTagChoices(t *testing.T) {
+	type test struct {
+		tag    Tag
+		want   []string
+		wantOK bool
+	}
+
+	for tn, tc := range map[string]test{
+		"empty":           {},
+		"single":          {"choices:auto", []string{"auto"}, true},
+		"several":         {"flag:color;choices: red|green | blue", []string{"red", "green", "blue"}, true},
+		"explicit nil":    {"choices:", nil, false},
+		"empty not ok":    {"choices:red||blue", nil, false},
+		"trailing not ok": {"choices:red|", nil, false},
+		"repeated not ok": {"choices:red|blue|red", nil, false},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			got, ok := tc.tag.Choices()
+			if ok != tc.wantOK {
+				t.Errorf("Choices(): ok mismatch: got: %v want: %v", got, tc.wantOK)
+			}
+			if diff := cmp.Diff(got, tc.want); diff != "" {
+				t.Errorf("Choices(): mismatch (-got,+want):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestTagMigrate(t *testing.T) {
+	type test struct {
+		tag    Tag
+		want   []string
+		wantOK bool
+	}
+
+	for tn, tc := range map[string]test{
+		"empty":          {},
+		"single":         {"flag:new-name;migrate:old-name", []string{"old-name"}, true},
+		"several":        {"migrate:old, older ,oldest", []string{"old", "older", "oldest"}, true},
+		"explicit nil":   {"migrate:", nil, false},
+		"short not ok":   {"migrate:o", nil, false},
+		"posixy not ok":  {"migrate:old,o", nil, false},
+		"invalid not ok": {"migrate:old!", nil, false},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			got, ok := tc.tag.Migrate()
+			if ok != tc.wantOK {
+				t.Errorf("Migrate(): ok mismatch: got: %v want: %v", got, tc.wantOK)
+			}
+			if diff := cmp.Diff(got, tc.want); diff != "" {
+				t.Errorf("Migrate(): mismatch (-got,+want):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestTagValidator(t *testing.T) {
+	type test struct {
+		tag    Tag
+		want   string
+		wantOK bool
+	}
+
+	for tn, tc := range map[string]test{
+		"empty":            {},
+		"method":           {"arg:0;validate:CheckPort", "CheckPort", true},
+		"unexported":       {"validate:checkPort", "", false},
+		"not an ident":     {"validate:Check Port", "", false},
+		"explicitly unset": {"validate:", "", false},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			got, ok := tc.tag.Validator()
+			if ok != tc.wantOK {
+				t.Errorf("Validator(): ok mismatch: got: %v want: %v", got, tc.wantOK)
+			}
+			if diff := cmp.Diff(got, tc.want); diff != "" {
+				t.Errorf("Validator(): mismatch (-got,+want):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestTagPrefix(t *testing.T) {
+	type test struct {
+		tag    Tag
+		want   string
+		wantOK bool
+	}
+
+	for tn, tc := range map[string]test{
+		"empty":            {},
+		"prefix":           {"prefix:db-", "db-", true},
+		"underscore":       {"prefix:db_", "db_", true},
+		"leading dash":     {"prefix:-db", "", false},
+		"bad character":    {"prefix:d.b", "", false},
+		"explicitly unset": {"prefix:", "", false},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			got, ok := tc.tag.Prefix()
+			if ok != tc.wantOK {
+				t.Errorf("Prefix(): ok mismatch: got: %v want: %v", got, tc.wantOK)
+			}
+			if diff := cmp.Diff(got, tc.want); diff != "" {
+				t.Errorf("Prefix(): mismatch (-got,+want):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestTagOmit(t *testing.T) {
+	type test struct {
+		tag    Tag
+		want   []string
+		wantOK bool
+	}
+
+	for tn, tc := range map[string]test{
+		"empty":      {},
+		"one":        {"omit:Debug", []string{"Debug"}, true},
+		"several":    {"omit: Debug ,Trace", []string{"Debug", "Trace"}, true},
+		"unexported": {"omit:Debug,trace", nil, false},
+		"empty name": {"omit:Debug,", nil, false},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			got, ok := tc.tag.Omit()
+			if ok != tc.wantOK {
+				t.Errorf("Omit(): ok mismatch: got: %v want: %v", got, tc.wantOK)
+			}
+			if diff := cmp.Diff(got, tc.want); diff != "" {
+				t.Errorf("Omit(): mismatch (-got,+want):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestTagLock(t *testing.T) {
+	type test struct {
+		tag    Tag
+		want   string
+		wantOK bool
+	}
+
+	for tn, tc := range map[string]test{
+		"empty":    {},
+		"bare":     {"lock", "", true},
+		"named":    {"lock:deploy-prod", "deploy-prod", true},
+		"path":     {"lock:../deploy", "", false},
+		"unusable": {"lock:Deploy", "", false},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			got, ok := tc.tag.Lock()
+			if ok != tc.wantOK {
+				t.Errorf("Lock(): ok mismatch: got: %v want: %v", ok, tc.wantOK)
+			}
+			if got != tc.want {
+				t.Errorf("Lock(): got: %q want: %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestTagSubcommand(t *testing.T) {
+	type test struct {
+		tag    Tag
+		want   string
+		wantOK bool
+	}
+
+	for tn, tc := range map[string]test{
+		"empty":    {},
+		"bare":     {"subcommand", "", true},
+		"named":    {"subcommand:add-remote", "add-remote", true},
+		"unusable": {"subcommand:Add", "", false},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			got, ok := tc.tag.Subcommand()
+			if ok != tc.wantOK {
+				t.Errorf("Subcommand(): ok mismatch: got: %v want: %v", ok, tc.wantOK)
+			}
+			if got != tc.want {
+				t.Errorf("Subcommand(): got: %q want: %q", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+// Package arrays is a test for cliche commands with fixed-size array inputs.
+package arrays
+
+import "context"
+
+const three = 3
+
+// Pairs is a cliche command which takes positional arguments into arrays.
+//
+//go:generate cliche -type=Pairs
+type Pairs struct {
+	// First pair of arguments.
+	First [2]string `cliche:"arg:[0:2]"`
+	// Second pair of arguments.
+	Second [2]string `cliche:"arg:2"`
+	// Rest of the arguments, three of them.
+	Rest [three]string `cliche:"arg:[4:]"`
+	// Flags is not bound to positional arguments.
+	Flags [2]string
+}
+
+// Run the Pairs command.
+func (cmd *Pairs) Run(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,27 @@
+// Package choices is a test for cliche commands whose inputs are limited to a
+// set of values.
+package choices
+
+import (
+	"context"
+	"time"
+)
+
+// Paint is a cliche command which paints in a few colors.
+//
+//go:generate cliche -type=Paint
+type Paint struct {
+	// Color of the paint.
+	Color string `cliche:"flag:color,c;default:red;choices:red|green|blue"`
+	// Finishes applied, in order.
+	Finishes []string `cliche:"flag:finish;sep:,;choices:matte|gloss"`
+	// Wait between coats.
+	Wait time.Duration `cliche:"flag:wait;choices:1h|2h"`
+	// Surface painted.
+	Surface string `cliche:"arg:0;choices:wall|door"`
+}
+
+// Run the Paint command.
+func (cmd *Paint) Run(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,24 @@
+// Package cleanup is a test for cliche commands which hold resources.
+package cleanup
+
+import "context"
+
+// Tidy is a cliche command which cleans up after itself.
+//
+//go:generate cliche -type=Tidy
+type Tidy struct{}
+
+// Run the Tidy command.
+func (cmd *Tidy) Run(ctx context.Context) error {
+	return nil
+}
+
+// Close resources held by the Tidy command.
+func (cmd *Tidy) Close() error {
+	return nil
+}
+
+// Shutdown the Tidy command.
+func (cmd *Tidy) Shutdown(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,18 @@
+// Package colored is a test for cliche commands writing colored output.
+package colored
+
+import (
+	"context"
+	"fmt"
+
+	"idontfixcomputers.com/cliche"
+)
+
+// Paint is a cliche command which writes colored output.
+type Paint struct{}
+
+// Run the Paint command.
+func (cmd *Paint) Run(ctx context.Context) error {
+	fmt.Fprintln(cliche.IOFrom(ctx).Out, "\x1b[32mgreen\x1b[0m")
+	return nil
+}
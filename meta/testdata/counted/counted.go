@@ -0,0 +1,21 @@
+// Package counted is a test for cliche commands with counting flags.
+package counted
+
+import "context"
+
+// Sync is a cliche command whose output grows more detailed with each -v.
+//
+//go:generate cliche -type=Sync
+type Sync struct {
+	// Verbose output, more so each time it is given.
+	Verbose int `cliche:"flag:verbose,v;count"`
+	// Quiet output, less so each time it is given.
+	Quiet uint8 `cliche:"flag:q;count"`
+	// Retries of each failed transfer.
+	Retries int `cliche:"flag:retries,r;default:3"`
+}
+
+// Run the Sync command.
+func (cmd *Sync) Run(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,29 @@
+// Package custom is a test for cliche commands which document themselves.
+package custom
+
+import (
+	"context"
+	"fmt"
+
+	"idontfixcomputers.com/cliche"
+)
+
+// Special is a cliche command with unusual documentation needs.
+//
+//go:generate cliche -type=Special
+type Special struct{}
+
+// Run the Special command.
+func (cmd *Special) Run(ctx context.Context) error {
+	return nil
+}
+
+// Help for the Special command.
+func (cmd *Special) Help(io cliche.IO) {
+	fmt.Fprintln(io.Out, "Special commands need special help.")
+}
+
+// Usage of the Special command.
+func (cmd *Special) Usage() string {
+	return "custom [--special]"
+}
@@ -0,0 +1,26 @@
+// Package docs is a test for cliche help rendering. Its doc comment contains
+// structure which should survive into help output.
+//
+// # Usage
+//
+// Inputs are provided as:
+//   - flags, and
+//   - positional arguments.
+//
+// For example:
+//
+//	docs -name=World
+package docs
+
+import "context"
+
+// Documented is a cliche command whose doc comment spans
+// several lines.
+//
+//go:generate cliche -type=Documented
+type Documented struct{}
+
+// Run the Documented command.
+func (cmd *Documented) Run(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,46 @@
+// Package embedded is a test for cliche commands which embed shared options.
+package embedded
+
+import (
+	"context"
+	"net/http"
+)
+
+// Connection options shared between commands.
+type Connection struct {
+	// Host to connect to.
+	Host string `cliche:"flag:host,H;migrate:hostname"`
+	// Verbose connection logging.
+	Verbose bool `cliche:"flag:verbose;group:Debugging"`
+	// Trace every packet.
+	Trace bool `cliche:"flag:trace"`
+	// Loop back to Connection, which is not flattened twice.
+	*Connection
+}
+
+// Logging options shared between commands.
+type Logging struct {
+	// Level of logs.
+	Level string `cliche:"flag:level"`
+}
+
+// Migrate is a cliche command which embeds shared options.
+//
+//go:generate cliche -type=Migrate
+type Migrate struct {
+	// Source database.
+	Connection `cliche:"prefix:db-;omit:Trace;group:Database"`
+	// Logging options are exposed as they are, but listed last.
+	*Logging `cliche:"order:10"`
+	// Excluded options.
+	Hidden Connection `cliche:"-"`
+	// Client from another package, which can't be flattened.
+	http.Client
+	// Dry run only.
+	DryRun bool `cliche:"flag:dry-run"`
+}
+
+// Run the Migrate command.
+func (cmd *Migrate) Run(ctx context.Context) error {
+	return nil
+}
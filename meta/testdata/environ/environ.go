@@ -0,0 +1,29 @@
+// Package environ is a test for cliche commands with flags bound to
+// environment variables.
+package environ
+
+import "context"
+
+// Serve is a cliche command which serves requests.
+type Serve struct {
+	// Port to listen on.
+	Port int `cliche:"flag:port,p;default:8080;env:SERVE_PORT"`
+	// Log each request.
+	Log bool `cliche:"negatable;env:SERVE_LOG"`
+}
+
+// Run the Serve command.
+func (cmd *Serve) Run(ctx context.Context) error {
+	return nil
+}
+
+// Status is a cliche command which reports the status of the server.
+type Status struct {
+	// Address of the server.
+	Addr string `cliche:"flag:addr;env:STATUS_ADDR"`
+}
+
+// Run the Status command.
+func (cmd *Status) Run(ctx context.Context) error {
+	return nil
+}
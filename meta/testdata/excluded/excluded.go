@@ -0,0 +1,20 @@
+// Package excluded is a test for cliche commands with fields excluded from the
+// command line.
+package excluded
+
+import "context"
+
+// Partial is a cliche command which keeps some exported fields to itself.
+//
+//go:generate cliche -type=Partial
+type Partial struct {
+	// Name of the thing.
+	Name string
+	// Cache is used by Run, and never set on the command line.
+	Cache map[string]string `cliche:"-"`
+}
+
+// Run the Partial command.
+func (cmd *Partial) Run(ctx context.Context) error {
+	return nil
+}
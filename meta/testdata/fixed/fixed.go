@@ -0,0 +1,21 @@
+// Package fixed is a test for cliche commands with fixed-size array inputs
+// which follow other positional arguments.
+package fixed
+
+import "context"
+
+// Move is a cliche command which takes a piece and the squares it moves
+// between.
+//
+//go:generate cliche -type=Move
+type Move struct {
+	// Piece moved.
+	Piece string `cliche:"arg:0"`
+	// Squares moved from and to.
+	Squares [2]string `cliche:"arg:[1:3]"`
+}
+
+// Run the Move command.
+func (cmd *Move) Run(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,44 @@
+// Package form is a test for cliche commands asking for their flags with an
+// interactive form.
+package form
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"idontfixcomputers.com/cliche"
+)
+
+// Greet is a cliche command which greets someone.
+type Greet struct {
+	// Name to greet.
+	Name string `cliche:"flag:name;required"`
+	// Color of the greeting.
+	Color string `cliche:"flag:color;choices:red|green|blue;default:red"`
+	// Loud shouts the greeting.
+	Loud bool `cliche:"flag:loud"`
+	// Token to sign the greeting with.
+	Token string `cliche:"flag:token;secret;env:FORM_TOKEN"`
+	// Times to greet.
+	Times int `cliche:"flag:times;default:1;validate:CheckTimes"`
+	// Verbose is counted, so not asked for.
+	Verbose int `cliche:"flag:v;count"`
+}
+
+// Run the Greet command.
+func (cmd *Greet) Run(ctx context.Context) error {
+	out := cliche.IOFrom(ctx).Out
+	for range cmd.Times {
+		fmt.Fprintf(out, "hello %v in %v (loud=%v, signed=%v)\n", cmd.Name, cmd.Color, cmd.Loud, cmd.Token != "")
+	}
+	return nil
+}
+
+// CheckTimes validates the Times flag.
+func (cmd *Greet) CheckTimes(times string) error {
+	if times == "0" {
+		return errors.New("must greet at least once")
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+// Package globals is a test for cliche commands whose subcommands declare
+// global flags.
+package globals
+
+import "context"
+
+// Build is a cliche command which builds a target.
+type Build struct {
+	// Verbose logs more.
+	Verbose int `cliche:"flag:verbose,v;count;global;verbosity"`
+	// Target to build.
+	Target string `cliche:"arg:0;default:all"`
+}
+
+// Run the Build command.
+func (cmd *Build) Run(ctx context.Context) error {
+	return nil
+}
+
+// Clean is a cliche command which removes built files.
+type Clean struct {
+	// Verbose logs more.
+	Verbose int `cliche:"flag:verbose,v;count;global;verbosity"`
+	// Config file to read.
+	Config string `cliche:"flag:config;default:build.yaml;global"`
+	// DryRun lists files without removing them.
+	DryRun bool `cliche:"flag:dry-run;dryrun"`
+}
+
+// Run the Clean command.
+func (cmd *Clean) Run(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,24 @@
+// Package hidden is a test for cliche commands with hidden and deprecated
+// flags.
+package hidden
+
+import "context"
+
+// Serve is a cliche command whose flags are changing.
+//
+//go:generate cliche -type=Serve
+type Serve struct {
+	// Port listened on.
+	Port int `cliche:"flag:port,p;default:8080"`
+	// Addr listened on, as host:port.
+	Addr string `cliche:"flag:addr;deprecated:use --port instead"`
+	// Debug endpoints served.
+	Debug bool `cliche:"flag:debug;hidden"`
+	// Legacy routes served.
+	Legacy bool `cliche:"flag:legacy;default:true;negatable;deprecated"`
+}
+
+// Run the Serve command.
+func (cmd *Serve) Run(ctx context.Context) error {
+	return nil
+}
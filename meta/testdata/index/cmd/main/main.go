@@ -0,0 +1,12 @@
+// Command main is a test for cliche indexes, which skip package main.
+package main
+
+import "context"
+
+//go:generate cliche -type=Skipped
+
+// Skipped can't be imported.
+type Skipped struct{}
+
+// Run the Skipped command.
+func (Skipped) Run(context.Context) error { return nil }
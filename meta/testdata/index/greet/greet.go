@@ -0,0 +1,17 @@
+// Package greet is a test for cliche indexes of the commands of a module.
+package greet
+
+import "context"
+
+//go:generate go run idontfixcomputers.com/cliche/cmd/cliche -type=Greet
+
+// Greet greets someone.
+type Greet struct {
+	// Name of the person greeted.
+	Name string `cliche:"arg:0;default:World"`
+}
+
+// Run the Greet command.
+func (cmd *Greet) Run(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,18 @@
+// Package quiet is a test for cliche indexes of commands generated into
+// packages of their own.
+package quiet
+
+import "context"
+
+//go:generate go run idontfixcomputers.com/cliche/cmd/cliche -type=Hush -internal
+
+// Hush hushes someone.
+type Hush struct {
+	// Name of the person hushed.
+	Name string `cliche:"arg:0"`
+}
+
+// Run the Hush command.
+func (cmd *Hush) Run(ctx context.Context) error {
+	return nil
+}
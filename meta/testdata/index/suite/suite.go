@@ -0,0 +1,20 @@
+// Package suite is a test for cliche indexes of commands made of several
+// types.
+package suite
+
+import "context"
+
+//go:generate go run idontfixcomputers.com/cliche/cmd/cliche -types=Up,Down -name=updown -default=up
+//go:generate go run idontfixcomputers.com/cliche/cmd/cliche completion -types=Up,Down bash
+
+// Up goes up.
+type Up struct{}
+
+// Run the Up command.
+func (Up) Run(context.Context) error { return nil }
+
+// Down goes down.
+type Down struct{}
+
+// Run the Down command.
+func (Down) Run(context.Context) error { return nil }
@@ -0,0 +1,12 @@
+// Package greet is a test for cliche indexes of packages with the same name.
+package greet
+
+import "context"
+
+//go:generate cliche -type=Wave -name=wave -v
+
+// Wave waves at someone.
+type Wave struct{}
+
+// Run the Wave command.
+func (Wave) Run(context.Context) error { return nil }
@@ -0,0 +1,22 @@
+// Package inject is a test for cliche commands with injected dependencies.
+package inject
+
+import (
+	"context"
+	"net/http"
+)
+
+// Fetcher is a cliche command which is handed an HTTP client.
+//
+//go:generate cliche -type=Fetcher
+type Fetcher struct {
+	// URL to fetch.
+	URL string `cliche:"arg:0"`
+	// Client used to fetch the URL.
+	Client *http.Client `cliche:"inject"`
+}
+
+// Run the Fetcher command.
+func (cmd *Fetcher) Run(ctx context.Context) error {
+	return nil
+}
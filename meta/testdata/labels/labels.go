@@ -0,0 +1,24 @@
+// Package labels is a test for cliche commands with map flags, and flags given
+// several values at once.
+package labels
+
+import "context"
+
+// Deploy is a cliche command which deploys a service with labels and limits.
+//
+//go:generate cliche -type=Deploy
+type Deploy struct {
+	// Labels of the service, as key=value.
+	Labels map[string]string `cliche:"flag:label,l;default:team=infra"`
+
+	// Limits on resources, as resource:amount.
+	Limits map[string]int `cliche:"flag:limit;sep::"`
+
+	// Regions to deploy to, separated by commas.
+	Regions []string `cliche:"flag:region;sep:,;default:us-east,eu-west"`
+}
+
+// Run the Deploy command.
+func (cmd *Deploy) Run(ctx context.Context) error {
+	return nil
+}
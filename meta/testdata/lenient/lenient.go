@@ -0,0 +1,16 @@
+// Package lenient is a test for cliche commands whose tags have unknown
+// components, which are only generated when not strict.
+package lenient
+
+import "context"
+
+// Greet is a cliche command with a misspelled tag component.
+type Greet struct {
+	// Name to greet.
+	Name string `cliche:"flag:name;defualt:World"`
+}
+
+// Run the Greet command.
+func (cmd *Greet) Run(ctx context.Context) error {
+	return nil
+}
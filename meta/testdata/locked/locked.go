@@ -0,0 +1,21 @@
+// Package locked is a test for cliche commands which must not run
+// concurrently.
+package locked
+
+import "context"
+
+// Purge is a cliche command which deletes everything, so holds a lock.
+//
+//go:generate cliche -type=Purge
+type Purge struct {
+	// Force deletion without asking.
+	Force bool `cliche:"flag:force,f"`
+
+	// NoLock runs the purge even while another is running.
+	NoLock bool `cliche:"lock:purge-data"`
+}
+
+// Run the Purge command.
+func (cmd *Purge) Run(ctx context.Context) error {
+	return nil
+}
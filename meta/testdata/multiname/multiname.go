@@ -0,0 +1,24 @@
+// Package multiname is a test for cliche commands with fields declaring
+// several names at once.
+package multiname
+
+import "context"
+
+// Endpoint is a cliche command with multi-name fields.
+//
+//go:generate cliche -type=Endpoint
+type Endpoint struct {
+	// Host and Port to connect to.
+	Host, Port string
+	// Scheme is tagged, and so is secret, but the tag can't apply to both.
+	Scheme, Secret string `cliche:"flag:scheme"`
+	// Left out entirely.
+	Ignored, AlsoIgnored string `cliche:"-"`
+	// Only the exported name of these counts.
+	Timeout, retries int
+}
+
+// Run the Endpoint command.
+func (cmd *Endpoint) Run(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,23 @@
+// Package negated is a test for cliche commands with negatable flags.
+package negated
+
+import "context"
+
+// Build is a cliche command whose flags may be turned off.
+//
+//go:generate cliche -type=Build
+type Build struct {
+	// Color the output.
+	Color bool `cliche:"flag:color,c;default:true;negatable"`
+	// Cache results between builds.
+	Cache bool `cliche:"default:true;negatable"`
+	// Sign the build, or confirm that it should not be with --no-sign.
+	Sign bool `cliche:"flag:sign;negatable;required"`
+	// Verbose output.
+	Verbose bool `cliche:"flag:verbose,v"`
+}
+
+// Run the Build command.
+func (cmd *Build) Run(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,18 @@
+// Package norun is a test for types which cannot be wrapped as cliche
+// commands, because they have no suitable Run method.
+package norun
+
+import "context"
+
+// Unrunnable is not a cliche command, despite its best efforts.
+//
+//go:generate cliche -type=Unrunnable
+type Unrunnable struct{}
+
+// Run the Unrunnable command, but with the wrong signature.
+func (cmd *Unrunnable) Run(ctx context.Context, args []string) error {
+	return nil
+}
+
+// Stop the Unrunnable command.
+func (cmd Unrunnable) Stop() {}
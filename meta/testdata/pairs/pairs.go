@@ -0,0 +1,21 @@
+// Package pairs is a test for cliche commands which bind positional arguments
+// as keys and values.
+package pairs
+
+import "context"
+
+// Set is a cliche command which sets configuration values, given as
+// alternating keys and values after the name of the configuration.
+//
+//go:generate cliche -type=Set
+type Set struct {
+	// Config to change.
+	Config string `cliche:"arg:0"`
+	// Values to set, by key.
+	Values map[string]int `cliche:"arg:[1:];pairs"`
+}
+
+// Run the Set command.
+func (cmd *Set) Run(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,43 @@
+// Package pflagged is a test for cliche commands whose flags are bound to a
+// pflag.FlagSet.
+package pflagged
+
+import (
+	"context"
+	"errors"
+)
+
+// Deploy is a cliche command embedded in a program built on pflag.
+//
+//go:generate cliche -type=Deploy -pflag
+type Deploy struct {
+	// Region deployed to.
+	Region string `cliche:"flag:region,r;required;validate:CheckRegion"`
+	// Replicas of each service.
+	Replicas int `cliche:"flag:replicas;default:2;migrate:instances"`
+	// Color the output.
+	Color bool `cliche:"flag:color;default:true;negatable"`
+	// Approve the deployment, or confirm that it needs none with --no-approve.
+	Approve bool `cliche:"flag:approve;negatable;required"`
+	// Verbose output, more so each time it is given.
+	Verbose int `cliche:"flag:verbose,v;count"`
+	// Zone deployed to.
+	Zone string `cliche:"flag:zone;deprecated:use --region instead"`
+	// Trace requests.
+	Trace bool `cliche:"flag:trace;hidden"`
+	// Services deployed.
+	Services []string `cliche:"arg:[0:]"`
+}
+
+// CheckRegion fails for regions which are not known.
+func (cmd *Deploy) CheckRegion(region string) error {
+	if region == "" {
+		return errors.New("empty region")
+	}
+	return nil
+}
+
+// Run the Deploy command.
+func (cmd *Deploy) Run(ctx context.Context) error {
+	return nil
+}
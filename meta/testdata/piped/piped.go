@@ -0,0 +1,44 @@
+// Package piped is a test for cliche commands run in pipelines.
+package piped
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"idontfixcomputers.com/cliche"
+)
+
+// Item listed by List.
+type Item struct {
+	Name string `json:"name"`
+}
+
+// List is a cliche command which lists items, as NDJSON.
+type List struct {
+	// Names of the items.
+	Names []string `cliche:"arg:[0:]"`
+}
+
+// Run the List command.
+func (cmd *List) Run(ctx context.Context) error {
+	enc := json.NewEncoder(cliche.IOFrom(ctx).Out)
+	for _, name := range cmd.Names {
+		if err := enc.Encode(Item{Name: name}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Count is a cliche command which counts the items it reads.
+type Count struct {
+	// Items to count.
+	Items []Item `cliche:"stdin:ndjson"`
+}
+
+// Run the Count command.
+func (cmd *Count) Run(ctx context.Context) error {
+	fmt.Fprintln(cliche.IOFrom(ctx).Out, len(cmd.Items))
+	return nil
+}
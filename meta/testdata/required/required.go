@@ -0,0 +1,27 @@
+// Package required is a test for cliche commands with inputs which must be
+// given.
+package required
+
+import "context"
+
+// Copy is a cliche command which needs to be told what to copy, and how.
+//
+//go:generate cliche -type=Copy
+type Copy struct {
+	// Token with which to authenticate.
+	Token string `cliche:"flag:token,t;required"`
+
+	// Mode of the copy.
+	Mode string `cliche:"flag:mode;default:fast"`
+
+	// Source to copy from.
+	Source string `cliche:"arg:0;required"`
+
+	// Destination to copy to.
+	Destination string `cliche:"arg:1;required"`
+}
+
+// Run the Copy command.
+func (cmd *Copy) Run(ctx context.Context) error {
+	return nil
+}
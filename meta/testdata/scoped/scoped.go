@@ -0,0 +1,33 @@
+// Package scoped is a test for cliche commands whose type name is reused in
+// narrower scopes.
+package scoped
+
+import "context"
+
+func helper() any {
+	// Shadowed is not the command, despite its name.
+	type Shadowed struct {
+		Wrong string
+	}
+	return Shadowed{}
+}
+
+var factory = func() any {
+	type Shadowed struct {
+		AlsoWrong string
+	}
+	return Shadowed{}
+}
+
+// Shadowed is a cliche command with a name reused within functions.
+//
+//go:generate cliche -type=Shadowed
+type Shadowed struct {
+	// Right is the only input.
+	Right string
+}
+
+// Run the Shadowed command.
+func (cmd *Shadowed) Run(ctx context.Context) error {
+	return nil
+}
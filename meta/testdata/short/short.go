@@ -0,0 +1,23 @@
+// Package short is a test for cliche commands with short-only flags.
+package short
+
+import "context"
+
+// Search is a cliche command with flags named by a single letter.
+//
+//go:generate cliche -type=Search
+type Search struct {
+	// Count matches, rather than listing them.
+	Count bool `cliche:"flag:c"`
+	// Context lines shown around each match.
+	Context int `cliche:"flag:C;default:2"`
+	// Pattern searched for.
+	Pattern string `cliche:"flag:pattern,e;required"`
+	// Paths searched.
+	Paths []string `cliche:"arg:[0:]"`
+}
+
+// Run the Search command.
+func (cmd *Search) Run(ctx context.Context) error {
+	return nil
+}
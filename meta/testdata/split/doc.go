@@ -0,0 +1,2 @@
+// Package split is a test for cliche commands declared across several files.
+package split
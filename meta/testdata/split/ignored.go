@@ -0,0 +1,9 @@
+//go:build ignore
+
+package split
+
+// Divided is declared again, but excluded from builds.
+type Divided struct {
+	// Ignored input.
+	Ignored string
+}
@@ -0,0 +1,9 @@
+package split
+
+// Divided is a cliche command whose declaration and methods live apart.
+//
+//go:generate cliche -type=Divided
+type Divided struct {
+	// Name of the thing.
+	Name string
+}
@@ -0,0 +1,7 @@
+package split
+
+// Divided is declared again, which tests are free to do.
+type Divided struct {
+	// Tested input.
+	Tested string
+}
@@ -0,0 +1,21 @@
+// Package strided is a test for cliche commands which interleave positional
+// arguments.
+package strided
+
+import "context"
+
+// Setenv is a cliche command which sets environment variables, given as
+// alternating names and values.
+//
+//go:generate cliche -type=Setenv
+type Setenv struct {
+	// Names of the variables.
+	Names []string `cliche:"arg:[0::2]"`
+	// Values of the variables.
+	Values []string `cliche:"arg:[1::2]"`
+}
+
+// Run the Setenv command.
+func (cmd *Setenv) Run(ctx context.Context) error {
+	return nil
+}
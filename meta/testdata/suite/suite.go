@@ -0,0 +1,31 @@
+// Package suite is a test for cliche commands made of several types.
+package suite
+
+import (
+	"context"
+	"time"
+)
+
+// Fetch is a cliche command which fetches from a remote.
+type Fetch struct {
+	// Remote to fetch from.
+	Remote string `cliche:"arg:0;default:origin"`
+	// Timeout of the fetch.
+	Timeout time.Duration `cliche:"flag:timeout;default:1m"`
+}
+
+// Run the Fetch command.
+func (cmd *Fetch) Run(ctx context.Context) error {
+	return nil
+}
+
+// Push is a cliche command which pushes to a remote.
+type Push struct {
+	// Force the push.
+	Force bool `cliche:"flag:force,f"`
+}
+
+// Run the Push command.
+func (cmd *Push) Run(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,44 @@
+// Package timed is a test for cliche commands run with a timeout.
+package timed
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Sleep is a cliche command which sleeps, unless it times out first.
+type Sleep struct {
+	// For how long to sleep.
+	For time.Duration `cliche:"flag:for;default:1s"`
+}
+
+// Run the Sleep command.
+func (cmd *Sleep) Run(ctx context.Context) error {
+	return pause(ctx, cmd.For)
+}
+
+// Wait is a cliche command which waits, however long that takes.
+type Wait struct {
+	// For how long to wait.
+	For time.Duration `cliche:"flag:for;default:1s"`
+}
+
+// Run the Wait command.
+func (cmd *Wait) Run(ctx context.Context) error {
+	return pause(ctx, cmd.For)
+}
+
+// Untimed opts Wait out of the timeout.
+func (*Wait) Untimed() {}
+
+// pause until d has passed, or ctx is done.
+func pause(ctx context.Context, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		fmt.Println("done")
+		return nil
+	}
+}
@@ -0,0 +1,29 @@
+// Package times is a test for cliche commands which take durations and
+// timestamps.
+package times
+
+import (
+	"context"
+	"time"
+)
+
+// Report is a cliche command which reports on a period.
+//
+//go:generate cliche -type=Report
+type Report struct {
+	// Since is the first day reported on.
+	Since time.Time `cliche:"flag:since;layout:DateOnly;tz:Local"`
+	// Until is when the report ends.
+	Until time.Time `cliche:"flag:until"`
+	// Every is the interval between rows.
+	Every time.Duration `cliche:"flag:every;default:24h"`
+	// Skip days, such as holidays.
+	Skip []time.Time `cliche:"flag:skip;layout:DateOnly"`
+	// Days to highlight.
+	Days []time.Time `cliche:"arg:[0:];layout:02/01/2006;tz:Europe/London"`
+}
+
+// Run the Report command.
+func (cmd *Report) Run(ctx context.Context) error {
+	return nil
+}
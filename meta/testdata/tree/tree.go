@@ -0,0 +1,72 @@
+// Package tree is a test for cliche commands with nested subcommands.
+package tree
+
+import "context"
+
+// Tool is a cliche command made only of subcommands.
+type Tool struct {
+	// Remote repositories.
+	Remote *Remote `cliche:"subcommand"`
+	// Status of the working tree.
+	Status Status `cliche:"subcommand:st"`
+}
+
+// Remote lists remote repositories.
+type Remote struct {
+	// Verbose listing, with URLs.
+	Verbose bool `cliche:"flag:verbose,v"`
+	// Adding remotes.
+	Add `cliche:"subcommand"`
+	// Removing remotes.
+	Remove Remove `cliche:"subcommand:rm"`
+}
+
+// Run the Remote command.
+func (cmd *Remote) Run(ctx context.Context) error {
+	return nil
+}
+
+// Add adds a remote repository.
+type Add struct {
+	// Name of the remote.
+	Name string `cliche:"arg:0"`
+	// URL of the remote.
+	URL string `cliche:"arg:1"`
+}
+
+// Run the Add command.
+func (cmd *Add) Run(ctx context.Context) error {
+	return nil
+}
+
+// Remove removes a remote repository.
+type Remove struct {
+	// Name of the remote.
+	Name string `cliche:"arg:0"`
+}
+
+// Run the Remove command.
+func (cmd *Remove) Run(ctx context.Context) error {
+	return nil
+}
+
+// Status shows the status of the working tree.
+type Status struct {
+	// Short format.
+	Short bool `cliche:"flag:short,s"`
+}
+
+// Run the Status command.
+func (cmd *Status) Run(ctx context.Context) error {
+	return nil
+}
+
+// Loop is a cliche command which is its own subcommand, which is dropped.
+type Loop struct {
+	Again *Loop `cliche:"subcommand"`
+}
+
+// Run the Loop command.
+func (cmd *Loop) Run(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,7 @@
+package twice
+
+// Twice is declared again, which is not a valid package.
+type Twice struct {
+	// Second declaration's input.
+	Second string
+}
@@ -0,0 +1,17 @@
+// Package twice is a test for cliche commands declared in more than one file.
+package twice
+
+import "context"
+
+// Twice is a cliche command declared once here, and again in another file.
+//
+//go:generate cliche -type=Twice
+type Twice struct {
+	// First declaration's input.
+	First string
+}
+
+// Run the Twice command.
+func (cmd *Twice) Run(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,59 @@
+// Package validators is a test for cliche commands which validate positional
+// arguments.
+package validators
+
+import (
+	"context"
+	"errors"
+)
+
+// Route to dial through.
+type Route struct {
+	// Gateway to dial through.
+	Gateway string `cliche:"arg:4"`
+}
+
+// Dialer is a cliche command which checks its arguments before Run.
+//
+//go:generate cliche -type=Dialer
+type Dialer struct {
+	// Host to dial.
+	Host string `cliche:"arg:0;validate:CheckHost"`
+	// Port to dial.
+	Port string `cliche:"arg:1"`
+	// Proto to dial with.
+	Proto string `cliche:"arg:2"`
+	// Missing validator, which is not found.
+	Missing string `cliche:"arg:3;validate:CheckMissing"`
+	// Route is embedded, and its Gateway validated by convention.
+	Route
+}
+
+// Run the Dialer command.
+func (cmd *Dialer) Run(ctx context.Context) error {
+	return nil
+}
+
+// CheckHost validates the Host argument.
+func (cmd *Dialer) CheckHost(host string) error {
+	if host == "" {
+		return errors.New("empty host")
+	}
+	return nil
+}
+
+// ValidateArgPort validates the Port argument by convention.
+func (cmd *Dialer) ValidateArgPort(port string) error {
+	return nil
+}
+
+// ValidateArgProto has the wrong signature to be a validator.
+func (cmd *Dialer) ValidateArgProto(proto int) error {
+	return nil
+}
+
+// ValidateArgGateway validates the Gateway argument of the embedded Route by
+// convention.
+func (cmd *Dialer) ValidateArgGateway(gateway string) error {
+	return nil
+}
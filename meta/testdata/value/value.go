@@ -0,0 +1,17 @@
+// Package value is a test for cliche commands with a value receiver Run method.
+package value
+
+import "context"
+
+// Valuable is a cliche command which is Run by value.
+//
+//go:generate cliche -type=Valuable
+type Valuable struct {
+	// Name to greet.
+	Name string `cliche:"arg:0"`
+}
+
+// Run the Valuable command.
+func (cmd Valuable) Run(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,26 @@
+// Package verbs is a test for cliche commands with several verbs.
+package verbs
+
+import "context"
+
+// Remote is a cliche command which is Run through its verbs.
+//
+//go:generate cliche -type=Remote
+type Remote struct {
+	// URL of the remote.
+	URL string
+}
+
+// RunPush pushes to the remote.
+func (cmd *Remote) RunPush(ctx context.Context) error {
+	return nil
+}
+
+// RunFetchAll fetches everything
+// from the remote.
+func (cmd Remote) RunFetchAll(ctx context.Context) error {
+	return nil
+}
+
+// Runner is not a verb, because it has the wrong signature.
+func (cmd *Remote) Runner() {}
@@ -0,0 +1,590 @@
+package meta
+
+import (
+	"errors"
+	"fmt"
+	"go/token"
+	"strings"
+)
+
+// ValidationError describes a problem with a Command found by Validate, or a
+// questionable declaration found by Warnings, and where in the source it
+// comes from.
+type ValidationError struct {
+	// Pos in the source of the problem, which is not valid when the Command
+	// was not compiled from source.
+	Pos token.Position
+	Err error
+	// Warning is true when the problem does not prevent generating the
+	// command, but is likely a mistake.
+	Warning bool
+}
+
+func (err *ValidationError) Error() string {
+	msg := err.Err.Error()
+	if err.Warning {
+		msg = "warning: " + msg
+	}
+	if err.Pos.IsValid() {
+		return fmt.Sprintf("%v: %v", err.Pos, msg)
+	}
+	return msg
+}
+
+func (err *ValidationError) Unwrap() error {
+	return err.Err
+}
+
+// validName is true for names which are usable as commands on the command
+// line: lowercase letters, digits and dashes, beginning with a letter.
+func validName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z':
+		case i > 0 && (r >= '0' && r <= '9' || r == '-'):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// unbindableType is true for types which can never be bound from command line
+// strings. Types which are not obviously unbindable, such as named types, may
+// be supported by a custom parser, so are allowed.
+func unbindableType(typ string) bool {
+	elem := strings.TrimLeft(typ, "*")
+	if strings.HasPrefix(elem, "[") {
+		if _, e, ok := strings.Cut(elem, "]"); ok {
+			elem = strings.TrimLeft(e, "*")
+		}
+	}
+	if elem == "any" {
+		return true
+	}
+	for _, prefix := range []string{"func(", "chan ", "chan<-", "<-chan", "map[", "interface{", "struct{", "["} {
+		if strings.HasPrefix(elem, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// integerType is true for the built-in integer types, which counting flags
+// may be bound to.
+func integerType(typ string) bool {
+	switch typ {
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64":
+		return true
+	}
+	return false
+}
+
+// containsString is true when s is one of values.
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// multiValued is true for types which can hold more than a single value.
+func multiValued(typ string) bool {
+	return strings.HasPrefix(typ, "[") || strings.HasPrefix(typ, "map[")
+}
+
+// ArgRange returns the half-open range of positional arguments consumed by an
+// input, with an end of -1 when all remaining arguments are consumed.
+func ArgRange(input CommandInput, spec *ArgSpec) (start, end int) {
+	switch {
+	case spec.End != 0:
+		return spec.Start, spec.End
+	case input.Arity > 0:
+		return spec.Start, spec.Start + input.Arity
+	}
+	return spec.Start, spec.Start + 1
+}
+
+// argsOverlap is true when two ranges of positional arguments, each half-open
+// with an end of -1 when open, and consuming every step-th argument from its
+// start, share an argument.
+func argsOverlap(start1, end1, step1, start2, end2, step2 int) bool {
+	from := start1
+	if start2 > from {
+		from = start2
+	}
+	// The pattern of shared arguments repeats with a period of both steps, so
+	// if none is shared within one period of where both ranges have begun,
+	// none ever is.
+	for i := from; i < from+step1*step2; i++ {
+		if (end1 != -1 && i >= end1) || (end2 != -1 && i >= end2) {
+			return false
+		}
+		if (i-start1)%step1 == 0 && (i-start2)%step2 == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// tree returns the command and all of its subcommands, recursively.
+func (meta *Command) tree() []*Command {
+	cmds := []*Command{meta}
+	for _, child := range meta.Children {
+		if child != nil {
+			cmds = append(cmds, child.tree()...)
+		}
+	}
+	return cmds
+}
+
+// Validate the Command for consistency, such that it can be used to generate
+// a working command line interface. All problems found are returned together,
+// each as a *ValidationError. The checks are:
+//
+//   - the command and verb names are legal on the command line, and distinct
+//   - every input's tag parses strictly, and any validator it names exists
+//   - no two inputs share a flag name, current or former
+//   - no two inputs consume the same positional argument, and at most one
+//     consumes all remaining arguments
+//   - inputs consuming ranges of arguments have types which hold many values,
+//     and those consuming every step-th argument are slices
+//   - arrays bound to closed ranges of arguments are as long as the ranges
+//   - no input has a type which can never be bound from the command line
+//   - only time.Time inputs have a timestamp layout or zone
+//   - at most one input controls the command's lock, and it is a bool flag
+//   - at most one input requests a dry run, and it is a bool flag
+//   - at most one input sets the verbosity, and it is an integer flag
+//   - negatable inputs are bool flags with long names
+//   - counting inputs are integer flags
+//   - hidden, secret, deprecated and global inputs are flags
+//   - inputs bound to environment variables are flags other than counts, each
+//     bound to a variable of its own
+//   - global inputs are declared alike by every subcommand declaring them,
+//     and their flags are not those of other inputs of the commands above
+//     them, which accept them on their behalf
+//   - inputs with choices are flags or positional arguments which are not
+//     maps or counts, and their defaults are among the choices
+//   - required inputs are flags or positional arguments, without defaults
+//   - subcommands are valid themselves, distinctly named, and declared in the
+//     same package, and every command of the tree has a distinct generated
+//     function, except for those run from other packages, which are only
+//     distinctly named
+//   - commands with subcommands have no positional arguments of their own
+//   - a default names a verb or subcommand of a command which can't run itself
+//   - a command with an env command has verbs or subcommands, none named env,
+//     and likewise for a selfupdate command
+//   - flags registered on behalf of options, such as --timeout, are not those
+//     of inputs of the command, or of globals it accepts
+func (meta *Command) Validate() error {
+	if meta == nil {
+		return errors.New("nil Command")
+	}
+	return meta.validate(meta.Lenient)
+}
+
+// validate the Command as Validate does, with unknown tag components left to
+// Warnings when lenient, as they are for the whole tree of the root command.
+func (meta *Command) validate(lenient bool) error {
+	var errs []error
+	problem := func(pos token.Position, format string, args ...any) {
+		errs = append(errs, &ValidationError{Pos: pos, Err: fmt.Errorf(format, args...)})
+	}
+
+	if !validName(meta.Name) {
+		problem(meta.Pos, "command name %q is not a valid command line name", meta.Name)
+	}
+	verbs := make(map[string]bool)
+	for _, verb := range meta.Verbs {
+		if !validName(verb.Name) {
+			problem(meta.Pos, "verb name %q is not a valid command line name", verb.Name)
+		}
+		if verbs[verb.Name] {
+			problem(meta.Pos, "verb %q is declared more than once", verb.Name)
+		}
+		verbs[verb.Name] = true
+	}
+	// Functions generated for commands within the tree of a child are checked
+	// by the child, leaving those between trees and with this command.
+	funcs := map[string]string{meta.funcName(): meta.Name}
+	for _, child := range meta.Children {
+		if child == nil {
+			problem(meta.Pos, "subcommand is nil")
+			continue
+		}
+		if verbs[child.Name] {
+			problem(child.Pos, "subcommand %q is declared more than once", child.Name)
+		}
+		verbs[child.Name] = true
+		if meta.external(child) {
+			// Commands run from other packages are generated there.
+			continue
+		}
+		if child.Package != meta.Package {
+			problem(child.Pos, "subcommand %v is declared in package %v, not %v", child.Name, child.Package, meta.Package)
+		}
+		tree := child.tree()
+		for _, cmd := range tree {
+			if other, ok := funcs[cmd.funcName()]; ok {
+				problem(cmd.Pos, "subcommand %v would be generated as %v, as is command %v", cmd.Name, cmd.funcName(), other)
+			}
+		}
+		for _, cmd := range tree {
+			funcs[cmd.funcName()] = cmd.Name
+		}
+		if err := child.validate(lenient); err != nil {
+			errs = append(errs, err.(interface{ Unwrap() []error }).Unwrap()...)
+		}
+	}
+
+	if meta.Default != "" {
+		switch {
+		case !verbs[meta.Default]:
+			problem(meta.Pos, "default %q is not a verb or subcommand", meta.Default)
+		case meta.runnable:
+			problem(meta.Pos, "default %q is never run, since the command runs itself", meta.Default)
+		}
+	}
+
+	if meta.Env {
+		switch {
+		case len(verbs) == 0:
+			problem(meta.Pos, "env command can't be told from the command's arguments, since it has no verbs or subcommands")
+		case verbs["env"]:
+			problem(meta.Pos, "env command is also declared as a verb or subcommand")
+		}
+	}
+	if meta.SelfUpdate {
+		switch {
+		case len(verbs) == 0:
+			problem(meta.Pos, "selfupdate command can't be told from the command's arguments, since it has no verbs or subcommands")
+		case verbs["selfupdate"]:
+			problem(meta.Pos, "selfupdate command is also declared as a verb or subcommand")
+		}
+	}
+
+	flags := make(map[string]string)
+	envs := make(map[string]string)
+	type claim struct {
+		field            string
+		start, end, step int
+	}
+	var claims []claim
+	var lock, dryRun, verbosity string
+	parse := ParseTagStrict
+	if lenient {
+		parse = ParseTag
+	}
+	for _, input := range meta.Inputs {
+		tag, err := parse(string(input.Tag))
+		if err != nil {
+			for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+				errs = append(errs, &ValidationError{Pos: input.TagPos, Err: fmt.Errorf("field %v: %w", input.FieldName, e)})
+			}
+		}
+		if tag.Excluded {
+			continue
+		}
+		key, elem, isMap := mapType(input.Type)
+		mapFlag := isMap && tag.Arg == nil && !tag.Inject && !tag.Stdin
+		switch {
+		case isMap && (tag.Pairs || mapFlag):
+			if multiValued(key) || multiValued(elem) || unbindableType(key) || unbindableType(elem) {
+				problem(input.Pos, "field %v: type %v cannot be bound from the command line", input.FieldName, input.Type)
+			}
+		case !tag.Inject && !tag.Stdin && unbindableType(input.Type):
+			problem(input.Pos, "field %v: type %v cannot be bound from the command line", input.FieldName, input.Type)
+		}
+		_, slice, isArray := elemType(input.Type)
+		sliceFlag := isArray && slice && input.Type != "[]byte" && tag.Arg == nil && !tag.Inject && !tag.Stdin
+		switch {
+		case tag.Separator == "":
+		case !mapFlag && !sliceFlag:
+			problem(input.TagPos, "field %v: has a separator, but is not a slice or map flag", input.FieldName)
+		case mapFlag && strings.Contains(tag.Separator, ","):
+			problem(input.TagPos, "field %v: separates keys and values with a comma, which separates the entries of its default", input.FieldName)
+		}
+
+		if tag.Validator != "" && input.Validator == "" {
+			problem(input.TagPos, "field %v: has no validator method %v(string) error", input.FieldName, tag.Validator)
+		}
+		if tag.Required {
+			switch {
+			case tag.Inject || tag.Stdin:
+				problem(input.TagPos, "field %v: is required, but is not a flag or positional argument", input.FieldName)
+			case tag.Default != "":
+				problem(input.TagPos, "field %v: is required, but has a default", input.FieldName)
+			}
+		}
+		if tag.Negatable {
+			switch {
+			case input.Type != "bool" || tag.Arg != nil || tag.Inject || tag.Stdin:
+				problem(input.TagPos, "field %v: is negatable, but is not a bool flag", input.FieldName)
+			case tag.Flag.ShortOnly():
+				problem(input.TagPos, "field %v: is negatable, but has no long flag name", input.FieldName)
+			case tag.Lock && tag.Flag == nil:
+				problem(input.TagPos, "field %v: is negatable, but controls the command's lock, as -no-lock", input.FieldName)
+			}
+		}
+		if tag.Count && (!integerType(input.Type) || tag.Arg != nil || tag.Inject || tag.Stdin) {
+			problem(input.TagPos, "field %v: is a count, but is not an integer flag", input.FieldName)
+		}
+		if tag.Hidden && (tag.Arg != nil || tag.Inject || tag.Stdin) {
+			problem(input.TagPos, "field %v: is hidden, but is not a flag", input.FieldName)
+		}
+		if tag.Secret && (tag.Arg != nil || tag.Inject || tag.Stdin) {
+			problem(input.TagPos, "field %v: is secret, but is not a flag", input.FieldName)
+		}
+		if tag.Deprecated && (tag.Arg != nil || tag.Inject || tag.Stdin) {
+			problem(input.TagPos, "field %v: is deprecated, but is not a flag", input.FieldName)
+		}
+		if tag.Global && (tag.Arg != nil || tag.Inject || tag.Stdin) {
+			problem(input.TagPos, "field %v: is global, but is not a flag", input.FieldName)
+		}
+		if tag.Env != "" {
+			switch {
+			case tag.Arg != nil || tag.Inject || tag.Stdin:
+				problem(input.TagPos, "field %v: is bound to environment variable %v, but is not a flag", input.FieldName, tag.Env)
+			case tag.Count:
+				problem(input.TagPos, "field %v: is bound to environment variable %v, but is a count", input.FieldName, tag.Env)
+			}
+			if other, ok := envs[tag.Env]; ok {
+				problem(input.TagPos, "field %v: environment variable %v is also bound to field %v", input.FieldName, tag.Env, other)
+			} else {
+				envs[tag.Env] = input.FieldName
+			}
+		}
+		if len(tag.Choices) > 0 {
+			switch {
+			case tag.Inject || tag.Stdin:
+				problem(input.TagPos, "field %v: has choices, but is not a flag or positional argument", input.FieldName)
+			case strings.HasPrefix(input.Type, "map["):
+				problem(input.TagPos, "field %v: has choices, but type %v is a map", input.FieldName, input.Type)
+			case tag.Count:
+				problem(input.TagPos, "field %v: has choices, but is a count", input.FieldName)
+			case tag.Default != "":
+				defaults := []string{tag.Default}
+				if _, slice, ok := elemType(input.Type); ok && slice && tag.Arg == nil && input.Type != "[]byte" {
+					sep := tag.Separator
+					if sep == "" {
+						sep = ","
+					}
+					defaults = strings.Split(tag.Default, sep)
+				}
+				for _, value := range defaults {
+					if !containsString(tag.Choices, value) {
+						problem(input.TagPos, "field %v: default %q is not one of its choices", input.FieldName, value)
+					}
+				}
+			}
+		}
+		if tag.Lock {
+			if lock != "" {
+				problem(input.TagPos, "field %v: the command's lock is also controlled by field %v", input.FieldName, lock)
+			} else {
+				lock = input.FieldName
+			}
+			if input.Type != "bool" || tag.Arg != nil || tag.Inject || tag.Stdin {
+				problem(input.TagPos, "field %v: controls the command's lock, but is not a bool flag", input.FieldName)
+			}
+		}
+		if tag.DryRun {
+			if dryRun != "" {
+				problem(input.TagPos, "field %v: a dry run is also requested by field %v", input.FieldName, dryRun)
+			} else {
+				dryRun = input.FieldName
+			}
+			if input.Type != "bool" || tag.Arg != nil || tag.Inject || tag.Stdin {
+				problem(input.TagPos, "field %v: requests a dry run, but is not a bool flag", input.FieldName)
+			}
+		}
+		if tag.Verbosity {
+			if verbosity != "" {
+				problem(input.TagPos, "field %v: the verbosity is also set by field %v", input.FieldName, verbosity)
+			} else {
+				verbosity = input.FieldName
+			}
+			if !integerType(input.Type) || tag.Arg != nil || tag.Inject || tag.Stdin {
+				problem(input.TagPos, "field %v: sets the verbosity, but is not an integer flag", input.FieldName)
+			}
+		}
+		if tag.Layout != "" || tag.Timezone != "" {
+			typ := input.Type
+			if elem, _, ok := elemType(typ); ok {
+				typ = elem
+			} else if _, elem, ok := mapType(typ); ok && tag.Pairs {
+				typ = elem
+			}
+			if typ != "time.Time" {
+				problem(input.TagPos, "field %v: has a timestamp layout or zone, but type %v is not time.Time", input.FieldName, input.Type)
+			}
+		}
+		if tag.Pairs {
+			switch {
+			case tag.Arg == nil || tag.Arg.End == 0:
+				problem(input.TagPos, "field %v: binds pairs of arguments, but not a range of them", input.FieldName)
+			case !strings.HasPrefix(input.Type, "map["):
+				problem(input.TagPos, "field %v: binds pairs of arguments, but type %v is not a map", input.FieldName, input.Type)
+			case tag.Arg.End > 0 && (tag.Arg.End-tag.Arg.Start)%2 != 0:
+				problem(input.TagPos, "field %v: binds pairs of arguments, but its range holds an odd number of them", input.FieldName)
+			}
+		}
+		if len(tag.Migrate) > 0 && tag.Flag == nil {
+			problem(input.TagPos, "field %v: migrates former flag names, but is not a flag", input.FieldName)
+		}
+		if tag.Flag != nil {
+			var names []string
+			if tag.Flag.Long != "" {
+				names = append(names, "--"+tag.Flag.Long)
+			}
+			if tag.Flag.Short != "" {
+				names = append(names, "-"+tag.Flag.Short)
+			}
+			if negated := NegatedName(input, tag); negated != "" {
+				names = append(names, "--"+negated)
+			}
+			for _, old := range tag.Migrate {
+				names = append(names, "--"+old)
+			}
+			for _, name := range names {
+				if other, ok := flags[name]; ok {
+					problem(input.TagPos, "field %v: flag %v is also declared by field %v", input.FieldName, name, other)
+					continue
+				}
+				flags[name] = input.FieldName
+			}
+		}
+
+		if tag.Arg != nil {
+			if len(meta.Children) > 0 {
+				problem(input.TagPos, "field %v: is a positional argument, but the command's arguments name its subcommands", input.FieldName)
+			}
+			start, end := ArgRange(input, tag.Arg)
+			step := tag.Arg.Stride()
+			if n := (end - start + step - 1) / step; input.Arity > 0 && end != -1 && n != input.Arity {
+				// An array may be bound to a single index, meaning it
+				// consumes arguments from there, or to an open range.
+				// Otherwise, the range must be the length of the array.
+				problem(input.TagPos, "field %v: consumes %d positional arguments, but type %v holds %d", input.FieldName, n, input.Type, input.Arity)
+			} else if end-start != 1 && !multiValued(input.Type) {
+				problem(input.TagPos, "field %v: consumes many arguments, but type %v holds one value", input.FieldName, input.Type)
+			} else if step > 1 && !strings.HasPrefix(input.Type, "[]") {
+				problem(input.TagPos, "field %v: consumes one argument in every %d, but type %v is not a slice", input.FieldName, step, input.Type)
+			}
+			for _, c := range claims {
+				if argsOverlap(start, end, step, c.start, c.end, c.step) {
+					problem(input.TagPos, "field %v: positional arguments overlap those of field %v", input.FieldName, c.field)
+				}
+			}
+			claims = append(claims, claim{input.FieldName, start, end, step})
+		}
+	}
+
+	lifted := make(map[string]string)
+	if len(meta.Children) > 0 {
+		globals, err := meta.liftedGlobals()
+		if err != nil {
+			problem(meta.Pos, "%w", err)
+		}
+		for _, input := range globals {
+			tag, _ := ParseTag(string(input.Tag))
+			for _, name := range FlagNames(input, tag) {
+				name = "-" + name
+				if len(name) > 2 {
+					name = "-" + name
+				}
+				if other, ok := flags[name]; ok {
+					problem(input.TagPos, "field %v: global flag %v is also declared by field %v of command %v", input.FieldName, name, other, meta.Name)
+				}
+				lifted[name] = input.FieldName
+			}
+		}
+	}
+	for _, f := range meta.runtimeFlags() {
+		name := "--" + f.Name
+		if other, ok := flags[name]; ok {
+			problem(meta.Pos, "flag %v of the -%v option is also declared by field %v", name, f.Option, other)
+		}
+		if other, ok := lifted[name]; ok {
+			problem(meta.Pos, "flag %v of the -%v option is also declared by global field %v", name, f.Option, other)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Warnings returns the declarations of the Command and its subcommands which,
+// though valid, contradict themselves or have no effect, each as a
+// *ValidationError with Warning set. The checks are that:
+//
+//   - required flags are not bools, which could only ever be given as true,
+//     unless they are negatable
+//   - injected inputs and those read from stdin have no defaults, which would
+//     never be used
+//   - required positional arguments do not follow optional ones, which would
+//     then have to be given as well
+//   - tags have no unknown components, when the command is Lenient
+func (meta *Command) Warnings() []*ValidationError {
+	if meta == nil {
+		return nil
+	}
+	return meta.warnings(meta.Lenient)
+}
+
+// warnings returns the Warnings of the Command, with unknown tag components
+// among them when lenient.
+func (meta *Command) warnings(lenient bool) []*ValidationError {
+	var warnings []*ValidationError
+	warn := func(pos token.Position, format string, args ...any) {
+		warnings = append(warnings, &ValidationError{Pos: pos, Err: fmt.Errorf(format, args...), Warning: true})
+	}
+
+	type optional struct {
+		field string
+		start int
+	}
+	var optionals []optional
+	for _, input := range meta.Inputs {
+		tag, err := ParseTag(string(input.Tag))
+		if err != nil || tag.Excluded {
+			continue
+		}
+		if lenient {
+			for _, e := range input.Tag.unknownComponents() {
+				warnings = append(warnings, &ValidationError{Pos: input.TagPos, Err: fmt.Errorf("field %v: %w", input.FieldName, e), Warning: true})
+			}
+		}
+		flag := tag.Arg == nil && !tag.Inject && !tag.Stdin
+		switch {
+		case tag.Required && flag && input.Type == "bool" && !tag.Negatable:
+			warn(input.TagPos, "field %v: is a required bool flag, so is always true", input.FieldName)
+		case tag.Default != "" && tag.Inject:
+			warn(input.TagPos, "field %v: has a default, which is never used, since it is injected", input.FieldName)
+		case tag.Default != "" && tag.Stdin:
+			warn(input.TagPos, "field %v: has a default, which is never used, since it is read from stdin", input.FieldName)
+		}
+		if tag.Arg == nil || tag.Inject || tag.Stdin {
+			continue
+		}
+		start, _ := ArgRange(input, tag.Arg)
+		if !tag.Required {
+			optionals = append(optionals, optional{input.FieldName, start})
+			continue
+		}
+		for _, o := range optionals {
+			if o.start < start {
+				warn(input.TagPos, "field %v: is a required argument, so optional argument %v before it must always be given", input.FieldName, o.field)
+			}
+		}
+	}
+	for _, child := range meta.Children {
+		if child != nil {
+			warnings = append(warnings, child.warnings(lenient)...)
+		}
+	}
+	return warnings
+}
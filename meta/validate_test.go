@@ -0,0 +1,503 @@
+package meta
+
+import (
+	"errors"
+	"go/token"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCommandValidate(t *testing.T) {
+	pos := token.Position{Filename: "tool.go", Line: 1, Column: 1}
+	type test struct {
+		cmd  *Command
+		want []string
+	}
+
+	for tn, tc := range map[string]test{
+		"valid": {
+			&Command{
+				Name:  "tool",
+				Verbs: []Verb{{Name: "fetch-all"}, {Name: "push"}},
+				Inputs: []CommandInput{
+					{FieldName: "Host", Tag: "flag:host,H", Type: "string"},
+					{FieldName: "Verbose", Tag: "flag:verbose,v", Type: "bool"},
+					{FieldName: "Source", Tag: "arg:0", Type: "string"},
+					{FieldName: "Pair", Tag: "arg:1", Type: "[2]string", Arity: 2},
+					{FieldName: "Rest", Tag: "arg:[3:]", Type: "[]string"},
+					{FieldName: "Client", Tag: "inject", Type: "func()"},
+					{FieldName: "Custom", Type: "uuid.UUID"},
+					{FieldName: "Ignored", Tag: "-", Type: "chan int"},
+					{FieldName: "NoLock", Tag: "lock", Type: "bool"},
+					{FieldName: "Token", Tag: "flag:token;required", Type: "string"},
+				},
+			},
+			nil,
+		},
+		"nil": {nil, []string{"nil Command"}},
+		"bad names": {
+			&Command{Name: "Tool", Pos: pos, Verbs: []Verb{{Name: "push"}, {Name: "push"}, {Name: "-x"}}},
+			[]string{
+				`tool.go:1:1: command name "Tool" is not a valid command line name`,
+				`tool.go:1:1: verb "push" is declared more than once`,
+				`tool.go:1:1: verb name "-x" is not a valid command line name`,
+			},
+		},
+		"bad tags": {
+			&Command{Name: "tool", Inputs: []CommandInput{
+				{FieldName: "Host", Tag: "falg:host;arg:[a]", Type: "string", TagPos: pos},
+			}},
+			[]string{
+				`tool.go:1:1: field Host: invalid tag component arg:"[a]": non-numeric index "a"`,
+				`tool.go:1:1: field Host: invalid tag component falg:"host": unknown component; did you mean flag:?`,
+			},
+		},
+		"flag collisions": {
+			&Command{Name: "tool", Inputs: []CommandInput{
+				{FieldName: "Host", Tag: "flag:host,h", Type: "string"},
+				{FieldName: "Help", Tag: "flag:help,h", Type: "bool"},
+				{FieldName: "Hostname", Tag: "flag:host", Type: "string"},
+				{FieldName: "Hidden", Tag: "flag:h", Type: "bool"},
+				{FieldName: "Name", Tag: "flag:name;migrate:help", Type: "string"},
+				{FieldName: "Positional", Tag: "arg:0;migrate:pos", Type: "string"},
+				{FieldName: "Checked", Tag: "arg:1;validate:Check", Type: "string"},
+			}},
+			[]string{
+				"field Help: flag -h is also declared by field Host",
+				"field Hostname: flag --host is also declared by field Host",
+				"field Hidden: flag -h is also declared by field Host",
+				"field Name: flag --help is also declared by field Help",
+				"field Positional: migrates former flag names, but is not a flag",
+				"field Checked: has no validator method Check(string) error",
+			},
+		},
+		"locks": {
+			&Command{Name: "tool", Inputs: []CommandInput{
+				{FieldName: "NoLock", Tag: "lock", Type: "bool"},
+				{FieldName: "Unlocked", Tag: "lock:other", Type: "bool"},
+				{FieldName: "Lock", Tag: "arg:0;lock", Type: "string"},
+			}},
+			[]string{
+				"field Unlocked: the command's lock is also controlled by field NoLock",
+				"field Lock: the command's lock is also controlled by field NoLock",
+				"field Lock: controls the command's lock, but is not a bool flag",
+			},
+		},
+		"dry runs and verbosity": {
+			&Command{Name: "tool", Inputs: []CommandInput{
+				{FieldName: "DryRun", Tag: "flag:dry-run;dryrun", Type: "bool"},
+				{FieldName: "Pretend", Tag: "flag:pretend;dryrun", Type: "string"},
+				{FieldName: "Verbose", Tag: "flag:verbose,v;count;verbosity", Type: "int"},
+				{FieldName: "Level", Tag: "arg:0;verbosity", Type: "int"},
+			}},
+			[]string{
+				"field Pretend: a dry run is also requested by field DryRun",
+				"field Pretend: requests a dry run, but is not a bool flag",
+				"field Level: the verbosity is also set by field Verbose",
+				"field Level: sets the verbosity, but is not an integer flag",
+			},
+		},
+		"required": {
+			&Command{Name: "tool", Inputs: []CommandInput{
+				{FieldName: "Port", Tag: "flag:port;required;default:80", Type: "int"},
+				{FieldName: "Body", Tag: "stdin;required", Type: "string"},
+			}},
+			[]string{
+				"field Port: is required, but has a default",
+				"field Body: is required, but is not a flag or positional argument",
+			},
+		},
+		"subcommands": {
+			&Command{Name: "tool", Package: "tool", Children: []*Command{
+				{Name: "push", Package: "tool", Type: "Push"},
+				{Name: "push", Package: "tool", Type: "Pusher"},
+				{Name: "Fetch", Package: "other", Type: "Tool"},
+			}},
+			[]string{
+				`subcommand "push" is declared more than once`,
+				"subcommand Fetch is declared in package other, not tool",
+				"subcommand Fetch would be generated as RunTool, as is command tool",
+				`command name "Fetch" is not a valid command line name`,
+			},
+		},
+		"subcommand tree": {
+			&Command{Name: "tool", Type: "Tool", Inputs: []CommandInput{
+				{FieldName: "Path", Tag: "arg:0", Type: "string"},
+			}, Children: []*Command{
+				{Name: "add", Type: "Add"},
+				{Name: "remote", Type: "Remote", Children: []*Command{{Name: "add", Type: "Add"}}},
+			}},
+			[]string{
+				"subcommand add would be generated as RunAdd, as is command add",
+				"field Path: is a positional argument, but the command's arguments name its subcommands",
+			},
+		},
+		"globals": {
+			&Command{Name: "tool", Type: "Tool", Inputs: []CommandInput{
+				{FieldName: "Verbose", Tag: "flag:verbose;global", Type: "bool"},
+				{FieldName: "Config", Tag: "flag:config,c", Type: "string"},
+			}, Children: []*Command{
+				{Name: "build", Type: "Build", Inputs: []CommandInput{
+					{FieldName: "Verbose", Tag: "flag:verbose;global", Type: "bool"},
+					{FieldName: "Config", Tag: "flag:config;global", Type: "string"},
+					{FieldName: "Target", Tag: "arg:0;global", Type: "string"},
+				}},
+			}},
+			[]string{
+				"field Target: is global, but is not a flag",
+				"field Config: global flag --config is also declared by field Config of command tool",
+			},
+		},
+		"global disagreements": {
+			&Command{Name: "tool", Children: []*Command{
+				{Name: "build", Type: "Build", Inputs: []CommandInput{
+					{FieldName: "Verbose", Tag: "flag:verbose;global", Type: "bool"},
+				}},
+				{Name: "clean", Type: "Clean", Inputs: []CommandInput{
+					{FieldName: "Verbose", Tag: "flag:verbose;count;global", Type: "int"},
+				}},
+			}},
+			[]string{`global input "verbose" declared differently by commands "build" and "clean"`},
+		},
+		"defaults": {
+			&Command{Name: "tool", Default: "pull", Verbs: []Verb{{Name: "push"}}},
+			[]string{`default "pull" is not a verb or subcommand`},
+		},
+		"positional overlap": {
+			&Command{Name: "tool", Inputs: []CommandInput{
+				{FieldName: "First", Tag: "arg:0", Type: "string"},
+				{FieldName: "Pair", Tag: "arg:[0:2]", Type: "[]string"},
+				{FieldName: "Rest", Tag: "arg:[1:]", Type: "[]string"},
+				{FieldName: "More", Tag: "arg:[5:]", Type: "[]string"},
+			}},
+			[]string{
+				"field Pair: positional arguments overlap those of field First",
+				"field Rest: positional arguments overlap those of field Pair",
+				"field More: positional arguments overlap those of field Rest",
+			},
+		},
+		"timestamps": {
+			&Command{Name: "tool", Inputs: []CommandInput{
+				{FieldName: "Since", Tag: "flag:since;layout:DateOnly;tz:UTC", Type: "time.Time"},
+				{FieldName: "Days", Tag: "arg:[0:];layout:DateOnly", Type: "[]time.Time"},
+				{FieldName: "Timeout", Tag: "flag:timeout;layout:Kitchen", Type: "time.Duration"},
+				{FieldName: "Dates", Tag: "flag:date;tz:Local", Type: "[]string"},
+			}},
+			[]string{
+				"field Timeout: has a timestamp layout or zone, but type time.Duration is not time.Time",
+				"field Dates: has a timestamp layout or zone, but type []string is not time.Time",
+			},
+		},
+		"strided positional": {
+			&Command{Name: "tool", Inputs: []CommandInput{
+				{FieldName: "Keys", Tag: "arg:[1::2]", Type: "[]string"},
+				{FieldName: "Values", Tag: "arg:[2::2]", Type: "[]string"},
+				{FieldName: "Thirds", Tag: "arg:[3::3]", Type: "[]string"},
+				{FieldName: "Command", Tag: "arg:0", Type: "string"},
+				{FieldName: "Pairs", Tag: "arg:[0:4:2]", Type: "[2]string", Arity: 2},
+			}},
+			[]string{
+				"field Thirds: positional arguments overlap those of field Keys",
+				"field Thirds: positional arguments overlap those of field Values",
+				"field Pairs: consumes one argument in every 2, but type [2]string is not a slice",
+				"field Pairs: positional arguments overlap those of field Values",
+				"field Pairs: positional arguments overlap those of field Command",
+			},
+		},
+		"pairs": {
+			&Command{Name: "tool", Inputs: []CommandInput{
+				{FieldName: "Vars", Tag: "arg:[1:3];pairs", Type: "map[string]int"},
+				{FieldName: "Command", Tag: "arg:0;pairs", Type: "string"},
+				{FieldName: "Odd", Tag: "arg:[3:6];pairs", Type: "map[string]string"},
+				{FieldName: "Flags", Tag: "flag:set;pairs", Type: "map[string]string"},
+				{FieldName: "List", Tag: "arg:[6:8];pairs", Type: "[]string"},
+				{FieldName: "Nested", Tag: "arg:[8:10];pairs", Type: "map[string][]string"},
+				{FieldName: "Unpaired", Tag: "arg:[10:12]", Type: "map[string]string"},
+			}},
+			[]string{
+				"field Command: binds pairs of arguments, but not a range of them",
+				"field Odd: binds pairs of arguments, but its range holds an odd number of them",
+				"field Flags: binds pairs of arguments, but not a range of them",
+				"field List: binds pairs of arguments, but type []string is not a map",
+				"field Nested: type map[string][]string cannot be bound from the command line",
+				"field Unpaired: type map[string]string cannot be bound from the command line",
+			},
+		},
+		"separated flags": {
+			&Command{Name: "tool", Inputs: []CommandInput{
+				{FieldName: "Labels", Tag: "flag:label;sep::", Type: "map[string]string"},
+				{FieldName: "Limits", Type: "map[string]int"},
+				{FieldName: "Lists", Type: "map[string][]string"},
+				{FieldName: "Name", Tag: "flag:name;sep::", Type: "string"},
+				{FieldName: "Env", Tag: "stdin:json;sep::", Type: "map[string]string"},
+				{FieldName: "Tags", Tag: "flag:tag;sep:,", Type: "[]string"},
+				{FieldName: "Hosts", Tag: "arg:[0:];sep:,", Type: "[]string"},
+				{FieldName: "Pairs", Tag: "flag:pair;sep:,", Type: "map[string]string"},
+			}},
+			[]string{
+				"field Lists: type map[string][]string cannot be bound from the command line",
+				"field Name: has a separator, but is not a slice or map flag",
+				"field Env: has a separator, but is not a slice or map flag",
+				"field Hosts: has a separator, but is not a slice or map flag",
+				"field Pairs: separates keys and values with a comma, which separates the entries of its default",
+			},
+		},
+		"negatable": {
+			&Command{Name: "tool", Inputs: []CommandInput{
+				{FieldName: "Color", Tag: "flag:color;negatable", Type: "bool"},
+				{FieldName: "NoColor", Tag: "flag:no-color", Type: "bool"},
+				{FieldName: "Level", Tag: "flag:level;negatable", Type: "int"},
+				{FieldName: "Quiet", Tag: "flag:q;negatable", Type: "bool"},
+				{FieldName: "Wait", Tag: "lock;negatable", Type: "bool"},
+			}},
+			[]string{
+				"field NoColor: flag --no-color is also declared by field Color",
+				"field Level: is negatable, but is not a bool flag",
+				"field Quiet: is negatable, but has no long flag name",
+				"field Wait: is negatable, but controls the command's lock, as -no-lock",
+			},
+		},
+		"count": {
+			&Command{Name: "tool", Inputs: []CommandInput{
+				{FieldName: "Verbose", Tag: "flag:verbose,v;count", Type: "int"},
+				{FieldName: "Quiet", Tag: "flag:q;count", Type: "uint8"},
+				{FieldName: "Debug", Tag: "flag:debug;count", Type: "bool"},
+				{FieldName: "Level", Tag: "arg:0;count", Type: "int"},
+			}},
+			[]string{
+				"field Debug: is a count, but is not an integer flag",
+				"field Level: is a count, but is not an integer flag",
+			},
+		},
+		"choices": {
+			&Command{Name: "tool", Inputs: []CommandInput{
+				{FieldName: "Color", Tag: "flag:color;default:red;choices:red|green|blue", Type: "string"},
+				{FieldName: "Shade", Tag: "flag:shade;default:dark;choices:light|medium", Type: "string"},
+				{FieldName: "Tags", Tag: "flag:tag;default:a+c;sep:+;choices:a|b", Type: "[]string"},
+				{FieldName: "Labels", Tag: "flag:label;choices:a|b", Type: "map[string]string"},
+				{FieldName: "Verbose", Tag: "flag:v;count;choices:1|2", Type: "int"},
+				{FieldName: "Config", Tag: "stdin;choices:a|b", Type: "string"},
+				{FieldName: "Mode", Tag: "arg:0;choices:fast|slow", Type: "string"},
+			}},
+			[]string{
+				`field Shade: default "dark" is not one of its choices`,
+				`field Tags: default "c" is not one of its choices`,
+				"field Labels: has choices, but type map[string]string is a map",
+				"field Verbose: has choices, but is a count",
+				"field Config: has choices, but is not a flag or positional argument",
+			},
+		},
+		"hidden, secret and deprecated": {
+			&Command{Name: "tool", Inputs: []CommandInput{
+				{FieldName: "Debug", Tag: "flag:debug;hidden", Type: "bool"},
+				{FieldName: "Addr", Tag: "flag:addr;deprecated:use --port instead", Type: "string"},
+				{FieldName: "Token", Tag: "flag:token;secret", Type: "string"},
+				{FieldName: "Target", Tag: "arg:0;hidden", Type: "string"},
+				{FieldName: "Config", Tag: "stdin;deprecated", Type: "string"},
+				{FieldName: "Password", Tag: "arg:1;secret", Type: "string"},
+			}},
+			[]string{
+				"field Target: is hidden, but is not a flag",
+				"field Config: is deprecated, but is not a flag",
+				"field Password: is secret, but is not a flag",
+			},
+		},
+		"env": {
+			&Command{Name: "tool", Inputs: []CommandInput{
+				{FieldName: "Port", Tag: "flag:port;env:PORT", Type: "int"},
+				{FieldName: "Listen", Tag: "flag:listen;env:PORT", Type: "string"},
+				{FieldName: "Verbose", Tag: "flag:v;count;env:VERBOSE", Type: "int"},
+				{FieldName: "Target", Tag: "arg:0;env:TARGET", Type: "string"},
+			}},
+			[]string{
+				"field Listen: environment variable PORT is also bound to field Port",
+				"field Verbose: is bound to environment variable VERBOSE, but is a count",
+				"field Target: is bound to environment variable TARGET, but is not a flag",
+			},
+		},
+		"env command": {
+			&Command{Name: "tool", Pos: pos, Env: true},
+			[]string{"tool.go:1:1: env command can't be told from the command's arguments, since it has no verbs or subcommands"},
+		},
+		"env verb": {
+			&Command{Name: "tool", Pos: pos, Env: true, Verbs: []Verb{{Name: "env"}}},
+			[]string{"tool.go:1:1: env command is also declared as a verb or subcommand"},
+		},
+		"timeout": {
+			&Command{Name: "tool", Pos: pos, Type: "Tool", Timeout: true, Inputs: []CommandInput{
+				{FieldName: "Timeout", Tag: "flag:timeout", Type: "time.Duration"},
+			}, Children: []*Command{
+				{Name: "build", Type: "Build", Inputs: []CommandInput{
+					{FieldName: "Deadline", Tag: "flag:timeout;global", Type: "time.Duration"},
+				}},
+			}},
+			[]string{
+				"field Deadline: global flag --timeout is also declared by field Timeout of command tool",
+				"tool.go:1:1: flag --timeout of the -timeout option is also declared by field Timeout",
+				"tool.go:1:1: flag --timeout of the -timeout option is also declared by global field Deadline",
+			},
+		},
+		"schedule": {
+			&Command{Name: "tool", Pos: pos, Type: "Tool", Schedule: true, Inputs: []CommandInput{
+				{FieldName: "Interval", Tag: "flag:every", Type: "time.Duration"},
+			}},
+			[]string{"tool.go:1:1: flag --every of the -schedule option is also declared by field Interval"},
+		},
+		"interactive": {
+			&Command{Name: "tool", Pos: pos, Type: "Tool", Interactive: true, Verbs: []Verb{{Name: "build"}}, Inputs: []CommandInput{
+				{FieldName: "Prompt", Tag: "flag:interactive", Type: "bool"},
+			}},
+			[]string{"tool.go:1:1: flag --interactive of the -interactive option is also declared by field Prompt"},
+		},
+		"manifest": {
+			&Command{Name: "tool", Pos: pos, Type: "Tool", Manifest: true, Verbs: []Verb{{Name: "build"}}, Inputs: []CommandInput{
+				{FieldName: "Config", Tag: "flag:from-file", Type: "string"},
+			}},
+			[]string{"tool.go:1:1: flag --from-file of the -manifest option is also declared by field Config"},
+		},
+		"show config": {
+			&Command{Name: "tool", Pos: pos, Type: "Tool", ShowConfig: true, Verbs: []Verb{{Name: "build"}}, Inputs: []CommandInput{
+				{FieldName: "Config", Tag: "flag:show-config", Type: "bool"},
+			}},
+			[]string{"tool.go:1:1: flag --show-config of the -showconfig option is also declared by field Config"},
+		},
+		"selfupdate command": {
+			&Command{Name: "tool", Pos: pos, SelfUpdate: true},
+			[]string{"tool.go:1:1: selfupdate command can't be told from the command's arguments, since it has no verbs or subcommands"},
+		},
+		"selfupdate verb": {
+			&Command{Name: "tool", Pos: pos, SelfUpdate: true, Verbs: []Verb{{Name: "selfupdate"}}},
+			[]string{"tool.go:1:1: selfupdate command is also declared as a verb or subcommand"},
+		},
+		"arity and types": {
+			&Command{Name: "tool", Inputs: []CommandInput{
+				{FieldName: "Mismatched", Tag: "arg:[0:3]", Type: "[2]string", Arity: 2},
+				{FieldName: "Many", Tag: "arg:[3:5]", Type: "string"},
+				{FieldName: "Callback", Type: "func() error"},
+				{FieldName: "Anything", Type: "any"},
+				{FieldName: "Nested", Type: "[][]string"},
+				{FieldName: "Pointers", Type: "[]*int"},
+			}},
+			[]string{
+				"field Mismatched: consumes 3 positional arguments, but type [2]string holds 2",
+				"field Many: consumes many arguments, but type string holds one value",
+				"field Callback: type func() error cannot be bound from the command line",
+				"field Anything: type any cannot be bound from the command line",
+				"field Nested: type [][]string cannot be bound from the command line",
+			},
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			err := tc.cmd.Validate()
+			var got []string
+			if joined, ok := err.(interface{ Unwrap() []error }); ok {
+				for _, e := range joined.Unwrap() {
+					var verr *ValidationError
+					if !errors.As(e, &verr) {
+						t.Errorf("Validate(): error is not a *ValidationError: %v", e)
+					}
+					got = append(got, e.Error())
+				}
+			} else if err != nil {
+				got = append(got, err.Error())
+			}
+			if diff := cmp.Diff(got, tc.want); diff != "" {
+				t.Errorf("Validate(): mismatch(-got,+want):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestCommandWarnings(t *testing.T) {
+	pos := token.Position{Filename: "tool.go", Line: 3, Column: 2}
+	type test struct {
+		cmd  *Command
+		want []string
+	}
+
+	for tn, tc := range map[string]test{
+		"nil":  {nil, nil},
+		"none": {&Command{Name: "tool", Inputs: []CommandInput{{FieldName: "Token", Tag: "flag:token;required", Type: "string"}}}, nil},
+		"contradictions": {
+			&Command{Name: "tool", Inputs: []CommandInput{
+				{FieldName: "Force", Tag: "flag:force;required", Type: "bool", TagPos: pos},
+				{FieldName: "Client", Tag: "inject;default:none", Type: "*http.Client"},
+				{FieldName: "Body", Tag: "stdin;default:{}", Type: "string"},
+				{FieldName: "Source", Tag: "arg:0;default:.", Type: "string"},
+				{FieldName: "Destination", Tag: "arg:1;required", Type: "string"},
+				{FieldName: "Excluded", Tag: "-", Type: "bool"},
+			}},
+			[]string{
+				"tool.go:3:2: warning: field Force: is a required bool flag, so is always true",
+				"warning: field Client: has a default, which is never used, since it is injected",
+				"warning: field Body: has a default, which is never used, since it is read from stdin",
+				"warning: field Destination: is a required argument, so optional argument Source before it must always be given",
+			},
+		},
+		"subcommands": {
+			&Command{Name: "tool", Children: []*Command{
+				{Name: "add", Inputs: []CommandInput{{FieldName: "Yes", Tag: "required", Type: "bool"}}},
+			}},
+			[]string{"warning: field Yes: is a required bool flag, so is always true"},
+		},
+		"strict": {
+			&Command{Name: "tool", Inputs: []CommandInput{{FieldName: "Host", Tag: "falg:host", Type: "string"}}},
+			nil,
+		},
+		"lenient": {
+			&Command{Name: "tool", Lenient: true, Inputs: []CommandInput{{FieldName: "Host", Tag: "falg:host", Type: "string", TagPos: pos}},
+				Children: []*Command{
+					{Name: "add", Inputs: []CommandInput{{FieldName: "Yes", Tag: "flag:yes;requird", Type: "bool"}}},
+				}},
+			[]string{
+				`tool.go:3:2: warning: field Host: invalid tag component falg:"host": unknown component; did you mean flag:?`,
+				`warning: field Yes: invalid tag component requird: unknown component; did you mean required:?`,
+			},
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			if tc.cmd != nil && tc.cmd.Lenient {
+				if err := tc.cmd.Validate(); err != nil {
+					t.Errorf("Validate(): unexpected error for lenient command: %v", err)
+				}
+			}
+			var got []string
+			for _, warning := range tc.cmd.Warnings() {
+				if !warning.Warning {
+					t.Errorf("Warnings(): %v is not a warning", warning)
+				}
+				got = append(got, warning.Error())
+			}
+			if diff := cmp.Diff(got, tc.want); diff != "" {
+				t.Errorf("Warnings(): mismatch (-got,+want):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestCommandValidateTestdata(t *testing.T) {
+	for path, typ := range map[string]string{
+		"testdata/simple/simple.go":     "Tester",
+		"testdata/inject/inject.go":     "Fetcher",
+		"testdata/arrays/arrays.go":     "Pairs",
+		"testdata/verbs/verbs.go":       "Remote",
+		"testdata/excluded/excluded.go": "Partial",
+		"testdata/tree/tree.go":         "Tool",
+		"testdata/short/short.go":       "Search",
+		"testdata/negated/negated.go":   "Build",
+		"testdata/counted/counted.go":   "Sync",
+		"testdata/pflagged/pflagged.go": "Deploy",
+		"testdata/choices/choices.go":   "Paint",
+	} {
+		t.Run(path, func(t *testing.T) {
+			cmd := FromFile(file(t, path), typ)
+			if err := cmd.Validate(); err != nil {
+				t.Errorf("Validate(): unexpected error: %v", err)
+			}
+			for _, warning := range cmd.Warnings() {
+				t.Errorf("Warnings(): unexpected warning: %v", warning)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package meta
+
+import (
+	"errors"
+	"go/build"
+	"go/token"
+	"path/filepath"
+)
+
+// Vet compiles each command which the cliche go:generate directives of the
+// package in dir generate, without generating any code, and returns the
+// problems which generating them would find: those of Validate and Warnings,
+// and any failure to compile a command, positioned at its directive. A
+// directory without Go files has none. The error is for failing to read the
+// package.
+func Vet(dir string) ([]*ValidationError, error) {
+	pkg, err := build.ImportDir(dir, 0)
+	if err != nil {
+		var noGo *build.NoGoError
+		if errors.As(err, &noGo) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var problems []*ValidationError
+	for _, name := range pkg.GoFiles {
+		directives, err := generateDirectives(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range directives {
+			cmd, _, err := fromDirective(dir, d.args)
+			if err != nil {
+				problems = append(problems, &ValidationError{Pos: d.pos, Err: err})
+				continue
+			}
+			// Some problems are only found generating a command which is
+			// otherwise valid.
+			err = cmd.Validate()
+			if err == nil {
+				_, err = cmd.generation("", cmd.sourceName())
+			}
+			problems = append(problems, validationErrors(err, d.pos)...)
+			problems = append(problems, cmd.Warnings()...)
+		}
+	}
+	return problems, nil
+}
+
+// validationErrors returns the ValidationErrors which err, as returned by
+// Validate, joins. Any other error, or one without a position, is positioned
+// at pos.
+func validationErrors(err error, pos token.Position) []*ValidationError {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		var errs []*ValidationError
+		for _, err := range joined.Unwrap() {
+			errs = append(errs, validationErrors(err, pos)...)
+		}
+		return errs
+	}
+	var verr *ValidationError
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &verr) && verr.Pos.IsValid():
+		return []*ValidationError{verr}
+	}
+	return []*ValidationError{{Pos: pos, Err: err}}
+}
@@ -0,0 +1,38 @@
+package meta
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestVet(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		dir  string
+		want []string
+	}{
+		"valid":       {"testdata/simple", nil},
+		"no go files": {"testdata", nil},
+		"invalid":     {"testdata/validators", []string{"validators.go:27:17: field Missing: has no validator method CheckMissing(string) error"}},
+		"generation":  {"testdata/arrays", []string{"arrays.go:10:1: field Flags: array type [2]string can't be bound to a flag"}},
+		"no type":     {"testdata/norun", []string{"norun.go:9:1: go:generate cliche -type=Unrunnable: no command type Unrunnable found"}},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			problems, err := Vet(tc.dir)
+			if err != nil {
+				t.Fatalf("Vet(): unexpected error: %v", err)
+			}
+			if len(problems) != len(tc.want) {
+				t.Fatalf("Vet(): got problems %v, want %d", problems, len(tc.want))
+			}
+			for i, want := range tc.want {
+				if got := problems[i].Error(); !strings.Contains(got, want) {
+					t.Errorf("Vet(): got problem %q, want one containing %q", got, want)
+				}
+			}
+		})
+	}
+
+	if _, err := Vet("testdata/missing"); err == nil {
+		t.Error("Vet(): wanted error for missing directory, got nil")
+	}
+}
@@ -0,0 +1,74 @@
+package cliche
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// MigrateFlags rewrites uses of former flag names in args to the current
+// names of the flags of fs, so that renamed flags keep working in existing
+// scripts. Renames maps former long flag names, as declared with the migrate
+// tag component, to current ones. Both -name and --name forms are rewritten,
+// with or without an =value suffix. As the flag package does, it stops at the
+// first argument which is neither a flag nor the value of one, or at --,
+// leaving the rest as they are. When notices is not nil, a one-line notice is
+// written to it for each former name used.
+func MigrateFlags(fs *flag.FlagSet, args []string, renames map[string]string, notices io.Writer) []string {
+	if len(renames) == 0 {
+		return args
+	}
+	ret := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !isFlag(arg) {
+			return append(ret, args[i:]...)
+		}
+		dashes := "--"
+		name, ok := strings.CutPrefix(arg, dashes)
+		if !ok {
+			dashes = "-"
+			name = arg[1:]
+		}
+		name, value, hasValue := strings.Cut(name, "=")
+		current, ok := renames[name]
+		switch {
+		case !ok:
+			ret = append(ret, arg)
+			current = name
+		case hasValue:
+			ret = append(ret, dashes+current+"="+value)
+		default:
+			ret = append(ret, dashes+current)
+		}
+		if ok && notices != nil {
+			fmt.Fprintf(notices, "Flag %v%v has been renamed to %v%v.\n", dashes, name, dashes, current)
+		}
+		if !hasValue && takesValue(fs, current) && i+1 < len(args) {
+			i++
+			ret = append(ret, args[i])
+		}
+	}
+	return ret
+}
+
+// WarnDeprecated writes a warning to notices for each flag of fs which was
+// given and is deprecated. Notes maps the names of deprecated flags, as
+// declared with the deprecated tag component, to the notes shown with their
+// warnings, such as "use --new-flag instead", which may be empty.
+func WarnDeprecated(fs *flag.FlagSet, notes map[string]string, notices io.Writer) {
+	if len(notes) == 0 || notices == nil {
+		return
+	}
+	fs.Visit(func(f *flag.Flag) {
+		note, ok := notes[f.Name]
+		switch {
+		case !ok:
+		case note == "":
+			fmt.Fprintf(notices, "Flag -%v has been deprecated.\n", f.Name)
+		default:
+			fmt.Fprintf(notices, "Flag -%v has been deprecated, %v\n", f.Name, note)
+		}
+	})
+}
@@ -0,0 +1,120 @@
+package cliche
+
+import (
+	"flag"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+// migrateFlagSet returns a flag set with the current names of the flags
+// renamed in TestMigrateFlags.
+func migrateFlagSet() *flag.FlagSet {
+	var name, color string
+	var v bool
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	BindFlag(fs, &name, "name", "name")
+	BindFlag(fs, &color, "color", "color")
+	BindFlag(fs, &v, "verbose", "v")
+	return fs
+}
+
+func TestMigrateFlags(t *testing.T) {
+	renames := map[string]string{"old-name": "name", "colour": "color"}
+
+	type test struct {
+		args        []string
+		want        []string
+		wantNotices string
+	}
+	for tn, tc := range map[string]test{
+		"empty": {},
+		"unchanged": {
+			[]string{"--name", "foo", "-color=red", "arg"},
+			[]string{"--name", "foo", "-color=red", "arg"},
+			"",
+		},
+		"renamed": {
+			[]string{"--old-name", "foo", "-colour=red", "arg"},
+			[]string{"--name", "foo", "-color=red", "arg"},
+			"Flag --old-name has been renamed to --name.\nFlag -colour has been renamed to -color.\n",
+		},
+		"value with equals": {
+			[]string{"--old-name=a=b"},
+			[]string{"--name=a=b"},
+			"Flag --old-name has been renamed to --name.\n",
+		},
+		"after terminator": {
+			[]string{"--", "--old-name"},
+			[]string{"--", "--old-name"},
+			"",
+		},
+		"after positional": {
+			[]string{"-colour", "red", "arg", "--old-name"},
+			[]string{"-color", "red", "arg", "--old-name"},
+			"Flag -colour has been renamed to -color.\n",
+		},
+		"value like flag": {
+			[]string{"--name", "--colour", "--colour", "red"},
+			[]string{"--name", "--colour", "--color", "red"},
+			"Flag --colour has been renamed to --color.\n",
+		},
+		"bool": {
+			[]string{"-v", "-colour", "red"},
+			[]string{"-v", "-color", "red"},
+			"Flag -colour has been renamed to -color.\n",
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			var notices strings.Builder
+			got := MigrateFlags(migrateFlagSet(), tc.args, renames, &notices)
+			if diff := cmp.Diff(got, tc.want, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("MigrateFlags(): mismatch(-got,+want):\n%v", diff)
+			}
+			if diff := cmp.Diff(notices.String(), tc.wantNotices); diff != "" {
+				t.Errorf("MigrateFlags(): notices mismatch(-got,+want):\n%v", diff)
+			}
+		})
+	}
+
+	// Notices are optional.
+	if diff := cmp.Diff(MigrateFlags(migrateFlagSet(), []string{"--colour"}, renames, nil), []string{"--color"}); diff != "" {
+		t.Errorf("MigrateFlags(): mismatch(-got,+want):\n%v", diff)
+	}
+}
+
+func TestWarnDeprecated(t *testing.T) {
+	notes := map[string]string{"addr": "use --port instead", "a": "use --port instead", "legacy": ""}
+	for tn, tc := range map[string]struct {
+		args        []string
+		wantNotices string
+	}{
+		"unused":   {[]string{"-port", "80"}, ""},
+		"noted":    {[]string{"--addr", ":80"}, "Flag -addr has been deprecated, use --port instead\n"},
+		"short":    {[]string{"-a", ":80"}, "Flag -a has been deprecated, use --port instead\n"},
+		"bare":     {[]string{"-legacy"}, "Flag -legacy has been deprecated.\n"},
+		"defaults": {[]string{"-legacy=true", "-addr="}, "Flag -addr has been deprecated, use --port instead\nFlag -legacy has been deprecated.\n"},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			var (
+				addr   string
+				port   int
+				legacy bool
+			)
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			BindFlag(fs, &addr, "", "addr", "a")
+			BindFlag(fs, &port, "", "port")
+			BindFlag(fs, &legacy, "", "legacy")
+			if err := fs.Parse(tc.args); err != nil {
+				t.Fatalf("Parse(): unexpected error: %v", err)
+			}
+			var notices strings.Builder
+			WarnDeprecated(fs, notes, &notices)
+			if diff := cmp.Diff(notices.String(), tc.wantNotices); diff != "" {
+				t.Errorf("WarnDeprecated(): notices mismatch(-got,+want):\n%v", diff)
+			}
+		})
+	}
+}
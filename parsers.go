@@ -0,0 +1,225 @@
+package cliche
+
+import (
+	"encoding"
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNoParser is returned when a value is to be parsed into a type for which no
+// parser has been registered.
+var ErrNoParser = errors.New("no parser registered")
+
+var (
+	parsersMu sync.RWMutex
+	parsers   = make(map[reflect.Type]func(string) (any, error))
+)
+
+// RegisterParser registers fn to parse command line values into inputs of type
+// T, for types which cliche does not otherwise support. This allows projects
+// to bind their own domain types uniformly. Registering another parser for the
+// same type replaces the first.
+func RegisterParser[T any](fn func(string) (T, error)) {
+	parsersMu.Lock()
+	defer parsersMu.Unlock()
+	parsers[typeOf[T]()] = func(s string) (any, error) {
+		return fn(s)
+	}
+}
+
+// lookupParser returns the parser registered for typ, if any.
+func lookupParser(typ reflect.Type) (func(string) (any, error), bool) {
+	parsersMu.RLock()
+	defer parsersMu.RUnlock()
+	fn, ok := parsers[typ]
+	return fn, ok
+}
+
+var (
+	durationType = typeOf[time.Duration]()
+	timeType     = typeOf[time.Time]()
+	urlType      = typeOf[url.URL]()
+)
+
+// parseMethod parses s into a value of typ with the method by which a pointer
+// to typ implements encoding.TextUnmarshaler or flag.Value. Not ok when it
+// implements neither.
+func parseMethod(typ reflect.Type, s string) (reflect.Value, bool, error) {
+	p := reflect.New(typ)
+	switch m := p.Interface().(type) {
+	case encoding.TextUnmarshaler:
+		return p.Elem(), true, m.UnmarshalText([]byte(s))
+	case flag.Value:
+		return p.Elem(), true, m.Set(s)
+	}
+	return reflect.Value{}, false, nil
+}
+
+// ValueError is returned when a value given for a boolean or number can't be
+// parsed as one. It wraps the error of the strconv package, such as a
+// *strconv.NumError.
+type ValueError struct {
+	// Kind of value expected: "boolean", "integer" or "number".
+	Kind  string
+	Value string
+	Err   error
+}
+
+func (err *ValueError) Error() string {
+	if errors.Is(err.Err, strconv.ErrRange) {
+		return fmt.Sprintf("%v %q out of range", err.Kind, err.Value)
+	}
+	return fmt.Sprintf("invalid %v %q", err.Kind, err.Value)
+}
+
+func (err *ValueError) Unwrap() error {
+	return err.Err
+}
+
+// parseBuiltin parses s into a value of typ, when typ is a duration or a URL,
+// or its kind is that of a string, a boolean or a number. Booleans and numbers
+// which don't parse are a *ValueError.
+func parseBuiltin(typ reflect.Type, s string) (reflect.Value, error) {
+	v := reflect.New(typ).Elem()
+	switch typ {
+	case durationType:
+		d, err := time.ParseDuration(s)
+		v.SetInt(int64(d))
+		return v, err
+	case urlType:
+		// URLs only implement encoding.BinaryUnmarshaler, which is not
+		// generally a parser of text.
+		u, err := url.Parse(s)
+		if err == nil {
+			v.Set(reflect.ValueOf(u).Elem())
+		}
+		return v, err
+	}
+	switch typ.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return v, &ValueError{Kind: "boolean", Value: s, Err: err}
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 0, typ.Bits())
+		if err != nil {
+			return v, &ValueError{Kind: "integer", Value: s, Err: err}
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := strconv.ParseUint(s, 0, typ.Bits())
+		if err != nil {
+			return v, &ValueError{Kind: "integer", Value: s, Err: err}
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, typ.Bits())
+		if err != nil {
+			return v, &ValueError{Kind: "number", Value: s, Err: err}
+		}
+		v.SetFloat(f)
+	default:
+		return v, ErrNoParser
+	}
+	return v, nil
+}
+
+// Parse s into a value of type T, using the parser registered for T. Without
+// one, types implementing encoding.TextUnmarshaler or flag.Value through a
+// pointer, such as net.IP and time.Time, are parsed by those methods. Failing
+// that, strings, booleans, numbers and time.Duration, including types defined
+// in terms of those, are parsed as with the strconv and time packages, and
+// url.URL as with url.Parse. Pointers are parsed as what they point to. If T
+// is none of those, the returned error wraps ErrNoParser. Booleans and numbers
+// which don't parse are a *ValueError, such as invalid integer "abc".
+func Parse[T any](s string) (T, error) {
+	var zero T
+	v, err := parseValue(typeOf[T](), s)
+	if err != nil || !v.IsValid() {
+		return zero, err
+	}
+	return v.Interface().(T), nil
+}
+
+// parseValue parses s into a value of typ, as Parse does.
+func parseValue(typ reflect.Type, s string) (reflect.Value, error) {
+	fn, ok := lookupParser(typ)
+	if !ok && typ.Kind() == reflect.Pointer {
+		v, err := parseValue(typ.Elem(), s)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		p := reflect.New(typ.Elem())
+		p.Elem().Set(v)
+		return p, nil
+	}
+	if !ok {
+		if v, ok, err := parseMethod(typ, s); ok {
+			if err != nil {
+				return v, fmt.Errorf("parsing %q as %v: %w", s, typ, err)
+			}
+			return v, nil
+		}
+		v, err := parseBuiltin(typ, s)
+		var verr *ValueError
+		switch {
+		case errors.Is(err, ErrNoParser):
+			return v, fmt.Errorf("parsing %v: %w", typ, ErrNoParser)
+		case errors.As(err, &verr):
+			// The error names the value, and what it should have been.
+			return v, err
+		case err != nil:
+			return v, fmt.Errorf("parsing %q as %v: %w", s, typ, err)
+		}
+		return v, nil
+	}
+	v, err := fn(s)
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("parsing %q as %v: %w", s, typ, err)
+	}
+	return reflect.ValueOf(v), nil
+}
+
+// TimeParser returns a parser of times given in layout, which is as for
+// time.Parse, or RFC 3339 when empty. Times without an offset are in the zone
+// named tz, which is as for time.LoadLocation, or UTC when empty. It parses
+// the inputs of generated commands which have layout or tz tag components.
+func TimeParser(layout, tz string) func(string) (time.Time, error) {
+	if layout == "" {
+		layout = time.RFC3339
+	}
+	loc, err := time.LoadLocation(tz)
+	return func(s string) (time.Time, error) {
+		if err != nil {
+			return time.Time{}, err
+		}
+		return time.ParseInLocation(layout, s, loc)
+	}
+}
+
+// ChoiceParser returns a parser of values which must be one of choices, and
+// are then parsed with parse. The error for any other value names it and the
+// choices. It parses the inputs of generated commands which have choices tag
+// components.
+func ChoiceParser[T any](parse func(string) (T, error), choices ...string) func(string) (T, error) {
+	return func(s string) (T, error) {
+		for _, choice := range choices {
+			if s == choice {
+				return parse(s)
+			}
+		}
+		var zero T
+		return zero, fmt.Errorf("%q is not one of %v", s, strings.Join(choices, ", "))
+	}
+}
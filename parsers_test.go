@@ -0,0 +1,239 @@
+package cliche
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	_ "time/tzdata" // Time zones are needed regardless of the host.
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type color int
+
+const (
+	red color = iota
+	green
+)
+
+var errUnknownColor = errors.New("unknown color")
+
+func parseColor(s string) (color, error) {
+	switch strings.ToLower(s) {
+	case "red":
+		return red, nil
+	case "green":
+		return green, nil
+	}
+	return 0, fmt.Errorf("%w: %q", errUnknownColor, s)
+}
+
+func TestParse(t *testing.T) {
+	RegisterParser(parseColor)
+
+	for tn, tc := range map[string]struct {
+		in      string
+		want    color
+		wantErr error
+	}{
+		"red":     {"red", red, nil},
+		"green":   {"GREEN", green, nil},
+		"unknown": {"blue", 0, errUnknownColor},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			got, err := Parse[color](tc.in)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("Parse(): error mismatch: got: %v want: %v", err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Errorf("Parse(): got: %v want: %v", got, tc.want)
+			}
+		})
+	}
+
+	type unregistered struct{}
+	if _, err := Parse[unregistered]("anything"); !errors.Is(err, ErrNoParser) {
+		t.Errorf("Parse(): got error %v for unregistered type, want %v", err, ErrNoParser)
+	}
+}
+
+// level is an enum of a string kind, which must be parsed by its UnmarshalText
+// method rather than as any string.
+type level string
+
+func (l *level) UnmarshalText(text []byte) error {
+	switch s := strings.ToLower(string(text)); s {
+	case "debug", "info":
+		*l = level(s)
+		return nil
+	}
+	return fmt.Errorf("unknown level %q", text)
+}
+
+// hostPort is a flag.Value.
+type hostPort struct {
+	host, port string
+}
+
+func (hp *hostPort) String() string {
+	return net.JoinHostPort(hp.host, hp.port)
+}
+
+func (hp *hostPort) Set(s string) (err error) {
+	hp.host, hp.port, err = net.SplitHostPort(s)
+	return err
+}
+
+func TestParseMethods(t *testing.T) {
+	check := func(t *testing.T, got, want any, err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("Parse(): unexpected error: %v", err)
+		}
+		if diff := cmp.Diff(got, want, cmp.AllowUnexported(hostPort{})); diff != "" {
+			t.Errorf("Parse(): mismatch (-got,+want):\n%v", diff)
+		}
+	}
+	t.Run("text unmarshaler", func(t *testing.T) {
+		got, err := Parse[net.IP]("192.0.2.1")
+		check(t, got, net.ParseIP("192.0.2.1"), err)
+	})
+	t.Run("text unmarshaler of string kind", func(t *testing.T) {
+		got, err := Parse[level]("DEBUG")
+		check(t, got, level("debug"), err)
+	})
+	t.Run("flag value", func(t *testing.T) {
+		got, err := Parse[hostPort]("example.com:80")
+		check(t, got, hostPort{"example.com", "80"}, err)
+	})
+	t.Run("url", func(t *testing.T) {
+		got, err := Parse[url.URL]("https://example.com/path")
+		check(t, got, url.URL{Scheme: "https", Host: "example.com", Path: "/path"}, err)
+	})
+	t.Run("pointer", func(t *testing.T) {
+		got, err := Parse[*url.URL]("https://example.com")
+		check(t, got, &url.URL{Scheme: "https", Host: "example.com"}, err)
+	})
+
+	if _, err := Parse[level]("trace"); err == nil || !strings.Contains(err.Error(), `unknown level "trace"`) {
+		t.Errorf("Parse(): got error %v, want the unmarshaler's", err)
+	}
+	if _, err := Parse[*hostPort]("example.com"); err == nil {
+		t.Error("Parse(): wanted error for missing port, got nil")
+	}
+}
+
+func TestParseBuiltin(t *testing.T) {
+	type port uint16
+
+	check := func(t *testing.T, got, want any, err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("Parse(): unexpected error: %v", err)
+		}
+		if got != want {
+			t.Errorf("Parse(): got: %v want: %v", got, want)
+		}
+	}
+	t.Run("string", func(t *testing.T) {
+		got, err := Parse[string]("hello")
+		check(t, got, "hello", err)
+	})
+	t.Run("bool", func(t *testing.T) {
+		got, err := Parse[bool]("true")
+		check(t, got, true, err)
+	})
+	t.Run("int", func(t *testing.T) {
+		got, err := Parse[int]("-0x10")
+		check(t, got, -16, err)
+	})
+	t.Run("defined uint", func(t *testing.T) {
+		got, err := Parse[port]("8080")
+		check(t, got, port(8080), err)
+	})
+	t.Run("uintptr", func(t *testing.T) {
+		got, err := Parse[uintptr]("0xc000")
+		check(t, got, uintptr(0xc000), err)
+	})
+	t.Run("float", func(t *testing.T) {
+		got, err := Parse[float64]("1.5")
+		check(t, got, 1.5, err)
+	})
+	t.Run("duration", func(t *testing.T) {
+		got, err := Parse[time.Duration]("1m30s")
+		check(t, got, 90*time.Second, err)
+	})
+	t.Run("time", func(t *testing.T) {
+		got, err := Parse[time.Time]("2026-10-17T12:00:00Z")
+		check(t, got, time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC), err)
+	})
+
+	for _, tc := range []struct {
+		err  error
+		want string
+	}{
+		{func() error { _, err := Parse[int]("abc"); return err }(), `invalid integer "abc"`},
+		{func() error { _, err := Parse[port]("65536"); return err }(), `integer "65536" out of range`},
+		{func() error { _, err := Parse[float32]("1.5.1"); return err }(), `invalid number "1.5.1"`},
+		{func() error { _, err := Parse[bool]("yes"); return err }(), `invalid boolean "yes"`},
+	} {
+		var verr *ValueError
+		if tc.err == nil || tc.err.Error() != tc.want || !errors.As(tc.err, &verr) {
+			t.Errorf("Parse(): got error %v, want a *ValueError %q", tc.err, tc.want)
+		}
+	}
+	if _, err := Parse[port]("65536"); !errors.Is(err, strconv.ErrRange) {
+		t.Errorf("Parse(): got error %v for out of range value, want range error", err)
+	}
+	if _, err := Parse[uintptr]("-1"); err == nil || errors.Is(err, ErrNoParser) {
+		t.Errorf("Parse(): got error %v for negative uintptr, want range error", err)
+	}
+	if _, err := Parse[[]string]("a,b"); !errors.Is(err, ErrNoParser) {
+		t.Errorf("Parse(): got error %v for slice, want %v", err, ErrNoParser)
+	}
+}
+
+func TestTimeParser(t *testing.T) {
+	nyc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for tn, tc := range map[string]struct {
+		layout, tz, in string
+		want           time.Time
+		wantErr        bool
+	}{
+		"rfc3339":         {"", "", "2026-10-17T12:00:00+02:00", time.Date(2026, 10, 17, 10, 0, 0, 0, time.UTC), false},
+		"layout":          {time.DateOnly, "", "2026-10-17", time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC), false},
+		"zone":            {time.DateTime, "America/New_York", "2026-10-17 09:30:00", time.Date(2026, 10, 17, 9, 30, 0, 0, nyc), false},
+		"explicit offset": {"", "America/New_York", "2026-10-17T12:00:00Z", time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC), false},
+		"bad time":        {time.DateOnly, "", "17/10/2026", time.Time{}, true},
+		"bad zone":        {time.DateOnly, "Mars/Olympus_Mons", "2026-10-17", time.Time{}, true},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			got, err := TimeParser(tc.layout, tc.tz)(tc.in)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("TimeParser(): error mismatch: got: %v wantErr: %v", err, tc.wantErr)
+			}
+			if !got.Equal(tc.want) {
+				t.Errorf("TimeParser(): got: %v want: %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestChoiceParser(t *testing.T) {
+	parse := ChoiceParser(Parse[time.Duration], "1m", "1h")
+	if got, err := parse("1h"); err != nil || got != time.Hour {
+		t.Errorf("ChoiceParser(): got: %v, %v want: %v", got, err, time.Hour)
+	}
+	_, err := parse("60m")
+	if want := `"60m" is not one of 1m, 1h`; err == nil || err.Error() != want {
+		t.Errorf("ChoiceParser(): got error %v, want %q", err, want)
+	}
+}
@@ -0,0 +1,87 @@
+package cliche
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+)
+
+// PipeSeparator is the argument separating the stages of a pipeline run in one
+// process by RunPipeline. It is quoted to keep the shell from piping, as in
+// app list '|' count.
+const PipeSeparator = "|"
+
+// Piped is true when args, which exclude the program name, are a pipeline of
+// several stages: when any of them before a -- is PipeSeparator.
+func Piped(args []string) bool {
+	return len(SplitPipeline(args)) > 1
+}
+
+// SplitPipeline splits args, which exclude the program name, into the stages
+// of a pipeline, at each PipeSeparator before the first --. Those after it are
+// arguments of the last stage.
+func SplitPipeline(args []string) [][]string {
+	var stages [][]string
+	start := 0
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if arg == PipeSeparator {
+			stages = append(stages, args[start:i])
+			start = i + 1
+		}
+	}
+	return append(stages, args[start:])
+}
+
+// RunPipeline runs each stage of the pipeline in args, as split by
+// SplitPipeline, by calling run with its arguments, concurrently and in one
+// process. The output written to the Out of each stage is read from the In of
+// the next, as a shell pipeline would connect them, so that a command writing
+// structured output, such as NDJSON, feeds one reading structured input from
+// stdin. The first stage reads from the In of stdio, and the last writes to
+// its Out; all of them write to its Err. A stage which stops reading its
+// input early leaves the stage before it failing to write.
+//
+// The errors of the stages are returned joined, but for those of stages
+// failing to write because the stage after them returned.
+func RunPipeline(ctx context.Context, stdio IO, run func(context.Context, IO, []string) error, args []string) error {
+	stages := SplitPipeline(args)
+	errs := make([]error, len(stages))
+	var wg sync.WaitGroup
+	in := stdio.In
+	for i, stage := range stages {
+		stageIO := stdio
+		stageIO.In = in
+		var w *io.PipeWriter
+		if i < len(stages)-1 {
+			var r *io.PipeReader
+			r, w = io.Pipe()
+			stageIO.Out, in = w, r
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = run(ctx, stageIO, stage)
+			// The next stage reads to the end of the output, as it would
+			// from a shell pipeline, whether or not the stage failed.
+			if w != nil {
+				w.Close()
+			}
+			// Whatever the stage left unread is discarded, so that the
+			// stage before it fails to write rather than blocking.
+			if i > 0 {
+				stageIO.In.(*io.PipeReader).CloseWithError(io.ErrClosedPipe)
+			}
+		}()
+	}
+	wg.Wait()
+	for i := range errs[:len(errs)-1] {
+		if errors.Is(errs[i], io.ErrClosedPipe) {
+			errs[i] = nil
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,105 @@
+package cliche
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSplitPipeline(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		args  []string
+		want  [][]string
+		piped bool
+	}{
+		"empty":      {nil, [][]string{nil}, false},
+		"one stage":  {[]string{"list", "-a"}, [][]string{{"list", "-a"}}, false},
+		"pipeline":   {[]string{"list", "-a", "|", "grep", "x", "|", "count"}, [][]string{{"list", "-a"}, {"grep", "x"}, {"count"}}, true},
+		"after --":   {[]string{"grep", "--", "|", "x"}, [][]string{{"grep", "--", "|", "x"}}, false},
+		"before --":  {[]string{"list", "|", "grep", "--", "|"}, [][]string{{"list"}, {"grep", "--", "|"}}, true},
+		"empty last": {[]string{"list", "|"}, [][]string{{"list"}, {}}, true},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			if diff := cmp.Diff(SplitPipeline(tc.args), tc.want); diff != "" {
+				t.Errorf("SplitPipeline(): mismatch (-got,+want):\n%v", diff)
+			}
+			if got := Piped(tc.args); got != tc.piped {
+				t.Errorf("Piped(): got: %v want: %v", got, tc.piped)
+			}
+		})
+	}
+}
+
+// pipelineCommands run stages of pipelines: seq writes the numbers up to its
+// argument, one per line, grep writes the lines of its input containing its
+// argument, head writes the first line of its input, count writes the number of
+// lines of its input, and fail fails.
+func pipelineCommands(ctx context.Context, stdio IO, args []string) error {
+	lines := bufio.NewScanner(stdio.In)
+	switch args[0] {
+	case "seq":
+		var n int
+		fmt.Sscan(args[1], &n)
+		for i := 1; i <= n; i++ {
+			if _, err := fmt.Fprintln(stdio.Out, i); err != nil {
+				return err
+			}
+		}
+	case "grep":
+		for lines.Scan() {
+			if strings.Contains(lines.Text(), args[1]) {
+				fmt.Fprintln(stdio.Out, lines.Text())
+			}
+		}
+	case "head":
+		if lines.Scan() {
+			fmt.Fprintln(stdio.Out, lines.Text())
+		}
+	case "count":
+		n := 0
+		for lines.Scan() {
+			n++
+		}
+		fmt.Fprintln(stdio.Out, n)
+	case "fail":
+		return errors.New("failed")
+	}
+	return lines.Err()
+}
+
+func TestRunPipeline(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		args    string
+		in      string
+		want    string
+		wantErr string
+	}{
+		"one stage":  {"seq 3", "", "1\n2\n3\n", ""},
+		"pipeline":   {"seq 30 | grep 2 | count", "", "12\n", ""},
+		"stdin":      {"grep b | count", "a\nb\nbc\n", "2\n", ""},
+		"early exit": {"seq 100000 | head", "", "1\n", ""},
+		"failed":     {"fail | count", "", "0\n", "failed"},
+		"both":       {"fail | fail", "", "", "failed\nfailed"},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			stdio, capture := NewCaptureIO()
+			stdio.In = strings.NewReader(tc.in)
+			err := RunPipeline(context.Background(), stdio, pipelineCommands, strings.Fields(strings.ReplaceAll(tc.args, "|", " | ")))
+			var gotErr string
+			if err != nil {
+				gotErr = err.Error()
+			}
+			if gotErr != tc.wantErr {
+				t.Errorf("RunPipeline(): error mismatch: got: %q want: %q", gotErr, tc.wantErr)
+			}
+			if got := capture.Out(); got != tc.want {
+				t.Errorf("RunPipeline(): got output %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
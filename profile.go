@@ -0,0 +1,104 @@
+package cliche
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	runtimepprof "runtime/pprof"
+	"runtime/trace"
+)
+
+// Profiling options for investigating the performance of a command, which
+// start the corresponding profilers around its Run. The zero value profiles
+// nothing.
+type Profiling struct {
+	// Addr on which to serve the net/http/pprof handlers while running.
+	Addr string
+
+	// CPUProfile is the path of the file to which a CPU profile is written.
+	CPUProfile string
+
+	// Trace is the path of the file to which an execution trace is written.
+	Trace string
+}
+
+// RegisterFlags registers the --pprof-addr, --cpuprofile and --trace flags on
+// fs, which set the corresponding fields of p.
+func (p *Profiling) RegisterFlags(fs *flag.FlagSet) {
+	fs.StringVar(&p.Addr, "pprof-addr", p.Addr, "serve net/http/pprof on this `address` while running")
+	fs.StringVar(&p.CPUProfile, "cpuprofile", p.CPUProfile, "write a CPU profile to this `file`")
+	fs.StringVar(&p.Trace, "trace", p.Trace, "write an execution trace to this `file`")
+}
+
+// start the profilers selected by p. The returned function stops them, and
+// must be called even when an error is returned.
+func (p Profiling) start() (stop func() error, err error) {
+	var stops []func() error
+	stop = func() error {
+		var errs []error
+		for i := len(stops) - 1; i >= 0; i-- {
+			errs = append(errs, stops[i]())
+		}
+		return errors.Join(errs...)
+	}
+
+	if p.Addr != "" {
+		l, err := net.Listen("tcp", p.Addr)
+		if err != nil {
+			return stop, err
+		}
+		mux := http.NewServeMux()
+		mux.HandleFunc("/debug/pprof/", pprof.Index)
+		mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+		srv := &http.Server{Handler: mux}
+		go srv.Serve(l)
+		slog.Info("Serving pprof", slog.String("addr", l.Addr().String()))
+		stops = append(stops, srv.Close)
+	}
+	if p.CPUProfile != "" {
+		f, err := os.Create(p.CPUProfile)
+		if err != nil {
+			return stop, err
+		}
+		if err := runtimepprof.StartCPUProfile(f); err != nil {
+			return stop, errors.Join(err, f.Close())
+		}
+		stops = append(stops, func() error {
+			runtimepprof.StopCPUProfile()
+			return f.Close()
+		})
+	}
+	if p.Trace != "" {
+		f, err := os.Create(p.Trace)
+		if err != nil {
+			return stop, err
+		}
+		if err := trace.Start(f); err != nil {
+			return stop, errors.Join(err, f.Close())
+		}
+		stops = append(stops, func() error {
+			trace.Stop()
+			return f.Close()
+		})
+	}
+	return stop, nil
+}
+
+// Profile calls run with the profilers selected by p running, and stops them
+// once run returns. Errors starting or stopping the profilers are returned
+// along with any error from run.
+func Profile(ctx context.Context, p Profiling, run func(context.Context) error) error {
+	stop, err := p.start()
+	if err != nil {
+		return errors.Join(err, stop())
+	}
+	return errors.Join(run(ctx), stop())
+}
@@ -0,0 +1,57 @@
+package cliche
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestProfilingRegisterFlags(t *testing.T) {
+	var p Profiling
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	p.RegisterFlags(fs)
+	if err := fs.Parse([]string{"--pprof-addr=localhost:6060", "--cpuprofile", "cpu.out", "--trace=trace.out"}); err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	want := Profiling{Addr: "localhost:6060", CPUProfile: "cpu.out", Trace: "trace.out"}
+	if diff := cmp.Diff(p, want); diff != "" {
+		t.Errorf("RegisterFlags(): mismatch (-got,+want):\n%v", diff)
+	}
+}
+
+func TestProfile(t *testing.T) {
+	dir := t.TempDir()
+	p := Profiling{
+		Addr:       "localhost:0",
+		CPUProfile: filepath.Join(dir, "cpu.out"),
+		Trace:      filepath.Join(dir, "trace.out"),
+	}
+	errRun := errors.New("oh no")
+	if err := Profile(context.Background(), p, func(context.Context) error { return errRun }); !errors.Is(err, errRun) {
+		t.Errorf("Profile(): error mismatch: got: %v want: %v", err, errRun)
+	}
+	for _, path := range []string{p.CPUProfile, p.Trace} {
+		if fi, err := os.Stat(path); err != nil || fi.Size() == 0 {
+			t.Errorf("Profile(): %v not written: %v", path, err)
+		}
+	}
+}
+
+func TestProfileStartFailure(t *testing.T) {
+	p := Profiling{CPUProfile: filepath.Join(t.TempDir(), "missing", "cpu.out")}
+	var ran bool
+	if err := Profile(context.Background(), p, func(context.Context) error {
+		ran = true
+		return nil
+	}); err == nil {
+		t.Error("Profile(): wanted error, got nil")
+	}
+	if ran {
+		t.Error("Profile(): ran despite profiler failing to start")
+	}
+}
@@ -0,0 +1,76 @@
+package cliche
+
+import (
+	"os"
+	"runtime"
+	"strings"
+)
+
+// QuoteArgs formats args as a command line which can be pasted into a shell to
+// run the same command again, quoting each argument as needed for the current
+// platform: POSIX shell quoting, or on Windows, the quoting understood by
+// programs splitting their command line as the Microsoft C runtime does.
+func QuoteArgs(args ...string) string {
+	quote := quotePOSIX
+	if runtime.GOOS == "windows" {
+		quote = quoteWindows
+	}
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = quote(arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// Reinvocation returns the command line with which the program was invoked,
+// quoted by QuoteArgs, as for "to repeat this run, execute:" messages.
+// Secrets should be redacted from os.Args with RedactArgs before it is shown.
+func Reinvocation() string {
+	return QuoteArgs(os.Args...)
+}
+
+// posixSafe is true for characters which never need quoting in a POSIX shell.
+func posixSafe(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("@%+=:,./_-", r)
+}
+
+// quotePOSIX quotes s for a POSIX shell. Arguments of only safe characters are
+// left as they are, and all others are single-quoted, within which only the
+// single quote itself must be escaped.
+func quotePOSIX(s string) string {
+	if s != "" && strings.IndexFunc(s, func(r rune) bool { return !posixSafe(r) }) < 0 {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'"'"'`) + "'"
+}
+
+// quoteWindows quotes s as the Microsoft C runtime splits command lines: in
+// double quotes, with embedded quotes escaped by backslashes, and backslashes
+// doubled only where they precede a quote. Arguments holding characters which
+// cmd.exe treats specially, such as & or |, are quoted too, so that they are
+// passed on rather than interpreted. Within quotes, cmd.exe still expands
+// %name% when name is an environment variable.
+func quoteWindows(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\n\v\"&|<>^%()") {
+		return s
+	}
+	var b strings.Builder
+	b.WriteByte('"')
+	slashes := 0
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' {
+			slashes++
+			continue
+		}
+		if s[i] == '"' {
+			slashes = 2*slashes + 1
+		}
+		b.WriteString(strings.Repeat(`\`, slashes))
+		b.WriteByte(s[i])
+		slashes = 0
+	}
+	// Backslashes before the closing quote would escape it.
+	b.WriteString(strings.Repeat(`\`, 2*slashes))
+	b.WriteByte('"')
+	return b.String()
+}
@@ -0,0 +1,63 @@
+package cliche
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestQuotePOSIX(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		in, want string
+	}{
+		"empty":       {"", "''"},
+		"plain":       {"--name=World", "--name=World"},
+		"path":        {"/usr/local/bin/app", "/usr/local/bin/app"},
+		"space":       {"hello world", "'hello world'"},
+		"single":      {"it's", `'it'"'"'s'`},
+		"specials":    {"$HOME;rm *", "'$HOME;rm *'"},
+		"newline":     {"a\nb", "'a\nb'"},
+		"non-ascii":   {"héllo", "'héllo'"},
+		"double only": {`say "hi"`, `'say "hi"'`},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			if got := quotePOSIX(tc.in); got != tc.want {
+				t.Errorf("quotePOSIX(%q): got: %v want: %v", tc.in, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestQuoteWindows(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		in, want string
+	}{
+		"empty":              {"", `""`},
+		"plain":              {`C:\Program\app.exe`, `C:\Program\app.exe`},
+		"space":              {`C:\Program Files\app.exe`, `"C:\Program Files\app.exe"`},
+		"quote":              {`say "hi"`, `"say \"hi\""`},
+		"slash before quote": {`a\"b`, `"a\\\"b"`},
+		"trailing slash":     {`C:\My Dir\`, `"C:\My Dir\\"`},
+		"ampersand":          {`a&b`, `"a&b"`},
+		"pipe":               {`a|b`, `"a|b"`},
+		"redirect":           {`<in>out`, `"<in>out"`},
+		"caret":              {`a^b`, `"a^b"`},
+		"percent":            {`100%`, `"100%"`},
+		"parenthesis":        {`(x)`, `"(x)"`},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			if got := quoteWindows(tc.in); got != tc.want {
+				t.Errorf("quoteWindows(%q): got: %v want: %v", tc.in, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestQuoteArgs(t *testing.T) {
+	want := `app --name 'hello world'`
+	if runtime.GOOS == "windows" {
+		want = `app --name "hello world"`
+	}
+	if got := QuoteArgs("app", "--name", "hello world"); got != want {
+		t.Errorf("QuoteArgs(): got: %v want: %v", got, want)
+	}
+}
@@ -0,0 +1,59 @@
+package cliche
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Invocation describes a completed run of a command, as passed to a Reporter.
+// It never includes the values of the command's inputs.
+type Invocation struct {
+	// Path of the command which was run.
+	Path Path
+
+	// Duration of the run.
+	Duration time.Duration
+
+	// ExitStatus with which the program exits.
+	ExitStatus int
+}
+
+// Reporter receives usage telemetry after each run of a command, so that
+// organizations can measure the usage of their internal tools.
+type Reporter interface {
+	Report(ctx context.Context, inv Invocation)
+}
+
+// ReporterFunc adapts a function to the Reporter interface.
+type ReporterFunc func(ctx context.Context, inv Invocation)
+
+// Report calls f.
+func (f ReporterFunc) Report(ctx context.Context, inv Invocation) {
+	f(ctx, inv)
+}
+
+// OptOutEnv is the environment variable with which users opt out of usage
+// reporting, per the Console Do Not Track convention.
+const OptOutEnv = "DO_NOT_TRACK"
+
+// reportingDisabled is true when the user has opted out of usage reporting by
+// setting OptOutEnv to a true value.
+func reportingDisabled() bool {
+	v, ok := os.LookupEnv(OptOutEnv)
+	if !ok || v == "" {
+		return false
+	}
+	disabled, err := strconv.ParseBool(v)
+	// Any value which is not recognizably false is taken as opting out.
+	return err != nil || disabled
+}
+
+// Report inv to r, unless r is nil or the user has opted out of reporting.
+func Report(ctx context.Context, r Reporter, inv Invocation) {
+	if r == nil || reportingDisabled() {
+		return
+	}
+	r.Report(ctx, inv)
+}
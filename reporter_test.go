@@ -0,0 +1,50 @@
+package cliche
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReport(t *testing.T) {
+	inv := Invocation{
+		Path:       []string{"app", "remote", "add"},
+		Duration:   time.Second,
+		ExitStatus: 1,
+	}
+
+	for tn, tc := range map[string]struct {
+		env  string
+		set  bool
+		want []Invocation
+	}{
+		"unset":          {want: []Invocation{inv}},
+		"empty":          {env: "", set: true, want: []Invocation{inv}},
+		"false":          {env: "0", set: true, want: []Invocation{inv}},
+		"opted out":      {env: "1", set: true},
+		"opted out true": {env: "true", set: true},
+		"opted out yes":  {env: "yes", set: true},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			// Setenv restores the environment after the test, even when the
+			// variable is then unset.
+			t.Setenv(OptOutEnv, tc.env)
+			if !tc.set {
+				os.Unsetenv(OptOutEnv)
+			}
+			var got []Invocation
+			Report(context.Background(), ReporterFunc(func(ctx context.Context, inv Invocation) {
+				got = append(got, inv)
+			}), inv)
+			if diff := cmp.Diff(got, tc.want); diff != "" {
+				t.Errorf("Report(): mismatch(-got,+want):\n%v", diff)
+			}
+		})
+	}
+
+	// A nil Reporter is fine.
+	Report(context.Background(), nil, inv)
+}
@@ -0,0 +1,27 @@
+package cliche
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MissingError is returned by a command which was not given all of its
+// required inputs. It is a usage error, so the program exits with
+// DefaultExitCodes.Usage, unless the command chooses another.
+type MissingError struct {
+	// Inputs which are missing: flags like -name first, then positional
+	// arguments by name.
+	Inputs []string
+}
+
+func (err *MissingError) Error() string {
+	if len(err.Inputs) == 1 {
+		return fmt.Sprintf("missing required input %v", err.Inputs[0])
+	}
+	return fmt.Sprintf("missing required inputs %v", strings.Join(err.Inputs, ", "))
+}
+
+// ExitCode of a usage error.
+func (err *MissingError) ExitCode() int {
+	return DefaultExitCodes.Usage
+}
@@ -0,0 +1,17 @@
+package cliche
+
+import "testing"
+
+func TestMissingError(t *testing.T) {
+	for want, err := range map[string]*MissingError{
+		"missing required input -token":              {Inputs: []string{"-token"}},
+		"missing required inputs -token, -host, src": {Inputs: []string{"-token", "-host", "src"}},
+	} {
+		if got := err.Error(); got != want {
+			t.Errorf("Error(): got: %q want: %q", got, want)
+		}
+		if got := ExitCode(err); got != 2 {
+			t.Errorf("ExitCode(): got: %v want: 2", got)
+		}
+	}
+}
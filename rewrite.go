@@ -0,0 +1,47 @@
+package cliche
+
+import (
+	"context"
+	"errors"
+)
+
+// ArgsRewriter rewrites the arguments of a program, excluding its name, before
+// its command parses them; for example, to expand aliases the user has
+// configured. Programs register one with Provide, whose provider may load it
+// from a config file, and generated root commands and Run apply it.
+type ArgsRewriter interface {
+	RewriteArgs(ctx context.Context, args []string) ([]string, error)
+}
+
+// ArgsRewriterFunc adapts a function to the ArgsRewriter interface.
+type ArgsRewriterFunc func(ctx context.Context, args []string) ([]string, error)
+
+// RewriteArgs calls f.
+func (f ArgsRewriterFunc) RewriteArgs(ctx context.Context, args []string) ([]string, error) {
+	return f(ctx, args)
+}
+
+// Aliases is an ArgsRewriter which expands git-style aliases of commands, by
+// name, as ExpandAliases does; for example, co = "checkout -b".
+type Aliases map[string]string
+
+// RewriteArgs expands the aliases in args.
+func (a Aliases) RewriteArgs(_ context.Context, args []string) ([]string, error) {
+	return ExpandAliases(args, a)
+}
+
+// RewriteArgs rewrites args with the ArgsRewriter registered with Provide, and
+// returns them unchanged when there is none. Errors from its provider, such as
+// a config file which can't be read, are returned, as are those rewriting.
+func RewriteArgs(ctx context.Context, args []string) ([]string, error) {
+	rewriter, err := Inject[ArgsRewriter](ctx, "")
+	switch {
+	case errors.Is(err, ErrNoProvider):
+		return args, nil
+	case err != nil:
+		return nil, err
+	case rewriter == nil:
+		return args, nil
+	}
+	return rewriter.RewriteArgs(ctx, args)
+}
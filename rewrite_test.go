@@ -0,0 +1,61 @@
+package cliche
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// provideRewriter registers rewriter for the duration of the test.
+func provideRewriter(t *testing.T, rewriter ArgsRewriter, err error) {
+	Provide(func(context.Context) (ArgsRewriter, error) {
+		return rewriter, err
+	})
+	t.Cleanup(func() {
+		Provide(func(context.Context) (ArgsRewriter, error) {
+			return nil, nil
+		})
+	})
+}
+
+func TestRewriteArgs(t *testing.T) {
+	args := []string{"co", "main"}
+	got, err := RewriteArgs(context.Background(), args)
+	if err != nil || !cmp.Equal(got, args) {
+		t.Errorf("RewriteArgs(): got %q, %v without a rewriter, want %q", got, err, args)
+	}
+
+	provideRewriter(t, Aliases{"co": "checkout -b"}, nil)
+	got, err = RewriteArgs(context.Background(), args)
+	if want := []string{"checkout", "-b", "main"}; err != nil || !cmp.Equal(got, want) {
+		t.Errorf("RewriteArgs(): got %q, %v, want %q", got, err, want)
+	}
+
+	unreadable := errors.New("config unreadable")
+	provideRewriter(t, nil, unreadable)
+	if _, err := RewriteArgs(context.Background(), args); !errors.Is(err, unreadable) {
+		t.Errorf("RewriteArgs(): got error %v, want %v", err, unreadable)
+	}
+}
+
+func TestRunRewritten(t *testing.T) {
+	provideRewriter(t, ArgsRewriterFunc(func(_ context.Context, args []string) ([]string, error) {
+		return ExpandAliases(args, map[string]string{"fa": "-force fetch-all"})
+	}), nil)
+	stdio, _ := NewCaptureIO()
+	cmd := new(runVerbs)
+	if err := Run(context.Background(), stdio, cmd, []string{"--cliche-debug", "fa"}); err != nil {
+		t.Fatalf("Run(): unexpected error: %v", err)
+	}
+	if cmd.ran != "fetch-all" || !cmd.Force {
+		t.Errorf("Run(): ran %q with force %v, want fetch-all with force", cmd.ran, cmd.Force)
+	}
+
+	provideRewriter(t, Aliases{"loop": "loop"}, nil)
+	err := Run(context.Background(), stdio, new(runVerbs), []string{"loop"})
+	if ue := (*UsageError)(nil); !errors.As(err, &ue) {
+		t.Errorf("Run(): got error %v, want a usage error", err)
+	}
+}
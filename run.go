@@ -0,0 +1,904 @@
+package cliche
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/iancoleman/strcase"
+	"idontfixcomputers.com/cliche/meta"
+)
+
+// boundInput is a field of a command which is bound by Run.
+type boundInput struct {
+	// name of the field, qualified by the names of any embedded structs
+	// through which it is reached, as in Options.Verbose.
+	name string
+	tag  meta.ParsedTag
+	v    reflect.Value
+}
+
+// parse s into a value of typ, which is that of the input or of its elements.
+// Times are parsed in the layout and zone of the input's tag, when it has
+// either. Inputs with choices only parse those.
+func (in boundInput) parse(typ reflect.Type, s string) (reflect.Value, error) {
+	parse := func(s string) (reflect.Value, error) {
+		if typ != timeType || (in.tag.Layout == "" && in.tag.Timezone == "") {
+			return parseValue(typ, s)
+		}
+		t, err := TimeParser(meta.TimeLayout(in.tag.Layout), in.tag.Timezone)(s)
+		return reflect.ValueOf(t), err
+	}
+	if len(in.tag.Choices) > 0 {
+		return ChoiceParser(parse, in.tag.Choices...)(s)
+	}
+	return parse(s)
+}
+
+// separator of the values of the input, when it is a slice flag, or of its
+// keys and values, when it is a map flag.
+func (in boundInput) separator() string {
+	if in.tag.Separator == "" && in.v.Kind() == reflect.Map {
+		return meta.MapSeparator
+	}
+	return in.tag.Separator
+}
+
+// input describes in as meta does an input of a command compiled from source,
+// by its type as reflect prints it, with the changes to its tag made by the
+// fields embedding it.
+func (in boundInput) input() meta.CommandInput {
+	input := meta.CommandInput{FieldName: in.name, Tag: meta.Tag(in.tag.Canonical()), Type: typeName(in.v.Type())}
+	if in.v.Kind() == reflect.Array {
+		input.Arity = in.v.Len()
+	}
+	return input
+}
+
+// typeName is typ as written in source, as far as meta's checks of types go:
+// with byte for uint8 and without the space reflect puts in interface {} and
+// struct {}.
+func typeName(typ reflect.Type) string {
+	if typ.Kind() == reflect.Slice && typ.Elem() == reflect.TypeOf(byte(0)) {
+		return "[]byte"
+	}
+	return strings.NewReplacer("interface {", "interface{", "struct {", "struct{").Replace(typ.String())
+}
+
+// argName is the name of the input as shown for positional arguments, and
+// as its flag when its tag does not name one.
+func (in boundInput) argName() string {
+	return strcase.ToKebab(in.name[strings.LastIndex(in.name, ".")+1:])
+}
+
+// reflectInputs returns the inputs of the command struct v, flattening
+// embedded structs as generated code does: omit, prefix, group and order in the
+// tag of the embedding field apply to the embedded inputs. Embedded struct pointers
+// are allocated as needed. Types already being walked are in seen, so that
+// cycles of embedded pointers end.
+func reflectInputs(v reflect.Value, seen map[reflect.Type]bool) ([]boundInput, error) {
+	typ := v.Type()
+	seen[typ] = true
+	defer delete(seen, typ)
+
+	var inputs []boundInput
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		// The exported fields of unexported embedded structs are promoted, and
+		// so settable, but an unexported embedded pointer can't be allocated.
+		if !field.IsExported() && !(field.Anonymous && field.Type.Kind() == reflect.Struct) {
+			continue
+		}
+		tag, err := meta.ParseTagStrict(field.Tag.Get(meta.TagKey))
+		if err != nil {
+			return nil, fmt.Errorf("field %v: %w", field.Name, err)
+		}
+		if tag.Excluded {
+			continue
+		}
+
+		fv := v.Field(i)
+		embedded := field.Type
+		if embedded.Kind() == reflect.Pointer {
+			embedded = embedded.Elem()
+		}
+		if !field.Anonymous || embedded.Kind() != reflect.Struct || tag.Subcommand {
+			inputs = append(inputs, boundInput{name: field.Name, tag: tag, v: fv})
+			continue
+		}
+		if seen[embedded] {
+			continue
+		}
+		if fv.Kind() == reflect.Pointer {
+			if fv.IsNil() {
+				fv.Set(reflect.New(embedded))
+			}
+			fv = fv.Elem()
+		}
+		inner, err := reflectInputs(fv, seen)
+		if err != nil {
+			return nil, fmt.Errorf("field %v: %w", field.Name, err)
+		}
+		omit := make(map[string]bool)
+		for _, name := range tag.Omit {
+			omit[name] = true
+		}
+		for _, in := range inner {
+			if omit[in.name] {
+				continue
+			}
+			in.name = field.Name + "." + in.name
+			if tag.Prefix != "" && in.tag.Flag != nil {
+				in.tag.Flag = in.tag.Flag.WithPrefix(tag.Prefix)
+				migrate := make([]string, len(in.tag.Migrate))
+				for i, name := range in.tag.Migrate {
+					migrate[i] = tag.Prefix + name
+				}
+				in.tag.Migrate = migrate
+			}
+			if tag.Group != "" {
+				in.tag.Group = tag.Group
+			}
+			if in.tag.Order == 0 {
+				in.tag.Order = tag.Order
+			}
+			inputs = append(inputs, in)
+		}
+	}
+	return inputs, nil
+}
+
+// reflectFlag binds a flag to a field of a command. A field holding a slice,
+// other than []byte, or a map is a repeatable flag.
+type reflectFlag struct {
+	v reflect.Value
+	// set is shared between all names of a repeatable flag, and is true once
+	// the first value has replaced the default.
+	set *bool
+	// parse values, as boundInput.parse.
+	parse func(reflect.Type, string) (reflect.Value, error)
+	// sep separates the keys and values of a map flag, or the values of a
+	// slice flag, which policy splits them by.
+	sep    string
+	policy SlicePolicy
+}
+
+// repeatable is true for fields bound to repeatable flags.
+func repeatable(typ reflect.Type) bool {
+	return typ.Kind() == reflect.Slice && typ.Elem().Kind() != reflect.Uint8
+}
+
+func (f reflectFlag) String() string {
+	if !f.v.IsValid() {
+		return ""
+	}
+	if f.v.Kind() == reflect.Map {
+		return formatMap(f.v, f.sep)
+	}
+	if !repeatable(f.v.Type()) {
+		return fmt.Sprint(f.v.Interface())
+	}
+	var values []string
+	for i := 0; i < f.v.Len(); i++ {
+		values = append(values, fmt.Sprint(f.v.Index(i).Interface()))
+	}
+	if f.sep != "" {
+		return strings.Join(values, f.sep)
+	}
+	return strings.Join(values, ",")
+}
+
+func (f reflectFlag) Set(s string) error {
+	if f.v.Kind() == reflect.Map {
+		if !*f.set || f.v.IsNil() {
+			f.v.Set(reflect.MakeMap(f.v.Type()))
+			*f.set = true
+		}
+		return setMapEntry(f.v, s, f.sep, f.parse)
+	}
+	if !repeatable(f.v.Type()) {
+		v, err := f.parse(f.v.Type(), s)
+		if err == nil {
+			f.v.Set(v)
+		}
+		return err
+	}
+	values, replace := sliceValues(s, f.sep, f.policy)
+	if !*f.set || replace {
+		f.v.SetLen(0)
+		*f.set = true
+	}
+	for _, s := range values {
+		if err := f.append(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// append a value to the slice bound to a repeatable flag.
+func (f reflectFlag) append(s string) error {
+	v, err := f.parse(f.v.Type().Elem(), s)
+	if err != nil {
+		return err
+	}
+	f.v.Set(reflect.Append(f.v, v))
+	return nil
+}
+
+// IsBoolFlag allows boolean flags to be given without a value, as -v.
+func (f reflectFlag) IsBoolFlag() bool {
+	return f.v.IsValid() && f.v.Kind() == reflect.Bool
+}
+
+// setDefault sets the field of in to its default, which is a list for
+// repeatable flags: separated by the input's separator or commas for slices,
+// and by commas for maps.
+func setDefault(in boundInput) error {
+	f := reflectFlag{in.v, new(bool), in.parse, in.separator(), SliceRepeat}
+	switch {
+	case in.tag.Arg == nil && in.v.Kind() == reflect.Map:
+		for _, s := range strings.Split(in.tag.Default, ",") {
+			if err := f.Set(s); err != nil {
+				return err
+			}
+		}
+		return nil
+	case repeatable(in.v.Type()):
+		values := []string{in.tag.Default}
+		if in.tag.Arg == nil {
+			sep := ","
+			if in.tag.Separator != "" {
+				sep = in.tag.Separator
+			}
+			values = strings.Split(in.tag.Default, sep)
+		}
+		in.v.SetLen(0)
+		for _, s := range values {
+			if err := f.append(s); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return f.Set(in.tag.Default)
+}
+
+// validatorName is the name of the method validating in: the one named by
+// its tag, or for positional arguments, one named like ValidateArgField, after
+// the field's own name even when it is reached through embedded structs.
+func validatorName(in boundInput) string {
+	if in.tag.Validator == "" && in.tag.Arg != nil {
+		return "ValidateArg" + in.name[strings.LastIndex(in.name, ".")+1:]
+	}
+	return in.tag.Validator
+}
+
+// validatorOf returns the method of cmd which validates in, if any, as named
+// by validatorName.
+func validatorOf(cmd reflect.Value, in boundInput) func(string) error {
+	name := validatorName(in)
+	if name == "" {
+		return nil
+	}
+	if m := cmd.MethodByName(name); m.IsValid() {
+		if fn, ok := m.Interface().(func(string) error); ok {
+			return fn
+		}
+	}
+	return nil
+}
+
+// reflectVerbs returns the RunVerb methods of cmd, by subcommand name, and
+// their names in order.
+func reflectVerbs(cmd reflect.Value) (map[string]func(context.Context) error, []string) {
+	verbs := make(map[string]func(context.Context) error)
+	var names []string
+	for i := 0; i < cmd.NumMethod(); i++ {
+		verb, ok := strings.CutPrefix(cmd.Type().Method(i).Name, "Run")
+		if !ok || !token.IsExported(verb) {
+			continue
+		}
+		if fn, ok := cmd.Method(i).Interface().(func(context.Context) error); ok {
+			name := strcase.ToKebab(verb)
+			verbs[name] = fn
+			names = append(names, name)
+		}
+	}
+	return verbs, names
+}
+
+// treeGlobals returns the inputs tagged global by the command of type typ and
+// its subcommands, recursively, in order of first appearance by their flag
+// names. Types already being walked are in seen, so that cycles end. Commands
+// whose inputs can't be reflected have none; their errors are returned when
+// they run.
+func treeGlobals(typ reflect.Type, seen map[reflect.Type]bool) []boundInput {
+	if typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct || seen[typ] {
+		return nil
+	}
+	seen[typ] = true
+	defer delete(seen, typ)
+	inputs, err := reflectInputs(reflect.New(typ).Elem(), make(map[reflect.Type]bool))
+	if err != nil {
+		return nil
+	}
+	var globals []boundInput
+	declared := make(map[string]bool)
+	add := func(in boundInput) {
+		if name := meta.FlagNames(in.input(), in.tag)[0]; !declared[name] {
+			declared[name] = true
+			globals = append(globals, in)
+		}
+	}
+	for _, in := range inputs {
+		switch {
+		case in.tag.Global:
+			add(in)
+		case in.tag.Subcommand:
+			for _, global := range treeGlobals(in.v.Type(), seen) {
+				add(global)
+			}
+		}
+	}
+	return globals
+}
+
+// reflectCommand describes the command cmd, with the given inputs, verbs and
+// subcommands, as meta would have compiled it from source, so that Run
+// validates it as cliche does before generating code. It is named after its
+// type, since the name of the program is not declared by it. Subcommands are
+// validated as they run, as those of other packages are, but for the inputs
+// which their trees declare global, given by globals.
+func reflectCommand(cmd reflect.Value, inputs []boundInput, verbs []string, children map[string]reflect.Type, globals map[string][]boundInput) *meta.Command {
+	typ := cmd.Elem().Type()
+	c := &meta.Command{Name: strcase.ToKebab(typ.Name()), Package: typ.PkgPath(), Type: typ.Name()}
+	if c.Name == "" {
+		c.Name = "command"
+	}
+	for _, name := range verbs {
+		if child, ok := children[name]; ok {
+			if child.Kind() == reflect.Pointer {
+				child = child.Elem()
+			}
+			sub := &meta.Command{Name: name, ImportPath: child.PkgPath()}
+			for _, in := range globals[name] {
+				sub.Inputs = append(sub.Inputs, in.input())
+			}
+			c.Children = append(c.Children, sub)
+			continue
+		}
+		c.Verbs = append(c.Verbs, meta.Verb{Name: name})
+	}
+	for _, in := range inputs {
+		if in.tag.Subcommand {
+			continue
+		}
+		input := in.input()
+		if validatorOf(cmd, in) != nil {
+			input.Validator = validatorName(in)
+		}
+		c.Inputs = append(c.Inputs, input)
+	}
+	return c
+}
+
+// reflectHelp renders help for a command bound by Run. Without doc comments,
+// which only exist in source, it lists the command's verbs, arguments and
+// flags by name.
+func reflectHelp(name string, verbs []string, args, flags []boundInput, policy SlicePolicy) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Usage: %v [flags]", name)
+	if len(verbs) > 0 {
+		b.WriteString(" command")
+	}
+	for _, in := range args {
+		usage := in.argName()
+		switch {
+		case repeatable(in.v.Type()), in.tag.Pairs:
+			usage = "[" + usage + "...]"
+		case in.v.Kind() == reflect.Array:
+			usage += "..."
+		case in.tag.Default != "":
+			usage = "[" + usage + "]"
+		}
+		b.WriteString(" " + usage)
+	}
+	b.WriteString("\n")
+	if len(verbs) > 0 {
+		b.WriteString("\nCommands:\n")
+		for _, verb := range verbs {
+			fmt.Fprintf(&b, "  %v\n", verb)
+		}
+	}
+	// Flags are listed by group, in the order given by meta.InputGroups.
+	byName := make(map[string]boundInput)
+	listed := new(meta.Command)
+	for _, in := range flags {
+		byName[in.name] = in
+		listed.Inputs = append(listed.Inputs, meta.CommandInput{FieldName: in.name, Tag: meta.Tag(in.tag.Canonical())})
+	}
+	for _, group := range listed.InputGroups() {
+		heading := "Flags"
+		if group.Name != "" {
+			heading = group.Name
+		}
+		b.WriteString("\n" + heading + ":\n")
+		for _, input := range group.Inputs {
+			in := byName[input.FieldName]
+			if in.tag.Hidden {
+				continue
+			}
+			names := meta.FlagNames(in.input(), in.tag)
+			if negated := meta.NegatedName(in.input(), in.tag); negated != "" {
+				names = append(names, negated)
+			}
+			b.WriteString("  -" + strings.Join(names, ", -"))
+			if in.v.Kind() != reflect.Bool && !in.tag.Count {
+				b.WriteString(" " + in.v.Type().String())
+			}
+			var notes []string
+			if len(in.tag.Choices) > 0 {
+				notes = append(notes, "(one of "+strings.Join(in.tag.Choices, ", ")+")")
+			}
+			if in.v.Kind() == reflect.Slice && in.tag.Arg == nil {
+				if note := strings.TrimSpace(meta.SliceNote(policy.String(), in.separator())); note != "" {
+					notes = append(notes, note)
+				}
+			}
+			switch {
+			case in.tag.Deprecated && in.tag.DeprecatedNote != "":
+				notes = append(notes, "(deprecated, "+in.tag.DeprecatedNote+")")
+			case in.tag.Deprecated:
+				notes = append(notes, "(deprecated)")
+			}
+			if in.tag.Env != "" {
+				notes = append(notes, "(env "+in.tag.Env+")")
+			}
+			switch {
+			case in.tag.Required:
+				notes = append(notes, "(required)")
+			case !in.v.IsZero():
+				notes = append(notes, fmt.Sprintf("(default %v)", reflectFlag{v: in.v, sep: in.separator()}))
+			}
+			if len(notes) > 0 {
+				b.WriteString("\t" + strings.Join(notes, " "))
+			}
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// Run binds the inputs of cmd from args, which do not include the name of the
+// program, and runs it, without any generated code. Cmd must be a pointer to a
+// command struct, with a Run(context.Context) error method, RunVerb methods or
+// fields tagged subcommand, whose fields are bound as their cliche struct tags
+// describe: from flags, environment variables, positional arguments, standard
+// input and registered providers, with the same defaults, validators,
+// locking, cleanup, subcommands and context as generated code. Since doc
+// comments can't be read at run time, help lists inputs by name only; prefer
+// generated code where help matters. Args are first rewritten by the
+// ArgsRewriter registered with Provide, if any. Help requested with -h or
+// -help is shown on stdio, plainly as PlainIO describes, and flag.ErrHelp
+// returned.
+func Run(ctx context.Context, stdio IO, cmd any, args []string) error {
+	// Arguments are rewritten once, and after --cliche-debug is removed,
+	// which may precede an alias.
+	stdio = PlainIO(stdio, args)
+	ctx, args = Debug(WithIO(ctx, stdio), args)
+	args, err := RewriteArgs(ctx, args)
+	if err != nil {
+		return NewUsageError(err)
+	}
+	return run(ctx, stdio, cmd, strings.TrimSuffix(filepath.Base(os.Args[0]), ".exe"), args)
+}
+
+// run is Run for the command called name. Subcommands are run in the fields
+// declaring them, with cmd as their parent.
+func run(ctx context.Context, stdio IO, cmd any, name string, args []string) (err error) {
+	rv := reflect.ValueOf(cmd)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("running %T: not a pointer to a struct", cmd)
+	}
+	defer func() {
+		err = MapExitCodes(cmd, err)
+	}()
+	inputs, err := reflectInputs(rv.Elem(), make(map[reflect.Type]bool))
+	if err != nil {
+		return fmt.Errorf("running %T: %w", cmd, err)
+	}
+	verbs, verbNames := reflectVerbs(rv)
+	children := make(map[string]reflect.Type)
+	fields := make(map[string]reflect.Value)
+	globals := make(map[string][]boundInput)
+	for _, in := range inputs {
+		if in.tag.Subcommand {
+			child := in.tag.SubcommandName
+			if child == "" {
+				child = in.argName()
+			}
+			children[child], fields[child] = in.v.Type(), in.v
+			globals[child] = treeGlobals(in.v.Type(), map[reflect.Type]bool{rv.Elem().Type(): true})
+			verbNames = append(verbNames, child)
+		}
+	}
+	var runCmd func(context.Context) error
+	if r, ok := cmd.(interface{ Run(context.Context) error }); ok {
+		runCmd = r.Run
+	}
+	if runCmd == nil && len(verbs) == 0 && len(children) == 0 {
+		return fmt.Errorf("running %T: no Run(context.Context) error method", cmd)
+	}
+	if err := reflectCommand(rv, inputs, verbNames, children, globals).Validate(); err != nil {
+		return fmt.Errorf("running %T: %w", cmd, err)
+	}
+	ctx = WithIO(WithCommand(ctx, name), stdio)
+	ctx, args = Debug(ctx, args)
+	trace := TraceFrom(ctx)
+
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stdio.Err)
+	policy := SlicePolicyFrom(ctx)
+	var positional, flags, injects, stdins []boundInput
+	var lock *boundInput
+	renames := make(map[string]string)
+	deprecations := make(map[string]string)
+	for i, in := range inputs {
+		if in.tag.Default != "" {
+			if err := setDefault(in); err != nil {
+				return fmt.Errorf("default of %v: %w", in.name, err)
+			}
+		}
+		switch {
+		case in.tag.Subcommand:
+		case in.tag.Inject:
+			injects = append(injects, in)
+		case in.tag.Stdin:
+			stdins = append(stdins, in)
+		case in.tag.Arg != nil:
+			positional = append(positional, in)
+		default:
+			if in.tag.Lock {
+				lock = &inputs[i]
+			}
+			names := meta.FlagNames(in.input(), in.tag)
+			set := new(bool)
+			for _, name := range names {
+				if in.tag.Count {
+					fs.Var(countValue{in.v}, name, "")
+					continue
+				}
+				fs.Var(reflectFlag{in.v, set, in.parse, in.separator(), policy}, name, "")
+			}
+			if negated := meta.NegatedName(in.input(), in.tag); negated != "" {
+				fs.Var(negatedValue{in.v}, negated, "")
+			}
+			for _, old := range in.tag.Migrate {
+				renames[old] = names[0]
+			}
+			if in.tag.Deprecated {
+				for _, name := range names {
+					deprecations[name] = in.tag.DeprecatedNote
+				}
+				if negated := meta.NegatedName(in.input(), in.tag); negated != "" {
+					deprecations[negated] = in.tag.DeprecatedNote
+				}
+			}
+			flags = append(flags, in)
+		}
+	}
+	// The globals of subcommands, other than those which the command
+	// declares itself, are accepted for them, and passed on to them.
+	var lifted Globals
+	forward := make(map[string][]string)
+	listed := flags
+	for _, child := range verbNames {
+		for _, in := range globals[child] {
+			names := meta.FlagNames(in.input(), in.tag)
+			if f := fs.Lookup(names[0]); f != nil {
+				if _, ok := f.Value.(*globalValue); !ok {
+					continue
+				}
+			} else {
+				switch {
+				case in.tag.Count:
+					lifted.CountVar(fs, "", names...)
+				case in.v.Kind() == reflect.Bool:
+					lifted.BoolVar(fs, "", names...)
+				default:
+					lifted.Var(fs, "", names...)
+				}
+				in.tag.Group = "Global flags"
+				listed = append(listed, in)
+			}
+			forward[child] = append(forward[child], names[0])
+		}
+	}
+	help := reflectHelp(name, verbNames, positional, listed, policy)
+
+	args = ExpandCountFlags(fs, args)
+	args = MigrateFlags(fs, args, renames, stdio.Err)
+	trace.Parsing(args)
+	if err := ParseFlags(stdio, fs, cmd, help, args); err != nil {
+		return err
+	}
+	if trace != nil {
+		fields := make(map[string][]string)
+		for _, in := range flags {
+			fields[in.name] = meta.FlagNames(in.input(), in.tag)
+			if negated := meta.NegatedName(in.input(), in.tag); negated != "" {
+				fields[in.name] = append(fields[in.name], negated)
+			}
+		}
+		trace.Flags(fs, fields)
+	}
+	env := make(map[string][]string)
+	for _, in := range flags {
+		if in.tag.Env != "" {
+			env[in.tag.Env] = meta.FlagNames(in.input(), in.tag)
+			if negated := meta.NegatedName(in.input(), in.tag); negated != "" {
+				env[in.tag.Env] = append(env[in.tag.Env], negated)
+			}
+		}
+	}
+	if err := BindEnv(ctx, fs, env); err != nil {
+		return err
+	}
+	args = fs.Args()
+	WarnDeprecated(fs, deprecations, stdio.Err)
+	given := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		given[f.Name] = true
+	})
+	for _, in := range flags {
+		names := meta.FlagNames(in.input(), in.tag)
+		if negated := meta.NegatedName(in.input(), in.tag); negated != "" {
+			names = append(names, negated)
+		}
+		if validate := validatorOf(rv, in); validate != nil && anyGiven(given, names) {
+			v := fs.Lookup(names[0]).Value.String()
+			if err := trace.Validated("-"+names[0], v, validate(v)); err != nil {
+				return Usagef("flag -%v: %w", names[0], err)
+			}
+		}
+		switch {
+		case in.tag.DryRun:
+			ctx = WithDryRun(ctx, in.v.Bool())
+		case in.tag.Verbosity && in.v.CanInt():
+			ctx = WithVerbosity(ctx, Verbosity(in.v.Int()))
+		case in.tag.Verbosity:
+			ctx = WithVerbosity(ctx, Verbosity(in.v.Uint()))
+		}
+	}
+
+	if runCmd == nil && len(args) == 0 {
+		ShowHelp(stdio, cmd, help)
+		return flag.ErrHelp
+	}
+	// Help names a verb or subcommand to show the help of, unless one is
+	// named help.
+	_, helpField := fields["help"]
+	if len(args) > 0 && args[0] == "help" && len(verbNames) > 0 && !helpField && verbs["help"] == nil {
+		if len(args) == 1 {
+			ShowHelp(stdio, cmd, help)
+			return flag.ErrHelp
+		}
+		args = append(args[1:], "-h")
+	}
+	if len(args) > 0 {
+		if field, ok := fields[args[0]]; ok {
+			if field.Kind() == reflect.Pointer {
+				field.Set(reflect.New(field.Type().Elem()))
+			} else {
+				field = field.Addr()
+			}
+			return run(WithParent(ctx, cmd), stdio, field.Interface(), args[0], append(lifted.Args(forward[args[0]]...), args[1:]...))
+		}
+		if verb, ok := verbs[args[0]]; ok {
+			ctx = WithCommand(ctx, args[0])
+			runCmd, args = verb, args[1:]
+		}
+	}
+	if HelpRequested(args) {
+		ShowHelp(stdio, cmd, help)
+		return flag.ErrHelp
+	}
+	if runCmd == nil {
+		return Usagef("expected a command: one of %v", strings.Join(verbNames, ", "))
+	}
+
+	var missing []string
+	for _, in := range flags {
+		names := meta.FlagNames(in.input(), in.tag)
+		if negated := meta.NegatedName(in.input(), in.tag); negated != "" {
+			names = append(names, negated)
+		}
+		if in.tag.Required && !anyGiven(given, names) {
+			missing = append(missing, "-"+names[0])
+		}
+	}
+	for _, in := range positional {
+		if start, _ := meta.ArgRange(in.input(), in.tag.Arg); in.tag.Required && len(args) <= start {
+			missing = append(missing, in.argName())
+		}
+	}
+	if len(missing) > 0 {
+		return &MissingError{Inputs: missing}
+	}
+
+	maxArgs := 0
+	for _, in := range positional {
+		_, end := meta.ArgRange(in.input(), in.tag.Arg)
+		if end < 0 || maxArgs < 0 {
+			maxArgs = -1
+		} else if end > maxArgs {
+			maxArgs = end
+		}
+	}
+	if maxArgs >= 0 && len(args) > maxArgs {
+		return Usagef("unexpected arguments: %q", args[maxArgs:])
+	}
+	for _, in := range positional {
+		if err := bindArgs(rv, in, args, trace); err != nil {
+			return err
+		}
+	}
+
+	for _, in := range injects {
+		v, err := injectValue(ctx, in.v.Type(), in.tag.InjectName)
+		if err != nil {
+			return fmt.Errorf("injecting %v: %w", in.name, err)
+		}
+		if v != nil {
+			in.v.Set(reflect.ValueOf(v))
+		}
+		trace.Source(in.name, "injected")
+	}
+	for _, in := range stdins {
+		trace.Source(in.name, "read from standard input")
+		if err := bindStdin(stdio.In, in); err != nil {
+			return err
+		}
+	}
+
+	var held *Lock
+	if lock != nil && !lock.v.Bool() {
+		name := lock.tag.LockName
+		if name == "" {
+			name = fs.Name()
+		}
+		if held, err = AcquireLock(name); err != nil {
+			return err
+		}
+	}
+	defer func() {
+		err = errors.Join(err, held.Release())
+	}()
+
+	defer func() {
+		err = errors.Join(err, Cleanup(ctx, cmd))
+	}()
+	return Recover(ctx, runCmd)
+}
+
+// anyGiven is true when any of names is among those of the flags given.
+func anyGiven(given map[string]bool, names []string) bool {
+	for _, name := range names {
+		if given[name] {
+			return true
+		}
+	}
+	return false
+}
+
+// bindArgs sets the field of in from the positional arguments it is bound to,
+// validating each with the validator of cmd, if any, and reporting both to
+// trace.
+func bindArgs(cmd reflect.Value, in boundInput, args []string, trace *Trace) error {
+	start, end := meta.ArgRange(in.input(), in.tag.Arg)
+	trace.Arg(in.argName(), start, end, in.tag.Arg.Stride(), args)
+	validate := validatorOf(cmd, in)
+	// parse the i-th argument, counted from 0 but reported from 1.
+	parse := func(typ reflect.Type, i int) (reflect.Value, error) {
+		if validate != nil {
+			if err := trace.Validated(in.argName(), args[i], validate(args[i])); err != nil {
+				return reflect.Value{}, Usagef("argument %d (%v): %w", i+1, in.argName(), err)
+			}
+		}
+		v, err := in.parse(typ, args[i])
+		if err != nil {
+			return v, Usagef("argument %d (%v): %w", i+1, in.argName(), err)
+		}
+		return v, nil
+	}
+
+	switch {
+	case in.v.Kind() == reflect.Array:
+		if len(args) < end {
+			return Usagef("argument %v: expected %d values, got %d", in.argName(), end-start, len(args)-start)
+		}
+		for i := start; i < end; i++ {
+			v, err := parse(in.v.Type().Elem(), i)
+			if err != nil {
+				return err
+			}
+			in.v.Index(i - start).Set(v)
+		}
+
+	case in.tag.Pairs:
+		for i := start; i < len(args) && (end < 0 || i < end); i += 2 {
+			if i+1 == len(args) {
+				return Usagef("argument %v: missing value for key %q", in.argName(), args[i])
+			}
+			k, err := parse(in.v.Type().Key(), i)
+			if err != nil {
+				return err
+			}
+			v, err := parse(in.v.Type().Elem(), i+1)
+			if err != nil {
+				return err
+			}
+			if in.v.IsNil() {
+				in.v.Set(reflect.MakeMap(in.v.Type()))
+			}
+			in.v.SetMapIndex(k, v)
+		}
+
+	case repeatable(in.v.Type()):
+		if start < len(args) {
+			in.v.SetLen(0)
+		}
+		for i := start; i < len(args) && (end < 0 || i < end); i += in.tag.Arg.Stride() {
+			v, err := parse(in.v.Type().Elem(), i)
+			if err != nil {
+				return err
+			}
+			in.v.Set(reflect.Append(in.v, v))
+		}
+
+	case start < len(args):
+		v, err := parse(in.v.Type(), start)
+		if err != nil {
+			return err
+		}
+		in.v.Set(v)
+
+	case in.tag.Default == "":
+		return Usagef("missing argument %v", in.argName())
+	}
+	return nil
+}
+
+var readerType = typeOf[io.Reader]()
+
+// bindStdin sets the field of in from standard input.
+func bindStdin(stdin io.Reader, in boundInput) error {
+	if in.tag.StdinFormat != meta.StdinRaw {
+		return DecodeStdin(stdin, in.tag.StdinFormat, in.v.Addr().Interface())
+	}
+	if in.v.Type() == readerType {
+		in.v.Set(reflect.ValueOf(&stdin).Elem())
+		return nil
+	}
+	switch kind := in.v.Kind(); {
+	case kind == reflect.String, kind == reflect.Slice && in.v.Type().Elem().Kind() == reflect.Uint8:
+		b, err := io.ReadAll(stdin)
+		if err != nil {
+			return fmt.Errorf("reading stdin: %w", err)
+		}
+		in.v.Set(reflect.ValueOf(b).Convert(in.v.Type()))
+		return nil
+	}
+	return fmt.Errorf("field %v: raw stdin can't be read into type %v", in.name, in.v.Type())
+}
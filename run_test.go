@@ -0,0 +1,944 @@
+package cliche
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// RunOptions is exported, since only exported embedded pointers can be
+// allocated by reflection.
+type RunOptions struct {
+	Verbose bool   `cliche:"flag:verbose,v"`
+	Host    string `cliche:"flag:host;default:localhost;migrate:hostname"`
+	Debug   bool
+	Quiet   bool `cliche:"flag:q"`
+}
+
+type runTarget struct {
+	addr string
+}
+
+type runCommand struct {
+	Name        string        `cliche:"arg:0;default:World"`
+	Rest        []string      `cliche:"arg:[1:]"`
+	Count       int           `cliche:"flag:count,n;default:1;order:-1;validate:CheckCount"`
+	Tags        []string      `cliche:"flag:tag;default:a,b"`
+	Timeout     time.Duration `cliche:"default:5s"`
+	Target      *runTarget    `cliche:"inject:run-test"`
+	Body        string        `cliche:"stdin"`
+	Skipped     chan int      `cliche:"-"`
+	*RunOptions `cliche:"prefix:db-;omit:Debug;group:Database"`
+
+	ran bool
+}
+
+func (cmd *runCommand) CheckCount(s string) error {
+	if s == "0" {
+		return errors.New("must not be zero")
+	}
+	return nil
+}
+
+func (cmd *runCommand) Run(ctx context.Context) error {
+	cmd.ran = true
+	return nil
+}
+
+func TestRun(t *testing.T) {
+	ProvideNamed("run-test", func(context.Context) (*runTarget, error) {
+		return &runTarget{"example.com"}, nil
+	})
+
+	for tn, tc := range map[string]struct {
+		args []string
+		want runCommand
+	}{
+		"defaults": {nil, runCommand{
+			Name:       "World",
+			Count:      1,
+			Tags:       []string{"a", "b"},
+			Timeout:    5 * time.Second,
+			RunOptions: &RunOptions{Host: "localhost"},
+		}},
+		"everything": {
+			[]string{"-n", "3", "--tag=x", "-tag", "y", "-timeout=1m", "--db-verbose", "-db-q", "-db-hostname", "db.local", "Gopher", "and", "friends"},
+			runCommand{
+				Name:       "Gopher",
+				Rest:       []string{"and", "friends"},
+				Count:      3,
+				Tags:       []string{"x", "y"},
+				Timeout:    time.Minute,
+				RunOptions: &RunOptions{Verbose: true, Host: "db.local", Quiet: true},
+			},
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			stdio, capture := NewCaptureIO()
+			stdio.In = strings.NewReader("body")
+			cmd := new(runCommand)
+			if err := Run(context.Background(), stdio, cmd, tc.args); err != nil {
+				t.Fatalf("Run(): unexpected error: %v", err)
+			}
+			if !cmd.ran {
+				t.Error("Run(): command did not run")
+			}
+			if cmd.Target == nil || cmd.Target.addr != "example.com" || cmd.Body != "body" {
+				t.Errorf("Run(): got target %+v and body %q, want injected target and stdin", cmd.Target, cmd.Body)
+			}
+			got := *cmd
+			got.Target, got.Body, got.ran = nil, "", false
+			if diff := cmp.Diff(got, tc.want, cmp.AllowUnexported(runCommand{})); diff != "" {
+				t.Errorf("Run(): mismatch (-got,+want):\n%v", diff)
+			}
+			if strings.Contains(capture.Err(), "db-hostname") != strings.Contains(tn, "everything") {
+				t.Errorf("Run(): unexpected migration notices: %q", capture.Err())
+			}
+		})
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	ProvideNamed("run-test", func(context.Context) (*runTarget, error) {
+		return &runTarget{}, nil
+	})
+
+	for tn, tc := range map[string]struct {
+		cmd     any
+		args    []string
+		wantErr string
+	}{
+		"not a pointer":    {runCommand{}, nil, "not a pointer to a struct"},
+		"not runnable":     {&runTarget{}, nil, "no Run(context.Context) error method"},
+		"bad flag":         {&runCommand{}, []string{"-count=lots"}, `invalid value "lots" for flag -count: invalid integer "lots"`},
+		"invalid flag":     {&runCommand{}, []string{"-count=0"}, "flag -count: must not be zero"},
+		"unknown flag":     {&runCommand{}, []string{"-debug"}, "flag provided but not defined: -debug"},
+		"invalid default":  {&struct{ zeroLevel }{}, []string{"-level=0"}, "flag -level: must not be zero"},
+		"bad default":      {&struct{ badDefault }{}, nil, "default of badDefault.N"},
+		"missing arg":      {&struct{ missingArg }{}, nil, "missing argument name"},
+		"invalid embedded": {&validatedArg{}, []string{"nobody"}, "argument 1 (name): unknown name"},
+		"unexpected args":  {&struct{ missingArg }{}, []string{"a", "b", "c"}, `unexpected arguments: ["b" "c"]`},
+		"bad tag":          {&struct{ badTag }{}, nil, "field N:"},
+		"missing required": {&requiredInputs{}, []string{"src"}, "missing required inputs -token, dest"},
+		"short required":   {&requiredInputs{}, []string{"-t", "x", "src"}, "missing required input dest"},
+		"negated required": {&struct{ requiredSign }{}, nil, "missing required input -sign"},
+		"required default": {&struct{ requiredDefault }{}, nil, "field requiredDefault.Name: is required, but has a default"},
+		"overlapping args": {&struct{ overlappingArgs }{}, nil, "field overlappingArgs.Rest: positional arguments overlap those of field overlappingArgs.First"},
+		"short array":      {&struct{ offsetArray }{}, []string{"a", "b"}, "argument squares: expected 2 values, got 1"},
+		"array arity":      {&struct{ shortArray }{}, nil, "field shortArray.Pair: consumes 3 positional arguments, but type [2]string holds 2"},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			stdio, _ := NewCaptureIO()
+			err := Run(context.Background(), stdio, tc.cmd, tc.args)
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("Run(): got error %v, want one containing %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+type badDefault struct {
+	N int `cliche:"default:many"`
+}
+
+func (badDefault) Run(context.Context) error { return nil }
+
+type zeroLevel struct {
+	Level int `cliche:"validate:CheckLevel"`
+}
+
+func (zeroLevel) CheckLevel(s string) error {
+	if s == "0" {
+		return errors.New("must not be zero")
+	}
+	return nil
+}
+
+func (zeroLevel) Run(context.Context) error { return nil }
+
+type missingArg struct {
+	Name string `cliche:"arg:0"`
+}
+
+func (missingArg) Run(context.Context) error { return nil }
+
+type validatedArg struct {
+	missingArg
+}
+
+func (validatedArg) ValidateArgName(name string) error {
+	if name == "nobody" {
+		return errors.New("unknown name")
+	}
+	return nil
+}
+
+type requiredInputs struct {
+	Token  string `cliche:"flag:token,t;required"`
+	Source string `cliche:"arg:0;required"`
+	Dest   string `cliche:"arg:1;required"`
+}
+
+func (requiredInputs) Run(context.Context) error { return nil }
+
+type requiredSign struct {
+	Sign bool `cliche:"negatable;required"`
+}
+
+func (requiredSign) Run(context.Context) error { return nil }
+
+type requiredDefault struct {
+	Name string `cliche:"required;default:World"`
+}
+
+func (requiredDefault) Run(context.Context) error { return nil }
+
+type overlappingArgs struct {
+	First string   `cliche:"arg:0"`
+	Rest  []string `cliche:"arg:[0:]"`
+}
+
+func (overlappingArgs) Run(context.Context) error { return nil }
+
+type shortArray struct {
+	Pair [2]string `cliche:"arg:[0:3]"`
+}
+
+func (shortArray) Run(context.Context) error { return nil }
+
+type portArg struct {
+	Host string `cliche:"arg:0"`
+	Port int    `cliche:"arg:1"`
+}
+
+func (portArg) Run(context.Context) error { return nil }
+
+func TestRunArgumentParseError(t *testing.T) {
+	stdio, _ := NewCaptureIO()
+	err := Run(context.Background(), stdio, new(portArg), []string{"localhost", "abc"})
+	if want := `argument 2 (port): invalid integer "abc"`; err == nil || err.Error() != want {
+		t.Errorf("Run(): got error %v, want %q", err, want)
+	}
+	var numErr *strconv.NumError
+	if !errors.As(err, &numErr) || numErr.Func != "ParseInt" {
+		t.Errorf("Run(): got error %v, want one wrapping that of strconv.ParseInt", err)
+	}
+	if ExitCode(err) != DefaultExitCodes.Usage {
+		t.Errorf("ExitCode(): got %d, want %d", ExitCode(err), DefaultExitCodes.Usage)
+	}
+}
+
+type offsetArray struct {
+	Piece   string    `cliche:"arg:0"`
+	Squares [2]string `cliche:"arg:[1:3]"`
+}
+
+func (offsetArray) Run(context.Context) error { return nil }
+
+type badTag struct {
+	N int `cliche:"falg:n"`
+}
+
+func (badTag) Run(context.Context) error { return nil }
+
+type runVerbs struct {
+	Force bool
+	ran   string
+}
+
+func (cmd *runVerbs) RunFetchAll(context.Context) error {
+	cmd.ran = "fetch-all"
+	return nil
+}
+
+func (cmd *runVerbs) RunPush(ctx context.Context) error {
+	cmd.ran = strings.Join(CommandPath(ctx), " ")
+	return nil
+}
+
+func TestRunVerbs(t *testing.T) {
+	stdio, _ := NewCaptureIO()
+	cmd := new(runVerbs)
+	if err := Run(context.Background(), stdio, cmd, []string{"-force", "fetch-all"}); err != nil {
+		t.Fatalf("Run(): unexpected error: %v", err)
+	}
+	if cmd.ran != "fetch-all" || !cmd.Force {
+		t.Errorf("Run(): ran %q with force %v, want fetch-all with force", cmd.ran, cmd.Force)
+	}
+
+	// The verb is appended to the command path of the context.
+	ctx := WithCommand(context.Background(), "app")
+	if err := Run(ctx, stdio, cmd, []string{"push"}); err != nil {
+		t.Fatalf("Run(): unexpected error: %v", err)
+	}
+	prog := strings.TrimSuffix(filepath.Base(os.Args[0]), ".exe")
+	if want := "app " + prog + " push"; cmd.ran != want {
+		t.Errorf("Run(): got command path %q, want %q", cmd.ran, want)
+	}
+
+	err := Run(context.Background(), stdio, new(runVerbs), []string{"pull"})
+	if want := "expected a command: one of fetch-all, push"; err == nil || err.Error() != want {
+		t.Errorf("Run(): got error %v, want %q", err, want)
+	}
+}
+
+func TestRunHelp(t *testing.T) {
+	stdio, capture := NewCaptureIO()
+	err := Run(context.Background(), stdio, new(runCommand), []string{"-h"})
+	if !errors.Is(err, flag.ErrHelp) {
+		t.Errorf("Run(): got error %v, want flag.ErrHelp", err)
+	}
+	for _, want := range []string{
+		"[flags] [name] [rest...]\n",
+		"\nFlags:\n  -count, -n int\t(default 1)\n  -tag []string\t(default a,b)\n",
+		"\nDatabase:\n  -db-verbose\n",
+	} {
+		if !strings.Contains(capture.Out(), want) {
+			t.Errorf("Run(): help does not contain %q:\n%v", want, capture.Out())
+		}
+	}
+}
+
+type runPairs struct {
+	Keys   []string `cliche:"arg:[0::2]"`
+	Values []int    `cliche:"arg:[1::2]"`
+}
+
+func (runPairs) Run(context.Context) error { return nil }
+
+func TestRunStrided(t *testing.T) {
+	stdio, _ := NewCaptureIO()
+	cmd := new(runPairs)
+	if err := Run(context.Background(), stdio, cmd, []string{"a", "1", "b", "2", "c"}); err != nil {
+		t.Fatalf("Run(): unexpected error: %v", err)
+	}
+	want := runPairs{Keys: []string{"a", "b", "c"}, Values: []int{1, 2}}
+	if diff := cmp.Diff(*cmd, want); diff != "" {
+		t.Errorf("Run(): mismatch (-got,+want):\n%v", diff)
+	}
+
+	err := Run(context.Background(), stdio, &struct{ runStridedArray }{}, nil)
+	if want := "consumes one argument in every 2"; err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("Run(): got error %v, want one containing %q", err, want)
+	}
+}
+
+type runStridedArray struct {
+	Pair [2]string `cliche:"arg:[0:4:2]"`
+}
+
+func (runStridedArray) Run(context.Context) error { return nil }
+
+type runSet struct {
+	Config string         `cliche:"arg:0"`
+	Values map[string]int `cliche:"arg:[1:];pairs"`
+}
+
+func (runSet) Run(context.Context) error { return nil }
+
+func TestRunPairs(t *testing.T) {
+	stdio, _ := NewCaptureIO()
+	cmd := new(runSet)
+	if err := Run(context.Background(), stdio, cmd, []string{"db", "port", "5432", "pool", "4", "port", "5433"}); err != nil {
+		t.Fatalf("Run(): unexpected error: %v", err)
+	}
+	want := runSet{Config: "db", Values: map[string]int{"port": 5433, "pool": 4}}
+	if diff := cmp.Diff(*cmd, want); diff != "" {
+		t.Errorf("Run(): mismatch (-got,+want):\n%v", diff)
+	}
+
+	for want, args := range map[string][]string{
+		`argument values: missing value for key "pool"`: {"db", "port", "5432", "pool"},
+		`argument 3 (values): invalid integer "many"`:   {"db", "port", "many"},
+	} {
+		err := Run(context.Background(), stdio, new(runSet), args)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Run(%q): got error %v, want one containing %q", args, err, want)
+		}
+	}
+}
+
+type runDeploy struct {
+	Labels map[string]string `cliche:"flag:label,l;default:team=infra"`
+	Limits map[string]int    `cliche:"flag:limit;sep::"`
+}
+
+func (runDeploy) Run(context.Context) error { return nil }
+
+func TestRunMapFlags(t *testing.T) {
+	stdio, _ := NewCaptureIO()
+	cmd := new(runDeploy)
+	if err := Run(context.Background(), stdio, cmd, []string{"-limit", "cpu:2", "-limit", "mem:512"}); err != nil {
+		t.Fatalf("Run(): unexpected error: %v", err)
+	}
+	want := runDeploy{Labels: map[string]string{"team": "infra"}, Limits: map[string]int{"cpu": 2, "mem": 512}}
+	if diff := cmp.Diff(*cmd, want); diff != "" {
+		t.Errorf("Run(): mismatch (-got,+want):\n%v", diff)
+	}
+
+	cmd = new(runDeploy)
+	if err := Run(context.Background(), stdio, cmd, []string{"-l", "app=web", "--label", "tier=front"}); err != nil {
+		t.Fatalf("Run(): unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(cmd.Labels, map[string]string{"app": "web", "tier": "front"}); diff != "" {
+		t.Errorf("Run(): labels mismatch (-got,+want):\n%v", diff)
+	}
+
+	err := Run(context.Background(), stdio, new(runDeploy), []string{"-limit", "cpu=2"})
+	if want := `"cpu=2" is not a key and value separated by ":"`; err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("Run(): got error %v, want one containing %q", err, want)
+	}
+}
+
+type runRegions struct {
+	Regions []string `cliche:"flag:region;sep:,;default:us-east,eu-west"`
+	Zones   []string `cliche:"flag:zone"`
+}
+
+func (runRegions) Run(context.Context) error { return nil }
+
+func TestRunSeparatedFlags(t *testing.T) {
+	stdio, capture := NewCaptureIO()
+	args := []string{"-region", "ap-south,us-west", "-region", "sa-east", "-zone", "a,b", "-zone", "c"}
+	for _, tc := range []struct {
+		policy SlicePolicy
+		want   runRegions
+	}{
+		{SliceRepeat, runRegions{Regions: []string{"ap-south", "us-west", "sa-east"}, Zones: []string{"a,b", "c"}}},
+		{SliceSplit, runRegions{Regions: []string{"sa-east"}, Zones: []string{"c"}}},
+		{SliceBoth, runRegions{Regions: []string{"ap-south", "us-west", "sa-east"}, Zones: []string{"a", "b", "c"}}},
+	} {
+		cmd := new(runRegions)
+		if err := Run(WithSlicePolicy(context.Background(), tc.policy), stdio, cmd, args); err != nil {
+			t.Fatalf("Run(): unexpected error: %v", err)
+		}
+		if diff := cmp.Diff(*cmd, tc.want); diff != "" {
+			t.Errorf("Run() with policy %v: mismatch (-got,+want):\n%v", tc.policy, diff)
+		}
+	}
+
+	cmd := new(runRegions)
+	if err := Run(context.Background(), stdio, cmd, nil); err != nil {
+		t.Fatalf("Run(): unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(cmd.Regions, []string{"us-east", "eu-west"}); diff != "" {
+		t.Errorf("Run(): default mismatch (-got,+want):\n%v", diff)
+	}
+
+	if err := Run(WithSlicePolicy(context.Background(), SliceSplit), stdio, new(runRegions), []string{"-h"}); !errors.Is(err, flag.ErrHelp) {
+		t.Fatalf("Run(): got error %v, want flag.ErrHelp", err)
+	}
+	if want := "  -zone []string\t(values separated by \",\", each use replacing the last)\n"; !strings.Contains(capture.Out(), want) {
+		t.Errorf("Run(): help does not contain %q:\n%v", want, capture.Out())
+	}
+}
+
+type runSearch struct {
+	Count   bool `cliche:"flag:c"`
+	Context int  `cliche:"flag:C;default:2"`
+}
+
+func (runSearch) Run(context.Context) error { return nil }
+
+func TestRunShortOnlyFlags(t *testing.T) {
+	stdio, capture := NewCaptureIO()
+	cmd := new(runSearch)
+	if err := Run(context.Background(), stdio, cmd, []string{"-c", "--C", "5"}); err != nil {
+		t.Fatalf("Run(): unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(*cmd, runSearch{Count: true, Context: 5}); diff != "" {
+		t.Errorf("Run(): mismatch (-got,+want):\n%v", diff)
+	}
+
+	err := Run(context.Background(), stdio, new(runSearch), []string{"-h"})
+	if !errors.Is(err, flag.ErrHelp) {
+		t.Errorf("Run(): got error %v, want flag.ErrHelp", err)
+	}
+	if want := "\nFlags:\n  -c\n  -C int\t(default 2)\n"; !strings.Contains(capture.Out(), want) {
+		t.Errorf("Run(): help does not contain %q:\n%v", want, capture.Out())
+	}
+}
+
+type runBuild struct {
+	Color bool `cliche:"flag:color,c;default:true;negatable"`
+	Cache bool `cliche:"default:true;negatable"`
+}
+
+func (runBuild) Run(context.Context) error { return nil }
+
+func TestRunNegatableFlags(t *testing.T) {
+	stdio, capture := NewCaptureIO()
+	for _, tc := range []struct {
+		args []string
+		want runBuild
+	}{
+		{nil, runBuild{Color: true, Cache: true}},
+		{[]string{"-no-color", "--no-cache"}, runBuild{}},
+		{[]string{"-no-color", "-c"}, runBuild{Color: true, Cache: true}},
+	} {
+		cmd := new(runBuild)
+		if err := Run(context.Background(), stdio, cmd, tc.args); err != nil {
+			t.Fatalf("Run(): unexpected error: %v", err)
+		}
+		if diff := cmp.Diff(*cmd, tc.want); diff != "" {
+			t.Errorf("Run(%q): mismatch (-got,+want):\n%v", tc.args, diff)
+		}
+	}
+
+	for _, args := range [][]string{{"-sign"}, {"-no-sign"}} {
+		if err := Run(context.Background(), stdio, new(struct{ requiredSign }), args); err != nil {
+			t.Errorf("Run(%q): unexpected error: %v", args, err)
+		}
+	}
+
+	err := Run(context.Background(), stdio, new(runBuild), []string{"-h"})
+	if !errors.Is(err, flag.ErrHelp) {
+		t.Errorf("Run(): got error %v, want flag.ErrHelp", err)
+	}
+	if want := "\n  -color, -c, -no-color\t(default true)\n  -cache, -no-cache\t(default true)\n"; !strings.Contains(capture.Out(), want) {
+		t.Errorf("Run(): help does not contain %q:\n%v", want, capture.Out())
+	}
+
+	err = Run(context.Background(), stdio, new(struct {
+		runBuild
+		Level int `cliche:"negatable"`
+	}), nil)
+	if want := "field Level: is negatable, but is not a bool flag"; err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("Run(): got error %v, want one containing %q", err, want)
+	}
+}
+
+type runSync struct {
+	Verbose int   `cliche:"flag:verbose,v;count"`
+	Quiet   uint8 `cliche:"flag:q;count"`
+}
+
+func (runSync) Run(context.Context) error { return nil }
+
+func TestRunCountFlags(t *testing.T) {
+	stdio, capture := NewCaptureIO()
+	for _, tc := range []struct {
+		args []string
+		want runSync
+	}{
+		{nil, runSync{}},
+		{[]string{"-vvv", "-q"}, runSync{Verbose: 3, Quiet: 1}},
+		{[]string{"-v", "--verbose", "-qq"}, runSync{Verbose: 2, Quiet: 2}},
+		{[]string{"-verbose=4"}, runSync{Verbose: 4}},
+	} {
+		cmd := new(runSync)
+		if err := Run(context.Background(), stdio, cmd, tc.args); err != nil {
+			t.Fatalf("Run(): unexpected error: %v", err)
+		}
+		if diff := cmp.Diff(*cmd, tc.want); diff != "" {
+			t.Errorf("Run(%q): mismatch (-got,+want):\n%v", tc.args, diff)
+		}
+	}
+
+	err := Run(context.Background(), stdio, new(runSync), []string{"-h"})
+	if !errors.Is(err, flag.ErrHelp) {
+		t.Errorf("Run(): got error %v, want flag.ErrHelp", err)
+	}
+	if want := "\n  -verbose, -v\n  -q\n"; !strings.Contains(capture.Out(), want) {
+		t.Errorf("Run(): help does not contain %q:\n%v", want, capture.Out())
+	}
+
+	err = Run(context.Background(), stdio, new(struct {
+		runSync
+		Debug bool `cliche:"count"`
+	}), nil)
+	if want := "field Debug: is a count, but is not an integer flag"; err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("Run(): got error %v, want one containing %q", err, want)
+	}
+}
+
+type runPaint struct {
+	Color   string   `cliche:"flag:color,c;default:red;choices:red|green|blue"`
+	Finish  []string `cliche:"flag:finish;choices:matte|gloss"`
+	Surface string   `cliche:"arg:0;choices:wall|door"`
+}
+
+func (runPaint) Run(context.Context) error { return nil }
+
+func TestRunChoices(t *testing.T) {
+	stdio, capture := NewCaptureIO()
+	for _, tc := range []struct {
+		args    []string
+		want    runPaint
+		wantErr string
+	}{
+		{[]string{"wall"}, runPaint{Color: "red", Surface: "wall"}, ""},
+		{[]string{"-c", "blue", "-finish", "gloss", "door"}, runPaint{Color: "blue", Finish: []string{"gloss"}, Surface: "door"}, ""},
+		{[]string{"-c", "purple", "wall"}, runPaint{}, `invalid value "purple" for flag -c: "purple" is not one of red, green, blue`},
+		{[]string{"-finish", "shiny", "wall"}, runPaint{}, `"shiny" is not one of matte, gloss`},
+		{[]string{"window"}, runPaint{}, `argument 1 (surface): "window" is not one of wall, door`},
+	} {
+		cmd := new(runPaint)
+		err := Run(context.Background(), stdio, cmd, tc.args)
+		if tc.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("Run(%q): got error %v, want one containing %q", tc.args, err, tc.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Run(%q): unexpected error: %v", tc.args, err)
+		}
+		if diff := cmp.Diff(*cmd, tc.want); diff != "" {
+			t.Errorf("Run(%q): mismatch (-got,+want):\n%v", tc.args, diff)
+		}
+	}
+
+	err := Run(context.Background(), stdio, new(runPaint), []string{"-h"})
+	if !errors.Is(err, flag.ErrHelp) {
+		t.Errorf("Run(): got error %v, want flag.ErrHelp", err)
+	}
+	if want := "\n  -color, -c string\t(one of red, green, blue) (default red)\n"; !strings.Contains(capture.Out(), want) {
+		t.Errorf("Run(): help does not contain %q:\n%v", want, capture.Out())
+	}
+
+	err = Run(context.Background(), stdio, new(struct {
+		runPaint
+		Labels map[string]string `cliche:"flag:label;choices:a|b"`
+	}), []string{"wall"})
+	if want := "field Labels: has choices, but type map[string]string is a map"; err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("Run(): got error %v, want one containing %q", err, want)
+	}
+}
+
+type runServe struct {
+	Port   int    `cliche:"flag:port,p;default:8080"`
+	Addr   string `cliche:"flag:addr;deprecated:use --port instead"`
+	Debug  bool   `cliche:"flag:debug;hidden"`
+	Legacy bool   `cliche:"flag:legacy;negatable;deprecated"`
+}
+
+func (runServe) Run(context.Context) error { return nil }
+
+func TestRunHiddenDeprecatedFlags(t *testing.T) {
+	stdio, capture := NewCaptureIO()
+	cmd := new(runServe)
+	if err := Run(context.Background(), stdio, cmd, []string{"-debug", "-addr", ":80", "-no-legacy"}); err != nil {
+		t.Fatalf("Run(): unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(*cmd, runServe{Port: 8080, Addr: ":80", Debug: true}); diff != "" {
+		t.Errorf("Run(): mismatch (-got,+want):\n%v", diff)
+	}
+	want := "Flag -addr has been deprecated, use --port instead\nFlag -no-legacy has been deprecated.\n"
+	if diff := cmp.Diff(capture.Err(), want); diff != "" {
+		t.Errorf("Run(): warnings mismatch (-got,+want):\n%v", diff)
+	}
+
+	stdio, capture = NewCaptureIO()
+	err := Run(context.Background(), stdio, new(runServe), []string{"-h"})
+	if !errors.Is(err, flag.ErrHelp) {
+		t.Errorf("Run(): got error %v, want flag.ErrHelp", err)
+	}
+	if want := "\n  -addr string\t(deprecated, use --port instead)\n  -legacy, -no-legacy\t(deprecated)\n"; !strings.Contains(capture.Out(), want) {
+		t.Errorf("Run(): help does not contain %q:\n%v", want, capture.Out())
+	}
+	if strings.Contains(capture.Out(), "-debug") {
+		t.Errorf("Run(): help lists hidden flag -debug:\n%v", capture.Out())
+	}
+}
+
+type runTimes struct {
+	Since time.Time   `cliche:"flag:since;layout:DateOnly;tz:America/New_York"`
+	Until time.Time   `cliche:"flag:until"`
+	Days  []time.Time `cliche:"arg:[0:];layout:02/01/2006"`
+}
+
+func (runTimes) Run(context.Context) error { return nil }
+
+func TestRunTimes(t *testing.T) {
+	stdio, _ := NewCaptureIO()
+	cmd := new(runTimes)
+	args := []string{"-since", "2026-10-01", "-until", "2026-10-17T12:00:00+02:00", "16/10/2026"}
+	if err := Run(context.Background(), stdio, cmd, args); err != nil {
+		t.Fatalf("Run(): unexpected error: %v", err)
+	}
+	nyc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		got, want time.Time
+	}{
+		{cmd.Since, time.Date(2026, 10, 1, 0, 0, 0, 0, nyc)},
+		{cmd.Until, time.Date(2026, 10, 17, 10, 0, 0, 0, time.UTC)},
+		{cmd.Days[0], time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)},
+	} {
+		if !tc.got.Equal(tc.want) {
+			t.Errorf("Run(): got time %v, want %v", tc.got, tc.want)
+		}
+	}
+
+	err = Run(context.Background(), stdio, new(runTimes), []string{"-since", "01/10/2026"})
+	if want := `parsing time "01/10/2026" as "2006-01-02"`; err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("Run(): got error %v, want one containing %q", err, want)
+	}
+}
+
+type runLocked struct {
+	NoLock bool `cliche:"lock:run-test"`
+	lock   func() error
+}
+
+func (cmd *runLocked) Run(context.Context) error {
+	if cmd.lock != nil {
+		return cmd.lock()
+	}
+	return nil
+}
+
+func TestRunLock(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	stdio, _ := NewCaptureIO()
+
+	// While the command runs, another instance can't, unless it skips the
+	// lock.
+	var nested, unlocked error
+	cmd := &runLocked{lock: func() error {
+		nested = Run(context.Background(), stdio, new(runLocked), nil)
+		unlocked = Run(context.Background(), stdio, new(runLocked), []string{"--no-lock"})
+		return nil
+	}}
+	if err := Run(context.Background(), stdio, cmd, nil); err != nil {
+		t.Fatalf("Run(): unexpected error: %v", err)
+	}
+	var locked *LockedError
+	if !errors.As(nested, &locked) {
+		t.Errorf("Run(): got error %v while locked, want LockedError", nested)
+	}
+	if unlocked != nil {
+		t.Errorf("Run(): unexpected error with --no-lock: %v", unlocked)
+	}
+
+	// Once the command returns, the lock is released.
+	if err := Run(context.Background(), stdio, new(runLocked), nil); err != nil {
+		t.Errorf("Run(): unexpected error once unlocked: %v", err)
+	}
+}
+
+type runTree struct {
+	Remote *runRemote `cliche:"subcommand"`
+}
+
+type runRemote struct {
+	Verbose bool   `cliche:"flag:verbose,v"`
+	Add     runAdd `cliche:"subcommand:add"`
+}
+
+func (cmd *runRemote) Run(context.Context) error {
+	ranTree = "remote"
+	return nil
+}
+
+type runAdd struct {
+	Name string `cliche:"arg:0"`
+}
+
+func (cmd *runAdd) Run(ctx context.Context) error {
+	ranTree = strings.Join(CommandPath(ctx)[1:], " ") + " " + cmd.Name
+	// The subcommand runs in the field of its parent, whose flags it reads.
+	if remote, ok := Parent[*runRemote](ctx); ok && &remote.Add == cmd && remote.Verbose {
+		ranTree += " verbosely"
+	}
+	return nil
+}
+
+type runSysexits struct {
+	Remote *runRemote `cliche:"subcommand"`
+}
+
+func (*runSysexits) ExitCodes() ExitCodes { return ExitCodes{Usage: 64} }
+
+func TestRunExitCodes(t *testing.T) {
+	stdio, _ := NewCaptureIO()
+	for _, tc := range []struct {
+		cmd  any
+		args []string
+		want int
+	}{
+		{new(runSysexits), []string{"local"}, 64},
+		{new(runSysexits), []string{"remote", "-bogus"}, 64},
+		{new(runSysexits), []string{"remote", "add", "origin", "upstream"}, 64},
+		{new(runSysexits), []string{"remote"}, 0},
+		{new(runTree), []string{"remote", "add", "origin", "upstream"}, 2},
+	} {
+		err := Run(context.Background(), stdio, tc.cmd, tc.args)
+		if got := ExitCode(err); got != tc.want {
+			t.Errorf("Run(%q): got exit status %v (error %v), want %v", tc.args, got, err, tc.want)
+		}
+	}
+}
+
+// ranTree records what ran, since the commands which Run runs are not
+// returned.
+var ranTree string
+
+func TestRunSubcommands(t *testing.T) {
+	stdio, _ := NewCaptureIO()
+	for _, tc := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"remote"}, "remote"},
+		{[]string{"remote", "add", "origin"}, "remote add origin"},
+		{[]string{"remote", "-v", "add", "origin"}, "remote add origin verbosely"},
+	} {
+		ranTree = ""
+		if err := Run(context.Background(), stdio, new(runTree), tc.args); err != nil {
+			t.Errorf("Run(%q): unexpected error: %v", tc.args, err)
+		}
+		if ranTree != tc.want {
+			t.Errorf("Run(%q): ran %q, want %q", tc.args, ranTree, tc.want)
+		}
+	}
+
+	err := Run(context.Background(), stdio, new(runTree), []string{"local"})
+	if want := "expected a command: one of remote"; err == nil || err.Error() != want {
+		t.Errorf("Run(): got error %v, want %q", err, want)
+	}
+
+	// Run bare, a command which can't run itself shows help.
+	stdio, capture := NewCaptureIO()
+	if err := Run(context.Background(), stdio, new(runTree), nil); !errors.Is(err, flag.ErrHelp) {
+		t.Errorf("Run(): got error %v, want flag.ErrHelp", err)
+	}
+	if !strings.Contains(capture.Out(), "Commands:\n  remote\n") {
+		t.Errorf("Run(): help does not list subcommands:\n%v", capture.Out())
+	}
+}
+
+func TestRunHelpAnywhere(t *testing.T) {
+	for _, tc := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"help"}, "Commands:\n  remote\n"},
+		{[]string{"help", "remote"}, "-verbose, -v"},
+		{[]string{"help", "remote", "add"}, "Usage: add [flags] name\n"},
+		{[]string{"remote", "add", "origin", "--help"}, "Usage: add [flags] name\n"},
+		{[]string{"remote", "-bogus", "-h"}, "-verbose, -v"},
+	} {
+		ranTree = ""
+		stdio, capture := NewCaptureIO()
+		if err := Run(context.Background(), stdio, new(runTree), tc.args); !errors.Is(err, flag.ErrHelp) {
+			t.Errorf("Run(%q): got error %v, want flag.ErrHelp", tc.args, err)
+		}
+		if !strings.Contains(capture.Out(), tc.want) || capture.Err() != "" {
+			t.Errorf("Run(%q): got help %q and errors %q, want help containing %q", tc.args, capture.Out(), capture.Err(), tc.want)
+		}
+		if ranTree != "" {
+			t.Errorf("Run(%q): ran %q, want only help", tc.args, ranTree)
+		}
+	}
+}
+
+type runGlobalTree struct {
+	Loud  *runLoud  `cliche:"subcommand"`
+	Quiet *runQuiet `cliche:"subcommand"`
+}
+
+type runLoud struct {
+	Verbose int    `cliche:"flag:verbose,v;count;global"`
+	Config  string `cliche:"flag:config;default:app.yaml;global"`
+}
+
+func (cmd *runLoud) Run(context.Context) error {
+	ranTree = fmt.Sprintf("loud %d %v", cmd.Verbose, cmd.Config)
+	return nil
+}
+
+type runQuiet struct{}
+
+func (cmd *runQuiet) Run(context.Context) error {
+	ranTree = "quiet"
+	return nil
+}
+
+func TestRunGlobals(t *testing.T) {
+	stdio, capture := NewCaptureIO()
+	for _, tc := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"loud"}, "loud 0 app.yaml"},
+		{[]string{"-vv", "--config=c.yaml", "loud", "-v"}, "loud 3 c.yaml"},
+		{[]string{"loud", "--config", "c.yaml"}, "loud 0 c.yaml"},
+		// Subcommands which don't declare a global aren't passed it.
+		{[]string{"-v", "quiet"}, "quiet"},
+	} {
+		ranTree = ""
+		if err := Run(context.Background(), stdio, new(runGlobalTree), tc.args); err != nil {
+			t.Errorf("Run(%q): unexpected error: %v", tc.args, err)
+		}
+		if ranTree != tc.want {
+			t.Errorf("Run(%q): ran %q, want %q", tc.args, ranTree, tc.want)
+		}
+	}
+
+	if err := Run(context.Background(), stdio, new(runGlobalTree), []string{"-h"}); !errors.Is(err, flag.ErrHelp) {
+		t.Errorf("Run(): got error %v, want flag.ErrHelp", err)
+	}
+	if want := "Global flags:\n  -verbose, -v\n  -config string\n"; !strings.Contains(capture.Out(), want) {
+		t.Errorf("Run(): help does not contain %q:\n%v", want, capture.Out())
+	}
+}
+
+type runContext struct {
+	Verbose uint `cliche:"flag:v;count;verbosity"`
+	DryRun  bool `cliche:"flag:dry-run,n;dryrun"`
+
+	dryRun    bool
+	verbosity Verbosity
+}
+
+func (cmd *runContext) Run(ctx context.Context) error {
+	cmd.dryRun, cmd.verbosity = DryRunFrom(ctx), VerbosityFrom(ctx)
+	return nil
+}
+
+func TestRunContext(t *testing.T) {
+	stdio, _ := NewCaptureIO()
+	cmd := new(runContext)
+	if err := Run(context.Background(), stdio, cmd, []string{"-vv", "-n"}); err != nil {
+		t.Fatalf("Run(): unexpected error: %v", err)
+	}
+	if !cmd.dryRun || cmd.verbosity != 2 {
+		t.Errorf("Run(): ran with dry run %v and verbosity %v, want true and 2", cmd.dryRun, cmd.verbosity)
+	}
+}
+
+type runPanic struct{}
+
+func (runPanic) Run(context.Context) error { panic("boom") }
+
+func TestRunRecovers(t *testing.T) {
+	stdio, _ := NewCaptureIO()
+	var crashes []Crash
+	ctx := WithCrashReporter(context.Background(), CrashReporterFunc(func(ctx context.Context, crash Crash) {
+		crashes = append(crashes, crash)
+	}))
+	err := Run(ctx, stdio, new(runPanic), nil)
+	var panicked *PanicError
+	if !errors.As(err, &panicked) || panicked.Value != "boom" {
+		t.Errorf("Run(): got error %v, want a *PanicError of boom", err)
+	}
+	if len(crashes) != 1 {
+		t.Fatalf("Run(): got %d crash reports, want 1", len(crashes))
+	}
+	if len(crashes[0].Path) != 1 || !errors.Is(err, crashes[0].Err) {
+		t.Errorf("Run(): reported %v at %q, want the returned error at the command's path", crashes[0].Err, crashes[0].Path)
+	}
+}
@@ -0,0 +1,132 @@
+package cliche
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"time"
+)
+
+// Schedule on which a command is Run repeatedly, for commands which are as
+// useful as lightweight daemons as they are one-shot.
+type Schedule struct {
+	// Every is the interval between the end of one run and the start of the
+	// next.
+	Every time.Duration
+
+	// Jitter is the upper bound of a random duration added to each interval,
+	// to keep many instances from running in lockstep.
+	Jitter time.Duration
+
+	// Until is the time after which no further runs are started. The zero
+	// value means runs continue indefinitely.
+	Until time.Time
+
+	// KeepGoing continues the schedule after a run returns an error, which is
+	// logged. Otherwise, the first error ends the schedule.
+	KeepGoing bool
+}
+
+// RegisterFlags registers the --every, --jitter, --until and --keep-going
+// flags on fs, which set the corresponding fields of s. The --until flag takes
+// an RFC 3339 timestamp, or a duration from when the flags are parsed.
+func (s *Schedule) RegisterFlags(fs *flag.FlagSet) {
+	fs.DurationVar(&s.Every, "every", s.Every, "run repeatedly, this `interval` apart, rather than once")
+	fs.DurationVar(&s.Jitter, "jitter", s.Jitter, "add up to this `duration` to each interval, chosen at random")
+	fs.Func("until", "with --every, start no runs after this `time` or duration from now", func(v string) error {
+		if d, err := time.ParseDuration(v); err == nil {
+			s.Until = time.Now().Add(d)
+			return nil
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return fmt.Errorf("%q is neither an RFC 3339 time nor a duration", v)
+		}
+		s.Until = t
+		return nil
+	})
+	fs.BoolVar(&s.KeepGoing, "keep-going", s.KeepGoing, "with --every, keep running after a run fails")
+}
+
+// next returns how long to wait before the next run.
+func (s Schedule) next() time.Duration {
+	d := s.Every
+	if s.Jitter > 0 {
+		d += time.Duration(rand.Int63n(int64(s.Jitter)))
+	}
+	return d
+}
+
+// Repeat calls run immediately, and then again on the schedule until ctx is
+// done or the schedule ends. Cancelling ctx shuts down cleanly: it is passed on
+// to an in-flight run, and Repeat returns nil once that run returns. Otherwise,
+// the first error from run is returned, unless the schedule keeps going. A
+// schedule whose interval is not positive is an error.
+func Repeat(ctx context.Context, s Schedule, run func(context.Context) error) error {
+	if s.Every <= 0 {
+		return fmt.Errorf("schedule interval %v is not positive", s.Every)
+	}
+	for {
+		if !s.Until.IsZero() && !time.Now().Before(s.Until) {
+			return nil
+		}
+		if err := run(ctx); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			if !s.KeepGoing {
+				return err
+			}
+			slog.WarnContext(ctx, "Scheduled run failed", slog.Any("error", err))
+		}
+
+		t := time.NewTimer(s.next())
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return nil
+		case <-t.C:
+		}
+	}
+}
+
+// RunScheduled calls run once when s is the zero Schedule, as it is when none
+// of the flags registered by RegisterFlags are given, and Repeats it on s
+// otherwise. Only an interval makes a schedule: one which ends, but has no
+// interval, is an error.
+func RunScheduled(ctx context.Context, s Schedule, run func(context.Context) error) error {
+	switch {
+	case s == Schedule{}:
+		return run(ctx)
+	case s.Every == 0:
+		return errors.New("schedule has no interval")
+	}
+	return Repeat(ctx, s, run)
+}
+
+type scheduleKey struct{}
+
+// WithSchedule returns a copy of ctx carrying s, on which the command run with
+// it is run. Generated commands carry that of the root's schedule flags, when
+// generated with -schedule.
+func WithSchedule(ctx context.Context, s Schedule) context.Context {
+	return context.WithValue(ctx, scheduleKey{}, s)
+}
+
+// ScheduleFrom returns the Schedule carried by ctx, or the zero Schedule, on
+// which commands run once, when it carries none.
+func ScheduleFrom(ctx context.Context) Schedule {
+	s, _ := ctx.Value(scheduleKey{}).(Schedule)
+	return s
+}
+
+// Scheduled returns run, run on the Schedule carried by the context with which
+// it is called, as RunScheduled runs it.
+func Scheduled(run func(context.Context) error) func(context.Context) error {
+	return func(ctx context.Context) error {
+		return RunScheduled(ctx, ScheduleFrom(ctx), run)
+	}
+}
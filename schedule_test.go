@@ -0,0 +1,151 @@
+package cliche
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRepeat(t *testing.T) {
+	errRun := errors.New("oh no")
+
+	for tn, tc := range map[string]struct {
+		sched    Schedule
+		failOn   int
+		cancelOn int
+		wantRuns int
+		wantErr  error
+	}{
+		"cancelled":             {Schedule{Every: time.Millisecond, Jitter: time.Millisecond}, 0, 3, 3, nil},
+		"until passed":          {Schedule{Every: time.Millisecond, Until: time.Now().Add(-time.Second)}, 0, 0, 0, nil},
+		"error ends schedule":   {Schedule{Every: time.Millisecond}, 2, 0, 2, errRun},
+		"keep going past error": {Schedule{Every: time.Millisecond, KeepGoing: true}, 2, 4, 4, nil},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			var runs int
+			err := Repeat(ctx, tc.sched, func(ctx context.Context) error {
+				runs++
+				if runs == tc.cancelOn {
+					cancel()
+					return ctx.Err()
+				}
+				if runs == tc.failOn {
+					return errRun
+				}
+				return nil
+			})
+			if !errors.Is(err, tc.wantErr) {
+				t.Errorf("Repeat(): error mismatch: got: %v want: %v", err, tc.wantErr)
+			}
+			if runs != tc.wantRuns {
+				t.Errorf("Repeat(): got %d runs, want %d", runs, tc.wantRuns)
+			}
+		})
+	}
+}
+
+func TestRepeatUntil(t *testing.T) {
+	var runs int
+	s := Schedule{Every: 5 * time.Millisecond, Until: time.Now().Add(50 * time.Millisecond)}
+	if err := Repeat(context.Background(), s, func(context.Context) error {
+		runs++
+		return nil
+	}); err != nil {
+		t.Fatalf("Repeat(): unexpected error: %v", err)
+	}
+	if runs < 2 {
+		t.Errorf("Repeat(): got %d runs before Until, want at least 2", runs)
+	}
+}
+
+func TestRepeatInterval(t *testing.T) {
+	for _, every := range []time.Duration{0, -time.Second} {
+		var runs int
+		err := Repeat(context.Background(), Schedule{Every: every}, func(context.Context) error {
+			runs++
+			return nil
+		})
+		if err == nil || runs != 0 {
+			t.Errorf("Repeat(): got error %v after %d runs for interval %v, want error before running", err, runs, every)
+		}
+	}
+}
+
+func TestScheduleRegisterFlags(t *testing.T) {
+	var s Schedule
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	s.RegisterFlags(fs)
+	if err := fs.Parse([]string{"--every=5m", "--jitter", "10s", "--until=2030-01-02T03:04:05Z", "--keep-going"}); err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	want := Schedule{Every: 5 * time.Minute, Jitter: 10 * time.Second, Until: time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC), KeepGoing: true}
+	if diff := cmp.Diff(s, want); diff != "" {
+		t.Errorf("RegisterFlags(): mismatch (-got,+want):\n%v", diff)
+	}
+
+	before := time.Now()
+	if err := fs.Parse([]string{"--until=1h"}); err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	if d := s.Until.Sub(before); d < time.Hour || d > time.Hour+time.Minute {
+		t.Errorf("RegisterFlags(): --until=1h set Until %v from now, want an hour", d)
+	}
+	fs.SetOutput(io.Discard)
+	if err := fs.Parse([]string{"--until=tomorrow"}); err == nil {
+		t.Error("Parse(): wanted error for --until=tomorrow, got nil")
+	}
+}
+
+func TestRunScheduled(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		sched    Schedule
+		wantRuns int
+		wantErr  bool
+	}{
+		"once":        {Schedule{}, 1, false},
+		"repeated":    {Schedule{Every: time.Millisecond, Until: time.Now().Add(20 * time.Millisecond)}, 2, false},
+		"no interval": {Schedule{Until: time.Now().Add(time.Hour)}, 0, true},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			var runs int
+			err := RunScheduled(context.Background(), tc.sched, func(context.Context) error {
+				runs++
+				return nil
+			})
+			if (err != nil) != tc.wantErr {
+				t.Errorf("RunScheduled(): got error %v, want error: %v", err, tc.wantErr)
+			}
+			if runs < tc.wantRuns || (tc.wantRuns < 2 && runs != tc.wantRuns) {
+				t.Errorf("RunScheduled(): got %d runs, want %d", runs, tc.wantRuns)
+			}
+		})
+	}
+}
+
+func TestScheduled(t *testing.T) {
+	var runs int
+	run := Scheduled(func(context.Context) error {
+		runs++
+		return nil
+	})
+	if err := run(context.Background()); err != nil || runs != 1 {
+		t.Errorf("Scheduled(): got %d runs and error %v without a schedule, want 1 run", runs, err)
+	}
+	s := Schedule{Every: time.Millisecond, Until: time.Now().Add(20 * time.Millisecond)}
+	ctx := WithSchedule(context.Background(), s)
+	if diff := cmp.Diff(ScheduleFrom(ctx), s); diff != "" {
+		t.Errorf("ScheduleFrom(): mismatch (-got,+want):\n%v", diff)
+	}
+	runs = 0
+	if err := run(ctx); err != nil || runs < 2 {
+		t.Errorf("Scheduled(): got %d runs and error %v on a schedule, want several runs", runs, err)
+	}
+}
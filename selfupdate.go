@@ -0,0 +1,320 @@
+package cliche
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Release of a program, as found by a ReleaseSource.
+type Release struct {
+	// Version of the release, such as v1.2.0.
+	Version string
+
+	// URL from which the program's executable for the running platform is
+	// downloaded, and SHA256 the hex-encoded checksum it must have.
+	URL, SHA256 string
+}
+
+// ReleaseSource finds the latest release of a program, to which SelfUpdate
+// updates it.
+type ReleaseSource interface {
+	LatestRelease(ctx context.Context) (Release, error)
+}
+
+// Updater updates the running program to the latest release found by its
+// Source, as the selfupdate command generated with -selfupdate does. Programs
+// offering that command register one with Provide.
+type Updater struct {
+	// Version of the running program, which is up to date when it is that
+	// of the latest release, but for a leading v.
+	Version string
+
+	// Source of the program's releases, such as GitHubReleases or
+	// ReleaseManifest.
+	Source ReleaseSource
+
+	// Client downloads releases. The zero value uses http.DefaultClient.
+	Client *http.Client
+}
+
+// UpToDate is true when the program is at the version of rel.
+func (u *Updater) UpToDate(rel Release) bool {
+	return strings.TrimPrefix(u.Version, "v") == strings.TrimPrefix(rel.Version, "v")
+}
+
+// Install replaces the executable at path with that of rel, once its checksum
+// is verified. The download is written beside the executable, and renamed over
+// it only once complete, so that a failed update leaves it as it was.
+// Releases without checksums are not installed.
+func (u *Updater) Install(ctx context.Context, rel Release, path string) (err error) {
+	if rel.SHA256 == "" {
+		return fmt.Errorf("release %v has no checksum", rel.Version)
+	}
+	want, err := hex.DecodeString(rel.SHA256)
+	if err != nil {
+		return fmt.Errorf("release %v has an invalid checksum %q", rel.Version, rel.SHA256)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	body, err := get(ctx, u.Client, rel.URL)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			os.Remove(tmp.Name())
+		}
+	}()
+	sum := sha256.New()
+	_, err = io.Copy(io.MultiWriter(tmp, sum), body)
+	if err := errors.Join(err, tmp.Chmod(info.Mode().Perm()), tmp.Close()); err != nil {
+		return fmt.Errorf("downloading release %v: %w", rel.Version, err)
+	}
+	if got := sum.Sum(nil); !bytes.Equal(got, want) {
+		return fmt.Errorf("release %v has checksum %x, want %v", rel.Version, got, rel.SHA256)
+	}
+	if runtime.GOOS == "windows" {
+		// The running executable can't be replaced on Windows, but it can
+		// be moved aside.
+		old := path + ".old"
+		os.Remove(old)
+		if err := os.Rename(path, old); err != nil {
+			return err
+		}
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// get fetches url with client, or http.DefaultClient when it is nil, and
+// returns the body of a successful response.
+func get(ctx context.Context, client *http.Client, url string) (io.ReadCloser, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("fetching %v: %v", url, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// getJSON decodes the JSON fetched from url into v, as get fetches it.
+func getJSON(ctx context.Context, client *http.Client, url string, v any) error {
+	body, err := get(ctx, client, url)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	if err := json.NewDecoder(body).Decode(v); err != nil {
+		return fmt.Errorf("decoding %v: %w", url, err)
+	}
+	return nil
+}
+
+// Platform is that for which the program was built, as GOOS/GOARCH, such as
+// linux/amd64.
+func Platform() string {
+	return runtime.GOOS + "/" + runtime.GOARCH
+}
+
+// GitHubReleases is a ReleaseSource finding the latest release of a GitHub
+// repository, whose assets are named as by GoReleaser: an executable for each
+// platform, and a file of checksums in the format of sha256sum.
+type GitHubReleases struct {
+	// Repo is the repository, as owner/name.
+	Repo string
+
+	// Asset is the name of the executable for the running platform. The
+	// default is the name of the repository, followed by _GOOS_GOARCH, and
+	// .exe on Windows.
+	Asset string
+
+	// Checksums is the name of the file of checksums, by default
+	// checksums.txt.
+	Checksums string
+
+	// API is the URL of the GitHub API, by default https://api.github.com,
+	// for GitHub Enterprise.
+	API string
+
+	// Client queries the API. The zero value uses http.DefaultClient.
+	Client *http.Client
+}
+
+// LatestRelease finds the latest release of the repository, and the asset and
+// checksum for the running platform.
+func (g GitHubReleases) LatestRelease(ctx context.Context) (Release, error) {
+	api := strings.TrimSuffix(g.API, "/")
+	if api == "" {
+		api = "https://api.github.com"
+	}
+	asset := g.Asset
+	if asset == "" {
+		asset = fmt.Sprintf("%v_%v_%v", g.Repo[strings.LastIndex(g.Repo, "/")+1:], runtime.GOOS, runtime.GOARCH)
+		if runtime.GOOS == "windows" {
+			asset += ".exe"
+		}
+	}
+	checksums := g.Checksums
+	if checksums == "" {
+		checksums = "checksums.txt"
+	}
+	var latest struct {
+		TagName string `json:"tag_name"`
+		Assets  []struct {
+			Name string `json:"name"`
+			URL  string `json:"browser_download_url"`
+		} `json:"assets"`
+	}
+	if err := getJSON(ctx, g.Client, api+"/repos/"+g.Repo+"/releases/latest", &latest); err != nil {
+		return Release{}, err
+	}
+	rel := Release{Version: latest.TagName}
+	var sums string
+	for _, a := range latest.Assets {
+		switch a.Name {
+		case asset:
+			rel.URL = a.URL
+		case checksums:
+			sums = a.URL
+		}
+	}
+	if rel.URL == "" {
+		return Release{}, fmt.Errorf("release %v of %v has no asset %v", rel.Version, g.Repo, asset)
+	}
+	if sums == "" {
+		return Release{}, fmt.Errorf("release %v of %v has no checksums %v", rel.Version, g.Repo, checksums)
+	}
+	body, err := get(ctx, g.Client, sums)
+	if err != nil {
+		return Release{}, err
+	}
+	defer body.Close()
+	lines := bufio.NewScanner(body)
+	for lines.Scan() {
+		// Lines are the checksum and the name, which is marked with a *
+		// when the file was read in binary mode.
+		if fields := strings.Fields(lines.Text()); len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == asset {
+			rel.SHA256 = fields[0]
+		}
+	}
+	if err := lines.Err(); err != nil {
+		return Release{}, err
+	}
+	if rel.SHA256 == "" {
+		return Release{}, fmt.Errorf("release %v of %v has no checksum for %v", rel.Version, g.Repo, asset)
+	}
+	return rel, nil
+}
+
+// ReleaseManifest is a ReleaseSource reading the latest release from a JSON
+// manifest at URL, of the version and the executable and its checksum for
+// each platform, as Platform names them:
+//
+//	{
+//		"version": "v1.2.0",
+//		"platforms": {
+//			"linux/amd64": {"url": "https://example.com/app-linux-amd64", "sha256": "9f86d0..."}
+//		}
+//	}
+type ReleaseManifest struct {
+	URL string
+
+	// Client fetches the manifest. The zero value uses http.DefaultClient.
+	Client *http.Client
+}
+
+// LatestRelease reads the manifest, and the executable and checksum for the
+// running platform.
+func (m ReleaseManifest) LatestRelease(ctx context.Context) (Release, error) {
+	var manifest struct {
+		Version   string `json:"version"`
+		Platforms map[string]struct {
+			URL    string `json:"url"`
+			SHA256 string `json:"sha256"`
+		} `json:"platforms"`
+	}
+	if err := getJSON(ctx, m.Client, m.URL, &manifest); err != nil {
+		return Release{}, err
+	}
+	p, ok := manifest.Platforms[Platform()]
+	if !ok {
+		return Release{}, fmt.Errorf("release %v has no executable for %v", manifest.Version, Platform())
+	}
+	return Release{Version: manifest.Version, URL: p.URL, SHA256: p.SHA256}, nil
+}
+
+// SelfUpdate runs the selfupdate command generated with -selfupdate, with args
+// following its name: it updates the running program to the latest release,
+// with the Updater registered with Provide, unless it is up to date. With
+// --check, it only reports whether it is.
+func SelfUpdate(ctx context.Context, stdio IO, args []string) error {
+	path := strings.Join(append(CommandPath(ctx), "selfupdate"), " ")
+	help := "Usage: " + path + " [flags]\n\nUpdate the program to its latest release.\n\nFlags:\n  -check\tOnly report whether an update is available.\n"
+	fs := flag.NewFlagSet(path, flag.ContinueOnError)
+	fs.SetOutput(stdio.Err)
+	check := fs.Bool("check", false, "")
+	if err := ParseFlags(stdio, fs, nil, help, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return Usagef("unexpected arguments: %q", fs.Args())
+	}
+	u, err := Inject[*Updater](ctx, "")
+	if err != nil {
+		return fmt.Errorf("no release source: %w", err)
+	}
+	rel, err := u.Source.LatestRelease(ctx)
+	if err != nil {
+		return err
+	}
+	switch {
+	case u.UpToDate(rel):
+		fmt.Fprintf(stdio.Out, "%v is up to date\n", u.Version)
+		return nil
+	case *check:
+		fmt.Fprintf(stdio.Out, "%v is available, to update %v\n", rel.Version, u.Version)
+		return nil
+	}
+	exe, err := os.Executable()
+	if err == nil {
+		exe, err = filepath.EvalSymlinks(exe)
+	}
+	if err != nil {
+		return err
+	}
+	if err := u.Install(ctx, rel, exe); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdio.Out, "updated %v to %v\n", u.Version, rel.Version)
+	return nil
+}
@@ -0,0 +1,185 @@
+package cliche
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// releaseServer serves a release of app: its executable, checksums and
+// GitHub API response, and a manifest.
+func releaseServer(t *testing.T, exe string) *httptest.Server {
+	t.Helper()
+	sum := sha256.Sum256([]byte(exe))
+	asset := fmt.Sprintf("app_%v_%v", runtime.GOOS, runtime.GOARCH)
+	if runtime.GOOS == "windows" {
+		asset += ".exe"
+	}
+	mux := http.NewServeMux()
+	var srv *httptest.Server
+	mux.HandleFunc("/download/app", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, exe)
+	})
+	mux.HandleFunc("/download/checksums.txt", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "0000  app_plan9_386\n%x *%v\n", sum, asset)
+	})
+	mux.HandleFunc("/repos/example/app/releases/latest", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"tag_name": "v1.2.0", "assets": [
+			{"name": %q, "browser_download_url": "%v/download/app"},
+			{"name": "checksums.txt", "browser_download_url": "%v/download/checksums.txt"}
+		]}`, asset, srv.URL, srv.URL)
+	})
+	mux.HandleFunc("/manifest.json", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"version": "v1.3.0", "platforms": {%q: {"url": "%v/download/app", "sha256": "%x"}}}`, Platform(), srv.URL, sum)
+	})
+	srv = httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestReleaseSources(t *testing.T) {
+	srv := releaseServer(t, "new")
+	sum := sha256.Sum256([]byte("new"))
+	for tn, tc := range map[string]struct {
+		src     ReleaseSource
+		want    Release
+		wantErr string
+	}{
+		"github": {
+			GitHubReleases{Repo: "example/app", API: srv.URL},
+			Release{Version: "v1.2.0", URL: srv.URL + "/download/app", SHA256: hex.EncodeToString(sum[:])},
+			"",
+		},
+		"github asset": {
+			GitHubReleases{Repo: "example/app", API: srv.URL, Asset: "app.tar.gz"},
+			Release{},
+			"release v1.2.0 of example/app has no asset app.tar.gz",
+		},
+		"github checksums": {
+			GitHubReleases{Repo: "example/app", API: srv.URL, Checksums: "SHA256SUMS"},
+			Release{},
+			"release v1.2.0 of example/app has no checksums SHA256SUMS",
+		},
+		"manifest": {
+			ReleaseManifest{URL: srv.URL + "/manifest.json"},
+			Release{Version: "v1.3.0", URL: srv.URL + "/download/app", SHA256: hex.EncodeToString(sum[:])},
+			"",
+		},
+		"missing": {
+			ReleaseManifest{URL: srv.URL + "/missing.json"},
+			Release{},
+			"fetching " + srv.URL + "/missing.json: 404 Not Found",
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			got, err := tc.src.LatestRelease(context.Background())
+			var gotErr string
+			if err != nil {
+				gotErr = err.Error()
+			}
+			if gotErr != tc.wantErr {
+				t.Errorf("LatestRelease(): error mismatch: got: %q want: %q", gotErr, tc.wantErr)
+			}
+			if diff := cmp.Diff(got, tc.want); diff != "" {
+				t.Errorf("LatestRelease(): mismatch (-got,+want):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestUpdaterInstall(t *testing.T) {
+	srv := releaseServer(t, "new")
+	sum := sha256.Sum256([]byte("new"))
+	for tn, tc := range map[string]struct {
+		rel     Release
+		want    string
+		wantErr string
+	}{
+		"installed":    {Release{Version: "v2", URL: srv.URL + "/download/app", SHA256: hex.EncodeToString(sum[:])}, "new", ""},
+		"no checksum":  {Release{Version: "v2", URL: srv.URL + "/download/app"}, "old", "release v2 has no checksum"},
+		"bad checksum": {Release{Version: "v2", URL: srv.URL + "/download/app", SHA256: strings.Repeat("00", 32)}, "old", fmt.Sprintf("release v2 has checksum %x, want %v", sum, strings.Repeat("00", 32))},
+		"not found":    {Release{Version: "v2", URL: srv.URL + "/download/gone", SHA256: "00"}, "old", "fetching " + srv.URL + "/download/gone: 404 Not Found"},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			dir := t.TempDir()
+			exe := filepath.Join(dir, "app")
+			if err := os.WriteFile(exe, []byte("old"), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			err := new(Updater).Install(context.Background(), tc.rel, exe)
+			var gotErr string
+			if err != nil {
+				gotErr = err.Error()
+			}
+			if gotErr != tc.wantErr {
+				t.Errorf("Install(): error mismatch: got: %q want: %q", gotErr, tc.wantErr)
+			}
+			if got, err := os.ReadFile(exe); err != nil || string(got) != tc.want {
+				t.Errorf("Install(): executable holds %q (%v), want %q", got, err, tc.want)
+			}
+			if info, err := os.Stat(exe); err != nil || runtime.GOOS != "windows" && info.Mode().Perm() != 0o755 {
+				t.Errorf("Install(): executable has mode %v (%v), want -rwxr-xr-x", info.Mode(), err)
+			}
+			// Failed downloads are cleaned up.
+			if entries, _ := os.ReadDir(dir); len(entries) != 1 && runtime.GOOS != "windows" {
+				t.Errorf("Install(): directory holds %d files, want 1", len(entries))
+			}
+		})
+	}
+}
+
+func TestSelfUpdate(t *testing.T) {
+	ctx := WithCommand(context.Background(), "app")
+	srv := releaseServer(t, "new")
+	defer func() {
+		providersMu.Lock()
+		delete(providers, providerKey{typeOf[*Updater](), ""})
+		providersMu.Unlock()
+	}()
+
+	stdio, capture := NewCaptureIO()
+	if err := SelfUpdate(ctx, stdio, nil); !errors.Is(err, ErrNoProvider) {
+		t.Errorf("SelfUpdate(): got error %v, want ErrNoProvider", err)
+	}
+
+	for _, tc := range []struct {
+		version string
+		args    []string
+		want    string
+	}{
+		{"v1.2.0", nil, "v1.2.0 is up to date\n"},
+		{"1.2.0", nil, "1.2.0 is up to date\n"},
+		{"v1.1.0", []string{"--check"}, "v1.2.0 is available, to update v1.1.0\n"},
+	} {
+		Provide(func(context.Context) (*Updater, error) {
+			return &Updater{Version: tc.version, Source: GitHubReleases{Repo: "example/app", API: srv.URL}}, nil
+		})
+		stdio, capture = NewCaptureIO()
+		if err := SelfUpdate(ctx, stdio, tc.args); err != nil {
+			t.Errorf("SelfUpdate(%q): unexpected error: %v", tc.args, err)
+		}
+		if got := capture.Out(); got != tc.want {
+			t.Errorf("SelfUpdate(%q) at %v: got %q, want %q", tc.args, tc.version, got, tc.want)
+		}
+	}
+
+	stdio, capture = NewCaptureIO()
+	if err := SelfUpdate(ctx, stdio, []string{"-h"}); !errors.Is(err, flag.ErrHelp) {
+		t.Errorf("SelfUpdate(-h): got error %v, want flag.ErrHelp", err)
+	}
+	if want := "Usage: app selfupdate [flags]\n"; !strings.HasPrefix(capture.Out(), want) {
+		t.Errorf("SelfUpdate(-h): help does not start with %q:\n%v", want, capture.Out())
+	}
+}
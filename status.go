@@ -0,0 +1,105 @@
+package cliche
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// StatusEnv is the environment variable naming the file to which Main writes
+// the status of each run, for shell prompts to show. Nothing is written when
+// it is unset. The shell snippets written by WriteStatusSnippet set it, and
+// export the status from the file before each prompt.
+const StatusEnv = "CLICHE_STATUS_ENV"
+
+// WriteStatus writes the status of inv to the file named by StatusEnv, unless
+// it is unset. The file holds one VARIABLE=value line for each of:
+//
+//	CLICHE_STATUS_COMMAND      the command path, separated by spaces
+//	CLICHE_STATUS_EXIT         the exit status
+//	CLICHE_STATUS_DURATION_MS  the duration of the run, in milliseconds
+//
+// Values are not quoted, and never contain newlines.
+func WriteStatus(inv Invocation) error {
+	path := os.Getenv(StatusEnv)
+	if path == "" {
+		return nil
+	}
+	command := strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\r' {
+			return ' '
+		}
+		return r
+	}, strings.Join(inv.Path, " "))
+	status := fmt.Sprintf("CLICHE_STATUS_COMMAND=%v\nCLICHE_STATUS_EXIT=%d\nCLICHE_STATUS_DURATION_MS=%d\n",
+		command, inv.ExitStatus, inv.Duration.Milliseconds())
+	return os.WriteFile(path, []byte(status), 0o600)
+}
+
+// statusSnippets are the shell snippets written by WriteStatusSnippet. Each
+// points StatusEnv at a file of the shell's own, and exports the variables in
+// it before the prompt is drawn, preserving the status of the last command.
+var statusSnippets = map[string]string{
+	"bash": `export CLICHE_STATUS_ENV="${XDG_RUNTIME_DIR:-${TMPDIR:-/tmp}}/cliche-status.$$"
+__cliche_status() {
+	local ret=$? key value
+	if [ -r "$CLICHE_STATUS_ENV" ]; then
+		while IFS='=' read -r key value; do
+			case "$key" in
+			CLICHE_STATUS_ENV) ;;
+			CLICHE_STATUS_*) export "$key=$value" ;;
+			esac
+		done <"$CLICHE_STATUS_ENV"
+		rm -f "$CLICHE_STATUS_ENV"
+	fi
+	return $ret
+}
+PROMPT_COMMAND="__cliche_status${PROMPT_COMMAND:+;$PROMPT_COMMAND}"
+`,
+	"zsh": `export CLICHE_STATUS_ENV="${XDG_RUNTIME_DIR:-${TMPDIR:-/tmp}}/cliche-status.$$"
+__cliche_status() {
+	local ret=$? key value
+	if [[ -r $CLICHE_STATUS_ENV ]]; then
+		while IFS='=' read -r key value; do
+			case "$key" in
+			CLICHE_STATUS_ENV) ;;
+			CLICHE_STATUS_*) export "$key=$value" ;;
+			esac
+		done <"$CLICHE_STATUS_ENV"
+		rm -f "$CLICHE_STATUS_ENV"
+	fi
+	return $ret
+}
+autoload -Uz add-zsh-hook
+add-zsh-hook precmd __cliche_status
+`,
+	"fish": `set -l dir /tmp
+set -q TMPDIR; and set dir $TMPDIR
+set -q XDG_RUNTIME_DIR; and set dir $XDG_RUNTIME_DIR
+set -gx CLICHE_STATUS_ENV $dir/cliche-status.$fish_pid
+function __cliche_status --on-event fish_prompt
+	test -r "$CLICHE_STATUS_ENV"; or return
+	while read -l line
+		set -l kv (string split -m 1 = -- $line)
+		if test "$kv[1]" != CLICHE_STATUS_ENV; and string match -q 'CLICHE_STATUS_*' -- $kv[1]
+			set -gx $kv[1] $kv[2]
+		end
+	end <$CLICHE_STATUS_ENV
+	rm -f $CLICHE_STATUS_ENV
+end
+`,
+}
+
+// WriteStatusSnippet writes to w a snippet for the given shell, which is one
+// of bash, zsh or fish, to be sourced from its startup file. Within that
+// shell, the status of the last run of any program using Main is exported in
+// the variables written by WriteStatus, for prompt frameworks to show.
+func WriteStatusSnippet(w io.Writer, shell string) error {
+	snippet, ok := statusSnippets[shell]
+	if !ok {
+		return fmt.Errorf("unsupported shell %q: want bash, zsh or fish", shell)
+	}
+	_, err := fmt.Fprintf(w, "# Status of the last cliche command, for prompts. Generated by cliche.\n%v", snippet)
+	return err
+}
@@ -0,0 +1,79 @@
+package cliche
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWriteStatus(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "status")
+	inv := Invocation{Path: []string{"app", "remote\nadd"}, Duration: 1500 * time.Millisecond, ExitStatus: 3}
+
+	t.Setenv(StatusEnv, "")
+	if err := WriteStatus(inv); err != nil {
+		t.Fatalf("WriteStatus(): unexpected error while unset: %v", err)
+	}
+
+	t.Setenv(StatusEnv, path)
+	if err := WriteStatus(inv); err != nil {
+		t.Fatalf("WriteStatus(): unexpected error: %v", err)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "CLICHE_STATUS_COMMAND=app remote add\nCLICHE_STATUS_EXIT=3\nCLICHE_STATUS_DURATION_MS=1500\n"
+	if diff := cmp.Diff(string(got), want); diff != "" {
+		t.Errorf("WriteStatus(): mismatch (-got,+want):\n%v", diff)
+	}
+}
+
+func TestMainWritesStatus(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "status")
+	t.Setenv(StatusEnv, path)
+	Main(ExiterFunc(func(int) {}), func(context.Context) int { return 4 })
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Main(): status not written: %v", err)
+	}
+	if !strings.Contains(string(got), "CLICHE_STATUS_EXIT=4\n") {
+		t.Errorf("Main(): got status %q, want exit status 4", got)
+	}
+}
+
+func TestWriteStatusSnippet(t *testing.T) {
+	var b strings.Builder
+	if err := WriteStatusSnippet(&b, "tcsh"); err == nil {
+		t.Error("WriteStatusSnippet(): expected error for unsupported shell")
+	}
+
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash is not installed")
+	}
+	if err := WriteStatusSnippet(&b, "bash"); err != nil {
+		t.Fatalf("WriteStatusSnippet(): unexpected error: %v", err)
+	}
+	// Once the prompt hook runs after a command has written its status, the
+	// status is exported and the last command's exit status is kept.
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	script := b.String() + `printf 'CLICHE_STATUS_EXIT=2\nCLICHE_STATUS_COMMAND=app sub\n' >"$CLICHE_STATUS_ENV"
+false
+eval "$PROMPT_COMMAND"
+echo "$? $CLICHE_STATUS_EXIT $CLICHE_STATUS_COMMAND"
+`
+	out, err := exec.Command(bash, "--norc", "-c", script).CombinedOutput()
+	if err != nil {
+		t.Fatalf("bash: %v: %s", err, out)
+	}
+	if got, want := string(out), "1 2 app sub\n"; got != want {
+		t.Errorf("WriteStatusSnippet(): got %q from bash, want %q", got, want)
+	}
+}
@@ -0,0 +1,47 @@
+package cliche
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+)
+
+// DecodeStdin decodes structured data read from in into v, which must be a
+// non-nil pointer. Format is as specified by the stdin component of a cliche
+// tag: "json" decodes a single JSON document into v, and "ndjson" decodes a
+// stream of JSON documents, appending each to the slice pointed to by v.
+func DecodeStdin(in io.Reader, format string, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("decoding stdin: %T is not a non-nil pointer", v)
+	}
+	dec := json.NewDecoder(in)
+
+	switch format {
+	case "json":
+		if err := dec.Decode(v); err != nil {
+			return fmt.Errorf("decoding stdin as JSON: %w", err)
+		}
+		return nil
+
+	case "ndjson":
+		slice := rv.Elem()
+		if slice.Kind() != reflect.Slice {
+			return fmt.Errorf("decoding stdin as NDJSON: %T is not a pointer to a slice", v)
+		}
+		for n := 1; ; n++ {
+			elem := reflect.New(slice.Type().Elem())
+			err := dec.Decode(elem.Interface())
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("decoding stdin as NDJSON: document %d: %w", n, err)
+			}
+			slice.Set(reflect.Append(slice, elem.Elem()))
+		}
+	}
+	return fmt.Errorf("decoding stdin: unsupported format %q", format)
+}
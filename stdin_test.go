@@ -0,0 +1,61 @@
+package cliche
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type record struct {
+	Name  string
+	Count int
+}
+
+func TestDecodeStdinJSON(t *testing.T) {
+	var got record
+	if err := DecodeStdin(strings.NewReader(`{"Name": "foo", "Count": 2}`), "json", &got); err != nil {
+		t.Fatalf("DecodeStdin(): unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(got, record{"foo", 2}); diff != "" {
+		t.Errorf("DecodeStdin(): mismatch(-got,+want):\n%v", diff)
+	}
+}
+
+func TestDecodeStdinNDJSON(t *testing.T) {
+	in := `{"Name": "foo", "Count": 1}
+{"Name": "bar", "Count": 2}
+`
+	got := []record{{"existing", 0}}
+	if err := DecodeStdin(strings.NewReader(in), "ndjson", &got); err != nil {
+		t.Fatalf("DecodeStdin(): unexpected error: %v", err)
+	}
+	want := []record{{"existing", 0}, {"foo", 1}, {"bar", 2}}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("DecodeStdin(): mismatch(-got,+want):\n%v", diff)
+	}
+}
+
+func TestDecodeStdinErrors(t *testing.T) {
+	var r record
+	var rs []record
+	for tn, tc := range map[string]struct {
+		in     string
+		format string
+		v      any
+	}{
+		"not a pointer":        {`{}`, "json", r},
+		"nil pointer":          {`{}`, "json", (*record)(nil)},
+		"unsupported format":   {`{}`, "yaml", &r},
+		"malformed json":       {`{`, "json", &r},
+		"ndjson not slice":     {`{}`, "ndjson", &r},
+		"malformed ndjson":     {"{}\n{", "ndjson", &rs},
+		"ndjson type mismatch": {`{"Count": "one"}`, "ndjson", &rs},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			if err := DecodeStdin(strings.NewReader(tc.in), tc.format, tc.v); err == nil {
+				t.Errorf("DecodeStdin(): got no error, want one")
+			}
+		})
+	}
+}
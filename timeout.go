@@ -0,0 +1,64 @@
+package cliche
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+)
+
+// Timeout bounds how long a command may run, so that operators can bound any
+// invocation. The zero value does not bound it.
+type Timeout time.Duration
+
+// RegisterFlags registers the --timeout flag on fs, which sets t.
+func (t *Timeout) RegisterFlags(fs *flag.FlagSet) {
+	fs.DurationVar((*time.Duration)(t), "timeout", time.Duration(*t), "stop the command after this `duration`; default is no limit")
+}
+
+// Untimed is implemented by commands which opt out of Timeout, such as those
+// which run until they are stopped.
+type Untimed interface {
+	Untimed()
+}
+
+// RunTimed calls run with ctx, bounded by t when it is positive unless cmd
+// implements Untimed. When run fails once the timeout has passed, the error
+// says so.
+func RunTimed(ctx context.Context, t Timeout, cmd any, run func(context.Context) error) error {
+	if _, ok := cmd.(Untimed); ok || t <= 0 {
+		return run(ctx)
+	}
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(t))
+	defer cancel()
+	err := run(ctx)
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("timed out after %v: %w", time.Duration(t), err)
+	}
+	return err
+}
+
+type timeoutKey struct{}
+
+// WithTimeout returns a copy of ctx carrying t, which bounds the command run
+// with it. Generated commands carry that of the root's --timeout flag, when
+// generated with -timeout.
+func WithTimeout(ctx context.Context, t Timeout) context.Context {
+	return context.WithValue(ctx, timeoutKey{}, t)
+}
+
+// TimeoutFrom returns the Timeout carried by ctx, or the zero value, which does
+// not bound the command, when it carries none.
+func TimeoutFrom(ctx context.Context) Timeout {
+	t, _ := ctx.Value(timeoutKey{}).(Timeout)
+	return t
+}
+
+// Timed returns run bounded by the Timeout carried by the context with which
+// it is called, as RunTimed bounds it, unless cmd implements Untimed.
+func Timed(cmd any, run func(context.Context) error) func(context.Context) error {
+	return func(ctx context.Context) error {
+		return RunTimed(ctx, TimeoutFrom(ctx), cmd, run)
+	}
+}
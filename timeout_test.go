@@ -0,0 +1,93 @@
+package cliche
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"testing"
+	"time"
+)
+
+func TestTimeoutRegisterFlags(t *testing.T) {
+	var timeout Timeout
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	timeout.RegisterFlags(fs)
+	if err := fs.Parse([]string{"--timeout=90s"}); err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	if want := Timeout(90 * time.Second); timeout != want {
+		t.Errorf("RegisterFlags(): got: %v want: %v", timeout, want)
+	}
+}
+
+// untimedCommand opts out of timeouts.
+type untimedCommand struct{}
+
+func (untimedCommand) Untimed() {}
+
+func TestRunTimed(t *testing.T) {
+	// wait runs until ctx is done, or for a second at most.
+	wait := func(ctx context.Context) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+			return nil
+		}
+	}
+	errRun := errors.New("oh no")
+
+	for tn, tc := range map[string]struct {
+		timeout     Timeout
+		cmd         any
+		run         func(context.Context) error
+		wantErr     error
+		wantTimeout bool
+	}{
+		"timed out":  {Timeout(time.Millisecond), struct{}{}, wait, context.DeadlineExceeded, true},
+		"in time":    {Timeout(time.Minute), struct{}{}, func(context.Context) error { return nil }, nil, false},
+		"failed":     {Timeout(time.Minute), struct{}{}, func(context.Context) error { return errRun }, errRun, false},
+		"no timeout": {0, struct{}{}, func(ctx context.Context) error { _, ok := ctx.Deadline(); return boolErr(ok) }, nil, false},
+		"untimed":    {Timeout(time.Millisecond), untimedCommand{}, func(ctx context.Context) error { _, ok := ctx.Deadline(); return boolErr(ok) }, nil, false},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			err := RunTimed(context.Background(), tc.timeout, tc.cmd, tc.run)
+			if !errors.Is(err, tc.wantErr) {
+				t.Errorf("RunTimed(): error mismatch: got: %v want: %v", err, tc.wantErr)
+			}
+			if tc.wantTimeout && (err == nil || err.Error() != "timed out after 1ms: context deadline exceeded") {
+				t.Errorf("RunTimed(): got error %v, want one saying it timed out", err)
+			}
+		})
+	}
+}
+
+// boolErr is an error when ok, for runs which must not see a deadline.
+func boolErr(ok bool) error {
+	if ok {
+		return errors.New("run has a deadline")
+	}
+	return nil
+}
+
+func TestTimed(t *testing.T) {
+	deadline := func(ctx context.Context) error {
+		if _, ok := ctx.Deadline(); !ok {
+			return errors.New("run has no deadline")
+		}
+		return nil
+	}
+	ctx := WithTimeout(context.Background(), Timeout(time.Minute))
+	if got := TimeoutFrom(ctx); got != Timeout(time.Minute) {
+		t.Errorf("TimeoutFrom(): got: %v want: %v", got, time.Minute)
+	}
+	if err := Timed(struct{}{}, deadline)(ctx); err != nil {
+		t.Errorf("Timed(): %v", err)
+	}
+	if err := Timed(untimedCommand{}, deadline)(ctx); err == nil {
+		t.Error("Timed(): untimed command has a deadline")
+	}
+	if err := Timed(struct{}{}, deadline)(context.Background()); err == nil {
+		t.Error("Timed(): command run without a timeout has a deadline")
+	}
+}
@@ -0,0 +1,88 @@
+package cliche
+
+import (
+	"context"
+	"flag"
+	"io"
+	"log/slog"
+	"strconv"
+)
+
+// Verbosity of a command's diagnostic output, as set by the -q/--quiet and
+// -v/--verbose flags. Zero is the default, negative is quiet, and each step
+// above zero is more verbose.
+type Verbosity int
+
+// verboseFlag is a count-style boolean flag, which increments its Verbosity
+// each time it is given.
+type verboseFlag struct{ v *Verbosity }
+
+func (f verboseFlag) String() string {
+	if f.v == nil {
+		return "0"
+	}
+	return strconv.Itoa(int(*f.v))
+}
+
+func (f verboseFlag) Set(s string) error {
+	on, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	if on {
+		if *f.v < 0 {
+			*f.v = 0
+		}
+		*f.v++
+	}
+	return nil
+}
+
+func (verboseFlag) IsBoolFlag() bool { return true }
+
+// RegisterFlags registers the -q/--quiet and -v/--verbose flags on fs, which
+// set v. Verbose flags may be repeated, as in -v -v, to increase verbosity.
+func (v *Verbosity) RegisterFlags(fs *flag.FlagSet) {
+	quiet := func(s string) error {
+		on, err := strconv.ParseBool(s)
+		if on {
+			*v = -1
+		}
+		return err
+	}
+	fs.BoolFunc("q", "only report warnings and errors", quiet)
+	fs.BoolFunc("quiet", "only report warnings and errors", quiet)
+	fs.Var(verboseFlag{v}, "v", "report more detail; repeat for even more")
+	fs.Var(verboseFlag{v}, "verbose", "report more detail; repeat for even more")
+}
+
+// Level is the slog level at and above which messages are reported at v:
+// warnings when quiet, information by default, and debug messages when
+// verbose. Each further step of verbosity lowers the level by another four,
+// the distance between slog's own levels.
+func (v Verbosity) Level() slog.Level {
+	if v < 0 {
+		return slog.LevelWarn
+	}
+	return slog.LevelInfo - slog.Level(4*v)
+}
+
+// Handler returns an slog handler which writes text to w, reporting messages
+// at v's Level and above. Command trees install it with slog.SetDefault, so
+// every command reports diagnostics consistently.
+func (v Verbosity) Handler(w io.Writer) slog.Handler {
+	return slog.NewTextHandler(w, &slog.HandlerOptions{Level: v.Level()})
+}
+
+type verbosityKey struct{}
+
+// WithVerbosity returns a copy of ctx carrying v.
+func WithVerbosity(ctx context.Context, v Verbosity) context.Context {
+	return context.WithValue(ctx, verbosityKey{}, v)
+}
+
+// VerbosityFrom returns the Verbosity carried by ctx, or the default of zero.
+func VerbosityFrom(ctx context.Context) Verbosity {
+	v, _ := ctx.Value(verbosityKey{}).(Verbosity)
+	return v
+}
@@ -0,0 +1,71 @@
+package cliche
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestVerbosityRegisterFlags(t *testing.T) {
+	for tn, tc := range map[string]struct {
+		args []string
+		want Verbosity
+	}{
+		"none":          {nil, 0},
+		"quiet":         {[]string{"-q"}, -1},
+		"long quiet":    {[]string{"--quiet"}, -1},
+		"verbose":       {[]string{"-v"}, 1},
+		"very verbose":  {[]string{"-v", "--verbose", "-v"}, 3},
+		"verbose false": {[]string{"-v", "--verbose=false"}, 1},
+		"quiet wins":    {[]string{"-v", "-q"}, -1},
+		"verbose wins":  {[]string{"-q", "-v"}, 1},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			var v Verbosity
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			v.RegisterFlags(fs)
+			if err := fs.Parse(tc.args); err != nil {
+				t.Fatalf("Parse(): unexpected error: %v", err)
+			}
+			if v != tc.want {
+				t.Errorf("RegisterFlags(): got: %v want: %v", v, tc.want)
+			}
+		})
+	}
+}
+
+func TestVerbosityLevel(t *testing.T) {
+	for v, want := range map[Verbosity]slog.Level{
+		-1: slog.LevelWarn,
+		0:  slog.LevelInfo,
+		1:  slog.LevelDebug,
+		2:  slog.LevelDebug - 4,
+	} {
+		if got := v.Level(); got != want {
+			t.Errorf("Verbosity(%d).Level(): got: %v want: %v", v, got, want)
+		}
+	}
+}
+
+func TestVerbosityHandler(t *testing.T) {
+	var buf bytes.Buffer
+	log := slog.New(Verbosity(-1).Handler(&buf))
+	log.Info("hidden")
+	log.Warn("shown")
+	if got := buf.String(); strings.Contains(got, "hidden") || !strings.Contains(got, "shown") {
+		t.Errorf("Handler(): unexpected output: %q", got)
+	}
+}
+
+func TestVerbosityContext(t *testing.T) {
+	ctx := context.Background()
+	if got := VerbosityFrom(ctx); got != 0 {
+		t.Errorf("VerbosityFrom(): got: %v want: 0", got)
+	}
+	if got := VerbosityFrom(WithVerbosity(ctx, 2)); got != 2 {
+		t.Errorf("VerbosityFrom(): got: %v want: 2", got)
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !windows
+
+package cliche
+
+import "io"
+
+// enableVirtualTerminal is a no-op outside of Windows, where terminals always
+// interpret ANSI escape sequences.
+func enableVirtualTerminal(io.Writer) bool {
+	return true
+}
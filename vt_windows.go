@@ -0,0 +1,36 @@
+//go:build windows
+
+package cliche
+
+import (
+	"io"
+	"os"
+	"syscall"
+)
+
+// enableVirtualTerminalProcessing is the console mode flag with which Windows
+// consoles interpret ANSI escape sequences.
+const enableVirtualTerminalProcessing = 0x0004
+
+var procSetConsoleMode = syscall.NewLazyDLL("kernel32.dll").NewProc("SetConsoleMode")
+
+// enableVirtualTerminal turns on processing of ANSI escape sequences by the
+// console to which w writes, when w is a console. Consoles which predate
+// virtual terminal support refuse, in which case false is returned and output
+// to them must be plain.
+func enableVirtualTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	h := syscall.Handle(f.Fd())
+	var mode uint32
+	if err := syscall.GetConsoleMode(h, &mode); err != nil {
+		return false
+	}
+	if mode&enableVirtualTerminalProcessing != 0 {
+		return true
+	}
+	r, _, _ := procSetConsoleMode.Call(uintptr(h), uintptr(mode|enableVirtualTerminalProcessing))
+	return r != 0
+}